@@ -0,0 +1,140 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// serverConfig holds the HTTP server and API settings loaded from the environment.
+type serverConfig struct {
+	// ListenAddr is the TCP address the HTTP server listens on
+	ListenAddr string
+	// ReadHeaderTimeout is the maximum time allowed to read request headers
+	ReadHeaderTimeout time.Duration
+	// ReadTimeout is the maximum time allowed to read an entire request, including the body
+	ReadTimeout time.Duration
+	// WriteTimeout is the maximum time allowed to write a response
+	WriteTimeout time.Duration
+	// IdleTimeout is how long keep-alive connections are kept open between requests
+	IdleTimeout time.Duration
+	// ShutdownTimeout is how long in-flight requests may drain after SIGTERM or SIGINT
+	ShutdownTimeout time.Duration
+	// IdempotencyTTL is how long an Idempotency-Key replays its original response
+	IdempotencyTTL time.Duration
+	// WebhookDispatcher enables the background delivery of webhooks from this process
+	WebhookDispatcher bool
+	// WebhookPollInterval is how often the dispatcher looks for due deliveries when idle
+	WebhookPollInterval time.Duration
+	// WebhookTimeout bounds each webhook request
+	WebhookTimeout time.Duration
+	// WebhookMaxAttempts is the number of failed attempts after which a delivery is dead-lettered
+	WebhookMaxAttempts int
+	// ScreeningBlocklistFile names a file of blocked parties, one per line
+	ScreeningBlocklistFile string
+	// ScreeningThresholds holds per-currency review and rejection amounts, e.g. "USD=10000:50000,EUR=:20000"
+	ScreeningThresholds string
+	// ScreeningRemoteURL is the address of an external screening service
+	ScreeningRemoteURL string
+	// ScreeningRemoteTimeout bounds each request to the screening service
+	ScreeningRemoteTimeout time.Duration
+	// ScreeningRemoteFailureThreshold is the number of consecutive failures that stops calls to the screening service
+	ScreeningRemoteFailureThreshold int
+	// ScreeningRemoteCooldown is how long calls to a failing screening service stay stopped
+	ScreeningRemoteCooldown time.Duration
+	// SpendingLimits holds the limits every sender is held to, e.g. "USD/1h=20:5000,USD/24h=100:20000"
+	SpendingLimits string
+	// FXRatesFile names a file of static exchange rates, one pair per line
+	FXRatesFile string
+	// FXRatesURL is the address of an external exchange rate service
+	FXRatesURL string
+	// FXRatesTimeout bounds each request to the exchange rate service
+	FXRatesTimeout time.Duration
+	// FXQuoteTTL is how long a quote locks its exchange rate
+	FXQuoteTTL time.Duration
+	// Currencies restricts the service to a comma-separated list of ISO 4217 codes, e.g. "USD,EUR,KES"
+	Currencies string
+	// ISO20022AgentBIC is the BIC the service identifies itself by in the ISO 20022 messages it writes
+	ISO20022AgentBIC string
+}
+
+// loadServerConfig loads server configuration from environment variables.
+// Database settings are loaded separately by the db package.
+func loadServerConfig() serverConfig {
+	return serverConfig{
+		ListenAddr:        getEnv("HTTP_ADDR", ":8080"),
+		ReadHeaderTimeout: time.Duration(getEnvAsInt("HTTP_READ_HEADER_TIMEOUT_SECONDS", 5)) * time.Second,
+		ReadTimeout:       time.Duration(getEnvAsInt("HTTP_READ_TIMEOUT_SECONDS", 10)) * time.Second,
+		WriteTimeout:      time.Duration(getEnvAsInt("HTTP_WRITE_TIMEOUT_SECONDS", 30)) * time.Second,
+		IdleTimeout:       time.Duration(getEnvAsInt("HTTP_IDLE_TIMEOUT_SECONDS", 120)) * time.Second,
+		ShutdownTimeout:   time.Duration(getEnvAsInt("HTTP_SHUTDOWN_TIMEOUT_SECONDS", 15)) * time.Second,
+		IdempotencyTTL:    time.Duration(getEnvAsInt("IDEMPOTENCY_TTL_HOURS", 24)) * time.Hour,
+
+		WebhookDispatcher:   getEnvAsBool("WEBHOOK_DISPATCHER_ENABLED", true),
+		WebhookPollInterval: time.Duration(getEnvAsInt("WEBHOOK_POLL_INTERVAL_MS", 1000)) * time.Millisecond,
+		WebhookTimeout:      time.Duration(getEnvAsInt("WEBHOOK_TIMEOUT_SECONDS", 10)) * time.Second,
+		WebhookMaxAttempts:  getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+
+		ScreeningBlocklistFile:          getEnv("SCREENING_BLOCKLIST_FILE", ""),
+		ScreeningThresholds:             getEnv("SCREENING_THRESHOLDS", ""),
+		ScreeningRemoteURL:              getEnv("SCREENING_REMOTE_URL", ""),
+		ScreeningRemoteTimeout:          time.Duration(getEnvAsInt("SCREENING_REMOTE_TIMEOUT_MS", 2000)) * time.Millisecond,
+		ScreeningRemoteFailureThreshold: getEnvAsInt("SCREENING_REMOTE_FAILURE_THRESHOLD", 5),
+		ScreeningRemoteCooldown:         time.Duration(getEnvAsInt("SCREENING_REMOTE_COOLDOWN_SECONDS", 30)) * time.Second,
+
+		SpendingLimits: getEnv("SPENDING_LIMITS", ""),
+
+		FXRatesFile:    getEnv("FX_RATES_FILE", ""),
+		FXRatesURL:     getEnv("FX_RATES_URL", ""),
+		FXRatesTimeout: time.Duration(getEnvAsInt("FX_RATES_TIMEOUT_MS", 2000)) * time.Millisecond,
+		FXQuoteTTL:     time.Duration(getEnvAsInt("FX_QUOTE_TTL_SECONDS", 60)) * time.Second,
+
+		Currencies: getEnv("CURRENCIES", ""),
+
+		ISO20022AgentBIC: getEnv("ISO20022_AGENT_BIC", ""),
+	}
+}
+
+// getEnv retrieves an environment variable with a default value.
+func getEnv(key, defaultValue string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvAsInt retrieves an environment variable as an integer with a default value.
+// If the environment variable cannot be parsed as an integer, it returns the default value.
+func getEnvAsInt(key string, defaultValue int) int {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.Atoi(valueStr)
+	if err != nil {
+		log.Printf("Warning: Invalid value for %s: %s, using default: %d", key, valueStr, defaultValue)
+		return defaultValue
+	}
+
+	return value
+}
+
+// getEnvAsBool retrieves an environment variable as a boolean with a default value.
+// If the environment variable cannot be parsed as a boolean, it returns the default value.
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		log.Printf("Warning: Invalid value for %s: %s, using default: %t", key, valueStr, defaultValue)
+		return defaultValue
+	}
+
+	return value
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/abadojack/gapstack/internal/fx"
+)
+
+// newConverter builds the currency converter from the configuration, taking rates from either a static
+// rates file or a remote service. It returns nil, disabling conversion, if neither is configured.
+func newConverter(config serverConfig) (*fx.Converter, error) {
+	if config.FXQuoteTTL <= 0 {
+		return nil, errors.New("FX_QUOTE_TTL_SECONDS: must be positive")
+	}
+
+	switch {
+	case config.FXRatesFile != "" && config.FXRatesURL != "":
+		return nil, errors.New("FX_RATES_FILE and FX_RATES_URL cannot both be set")
+	case config.FXRatesFile != "":
+		rates, err := fx.LoadStaticRates(config.FXRatesFile)
+		if err != nil {
+			return nil, fmt.Errorf("FX_RATES_FILE: %w", err)
+		}
+		log.Printf("Converting currencies at %d static rates from %s", rates.Len(), config.FXRatesFile)
+		return fx.NewConverter(rates, config.FXQuoteTTL), nil
+	case config.FXRatesURL != "":
+		remote := fx.NewRemote(config.FXRatesURL)
+		remote.Client.Timeout = config.FXRatesTimeout
+		log.Printf("Converting currencies at rates from %s", config.FXRatesURL)
+		return fx.NewConverter(remote, config.FXQuoteTTL), nil
+	}
+	return nil, nil
+}
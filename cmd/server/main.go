@@ -1,25 +1,99 @@
 // Package main provides the entry point for the gapstack transaction service.
-// This service exposes a REST API for managing financial transactions backed by MySQL.
+// This service exposes a REST API for managing financial transactions backed by MySQL or PostgreSQL,
+// or by process memory for local demos. Run "server migrate up|down|status" to manage the SQL schema.
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 
 	"github.com/abadojack/gapstack/internal/api"
+	"github.com/abadojack/gapstack/internal/currency"
 	db "github.com/abadojack/gapstack/internal/db"
+	"github.com/abadojack/gapstack/internal/iso20022"
+	"github.com/abadojack/gapstack/internal/limits"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/abadojack/gapstack/internal/webhook"
 	"github.com/gorilla/mux"
 )
 
 func main() {
-	// Initialize database connection
-	database, err := db.NewDB()
+	var err error
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = runMigrate(os.Args[2:])
+	} else {
+		err = run()
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
+}
+
+// run starts the HTTP server and the webhook dispatcher, and blocks until the server fails or
+// receives SIGTERM/SIGINT. On a signal it stops accepting connections, drains in-flight requests
+// within the configured deadline, stops the dispatcher, and only then closes the database.
+func run() error {
+	// Initialize the storage backend selected by STORAGE
+	database, err := db.Open()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := database.Close(); err != nil {
+			log.Printf("error closing database: %v", err)
+		}
+	}()
+
+	// Load server settings after Open so values from the .env file are visible
+	config := loadServerConfig()
+
+	screener, err := newScreener(config)
+	if err != nil {
+		return err
+	}
+
+	converter, err := newConverter(config)
+	if err != nil {
+		return err
+	}
+
+	defaultLimits, err := models.ParseSpendingLimits(config.SpendingLimits)
+	if err != nil {
+		return fmt.Errorf("SPENDING_LIMITS: %w", err)
+	}
+	if len(defaultLimits) > 0 {
+		log.Printf("Enforcing %d default spending limits", len(defaultLimits))
+	}
+
+	currencies, err := currency.ParseSet(config.Currencies)
+	if err != nil {
+		return fmt.Errorf("CURRENCIES: %w", err)
+	}
+	if currencies != nil {
+		log.Printf("Accepting only %s", strings.Join(currencies.Codes(), ", "))
+	}
+
+	if config.ISO20022AgentBIC != "" && !iso20022.IsBIC(config.ISO20022AgentBIC) {
+		return fmt.Errorf("ISO20022_AGENT_BIC: %q is not a BIC", config.ISO20022AgentBIC)
+	}
 
 	// Create API handler with database dependency
 	handler := api.NewHandler(database)
+	handler.IdempotencyTTL = config.IdempotencyTTL
+	handler.Screener = screener
+	handler.FX = converter
+	handler.Currencies = currencies
+	handler.Agent = iso20022.Agent{BIC: config.ISO20022AgentBIC}
+	// The limiter is created even without defaults so that per-sender overrides are enforced
+	handler.Limiter = limits.NewLimiter(database, defaultLimits)
 
 	// Set up HTTP router with Gorilla Mux
 	r := mux.NewRouter()
@@ -27,7 +101,71 @@ func main() {
 	// Register all API routes
 	handler.RegisterRoutes(r)
 
-	// Start HTTP server on port 8080
-	log.Print("Listening on port 8080")
-	log.Fatal(http.ListenAndServe(":8080", r))
+	server := &http.Server{
+		Addr:              config.ListenAddr,
+		Handler:           r,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		ReadTimeout:       config.ReadTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+	}
+
+	// Event streams never finish on their own, so end them as soon as shutdown starts rather than
+	// letting them hold the drain open until its deadline
+	server.RegisterOnShutdown(handler.Events.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Deliver webhooks in the background until the server has drained. This deferred stop is
+	// registered after the database's Close, so the dispatcher finishes before the database closes.
+	dispatchCtx, stopDispatch := context.WithCancel(context.Background())
+	var dispatching sync.WaitGroup
+	defer func() {
+		stopDispatch()
+		dispatching.Wait()
+	}()
+	if config.WebhookDispatcher {
+		dispatcher := webhook.NewDispatcher(database)
+		dispatcher.PollInterval = config.WebhookPollInterval
+		dispatcher.Client.Timeout = config.WebhookTimeout
+		dispatcher.MaxAttempts = config.WebhookMaxAttempts
+		// The lease must outlast a request, or a slow endpoint would be claimed again mid-attempt
+		dispatcher.Lease = max(dispatcher.Lease, 2*config.WebhookTimeout)
+		dispatching.Add(1)
+		go func() {
+			defer dispatching.Done()
+			dispatcher.Run(dispatchCtx)
+		}()
+	}
+
+	// Start HTTP server in the background so we can wait for a shutdown signal
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Listening on %s", config.ListenAddr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+		log.Println("Shutdown signal received, draining in-flight requests")
+	}
+
+	// A second signal during the drain falls back to the default behaviour and kills the process
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	log.Println("Server stopped")
+	return nil
 }
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/abadojack/gapstack/internal/screening"
+)
+
+// newScreener builds the screening pipeline from the configuration: the blocklist first, since it rejects
+// outright, then amount thresholds, then the remote service. It returns nil if no screener is configured.
+func newScreener(config serverConfig) (screening.Screener, error) {
+	var screeners []screening.Screener
+
+	if config.ScreeningBlocklistFile != "" {
+		blocklist, err := screening.LoadBlocklist(config.ScreeningBlocklistFile)
+		if err != nil {
+			return nil, fmt.Errorf("SCREENING_BLOCKLIST_FILE: %w", err)
+		}
+		log.Printf("Screening against %d blocked parties from %s", blocklist.Len(), config.ScreeningBlocklistFile)
+		screeners = append(screeners, blocklist)
+	}
+
+	if config.ScreeningThresholds != "" {
+		thresholds, err := screening.ParseThresholds(config.ScreeningThresholds)
+		if err != nil {
+			return nil, fmt.Errorf("SCREENING_THRESHOLDS: %w", err)
+		}
+		log.Printf("Screening amounts in %d currencies", len(thresholds))
+		screeners = append(screeners, thresholds)
+	}
+
+	if config.ScreeningRemoteURL != "" {
+		remote := screening.NewRemote(config.ScreeningRemoteURL)
+		remote.Client.Timeout = config.ScreeningRemoteTimeout
+		remote.Breaker = screening.NewBreaker(config.ScreeningRemoteFailureThreshold, config.ScreeningRemoteCooldown)
+		log.Printf("Screening with the service at %s", config.ScreeningRemoteURL)
+		screeners = append(screeners, remote)
+	}
+
+	if len(screeners) == 0 {
+		return nil, nil
+	}
+	return screening.NewPipeline(screeners...), nil
+}
@@ -1,26 +1,39 @@
 -- Part 2: Data Handling & Queries
+--
+-- Queries 1-3 are served by the API under /reports (see README); these are their MySQL forms for ad hoc use.
+-- Amounts in different currencies are never added together.
 
--- 1) Total amount of completed transactions per user (sender)
+-- 1) Total amount of completed transactions per user (sender) and currency
+--    GET /reports/totals-by-sender
 SELECT
   sender AS user,
+  UPPER(currency) AS currency,
+  COUNT(*) AS completed_count,
   SUM(amount) AS total_completed_amount
 FROM transactions
 WHERE status = 'completed'
-GROUP BY sender
-ORDER BY total_completed_amount DESC;
+GROUP BY sender, UPPER(currency)
+ORDER BY UPPER(currency), total_completed_amount DESC, sender;
 
--- 2) Top 5 users by transaction volume (sum of amounts) in the last 30 days
-SELECT
-  sender AS user,
-  SUM(amount) AS volume_last_30d
-FROM transactions
-WHERE status = 'completed'
-  AND created_at >= NOW() - INTERVAL 30 DAY
-GROUP BY sender
-ORDER BY volume_last_30d DESC
-LIMIT 5;
+-- 2) Top 5 users by transaction volume (sum of amounts) in the last 30 days, in each currency
+--    GET /reports/top-senders?window=30d&limit=5
+SELECT user, currency, volume_last_30d
+FROM (
+  SELECT
+    sender AS user,
+    UPPER(currency) AS currency,
+    SUM(amount) AS volume_last_30d,
+    ROW_NUMBER() OVER (PARTITION BY UPPER(currency) ORDER BY SUM(amount) DESC, sender) AS sender_rank
+  FROM transactions
+  WHERE status = 'completed'
+    AND created_at >= NOW() - INTERVAL 30 DAY
+  GROUP BY sender, UPPER(currency)
+) ranked
+WHERE sender_rank <= 5
+ORDER BY currency, sender_rank;
 
 -- 3) All users with more than 3 failed transactions (sender)
+--    GET /reports/failure-heavy-senders?threshold=3
 SELECT
   sender AS user,
   COUNT(*) AS failed_count
@@ -28,6 +41,25 @@ FROM transactions
 WHERE status = 'failed'
 GROUP BY sender
 HAVING COUNT(*) > 3
-ORDER BY failed_count DESC;
+ORDER BY failed_count DESC, sender;
+
 
 
+-- 4) Account balances derived from the double-entry ledger
+SELECT
+  a.id AS account,
+  a.currency,
+  COALESCE(SUM(CASE WHEN e.direction = 'credit' THEN e.amount ELSE -e.amount END), 0) AS balance
+FROM accounts a
+LEFT JOIN ledger_entries e ON e.account_id = a.id
+GROUP BY a.id, a.currency
+ORDER BY a.id;
+
+-- 5) Ledger integrity check: every transaction's debits equal its credits (expect no rows)
+SELECT
+  transaction_id,
+  SUM(CASE WHEN direction = 'debit' THEN amount ELSE 0 END) AS debits,
+  SUM(CASE WHEN direction = 'credit' THEN amount ELSE 0 END) AS credits
+FROM ledger_entries
+GROUP BY transaction_id
+HAVING debits <> credits;
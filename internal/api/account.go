@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/currency"
+	"github.com/abadojack/gapstack/internal/db"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// CreateAccount handles POST requests to open a new account.
+// Accounts are also opened implicitly the first time a completed transaction names them;
+// opening one explicitly is only needed to choose its currency up front or to allow overdrafts.
+func (h *Handler) CreateAccount(w http.ResponseWriter, r *http.Request) {
+	var account models.Account
+	if err := json.NewDecoder(r.Body).Decode(&account); err != nil {
+		log.Println(err)
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidRequestBody, "invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	if errs := validateAccount(&account, h.Currencies); len(errs) > 0 {
+		writeValidationProblem(w, r, errs)
+		return
+	}
+	account.CreatedAt = time.Now()
+
+	if err := h.DB.CreateAccount(r.Context(), account); err != nil {
+		log.Println(err)
+		if errors.Is(err, db.ErrDuplicate) {
+			writeProblem(w, r, http.StatusConflict, codeConflict, "account already exists")
+			return
+		}
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error creating account")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, account)
+}
+
+// GetAccount handles GET requests to retrieve a single account by ID.
+func (h *Handler) GetAccount(w http.ResponseWriter, r *http.Request) {
+	account, err := h.DB.GetAccount(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeAccountError(w, r, err, "error getting account")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, account)
+}
+
+// GetAccountBalance handles GET requests for an account's balance, derived from its ledger entries.
+func (h *Handler) GetAccountBalance(w http.ResponseWriter, r *http.Request) {
+	balance, err := h.DB.GetAccountBalance(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeAccountError(w, r, err, "error getting balance")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, balance)
+}
+
+// ListAccountEntries handles GET requests for an account's ledger entries in posting order.
+// Pages are chained with the opaque next_cursor token; page_size is capped at maxPageSize.
+func (h *Handler) ListAccountEntries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	pageSize := parsePageSize(query.Get("page_size"))
+
+	var afterID int64
+	if token := query.Get("cursor"); token != "" {
+		var err error
+		afterID, err = strconv.ParseInt(token, 10, 64)
+		if err != nil || afterID < 0 {
+			var errs ValidationErrors
+			errs.add("cursor", fieldInvalid, "cursor is not a valid pagination token")
+			writeValidationProblem(w, r, errs)
+			return
+		}
+	}
+
+	accountID := mux.Vars(r)["id"]
+	entries, err := h.DB.GetLedgerEntries(r.Context(), accountID, afterID, pageSize+1)
+	if err != nil {
+		writeAccountError(w, r, err, "error getting ledger entries")
+		return
+	}
+	hasMore := len(entries) > pageSize
+	if hasMore {
+		entries = entries[:pageSize]
+	}
+	if entries == nil {
+		entries = []models.LedgerEntry{}
+	}
+
+	var nextCursor interface{}
+	links := []string{pageLink(r, "first", map[string]string{"cursor": ""})}
+	if hasMore {
+		token := strconv.FormatInt(entries[len(entries)-1].ID, 10)
+		nextCursor = token
+		links = append(links, pageLink(r, "next", map[string]string{"cursor": token}))
+	}
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"account_id":  accountID,
+		"page_size":   pageSize,
+		"has_more":    hasMore,
+		"entries":     entries,
+		"next_cursor": nextCursor,
+	})
+}
+
+// writeAccountError maps an account lookup failure to a problem response.
+func writeAccountError(w http.ResponseWriter, r *http.Request, err error, detail string) {
+	if errors.Is(err, db.ErrNotFound) {
+		writeProblem(w, r, http.StatusNotFound, codeNotFound, "account not found")
+		return
+	}
+	log.Println(err)
+	writeProblem(w, r, http.StatusInternalServerError, codeInternal, detail)
+}
+
+// validateAccount checks the fields of an account opening request.
+func validateAccount(account *models.Account, allowed *currency.Set) ValidationErrors {
+	var errs ValidationErrors
+
+	if account.ID == "" {
+		errs.add("id", fieldRequired, "id is required")
+	} else if len(account.ID) > 255 {
+		errs.add("id", fieldTooLong, "id must be 255 characters or less")
+	} else if models.IsExchangeAccount(account.ID) {
+		errs.add("id", fieldInvalid, "id must not start with fx:, which is reserved for currency exchange accounts")
+	}
+
+	if c, ok := validateCurrency("currency", account.Currency, allowed, &errs); ok {
+		account.Currency = c.Code
+	}
+
+	return errs
+}
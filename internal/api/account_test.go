@@ -0,0 +1,249 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abadojack/gapstack/internal/currency"
+	"github.com/abadojack/gapstack/internal/db"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// serveAccounts routes a request through the full API router.
+func serveAccounts(handler *Handler, method, target string, body []byte) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest(method, target, bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestHandler_CreateAccount(t *testing.T) {
+	t.Run("successful creation", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("CreateAccount", mock.MatchedBy(func(account models.Account) bool {
+			return account.ID == "treasury" && account.Currency == "USD" && account.AllowOverdraft
+		})).Return(nil)
+
+		rr := serveAccounts(handler, "POST", "/accounts", []byte(`{"id":"treasury","currency":"USD","allow_overdraft":true}`))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		var account models.Account
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &account))
+		assert.Equal(t, "treasury", account.ID)
+		assert.False(t, account.CreatedAt.IsZero())
+
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		rr := serveAccounts(handler, "POST", "/accounts", []byte(`{"currency":"XYZ"}`))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		var problem Problem
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+		require.Len(t, problem.Errors, 2)
+		assert.Equal(t, "id", problem.Errors[0].Field)
+		assert.Equal(t, "currency", problem.Errors[1].Field)
+
+		mockDB.AssertNotCalled(t, "CreateAccount", mock.Anything)
+	})
+
+	t.Run("currency outside the allow-list", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+		var err error
+		handler.Currencies, err = currency.NewSet("USD")
+		require.NoError(t, err)
+
+		rr := serveAccounts(handler, "POST", "/accounts", []byte(`{"id":"alice","currency":"eur"}`))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		var problem Problem
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+		assert.Equal(t, []FieldError{{Field: "currency", Code: fieldUnsupported, Message: "EUR is not supported"}}, problem.Errors)
+		mockDB.AssertNotCalled(t, "CreateAccount", mock.Anything)
+	})
+
+	t.Run("duplicate account", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("CreateAccount", mock.Anything).Return(fmt.Errorf("%w: account alice", db.ErrDuplicate))
+
+		rr := serveAccounts(handler, "POST", "/accounts", []byte(`{"id":"alice","currency":"USD"}`))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+}
+
+func TestHandler_GetAccount(t *testing.T) {
+	t.Run("successful retrieval", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("GetAccount", "alice").Return(&models.Account{ID: "alice", Currency: "USD"}, nil)
+
+		rr := serveAccounts(handler, "GET", "/accounts/alice", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"id":"alice"`)
+	})
+
+	t.Run("account not found", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("GetAccount", "nobody").Return(nil, fmt.Errorf("%w: account nobody", db.ErrNotFound))
+
+		rr := serveAccounts(handler, "GET", "/accounts/nobody", nil)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), "account not found")
+	})
+}
+
+func TestHandler_GetAccountBalance(t *testing.T) {
+	t.Run("successful retrieval", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		balance := &models.Balance{AccountID: "alice", Currency: "USD", Amount: models.MustParseAmount("42.50")}
+		mockDB.On("GetAccountBalance", "alice").Return(balance, nil)
+
+		rr := serveAccounts(handler, "GET", "/accounts/alice/balance", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"account_id":"alice","currency":"USD","amount":"42.50"}`, rr.Body.String())
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("GetAccountBalance", "alice").Return(nil, errors.New("database error"))
+
+		rr := serveAccounts(handler, "GET", "/accounts/alice/balance", nil)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Contains(t, rr.Body.String(), "error getting balance")
+	})
+}
+
+func TestHandler_ListAccountEntries(t *testing.T) {
+	makeEntries := func(ids ...int64) []models.LedgerEntry {
+		entries := make([]models.LedgerEntry, len(ids))
+		for i, id := range ids {
+			entries[i] = models.LedgerEntry{
+				ID:            id,
+				AccountID:     "alice",
+				TransactionID: fmt.Sprintf("txn-%d", id),
+				Direction:     models.DirectionCredit,
+				Amount:        models.MustParseAmount("1.00"),
+				Currency:      "USD",
+			}
+		}
+		return entries
+	}
+
+	t.Run("first page with more entries", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("GetLedgerEntries", "alice", int64(0), 3).Return(makeEntries(1, 4, 7), nil)
+
+		rr := serveAccounts(handler, "GET", "/accounts/alice/entries?page_size=2", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Len(t, response["entries"], 2)
+		assert.Equal(t, true, response["has_more"])
+		assert.Equal(t, "4", response["next_cursor"])
+		assert.Contains(t, rr.Header().Get("Link"), `</accounts/alice/entries?cursor=4&page_size=2>; rel="next"`)
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("GetLedgerEntries", "alice", int64(4), 3).Return(makeEntries(7), nil)
+
+		rr := serveAccounts(handler, "GET", "/accounts/alice/entries?page_size=2&cursor=4", nil)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Len(t, response["entries"], 1)
+		assert.Equal(t, false, response["has_more"])
+		assert.Nil(t, response["next_cursor"])
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		rr := serveAccounts(handler, "GET", "/accounts/alice/entries?cursor=abc", nil)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockDB.AssertNotCalled(t, "GetLedgerEntries", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("account not found", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("GetLedgerEntries", "nobody", int64(0), defaultPageSize+1).
+			Return([]models.LedgerEntry(nil), fmt.Errorf("%w: account nobody", db.ErrNotFound))
+
+		rr := serveAccounts(handler, "GET", "/accounts/nobody/entries", nil)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestHandler_UpdateTransaction_LedgerErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code string
+	}{
+		{"insufficient funds", fmt.Errorf("%w: account alice has 0.00 USD, needs 10.00", db.ErrInsufficientFunds), codeInsufficientFunds},
+		{"currency mismatch", fmt.Errorf("%w: account alice holds EUR, not USD", db.ErrCurrencyMismatch), codeCurrencyMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			handler := NewHandler(mockDB)
+
+			mockDB.On("UpdateTransaction", "txn-123", models.StatusChange{Status: models.StatusCompleted, Actor: "api"}).Return(tt.err)
+
+			rr := serveAccounts(handler, "PUT", "/transactions/txn-123", []byte(`{"status":"completed"}`))
+
+			assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+			var problem Problem
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+			assert.Equal(t, tt.code, problem.Code)
+		})
+	}
+}
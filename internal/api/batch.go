@@ -0,0 +1,272 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+const (
+	// maxBatchSize is the largest number of transactions accepted in one batch
+	maxBatchSize = 5000
+	// maxBatchBytes is the largest batch request body accepted
+	maxBatchBytes = 8 << 20
+	// ndjsonContentType is the media type of a batch sent as one JSON transaction per line
+	ndjsonContentType = "application/x-ndjson"
+	// batchReadTimeout is how long a client has to send a batch body, in place of the server's read timeout
+	batchReadTimeout = 2 * time.Minute
+)
+
+// Modes of a batch, chosen with the mode query parameter.
+const (
+	// batchAllOrNothing creates every transaction of the batch or, if any of them fails, none
+	batchAllOrNothing = "all_or_nothing"
+	// batchBestEffort creates every transaction that can be created and reports the rest
+	batchBestEffort = "best_effort"
+)
+
+// Statuses of an item in a batch response.
+const (
+	batchItemCreated = "created"
+	batchItemFailed  = "failed"
+)
+
+// batchResult is the outcome of one item of a batch.
+type batchResult struct {
+	// Index is the position of the item in the batch, counting from 0
+	Index int `json:"index"`
+	// Row is the line of the CSV file an imported item was read from
+	Row int `json:"row,omitempty"`
+	// Status is created or failed
+	Status string `json:"status"`
+	// Transaction is the created transaction
+	Transaction *models.Transaction `json:"transaction,omitempty"`
+	// Error is the problem that stopped the item being created, as a single request would have reported it
+	Error *Problem `json:"error,omitempty"`
+}
+
+// batchResponse is the body of a successful batch request.
+type batchResponse struct {
+	Mode    string        `json:"mode"`
+	Created int           `json:"created"`
+	Failed  int           `json:"failed"`
+	Results []batchResult `json:"results"`
+}
+
+// CreateTransactionBatch handles POST requests to create many transactions at once. The body is either a JSON
+// array of transaction requests or, with Content-Type application/x-ndjson, one transaction request per line.
+// Every item goes through the same validation, conversion, spending limits and screening as a single request,
+// with each item's limits counting the items before it.
+// In all_or_nothing mode, the default, the transactions are stored in a single SQL transaction if every item
+// is accepted, and otherwise none are stored and the failed items are reported in a 422 problem.
+// In best_effort mode each accepted item is stored on its own and the response reports the outcome of each.
+func (h *Handler) CreateTransactionBatch(w http.ResponseWriter, r *http.Request) {
+	liftBatchDeadlines(w)
+
+	var errs ValidationErrors
+	mode := parseBatchMode(r.URL.Query(), &errs)
+	if len(errs) > 0 {
+		writeValidationProblem(w, r, errs)
+		return
+	}
+
+	items, err := readBatch(w, r)
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidRequestBody, err.Error())
+		return
+	}
+
+	results := make([]batchResult, len(items))
+	transactions := make([]models.Transaction, len(items))
+	for i, item := range items {
+		results[i].Index = i
+		transactions[i], results[i].Error = h.prepareTransaction(r, item)
+	}
+	h.createBatch(w, r, mode, transactions, results)
+}
+
+// liftBatchDeadlines frees a batch request from the server's timeouts, which are sized for a single transaction,
+// where supported. Reading up to maxBatchBytes may take batchReadTimeout, and checking, screening and storing up
+// to maxBatchSize items and writing their results has no deadline, as with exports and event streams.
+func liftBatchDeadlines(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Now().Add(batchReadTimeout))
+	_ = rc.SetWriteDeadline(time.Time{})
+}
+
+// parseBatchMode reads the mode query parameter of a batch, defaulting to all_or_nothing.
+func parseBatchMode(query url.Values, errs *ValidationErrors) string {
+	mode := query.Get("mode")
+	if mode == "" {
+		return batchAllOrNothing
+	}
+	if mode != batchAllOrNothing && mode != batchBestEffort {
+		errs.add("mode", fieldInvalid, fmt.Sprintf("mode must be %s or %s", batchAllOrNothing, batchBestEffort))
+	}
+	return mode
+}
+
+// createBatch processes the prepared transactions of a batch in the given mode and writes the response:
+// the outcome of every item or, for an all_or_nothing batch with failed items, a 422 problem listing them.
+func (h *Handler) createBatch(w http.ResponseWriter, r *http.Request, mode string, transactions []models.Transaction, results []batchResult) {
+	if problem := h.processBatch(r, mode, transactions, results); problem != nil {
+		writeProblemBody(w, *problem)
+		return
+	}
+
+	response := batchResponse{Mode: mode, Results: results}
+	var failed []batchResult
+	for _, result := range results {
+		if result.Error != nil {
+			failed = append(failed, result)
+		}
+	}
+	response.Failed, response.Created = len(failed), len(results)-len(failed)
+	if mode == batchAllOrNothing && len(failed) > 0 {
+		problem := newProblem(r, http.StatusUnprocessableEntity, codeBatchRejected,
+			fmt.Sprintf("%d of %d transactions failed; none were created", len(failed), len(results)))
+		problem.Results = failed
+		writeProblemBody(w, *problem)
+		return
+	}
+
+	status := http.StatusCreated
+	if len(failed) > 0 {
+		status = http.StatusOK
+	}
+	writeJSON(w, status, response)
+}
+
+// processBatch takes the prepared transactions of a batch through the checks that depend on the other items,
+// spending limits and screening, and stores them in the given mode, recording the outcome of each item in its
+// result. Items whose result already holds an error failed to be prepared and are only reported.
+// In all_or_nothing mode nothing is stored if any item failed. The returned problem, if any, is a failure of
+// the batch as a whole, which leaves the results incomplete.
+func (h *Handler) processBatch(r *http.Request, mode string, transactions []models.Transaction, results []batchResult) *Problem {
+	// quotes maps each quote ID to the index of the item that uses it, as a quote can only be used once
+	quotes := make(map[string]int)
+	for i := range results {
+		if conversion := transactions[i].Conversion; results[i].Error == nil && conversion != nil && conversion.QuoteID != "" {
+			if first, ok := quotes[conversion.QuoteID]; ok {
+				results[i].Error = newProblem(r, http.StatusConflict, codeConflict, fmt.Sprintf("quote is already used by item %d", first))
+			} else {
+				quotes[conversion.QuoteID] = i
+			}
+		}
+		if results[i].Error != nil {
+			results[i].Status = batchItemFailed
+		}
+	}
+
+	if h.Limiter != nil {
+		// Only the accepted items count towards the limits of the items after them
+		var accepted []int
+		var checked []models.Transaction
+		for i := range results {
+			if results[i].Error == nil {
+				accepted = append(accepted, i)
+				checked = append(checked, transactions[i])
+			}
+		}
+		breaches, err := h.Limiter.CheckBatch(r.Context(), checked)
+		if err != nil {
+			log.Println(err)
+			return newProblem(r, http.StatusInternalServerError, codeInternal, "error checking spending limits")
+		}
+		for j, breach := range breaches {
+			if breach != nil {
+				i := accepted[j]
+				results[i].Status, results[i].Error = batchItemFailed, limitProblem(r, breach)
+			}
+		}
+	}
+
+	for i := range results {
+		if results[i].Error != nil {
+			continue
+		}
+		if problem := h.screenTransaction(r, &transactions[i]); problem != nil {
+			results[i].Status, results[i].Error = batchItemFailed, problem
+		}
+	}
+
+	if mode == batchAllOrNothing {
+		return h.storeAllOrNothing(r, transactions, results)
+	}
+	h.storeBestEffort(r, transactions, results)
+	return nil
+}
+
+// storeAllOrNothing stores the transactions of a batch in one go if none of its items failed.
+func (h *Handler) storeAllOrNothing(r *http.Request, transactions []models.Transaction, results []batchResult) *Problem {
+	for _, result := range results {
+		if result.Error != nil {
+			return nil
+		}
+	}
+
+	if err := h.DB.CreateTransactions(r.Context(), transactions); err != nil {
+		return createProblem(r, err)
+	}
+	for i := range results {
+		h.Events.Publish(transactions[i].ID)
+		results[i].Status, results[i].Transaction = batchItemCreated, &transactions[i]
+	}
+	return nil
+}
+
+// storeBestEffort stores each transaction of a batch whose item has not failed on its own.
+func (h *Handler) storeBestEffort(r *http.Request, transactions []models.Transaction, results []batchResult) {
+	for i := range results {
+		if results[i].Error != nil {
+			continue
+		}
+		if err := h.DB.CreateTransaction(r.Context(), transactions[i]); err != nil {
+			results[i].Status, results[i].Error = batchItemFailed, createProblem(r, err)
+		} else {
+			h.Events.Publish(transactions[i].ID)
+			results[i].Status, results[i].Transaction = batchItemCreated, &transactions[i]
+		}
+	}
+}
+
+// readBatch reads the items of a batch request body: the elements of a JSON array or, for an NDJSON body,
+// its non-blank lines. Items are returned undecoded so that a malformed NDJSON line fails only its own item.
+// The error is the detail to report to the client.
+func readBatch(w http.ResponseWriter, r *http.Request) ([]json.RawMessage, error) {
+	defer r.Body.Close()
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBatchBytes))
+	if err != nil {
+		log.Println(err)
+		return nil, fmt.Errorf("batch must be at most %d bytes", maxBatchBytes)
+	}
+
+	var items []json.RawMessage
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == ndjsonContentType {
+		for _, line := range bytes.Split(body, []byte("\n")) {
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				items = append(items, line)
+			}
+		}
+	} else if err := json.Unmarshal(body, &items); err != nil {
+		log.Println(err)
+		return nil, errors.New("batch must be a JSON array of transactions")
+	}
+
+	if len(items) == 0 {
+		return nil, errors.New("batch must contain at least one transaction")
+	}
+	if len(items) > maxBatchSize {
+		return nil, fmt.Errorf("batch must contain at most %d transactions", maxBatchSize)
+	}
+	return items, nil
+}
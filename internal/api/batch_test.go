@@ -0,0 +1,229 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/db"
+	"github.com/abadojack/gapstack/internal/limits"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// serveBatch sends a batch request with the given content type through a router.
+func serveBatch(handler *Handler, query, contentType, body string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("POST", "/transactions/batch"+query, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestHandler_CreateTransactionBatch(t *testing.T) {
+	t.Run("all or nothing creates every transaction at once", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("CreateTransactions", mock.MatchedBy(func(transactions []models.Transaction) bool {
+			return len(transactions) == 2 && transactions[0].Currency == "USD" && transactions[1].Receiver == "carol" &&
+				transactions[0].ID != "" && transactions[0].Status == models.StatusPending
+		})).Return(nil)
+
+		rr := serveBatch(NewHandler(mockDB), "", "application/json", `[
+			{"amount": "10", "currency": "usd", "sender": "payroll", "receiver": "bob"},
+			{"amount": "20", "currency": "USD", "sender": "payroll", "receiver": "carol"}
+		]`)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var response batchResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, batchAllOrNothing, response.Mode)
+		assert.Equal(t, 2, response.Created)
+		assert.Equal(t, 0, response.Failed)
+		require.Len(t, response.Results, 2)
+		assert.Equal(t, 1, response.Results[1].Index)
+		assert.Equal(t, batchItemCreated, response.Results[1].Status)
+		require.NotNil(t, response.Results[1].Transaction)
+		assert.Equal(t, "20.00", response.Results[1].Transaction.Amount.String())
+		mockDB.AssertNotCalled(t, "CreateTransaction", mock.Anything)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("all or nothing rejects the batch if any item fails", func(t *testing.T) {
+		mockDB := new(MockDB)
+
+		rr := serveBatch(NewHandler(mockDB), "?mode=all_or_nothing", "application/json", `[
+			{"amount": "10", "currency": "USD", "sender": "payroll", "receiver": "bob"},
+			{"amount": "10", "currency": "USD", "sender": "payroll"},
+			"not a transaction"
+		]`)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		var problem Problem
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+		assert.Equal(t, codeBatchRejected, problem.Code)
+		assert.Equal(t, "2 of 3 transactions failed; none were created", problem.Detail)
+		require.Len(t, problem.Results, 2)
+		assert.Equal(t, 1, problem.Results[0].Index)
+		assert.Equal(t, batchItemFailed, problem.Results[0].Status)
+		assert.Equal(t, codeValidationFailed, problem.Results[0].Error.Code)
+		assert.Equal(t, "receiver", problem.Results[0].Error.Errors[0].Field)
+		assert.Equal(t, 2, problem.Results[1].Index)
+		assert.Equal(t, codeInvalidRequestBody, problem.Results[1].Error.Code)
+		mockDB.AssertNotCalled(t, "CreateTransactions", mock.Anything)
+	})
+
+	t.Run("all or nothing reports a store failure for the whole batch", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("CreateTransactions", mock.Anything).Return(db.ErrConflict)
+
+		rr := serveBatch(NewHandler(mockDB), "", "application/json", `[{"amount": "10", "currency": "USD", "sender": "payroll", "receiver": "bob"}]`)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Contains(t, rr.Body.String(), codeConflict)
+	})
+
+	t.Run("best effort creates what it can from NDJSON", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("CreateTransaction", mock.MatchedBy(func(tx models.Transaction) bool { return tx.Receiver == "bob" })).Return(nil)
+		mockDB.On("CreateTransaction", mock.MatchedBy(func(tx models.Transaction) bool { return tx.Receiver == "dave" })).
+			Return(errors.New("database error"))
+
+		rr := serveBatch(NewHandler(mockDB), "?mode=best_effort", "application/x-ndjson; charset=utf-8",
+			`{"amount": "10", "currency": "USD", "sender": "payroll", "receiver": "bob"}`+"\n"+
+				`{"amount": "10", "currency": "USD",`+"\n"+
+				"\n"+
+				`{"amount": "10", "currency": "USD", "sender": "payroll", "receiver": "dave"}`+"\n")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response batchResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, batchBestEffort, response.Mode)
+		assert.Equal(t, 1, response.Created)
+		assert.Equal(t, 2, response.Failed)
+		require.Len(t, response.Results, 3)
+		assert.Equal(t, batchItemCreated, response.Results[0].Status)
+		assert.NotEmpty(t, response.Results[0].Transaction.ID)
+		assert.Equal(t, codeInvalidRequestBody, response.Results[1].Error.Code)
+		assert.Equal(t, 2, response.Results[2].Index)
+		assert.Equal(t, codeInternal, response.Results[2].Error.Code)
+		mockDB.AssertNotCalled(t, "CreateTransactions", mock.Anything)
+	})
+
+	t.Run("limits count the items before", func(t *testing.T) {
+		store := db.NewMemoryDB()
+		defaults, err := models.ParseSpendingLimits("USD/1h=2")
+		require.NoError(t, err)
+		handler := NewHandler(store)
+		handler.Limiter = limits.NewLimiter(store, defaults)
+
+		item := `{"amount": "10", "currency": "USD", "sender": "payroll", "receiver": "bob"}`
+		rr := serveBatch(handler, "?mode=best_effort", "application/json", "["+item+","+item+","+item+"]")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response batchResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Created)
+		require.Len(t, response.Results, 3)
+		assert.Equal(t, codeLimitExceeded, response.Results[2].Error.Code)
+		assert.Equal(t, limits.ExceededCount, response.Results[2].Error.Breach.Exceeded)
+		count, err := store.CountTransactions(t.Context(), db.TransactionFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		tooMany := "[" + strings.TrimSuffix(strings.Repeat("{},", maxBatchSize+1), ",") + "]"
+		tests := []struct {
+			name   string
+			query  string
+			body   string
+			code   string
+			detail string
+		}{
+			{"unknown mode", "?mode=some", "[{}]", codeValidationFailed, "validation failed: mode must be all_or_nothing or best_effort"},
+			{"not an array", "", `{"amount": "10"}`, codeInvalidRequestBody, "batch must be a JSON array of transactions"},
+			{"empty batch", "", "[]", codeInvalidRequestBody, "batch must contain at least one transaction"},
+			{"too many transactions", "", tooMany, codeInvalidRequestBody, "batch must contain at most 5000 transactions"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockDB := new(MockDB)
+
+				rr := serveBatch(NewHandler(mockDB), tt.query, "application/json", tt.body)
+
+				assert.Equal(t, http.StatusBadRequest, rr.Code)
+				var problem Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				assert.Equal(t, tt.code, problem.Code)
+				assert.Equal(t, tt.detail, problem.Detail)
+				mockDB.AssertNotCalled(t, "CreateTransactions", mock.Anything)
+			})
+		}
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		mockDB := new(MockDB)
+
+		rr := serveBatch(NewHandler(mockDB), "", "application/json", "["+strings.Repeat(" ", maxBatchBytes)+"]")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "batch must be at most")
+	})
+}
+
+// slowScreener approves every transaction after a delay.
+type slowScreener struct {
+	delay time.Duration
+}
+
+func (s slowScreener) Name() string {
+	return "slow"
+}
+
+func (s slowScreener) Screen(ctx context.Context, transaction models.Transaction) (models.Screening, error) {
+	time.Sleep(s.delay)
+	return models.Screening{Decision: models.ScreeningApprove}, nil
+}
+
+func TestHandler_CreateTransactionBatch_OutlivesServerTimeouts(t *testing.T) {
+	handler := NewHandler(db.NewMemoryDB())
+	handler.Screener = slowScreener{delay: 50 * time.Millisecond}
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	// Screening the batch takes three times as long as the server allows a request
+	server := httptest.NewUnstartedServer(router)
+	server.Config.ReadTimeout = 100 * time.Millisecond
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	t.Cleanup(func() {
+		handler.Events.Close()
+		server.Close()
+	})
+
+	items := make([]string, 6)
+	for i := range items {
+		items[i] = `{"amount": "10", "currency": "USD", "sender": "payroll", "receiver": "bob"}`
+	}
+	resp, err := server.Client().Post(server.URL+"/transactions/batch", "application/json",
+		strings.NewReader("["+strings.Join(items, ",")+"]"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	var response batchResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Equal(t, len(items), response.Created)
+}
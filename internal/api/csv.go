@@ -0,0 +1,313 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+const (
+	// csvContentType is the media type of exported transactions
+	csvContentType = "text/csv; charset=utf-8"
+	// exportFlushRows is the number of rows an export writes between flushes to the client
+	exportFlushRows = 500
+)
+
+// exportQueryParams is the set of query parameters accepted by the transaction export.
+var exportQueryParams = map[string]bool{
+	"format": true, "sort": true,
+	"status": true, "currency": true, "sender": true, "receiver": true,
+	"min_amount": true, "max_amount": true, "created_from": true, "created_to": true,
+}
+
+// exportColumns is the header row of a CSV export.
+var exportColumns = []string{
+	"id", "amount", "currency", "sender", "receiver", "status", "failure_reason",
+	"target_currency", "target_amount", "rate", "quote_id", "created_at",
+}
+
+// importColumns is the set of columns an import reads. Other columns, such as those of an export, are ignored.
+var importColumns = map[string]bool{
+	"amount": true, "currency": true, "sender": true, "receiver": true, "target_currency": true, "quote_id": true,
+}
+
+// requiredImportColumns are the columns every import must have.
+var requiredImportColumns = []string{"amount", "currency", "sender", "receiver"}
+
+// ExportTransactions handles GET requests to download every transaction matching the list filters.
+// format=csv, the only format, is required. Rows are written as they are read from the store, in the order
+// given by sort, so an export of any size holds no more than a few rows in memory. If the store fails once
+// rows have been sent, the connection is aborted so that the client cannot mistake a partial file for a
+// complete one.
+func (h *Handler) ExportTransactions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var errs ValidationErrors
+	rejectUnknownParams(query, exportQueryParams, &errs)
+	if format := query.Get("format"); format != "csv" {
+		errs.add("format", fieldUnsupported, "format must be csv")
+	}
+	filterQuery := url.Values{}
+	for param, values := range query {
+		if param != "format" && exportQueryParams[param] {
+			filterQuery[param] = values
+		}
+	}
+	filter, filterErrs := parseTransactionFilter(filterQuery)
+	errs = append(errs, filterErrs...)
+	if len(errs) > 0 {
+		writeValidationProblem(w, r, errs)
+		return
+	}
+
+	// A large export outlives the server's write timeout, so lift it for this response where supported
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	writer := csv.NewWriter(w)
+	rows := 0
+	// The response is started by the first row, or at the end if there is none, so that a query that fails
+	// straight away can still be reported as a problem
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", csvContentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="transactions.csv"`)
+		w.WriteHeader(http.StatusOK)
+		return writer.Write(exportColumns)
+	}
+
+	err := h.DB.EachTransaction(r.Context(), filter, func(transaction models.Transaction) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := writer.Write(exportRecord(transaction)); err != nil {
+			return err
+		}
+		if rows++; rows%exportFlushRows == 0 {
+			writer.Flush()
+			if err := writer.Error(); err != nil {
+				return err
+			}
+			return rc.Flush()
+		}
+		return nil
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil {
+		writer.Flush()
+		if err = writer.Error(); err == nil {
+			return
+		}
+	}
+
+	if r.Context().Err() == nil {
+		log.Println(err)
+	}
+	if !started {
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error exporting transactions")
+		return
+	}
+	panic(http.ErrAbortHandler)
+}
+
+// exportRecord formats a transaction as a row of exportColumns. Times are RFC 3339 in UTC.
+func exportRecord(transaction models.Transaction) []string {
+	var targetCurrency, targetAmount, rate, quoteID string
+	if conversion := transaction.Conversion; conversion != nil {
+		targetCurrency, targetAmount = conversion.TargetCurrency, conversion.TargetAmount.String()
+		rate, quoteID = conversion.Rate.String(), conversion.QuoteID
+	}
+	return []string{
+		transaction.ID,
+		transaction.Amount.String(),
+		transaction.Currency,
+		escapeCSVText(transaction.Sender),
+		escapeCSVText(transaction.Receiver),
+		string(transaction.Status),
+		escapeCSVText(transaction.FailureReason),
+		targetCurrency,
+		targetAmount,
+		rate,
+		quoteID,
+		transaction.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// escapeCSVText keeps a spreadsheet from evaluating client-supplied text as a formula by prefixing
+// values that start with a formula character with an apostrophe. unescapeCSVText reverses it.
+func escapeCSVText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// unescapeCSVText removes the apostrophe escapeCSVText adds, so that exported rows can be imported again.
+func unescapeCSVText(s string) string {
+	if escaped, ok := strings.CutPrefix(s, "'"); ok && escapeCSVText(escaped) == s {
+		return escaped
+	}
+	return s
+}
+
+// ImportTransactions handles POST requests to create transactions from a CSV file, such as corrections
+// prepared in a spreadsheet. The first row names the columns, in any order and case: amount, currency,
+// sender and receiver are required, target_currency and quote_id are optional, and any other column is
+// ignored. Each row is validated like the body of a single request and then created as an item of a batch,
+// with the same mode parameter and responses as CreateTransactionBatch. Every result carries the line of
+// the file its row was read from.
+func (h *Handler) ImportTransactions(w http.ResponseWriter, r *http.Request) {
+	liftBatchDeadlines(w)
+
+	var errs ValidationErrors
+	mode := parseBatchMode(r.URL.Query(), &errs)
+	if len(errs) > 0 {
+		writeValidationProblem(w, r, errs)
+		return
+	}
+
+	defer r.Body.Close()
+	reader := csv.NewReader(http.MaxBytesReader(w, r.Body, maxBatchBytes))
+	// Rows are checked against the header here, so that a short row fails on its own
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidRequestBody, "CSV must start with a header row")
+		return
+	}
+	if err != nil {
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidRequestBody, csvReadError(err))
+		return
+	}
+	columns, errs := parseImportHeader(header)
+	if len(errs) > 0 {
+		writeValidationProblem(w, r, errs)
+		return
+	}
+
+	var results []batchResult
+	var transactions []models.Transaction
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			writeProblem(w, r, http.StatusBadRequest, codeInvalidRequestBody, csvReadError(err))
+			return
+		}
+		// Spreadsheets often save trailing rows with every cell empty
+		if !slices.ContainsFunc(record, func(cell string) bool { return strings.TrimSpace(cell) != "" }) {
+			continue
+		}
+		if len(results) == maxBatchSize {
+			writeProblem(w, r, http.StatusBadRequest, codeInvalidRequestBody,
+				fmt.Sprintf("CSV must contain at most %d transactions", maxBatchSize))
+			return
+		}
+
+		line, _ := reader.FieldPos(0)
+		result := batchResult{Index: len(results), Row: line}
+		var transaction models.Transaction
+		if len(record) != len(header) {
+			result.Error = newProblem(r, http.StatusBadRequest, codeInvalidRequestBody,
+				fmt.Sprintf("row has %d columns but the header has %d", len(record), len(header)))
+		} else {
+			transaction, result.Error = h.prepareImportRow(r, columns, record)
+		}
+		results = append(results, result)
+		transactions = append(transactions, transaction)
+	}
+	if len(results) == 0 {
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidRequestBody, "CSV must contain at least one transaction")
+		return
+	}
+
+	h.createBatch(w, r, mode, transactions, results)
+}
+
+// parseImportHeader maps each column an import reads to its position in the header row.
+func parseImportHeader(header []string) (map[string]int, ValidationErrors) {
+	var errs ValidationErrors
+	columns := make(map[string]int)
+	for i, name := range header {
+		if i == 0 {
+			// Spreadsheets tend to save UTF-8 with a byte order mark
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !importColumns[name] {
+			continue
+		}
+		if _, ok := columns[name]; ok {
+			errs.add(name, fieldInvalid, fmt.Sprintf("%s column appears more than once", name))
+			continue
+		}
+		columns[name] = i
+	}
+
+	for _, name := range requiredImportColumns {
+		if _, ok := columns[name]; !ok {
+			errs.add(name, fieldRequired, fmt.Sprintf("%s column is required", name))
+		}
+	}
+	return columns, errs
+}
+
+// prepareImportRow builds the request to create a transaction from a row of an import and readies it like
+// prepareTransaction. A cell that cannot be parsed is reported against its column.
+func (h *Handler) prepareImportRow(r *http.Request, columns map[string]int, record []string) (models.Transaction, *Problem) {
+	cell := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var errs ValidationErrors
+	transaction := models.Transaction{
+		Currency: cell("currency"),
+		Sender:   unescapeCSVText(cell("sender")),
+		Receiver: unescapeCSVText(cell("receiver")),
+	}
+	if value := cell("amount"); value == "" {
+		errs.add("amount", fieldRequired, "amount is required")
+	} else if amount, err := models.ParseAmount(value); err != nil {
+		errs.add("amount", fieldInvalid, "amount must be a decimal number, such as 12.50")
+	} else {
+		transaction.Amount = amount
+	}
+
+	conversion := conversionRequest{TargetCurrency: cell("target_currency"), QuoteID: cell("quote_id")}
+	return h.acceptTransaction(r, transaction, conversion, errs)
+}
+
+// csvReadError turns an error reading an import into the detail to report to the client.
+func csvReadError(err error) string {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Sprintf("CSV is malformed on line %d: %v", parseErr.Line, parseErr.Err)
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return fmt.Sprintf("CSV must be at most %d bytes", maxBatchBytes)
+	}
+	log.Println(err)
+	return "error reading CSV"
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/db"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// serveCSV sends a request through a router with every route registered.
+func serveCSV(handler *Handler, method, target, body string) *httptest.ResponseRecorder {
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/csv")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestHandler_ExportTransactions(t *testing.T) {
+	t.Run("streams the filtered transactions", func(t *testing.T) {
+		createdAt := time.Date(2026, 3, 1, 12, 30, 0, 0, time.UTC)
+		transactions := []models.Transaction{
+			{ID: "txn-1", Amount: models.MustParseAmount("10.50"), Currency: "USD", Sender: "payroll", Receiver: "bob",
+				Status: models.StatusCompleted, CreatedAt: createdAt},
+			{ID: "txn-2", Amount: models.MustParseAmount("20.00"), Currency: "USD", Sender: "=HYPERLINK(\"x\")", Receiver: "carol",
+				Status: models.StatusFailed, FailureReason: "insufficient funds, try later", CreatedAt: createdAt,
+				Conversion: &models.Conversion{TargetCurrency: "KES", TargetAmount: models.MustParseAmount("2583.00"),
+					Rate: models.MustParseRate("129.15"), QuoteID: "quote-1"}},
+		}
+		filter := db.TransactionFilter{Currency: "USD", SortBy: db.SortByAmount, Descending: true}
+		mockDB := new(MockDB)
+		mockDB.On("EachTransaction", filter).Return(transactions, nil)
+
+		rr := serveCSV(NewHandler(mockDB), "GET", "/transactions/export?format=csv&currency=usd&sort=-amount", "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Header().Get("Content-Disposition"), "attachment")
+		assert.Equal(t, "id,amount,currency,sender,receiver,status,failure_reason,target_currency,target_amount,rate,quote_id,created_at\n"+
+			"txn-1,10.50,USD,payroll,bob,completed,,,,,,2026-03-01T12:30:00Z\n"+
+			`txn-2,20.00,USD,"'=HYPERLINK(""x"")",carol,failed,"insufficient funds, try later",KES,2583.00,129.15,quote-1,2026-03-01T12:30:00Z`+"\n",
+			rr.Body.String())
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("no matches is a header only", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("EachTransaction", mock.Anything).Return([]models.Transaction{}, nil)
+
+		rr := serveCSV(NewHandler(mockDB), "GET", "/transactions/export?format=csv", "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, strings.Join(exportColumns, ",")+"\n", rr.Body.String())
+	})
+
+	t.Run("store failure before any row", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("EachTransaction", mock.Anything).Return([]models.Transaction{}, errors.New("database error"))
+
+		rr := serveCSV(NewHandler(mockDB), "GET", "/transactions/export?format=csv", "")
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Contains(t, rr.Body.String(), codeInternal)
+	})
+
+	t.Run("store failure after rows aborts the response", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("EachTransaction", mock.Anything).
+			Return([]models.Transaction{{ID: "txn-1", Amount: models.MustParseAmount("1"), Currency: "USD"}}, errors.New("database error"))
+
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			serveCSV(NewHandler(mockDB), "GET", "/transactions/export?format=csv", "")
+		})
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		mockDB := new(MockDB)
+
+		rr := serveCSV(NewHandler(mockDB), "GET", "/transactions/export?format=xlsx&page=2&status=lost", "")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		var problem Problem
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+		fields := make([]string, len(problem.Errors))
+		for i, fieldErr := range problem.Errors {
+			fields[i] = fieldErr.Field
+		}
+		assert.ElementsMatch(t, []string{"format", "page", "status"}, fields)
+		mockDB.AssertNotCalled(t, "EachTransaction", mock.Anything)
+	})
+}
+
+func TestHandler_ImportTransactions(t *testing.T) {
+	t.Run("maps columns by header", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("CreateTransactions", mock.MatchedBy(func(transactions []models.Transaction) bool {
+			return len(transactions) == 2 &&
+				transactions[0].Sender == "payroll" && transactions[0].Receiver == "bob" &&
+				transactions[0].Amount.String() == "10.50" && transactions[0].Currency == "USD" &&
+				transactions[1].Sender == "=cmd" && transactions[1].Status == models.StatusPending
+		})).Return(nil)
+
+		rr := serveCSV(NewHandler(mockDB), "POST", "/transactions/import",
+			"\ufeffID, Receiver,SENDER,Currency,amount,status\n"+
+				"txn-old,bob,payroll,usd,10.5,completed\n"+
+				",,,,,\n"+
+				"txn-old,carol,'=cmd,USD,20,failed\n")
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var response batchResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, 2, response.Created)
+		require.Len(t, response.Results, 2)
+		assert.Equal(t, 2, response.Results[0].Row)
+		assert.Equal(t, 4, response.Results[1].Row)
+		assert.NotEqual(t, "txn-old", response.Results[0].Transaction.ID)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("reports every failed row", func(t *testing.T) {
+		mockDB := new(MockDB)
+
+		rr := serveCSV(NewHandler(mockDB), "POST", "/transactions/import",
+			"amount,currency,sender,receiver\n"+
+				"10,USD,payroll,bob\n"+
+				"ten,USD,payroll,payroll\n"+
+				"10,XYZ,payroll\n"+
+				"-5,USD,payroll,carol\n")
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		var problem Problem
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+		assert.Equal(t, codeBatchRejected, problem.Code)
+		require.Len(t, problem.Results, 3)
+
+		assert.Equal(t, 3, problem.Results[0].Row)
+		require.Len(t, problem.Results[0].Error.Errors, 2)
+		assert.Equal(t, FieldError{Field: "amount", Code: fieldInvalid, Message: "amount must be a decimal number, such as 12.50"},
+			problem.Results[0].Error.Errors[0])
+		assert.Equal(t, "receiver", problem.Results[0].Error.Errors[1].Field)
+
+		assert.Equal(t, 4, problem.Results[1].Row)
+		assert.Equal(t, codeInvalidRequestBody, problem.Results[1].Error.Code)
+		assert.Equal(t, "row has 3 columns but the header has 4", problem.Results[1].Error.Detail)
+
+		assert.Equal(t, 5, problem.Results[2].Row)
+		assert.Equal(t, "amount must be greater than 0", problem.Results[2].Error.Errors[0].Message)
+		mockDB.AssertNotCalled(t, "CreateTransactions", mock.Anything)
+	})
+
+	t.Run("best effort creates the valid rows", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("CreateTransaction", mock.Anything).Return(nil)
+
+		rr := serveCSV(NewHandler(mockDB), "POST", "/transactions/import?mode=best_effort",
+			"amount,currency,sender,receiver\n10,USD,payroll,bob\n10,USD,payroll,\n")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response batchResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, 1, response.Created)
+		assert.Equal(t, 1, response.Failed)
+		assert.Equal(t, 3, response.Results[1].Row)
+		assert.Equal(t, "receiver is required", response.Results[1].Error.Errors[0].Message)
+		mockDB.AssertNumberOfCalls(t, "CreateTransaction", 1)
+	})
+
+	t.Run("invalid files", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			body   string
+			code   string
+			detail string
+		}{
+			{"empty", "", codeInvalidRequestBody, "CSV must start with a header row"},
+			{"missing columns", "amount,currency,sender,note\n", codeValidationFailed, "validation failed: receiver column is required"},
+			{"duplicate column", "amount,currency,sender,receiver,Amount\n", codeValidationFailed, "validation failed: amount column appears more than once"},
+			{"no rows", "amount,currency,sender,receiver\n,,,\n", codeInvalidRequestBody, "CSV must contain at least one transaction"},
+			{"malformed", "amount,currency,sender,receiver\n10,USD,\"payroll,bob\n", codeInvalidRequestBody, "CSV is malformed on line 2: extraneous or missing \" in quoted-field"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockDB := new(MockDB)
+
+				rr := serveCSV(NewHandler(mockDB), "POST", "/transactions/import", tt.body)
+
+				assert.Equal(t, http.StatusBadRequest, rr.Code)
+				var problem Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				assert.Equal(t, tt.code, problem.Code)
+				assert.Equal(t, tt.detail, problem.Detail)
+				mockDB.AssertNotCalled(t, "CreateTransactions", mock.Anything)
+			})
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/db"
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// errInvalidCursor is returned when a pagination cursor token cannot be decoded
+// or was issued for a different sort order than the current request.
+var errInvalidCursor = errors.New("invalid cursor")
+
+// cursorToken is the JSON payload of an opaque pagination cursor.
+// Clients must treat the encoded token as opaque; its layout may change between releases.
+type cursorToken struct {
+	CreatedAt  time.Time     `json:"t"`
+	Amount     models.Amount `json:"a"`
+	ID         string        `json:"id"`
+	Before     bool          `json:"b,omitempty"`
+	SortBy     db.SortField  `json:"s"`
+	Descending bool          `json:"d,omitempty"`
+}
+
+// encodeCursor serializes a keyset position and the sort order it belongs to into an opaque URL-safe token.
+func encodeCursor(cursor db.Cursor, filter db.TransactionFilter) string {
+	data, _ := json.Marshal(cursorToken{
+		CreatedAt:  cursor.CreatedAt,
+		Amount:     cursor.Amount,
+		ID:         cursor.ID,
+		Before:     cursor.Before,
+		SortBy:     filter.SortBy,
+		Descending: filter.Descending,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a token produced by encodeCursor.
+// Tokens issued for a different sort order are rejected, since their position would be meaningless.
+func decodeCursor(token string, filter db.TransactionFilter) (*db.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	var payload cursorToken
+	if err := json.Unmarshal(data, &payload); err != nil || payload.ID == "" || payload.CreatedAt.IsZero() {
+		return nil, errInvalidCursor
+	}
+	if payload.SortBy != filter.SortBy || payload.Descending != filter.Descending {
+		return nil, errInvalidCursor
+	}
+
+	return &db.Cursor{
+		CreatedAt: payload.CreatedAt,
+		Amount:    payload.Amount,
+		ID:        payload.ID,
+		Before:    payload.Before,
+	}, nil
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/db"
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// listQueryParams is the set of query parameters accepted by the transaction list endpoint.
+// Anything else is rejected so that typos such as "stauts" are not silently ignored.
+var listQueryParams = map[string]bool{
+	"page": true, "page_size": true, "cursor": true, "sort": true, "include_total": true,
+	"status": true, "currency": true, "sender": true, "receiver": true,
+	"min_amount": true, "max_amount": true, "created_from": true, "created_to": true,
+}
+
+// parseTransactionFilter builds a typed filter from list query parameters.
+// Statuses may be comma-separated, amounts are exact decimals, times are RFC 3339,
+// and sort is "created_at" or "amount" with an optional leading "-" for descending order.
+func parseTransactionFilter(query url.Values) (db.TransactionFilter, ValidationErrors) {
+	filter := db.TransactionFilter{SortBy: db.SortByCreatedAt}
+	var errs ValidationErrors
+
+	rejectUnknownParams(query, listQueryParams, &errs)
+
+	if value := query.Get("status"); value != "" {
+		for _, part := range strings.Split(value, ",") {
+			status := models.Status(strings.TrimSpace(part))
+			if !status.IsValid() {
+				errs.add("status", fieldInvalid, fmt.Sprintf("unknown status %q", status))
+				continue
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	if value := query.Get("currency"); value != "" {
+		if !isValidCurrency(value) {
+			errs.add("currency", fieldInvalid, "currency must be a valid 3-letter ISO code (e.g., USD, EUR, GBP)")
+		}
+		filter.Currency = strings.ToUpper(value)
+	}
+
+	filter.Sender = query.Get("sender")
+	filter.Receiver = query.Get("receiver")
+
+	filter.MinAmount = parseAmountParam(query, "min_amount", &errs)
+	filter.MaxAmount = parseAmountParam(query, "max_amount", &errs)
+	if filter.MinAmount != nil && filter.MaxAmount != nil && filter.MinAmount.Cmp(*filter.MaxAmount) > 0 {
+		errs.add("max_amount", fieldOutOfRange, "max_amount must not be less than min_amount")
+	}
+
+	filter.CreatedFrom = parseTimeParam(query, "created_from", &errs)
+	filter.CreatedTo = parseTimeParam(query, "created_to", &errs)
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		errs.add("created_to", fieldOutOfRange, "created_to must be after created_from")
+	}
+
+	if value := query.Get("sort"); value != "" {
+		field := strings.TrimPrefix(value, "-")
+		filter.SortBy = db.SortField(field)
+		filter.Descending = strings.HasPrefix(value, "-")
+		if !filter.SortBy.IsValid() {
+			errs.add("sort", fieldInvalid, "sort must be one of created_at, -created_at, amount, -amount")
+		}
+	}
+
+	return filter, errs
+}
+
+// rejectUnknownParams reports every query parameter that is not in allowed, in a stable order.
+func rejectUnknownParams(query url.Values, allowed map[string]bool, errs *ValidationErrors) {
+	var unknown []string
+	for param := range query {
+		if !allowed[param] {
+			unknown = append(unknown, param)
+		}
+	}
+	sort.Strings(unknown)
+	for _, param := range unknown {
+		errs.add(param, fieldInvalid, fmt.Sprintf("unknown query parameter %q", param))
+	}
+}
+
+// parseAmountParam parses an optional exact decimal query parameter.
+func parseAmountParam(query url.Values, name string, errs *ValidationErrors) *models.Amount {
+	value := query.Get(name)
+	if value == "" {
+		return nil
+	}
+
+	amount, err := models.ParseAmount(value)
+	if err != nil {
+		errs.add(name, fieldInvalid, name+" must be a decimal amount")
+		return nil
+	}
+	return &amount
+}
+
+// parseTimeParam parses an optional RFC 3339 timestamp query parameter.
+func parseTimeParam(query url.Values, name string, errs *ValidationErrors) *time.Time {
+	value := query.Get(name)
+	if value == "" {
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		errs.add(name, fieldInvalid, name+" must be an RFC 3339 timestamp")
+		return nil
+	}
+	return &t
+}
+
+// parseCurrencyParam parses an optional currency query parameter, returning it in upper case.
+func parseCurrencyParam(query url.Values, errs *ValidationErrors) string {
+	value := query.Get("currency")
+	if value == "" {
+		return ""
+	}
+	if !isValidCurrency(value) {
+		errs.add("currency", fieldInvalid, "currency must be a valid 3-letter ISO code (e.g., USD, EUR, GBP)")
+	}
+	return strings.ToUpper(value)
+}
+
+// parseIntParam parses an optional integer query parameter that must lie between minimum and maximum.
+func parseIntParam(query url.Values, name string, defaultValue, minimum, maximum int, errs *ValidationErrors) int {
+	value := query.Get(name)
+	if value == "" {
+		return defaultValue
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		errs.add(name, fieldInvalid, name+" must be an integer")
+		return defaultValue
+	}
+	if n < minimum || n > maximum {
+		errs.add(name, fieldOutOfRange, fmt.Sprintf("%s must be between %d and %d", name, minimum, maximum))
+		return defaultValue
+	}
+	return n
+}
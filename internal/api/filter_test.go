@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/db"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTransactionFilter(t *testing.T) {
+	t.Run("all filters", func(t *testing.T) {
+		query, err := url.ParseQuery("status=pending,failed&currency=usd&sender=alice&receiver=bob" +
+			"&min_amount=10&max_amount=99.95&created_from=2025-10-01T00:00:00Z&created_to=2025-11-01T00:00:00Z&sort=-amount")
+		require.NoError(t, err)
+
+		filter, errs := parseTransactionFilter(query)
+		require.Empty(t, errs)
+
+		minAmount := models.MustParseAmount("10")
+		maxAmount := models.MustParseAmount("99.95")
+		from := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+		assert.Equal(t, db.TransactionFilter{
+			Statuses:    []models.Status{models.StatusPending, models.StatusFailed},
+			Currency:    "USD",
+			Sender:      "alice",
+			Receiver:    "bob",
+			MinAmount:   &minAmount,
+			MaxAmount:   &maxAmount,
+			CreatedFrom: &from,
+			CreatedTo:   &to,
+			SortBy:      db.SortByAmount,
+			Descending:  true,
+		}, filter)
+	})
+
+	t.Run("defaults to created_at ascending", func(t *testing.T) {
+		filter, errs := parseTransactionFilter(url.Values{})
+		require.Empty(t, errs)
+		assert.Equal(t, db.TransactionFilter{SortBy: db.SortByCreatedAt}, filter)
+	})
+
+	t.Run("invalid values are reported per field", func(t *testing.T) {
+		query, err := url.ParseQuery("stauts=pending&status=settled&min_amount=ten&created_from=yesterday&sort=sender")
+		require.NoError(t, err)
+
+		_, errs := parseTransactionFilter(query)
+
+		fields := make([]string, len(errs))
+		for i, fieldErr := range errs {
+			fields[i] = fieldErr.Field
+		}
+		assert.Equal(t, []string{"stauts", "status", "min_amount", "created_from", "sort"}, fields)
+	})
+
+	t.Run("inverted ranges", func(t *testing.T) {
+		query, err := url.ParseQuery("min_amount=100&max_amount=10&created_from=2025-11-01T00:00:00Z&created_to=2025-10-01T00:00:00Z")
+		require.NoError(t, err)
+
+		_, errs := parseTransactionFilter(query)
+		require.Len(t, errs, 2)
+		assert.Equal(t, "max_amount", errs[0].Field)
+		assert.Equal(t, "created_to", errs[1].Field)
+	})
+}
+
+func TestDecodeCursor_SortMismatch(t *testing.T) {
+	byAmount := db.TransactionFilter{SortBy: db.SortByAmount}
+	token := encodeCursor(db.Cursor{CreatedAt: time.Now(), ID: "txn-1"}, byAmount)
+
+	_, err := decodeCursor(token, byAmount)
+	assert.NoError(t, err)
+
+	_, err = decodeCursor(token, defaultFilter)
+	assert.ErrorIs(t, err, errInvalidCursor)
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/db"
+	"github.com/abadojack/gapstack/internal/iso20022"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// xmlContentType is the media type of ISO 20022 messages.
+const xmlContentType = "application/xml; charset=utf-8"
+
+// CreatePain001Transactions handles POST requests carrying a pain.001 customer credit transfer initiation.
+// Every transfer is created as an item of a batch, through the same validation, conversion, spending limits
+// and screening as a single request, with the same mode parameter as CreateTransactionBatch. A creditor
+// account held in another currency asks for a conversion to it, and a transfer with a UETR is given it as
+// its ID, so that a message sent twice is refused as duplicate.
+// The response is a pain.002 status report: 201 if every transfer was created, 200 if some were not, and
+// 422 if an all_or_nothing message was rejected, in which case only the failed transfers are reported.
+func (h *Handler) CreatePain001Transactions(w http.ResponseWriter, r *http.Request) {
+	liftBatchDeadlines(w)
+
+	var errs ValidationErrors
+	mode := parseBatchMode(r.URL.Query(), &errs)
+	if len(errs) > 0 {
+		writeValidationProblem(w, r, errs)
+		return
+	}
+
+	defer r.Body.Close()
+	initiation, err := iso20022.ParsePain001(http.MaxBytesReader(w, r.Body, maxBatchBytes))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidRequestBody, fmt.Sprintf("message must be at most %d bytes", maxBatchBytes))
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidRequestBody, err.Error())
+		return
+	}
+	if n := initiation.Len(); n == 0 || n > maxBatchSize {
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidRequestBody,
+			fmt.Sprintf("message must contain between 1 and %d transfers", maxBatchSize))
+		return
+	}
+
+	var results []batchResult
+	var transactions []models.Transaction
+	for _, payment := range initiation.Payments {
+		for _, transfer := range payment.Transfers {
+			conversion := conversionRequest{TargetCurrency: transfer.TargetCurrency}
+			transaction, problem := h.acceptTransaction(r, transfer.Transaction, conversion, nil)
+			if problem == nil && transfer.UETR != "" {
+				transaction.ID = transfer.UETR
+			}
+			results = append(results, batchResult{Index: len(results), Error: problem})
+			transactions = append(transactions, transaction)
+		}
+	}
+	if problem := h.processBatch(r, mode, transactions, results); problem != nil {
+		writeProblemBody(w, *problem)
+		return
+	}
+
+	report := iso20022.StatusReport{Initiation: initiation}
+	created, i := 0, 0
+	for _, payment := range initiation.Payments {
+		for _, transfer := range payment.Transfers {
+			result := results[i]
+			i++
+
+			status := iso20022.TransferStatus{PaymentID: payment.ID, Transfer: transfer, UETR: transfer.UETR}
+			switch {
+			case result.Error != nil:
+				status.Status, status.Reason = iso20022.StatusRejected, rejectionReason(result.Error)
+			case result.Transaction != nil:
+				created++
+				status.UETR, status.Status = result.Transaction.ID, iso20022.TransactionStatus(result.Transaction.Status)
+				if result.Transaction.Status == models.StatusFailed {
+					status.Reason = &iso20022.Reason{Code: iso20022.ReasonRegulatory, Info: result.Transaction.FailureReason}
+				}
+			default:
+				// Left out of a rejected all_or_nothing message through no fault of its own
+				continue
+			}
+			report.Transfers = append(report.Transfers, status)
+		}
+	}
+
+	httpStatus := http.StatusOK
+	switch {
+	case created == len(results):
+		httpStatus, report.GroupStatus = http.StatusCreated, iso20022.StatusAccepted
+	case created > 0:
+		report.GroupStatus = iso20022.StatusPartiallyAccepted
+	default:
+		report.GroupStatus = iso20022.StatusRejected
+		if mode == batchAllOrNothing {
+			httpStatus = http.StatusUnprocessableEntity
+		}
+	}
+	writeMessage(w, r, httpStatus, func(w io.Writer) error {
+		return iso20022.WritePain002(w, report, time.Now())
+	})
+}
+
+// rejectionReason picks the ISO 20022 reason code closest to the problem that failed a transfer, keeping
+// the problem's detail as the description.
+func rejectionReason(problem *Problem) *iso20022.Reason {
+	reason := &iso20022.Reason{Code: iso20022.ReasonNarrative, Info: problem.Detail}
+	switch problem.Code {
+	case codeValidationFailed:
+		if len(problem.Errors) == 0 {
+			break
+		}
+		switch problem.Errors[0].Field {
+		case "amount":
+			reason.Code = iso20022.ReasonInvalidAmount
+		case "currency", "target_currency":
+			reason.Code = iso20022.ReasonNotAllowedCurrency
+		case "sender", "receiver":
+			reason.Code = iso20022.ReasonIncorrectAccount
+		}
+	case codeConversionDisabled:
+		reason.Code = iso20022.ReasonNotAllowedCurrency
+	case codeLimitExceeded:
+		reason.Code = iso20022.ReasonNotAllowedAmount
+	case codeConflict:
+		reason.Code = iso20022.ReasonDuplicate
+	case codeInternal:
+		reason.Code = iso20022.ReasonNotSpecified
+	}
+	return reason
+}
+
+// GetTransactionPacs008 handles GET requests to download a completed transaction as a pacs.008 FI to FI
+// customer credit transfer, with the handler's Agent as debtor and creditor agent. A transaction that has not
+// completed is refused with a 409 problem.
+func (h *Handler) GetTransactionPacs008(w http.ResponseWriter, r *http.Request) {
+	transaction, err := h.DB.GetTransaction(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			writeProblem(w, r, http.StatusNotFound, codeNotFound, "transaction not found")
+			return
+		}
+		log.Println(err)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error getting transaction")
+		return
+	}
+	if transaction.Status != models.StatusCompleted {
+		writeProblem(w, r, http.StatusConflict, codeConflict,
+			fmt.Sprintf("transaction is %s; only completed transactions can be sent as pacs.008", transaction.Status))
+		return
+	}
+
+	writeMessage(w, r, http.StatusOK, func(w io.Writer) error {
+		return iso20022.WritePacs008(w, h.Agent, *transaction, time.Now())
+	})
+}
+
+// writeMessage writes an ISO 20022 message. The message is written in full before the response is started,
+// so that a failure to write it can still be reported as a problem.
+func writeMessage(w http.ResponseWriter, r *http.Request, status int, write func(io.Writer) error) {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		log.Println(err)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error writing message")
+		return
+	}
+
+	w.Header().Set("Content-Type", xmlContentType)
+	w.WriteHeader(status)
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Println(err)
+	}
+}
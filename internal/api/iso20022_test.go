@@ -0,0 +1,182 @@
+package api
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/abadojack/gapstack/internal/db"
+	"github.com/abadojack/gapstack/internal/iso20022"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// pain001Message is a pain.001 initiation from account "payroll" of one EUR 100.00 transfer to bob,
+// with a UETR, and one transfer of the given amount and currency to carol.
+const pain001Message = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.09">
+  <CstmrCdtTrfInitn>
+    <GrpHdr><MsgId>MSG-1</MsgId><CreDtTm>2026-10-16T08:30:00</CreDtTm><NbOfTxs>2</NbOfTxs></GrpHdr>
+    <PmtInf>
+      <PmtInfId>PMT-1</PmtInfId>
+      <PmtMtd>TRF</PmtMtd>
+      <DbtrAcct><Id><Othr><Id>payroll</Id></Othr></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-1</EndToEndId><UETR>8a562c67-ca16-48ba-b074-65581be6f011</UETR></PmtId>
+        <Amt><InstdAmt Ccy="EUR">100.00</InstdAmt></Amt>
+        <CdtrAcct><Id><Othr><Id>bob</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-2</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="%s">%s</InstdAmt></Amt>
+        <CdtrAcct><Id><Othr><Id>carol</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>`
+
+// statusReport is the part of a pain.002 response the tests check.
+type statusReport struct {
+	GroupStatus string `xml:"CstmrPmtStsRpt>OrgnlGrpInfAndSts>GrpSts"`
+	Transfers   []struct {
+		EndToEndID string `xml:"OrgnlEndToEndId"`
+		UETR       string `xml:"OrgnlUETR"`
+		Status     string `xml:"TxSts"`
+		Reason     string `xml:"StsRsnInf>Rsn>Cd"`
+		Info       string `xml:"StsRsnInf>AddtlInf"`
+	} `xml:"CstmrPmtStsRpt>OrgnlPmtInfAndSts>TxInfAndSts"`
+}
+
+func decodeStatusReport(t *testing.T, body []byte) statusReport {
+	t.Helper()
+	var report statusReport
+	require.NoError(t, xml.Unmarshal(body, &report))
+	return report
+}
+
+func TestHandler_CreatePain001Transactions(t *testing.T) {
+	t.Run("creates every transfer", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("CreateTransactions", mock.MatchedBy(func(transactions []models.Transaction) bool {
+			return len(transactions) == 2 &&
+				transactions[0].ID == "8a562c67-ca16-48ba-b074-65581be6f011" &&
+				transactions[0].Sender == "payroll" && transactions[0].Receiver == "bob" &&
+				transactions[0].Amount.String() == "100.00" && transactions[0].Currency == "EUR" &&
+				transactions[1].Receiver == "carol" && transactions[1].Status == models.StatusPending
+		})).Return(nil)
+
+		rr := serveCSV(NewHandler(mockDB), "POST", "/transactions/pain.001", fmt.Sprintf(pain001Message, "EUR", "25"))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, "application/xml; charset=utf-8", rr.Header().Get("Content-Type"))
+		report := decodeStatusReport(t, rr.Body.Bytes())
+		assert.Equal(t, iso20022.StatusAccepted, report.GroupStatus)
+		require.Len(t, report.Transfers, 2)
+		assert.Equal(t, "E2E-1", report.Transfers[0].EndToEndID)
+		assert.Equal(t, "8a562c67-ca16-48ba-b074-65581be6f011", report.Transfers[0].UETR)
+		assert.Equal(t, iso20022.StatusAcceptedSettlementInProcess, report.Transfers[0].Status)
+		assert.NotEmpty(t, report.Transfers[1].UETR, "a transfer without a UETR is reported with its new ID")
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("all or nothing rejects the message", func(t *testing.T) {
+		mockDB := new(MockDB)
+
+		rr := serveCSV(NewHandler(mockDB), "POST", "/transactions/pain.001", fmt.Sprintf(pain001Message, "XYZ", "25"))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		report := decodeStatusReport(t, rr.Body.Bytes())
+		assert.Equal(t, iso20022.StatusRejected, report.GroupStatus)
+		require.Len(t, report.Transfers, 1)
+		assert.Equal(t, "E2E-2", report.Transfers[0].EndToEndID)
+		assert.Equal(t, iso20022.StatusRejected, report.Transfers[0].Status)
+		assert.Equal(t, iso20022.ReasonNotAllowedCurrency, report.Transfers[0].Reason)
+		assert.Contains(t, report.Transfers[0].Info, "currency")
+		mockDB.AssertNotCalled(t, "CreateTransactions", mock.Anything)
+	})
+
+	t.Run("best effort reports each transfer", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("CreateTransaction", mock.MatchedBy(func(transaction models.Transaction) bool {
+			return transaction.Receiver == "bob"
+		})).Return(fmt.Errorf("%w: transaction 8a562c67-ca16-48ba-b074-65581be6f011", db.ErrDuplicate))
+		mockDB.On("CreateTransaction", mock.Anything).Return(nil)
+
+		rr := serveCSV(NewHandler(mockDB), "POST", "/transactions/pain.001?mode=best_effort", fmt.Sprintf(pain001Message, "EUR", "0"))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		report := decodeStatusReport(t, rr.Body.Bytes())
+		assert.Equal(t, iso20022.StatusRejected, report.GroupStatus)
+		require.Len(t, report.Transfers, 2)
+		assert.Equal(t, iso20022.ReasonDuplicate, report.Transfers[0].Reason)
+		assert.Equal(t, iso20022.ReasonInvalidAmount, report.Transfers[1].Reason)
+		mockDB.AssertNumberOfCalls(t, "CreateTransaction", 1)
+	})
+
+	t.Run("invalid message", func(t *testing.T) {
+		mockDB := new(MockDB)
+		message := strings.Replace(fmt.Sprintf(pain001Message, "EUR", "25"), "<NbOfTxs>2</NbOfTxs>", "<NbOfTxs>3</NbOfTxs>", 1)
+
+		rr := serveCSV(NewHandler(mockDB), "POST", "/transactions/pain.001", message)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		var problem Problem
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+		assert.Equal(t, codeInvalidRequestBody, problem.Code)
+		assert.Equal(t, `invalid ISO 20022 message: NbOfTxs is "3" but the message has 2 transactions`, problem.Detail)
+	})
+}
+
+func TestHandler_GetTransactionPacs008(t *testing.T) {
+	completed := &models.Transaction{
+		ID: "8a562c67-ca16-48ba-b074-65581be6f011", Amount: models.MustParseAmount("100.00"), Currency: "EUR",
+		Sender: "payroll", Receiver: "bob", Status: models.StatusCompleted,
+	}
+
+	t.Run("completed transaction", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("GetTransaction", completed.ID).Return(completed, nil)
+		handler := NewHandler(mockDB)
+		handler.Agent = iso20022.Agent{BIC: "GAPSKENA"}
+
+		rr := serveCSV(handler, "GET", "/transactions/"+completed.ID+"/pacs.008", "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/xml; charset=utf-8", rr.Header().Get("Content-Type"))
+		var message struct {
+			UETR   string `xml:"FIToFICstmrCdtTrf>CdtTrfTxInf>PmtId>UETR"`
+			Amount string `xml:"FIToFICstmrCdtTrf>CdtTrfTxInf>IntrBkSttlmAmt"`
+			Agent  string `xml:"FIToFICstmrCdtTrf>CdtTrfTxInf>DbtrAgt>FinInstnId>BICFI"`
+		}
+		require.NoError(t, xml.Unmarshal(rr.Body.Bytes(), &message))
+		assert.Equal(t, completed.ID, message.UETR)
+		assert.Equal(t, "100.00", message.Amount)
+		assert.Equal(t, "GAPSKENA", message.Agent)
+	})
+
+	t.Run("transaction not completed", func(t *testing.T) {
+		pending := *completed
+		pending.Status = models.StatusPending
+		mockDB := new(MockDB)
+		mockDB.On("GetTransaction", completed.ID).Return(&pending, nil)
+
+		rr := serveCSV(NewHandler(mockDB), "GET", "/transactions/"+completed.ID+"/pacs.008", "")
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Contains(t, rr.Body.String(), "only completed transactions")
+	})
+
+	t.Run("transaction not found", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("GetTransaction", "missing").Return(nil, fmt.Errorf("%w: transaction missing", db.ErrNotFound))
+
+		rr := serveCSV(NewHandler(mockDB), "GET", "/transactions/missing/pacs.008", "")
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
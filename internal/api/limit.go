@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/abadojack/gapstack/internal/currency"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// maxSenderLimits is the largest number of limit overrides a single sender may have.
+const maxSenderLimits = 20
+
+// senderLimitsRequest is the body of a request replacing a sender's limit overrides.
+type senderLimitsRequest struct {
+	Limits []models.SpendingLimit `json:"limits"`
+}
+
+// senderLimitsResponse shows a sender's overrides next to the limits they result in.
+type senderLimitsResponse struct {
+	Sender string `json:"sender"`
+	// Overrides are the limits set for this sender, including ones that lift a default
+	Overrides []models.SpendingLimit `json:"overrides"`
+	// Effective are the limits new transactions from the sender are checked against
+	Effective []models.SpendingLimit `json:"effective"`
+}
+
+// ListDefaultLimits handles GET requests for the spending limits that apply to every sender.
+func (h *Handler) ListDefaultLimits(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"limits": h.defaultLimits()})
+}
+
+// GetSenderLimits handles GET requests for a sender's limit overrides and the limits in force for them.
+// A sender without overrides is not an error: the defaults are in force.
+func (h *Handler) GetSenderLimits(w http.ResponseWriter, r *http.Request) {
+	sender := mux.Vars(r)["sender"]
+	overrides, err := h.DB.GetSenderLimits(r.Context(), sender)
+	if err != nil {
+		log.Println(err)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error getting sender limits")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.senderLimits(sender, overrides))
+}
+
+// SetSenderLimits handles PUT requests to replace every limit override of a sender.
+// An override replaces the default limit with the same currency and window, or adds one; an override
+// with neither max_count nor max_amount lifts the default for the sender.
+func (h *Handler) SetSenderLimits(w http.ResponseWriter, r *http.Request) {
+	var req senderLimitsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Println(err)
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidRequestBody, "invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	sender := mux.Vars(r)["sender"]
+	if errs := validateSenderLimits(sender, req.Limits, h.Currencies); len(errs) > 0 {
+		writeValidationProblem(w, r, errs)
+		return
+	}
+
+	if err := h.DB.SetSenderLimits(r.Context(), sender, req.Limits); err != nil {
+		log.Println(err)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error setting sender limits")
+		return
+	}
+
+	models.SortLimits(req.Limits)
+	writeJSON(w, http.StatusOK, h.senderLimits(sender, req.Limits))
+}
+
+// DeleteSenderLimits handles DELETE requests to remove a sender's limit overrides, restoring the defaults.
+func (h *Handler) DeleteSenderLimits(w http.ResponseWriter, r *http.Request) {
+	if err := h.DB.SetSenderLimits(r.Context(), mux.Vars(r)["sender"], nil); err != nil {
+		log.Println(err)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error deleting sender limits")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// defaultLimits returns the limits that apply to every sender, or none if limits are disabled.
+func (h *Handler) defaultLimits() []models.SpendingLimit {
+	if h.Limiter == nil || h.Limiter.Defaults == nil {
+		return []models.SpendingLimit{}
+	}
+	return h.Limiter.Defaults
+}
+
+// senderLimits builds the response describing a sender's overrides.
+func (h *Handler) senderLimits(sender string, overrides []models.SpendingLimit) senderLimitsResponse {
+	if overrides == nil {
+		overrides = []models.SpendingLimit{}
+	}
+	return senderLimitsResponse{
+		Sender:    sender,
+		Overrides: overrides,
+		Effective: models.EffectiveLimits(h.defaultLimits(), overrides),
+	}
+}
+
+// validateSenderLimits checks a sender's limit overrides, normalizing currencies to upper case and
+// amounts to the minor units of their currency.
+func validateSenderLimits(sender string, limits []models.SpendingLimit, allowed *currency.Set) ValidationErrors {
+	var errs ValidationErrors
+
+	if len(sender) > 255 {
+		errs.add("sender", fieldTooLong, "sender must be 255 characters or less")
+	}
+	if len(limits) > maxSenderLimits {
+		errs.add("limits", fieldOutOfRange, fmt.Sprintf("a sender may have at most %d limits", maxSenderLimits))
+		return errs
+	}
+
+	seen := make(map[string]bool)
+	for i := range limits {
+		limit := &limits[i]
+		field := fmt.Sprintf("limits[%d]", i)
+
+		if limit.Window == 0 {
+			errs.add(field+".window", fieldRequired, "window is required")
+		}
+		if limit.MaxCount < 0 {
+			errs.add(field+".max_count", fieldOutOfRange, "max_count must not be negative")
+		}
+
+		c, ok := validateCurrency(field+".currency", limit.Currency, allowed, &errs)
+		if !ok {
+			continue
+		}
+		limit.Currency = c.Code
+
+		if limit.MaxAmount != nil {
+			amount, err := limit.MaxAmount.Rescale(c.Exponent)
+			switch {
+			case limit.MaxAmount.Sign() <= 0:
+				errs.add(field+".max_amount", fieldOutOfRange, "max_amount must be greater than 0")
+			case limit.MaxAmount.Cmp(maxLimitAmount) > 0:
+				errs.add(field+".max_amount", fieldOutOfRange, "max_amount must be less than 1,000,000,000,000,000")
+			case err != nil:
+				errs.add(field+".max_amount", fieldPrecision, fmt.Sprintf("max_amount must have at most %d decimal places for %s", c.Exponent, limit.Currency))
+			default:
+				limit.MaxAmount = &amount
+			}
+		}
+
+		if limit.Window != 0 {
+			if seen[limit.Name()] {
+				errs.add(field, fieldInvalid, fmt.Sprintf("duplicate limit for %s", limit.Name()))
+			}
+			seen[limit.Name()] = true
+		}
+	}
+
+	return errs
+}
+
+// maxLimitAmount is the largest max_amount that fits the DECIMAL(19,4) column it is stored in.
+var maxLimitAmount = models.MustParseAmount("999999999999999")
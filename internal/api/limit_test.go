@@ -0,0 +1,234 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/limits"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newLimitedHandler returns a handler whose limiter reads from the mock with the given default limits.
+func newLimitedHandler(t *testing.T, mockDB *MockDB, spec string) *Handler {
+	t.Helper()
+	defaults, err := models.ParseSpendingLimits(spec)
+	require.NoError(t, err)
+
+	handler := NewHandler(mockDB)
+	handler.Limiter = limits.NewLimiter(mockDB, defaults)
+	return handler
+}
+
+func TestHandler_ListDefaultLimits(t *testing.T) {
+	rr := serveAccounts(newLimitedHandler(t, new(MockDB), "USD/24h=100:20000,USD/1h=10"), "GET", "/admin/limits", nil)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"limits": [
+		{"currency": "USD", "window": "1h", "max_count": 10},
+		{"currency": "USD", "window": "24h", "max_count": 100, "max_amount": "20000"}
+	]}`, rr.Body.String())
+
+	rr = serveAccounts(NewHandler(new(MockDB)), "GET", "/admin/limits", nil)
+	assert.JSONEq(t, `{"limits": []}`, rr.Body.String(), "limits are disabled without a limiter")
+}
+
+func TestHandler_GetSenderLimits(t *testing.T) {
+	t.Run("overrides and effective limits", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := newLimitedHandler(t, mockDB, "USD/1h=10,EUR/24h=5")
+
+		mockDB.On("GetSenderLimits", "alice").Return([]models.SpendingLimit{
+			{Currency: "EUR", Window: models.LimitWindow(24 * time.Hour)},
+			{Currency: "USD", Window: models.LimitWindow(time.Hour), MaxCount: 50},
+		}, nil)
+
+		rr := serveAccounts(handler, "GET", "/admin/limits/alice", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{
+			"sender": "alice",
+			"overrides": [{"currency": "EUR", "window": "24h"}, {"currency": "USD", "window": "1h", "max_count": 50}],
+			"effective": [{"currency": "USD", "window": "1h", "max_count": 50}]
+		}`, rr.Body.String())
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("sender without overrides", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := newLimitedHandler(t, mockDB, "USD/1h=10")
+
+		mockDB.On("GetSenderLimits", "bob").Return(nil, nil)
+
+		rr := serveAccounts(handler, "GET", "/admin/limits/bob", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"sender": "bob", "overrides": [], "effective": [{"currency": "USD", "window": "1h", "max_count": 10}]}`, rr.Body.String())
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("GetSenderLimits", "alice").Return(nil, errors.New("database error"))
+
+		rr := serveAccounts(NewHandler(mockDB), "GET", "/admin/limits/alice", nil)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestHandler_SetSenderLimits(t *testing.T) {
+	t.Run("replaces the overrides", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := newLimitedHandler(t, mockDB, "USD/1h=10")
+
+		amount := models.MustParseAmount("5000.00")
+		want := []models.SpendingLimit{
+			{Currency: "USD", Window: models.LimitWindow(24 * time.Hour), MaxAmount: &amount},
+			{Currency: "USD", Window: models.LimitWindow(time.Hour), MaxCount: 100},
+		}
+		mockDB.On("SetSenderLimits", "alice", want).Return(nil)
+
+		body := []byte(`{"limits": [
+			{"currency": "usd", "window": "24h", "max_amount": "5000"},
+			{"currency": "USD", "window": "60m", "max_count": 100}
+		]}`)
+		rr := serveAccounts(handler, "PUT", "/admin/limits/alice", body)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{
+			"sender": "alice",
+			"overrides": [
+				{"currency": "USD", "window": "1h", "max_count": 100},
+				{"currency": "USD", "window": "24h", "max_amount": "5000.00"}
+			],
+			"effective": [
+				{"currency": "USD", "window": "1h", "max_count": 100},
+				{"currency": "USD", "window": "24h", "max_amount": "5000.00"}
+			]
+		}`, rr.Body.String())
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			body   string
+			fields []string
+		}{
+			{"missing fields", `{"limits": [{}]}`, []string{"limits[0].window", "limits[0].currency"}},
+			{"invalid currency", `{"limits": [{"currency": "dollars", "window": "1h"}]}`, []string{"limits[0].currency"}},
+			{"withdrawn currency", `{"limits": [{"currency": "HRK", "window": "1h"}]}`, []string{"limits[0].currency"}},
+			{"negative count", `{"limits": [{"currency": "USD", "window": "1h", "max_count": -1}]}`, []string{"limits[0].max_count"}},
+			{"non-positive amount", `{"limits": [{"currency": "USD", "window": "1h", "max_amount": "0"}]}`, []string{"limits[0].max_amount"}},
+			{"too precise amount", `{"limits": [{"currency": "JPY", "window": "1h", "max_amount": "1.5"}]}`, []string{"limits[0].max_amount"}},
+			{"duplicate limit", `{"limits": [{"currency": "USD", "window": "1h"}, {"currency": "usd", "window": "60m"}]}`, []string{"limits[1]"}},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockDB := new(MockDB)
+				rr := serveAccounts(NewHandler(mockDB), "PUT", "/admin/limits/alice", []byte(tt.body))
+
+				assert.Equal(t, http.StatusBadRequest, rr.Code)
+				var problem Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				assert.Equal(t, codeValidationFailed, problem.Code)
+				var fields []string
+				for _, fieldErr := range problem.Errors {
+					fields = append(fields, fieldErr.Field)
+				}
+				assert.Equal(t, tt.fields, fields)
+				mockDB.AssertNotCalled(t, "SetSenderLimits", mock.Anything, mock.Anything)
+			})
+		}
+	})
+
+	t.Run("invalid window", func(t *testing.T) {
+		mockDB := new(MockDB)
+		rr := serveAccounts(NewHandler(mockDB), "PUT", "/admin/limits/alice", []byte(`{"limits": [{"currency": "USD", "window": "1y"}]}`))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), codeInvalidRequestBody)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("SetSenderLimits", "alice", mock.Anything).Return(errors.New("database error"))
+
+		rr := serveAccounts(NewHandler(mockDB), "PUT", "/admin/limits/alice", []byte(`{"limits": []}`))
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestHandler_DeleteSenderLimits(t *testing.T) {
+	mockDB := new(MockDB)
+	mockDB.On("SetSenderLimits", "alice", []models.SpendingLimit(nil)).Return(nil)
+
+	rr := serveAccounts(NewHandler(mockDB), "DELETE", "/admin/limits/alice", nil)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	mockDB.AssertExpectations(t)
+}
+
+func TestHandler_CreateTransaction_Limits(t *testing.T) {
+	body := []byte(`{"amount": "100.00", "currency": "USD", "sender": "alice", "receiver": "bob"}`)
+
+	t.Run("within limits", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := newLimitedHandler(t, mockDB, "USD/1h=10:1000")
+
+		mockDB.On("GetSenderLimits", "alice").Return(nil, nil)
+		mockDB.On("GetSpendingTotal", "alice", "USD", mock.AnythingOfType("time.Time")).
+			Return(&models.SpendingTotal{Count: 9, Amount: models.MustParseAmount("900.00")}, nil)
+		mockDB.On("CreateTransaction", mock.AnythingOfType("models.Transaction")).Return(nil)
+
+		rr := serveAccounts(handler, "POST", "/transactions", body)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("over a limit", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := newLimitedHandler(t, mockDB, "USD/1h=10:1000")
+
+		var since time.Time
+		mockDB.On("GetSenderLimits", "alice").Return(nil, nil)
+		mockDB.On("GetSpendingTotal", "alice", "USD", mock.MatchedBy(func(t time.Time) bool {
+			since = t
+			return true
+		})).Return(&models.SpendingTotal{Count: 3, Amount: models.MustParseAmount("950.00")}, nil)
+
+		rr := serveAccounts(handler, "POST", "/transactions", body)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		var problem Problem
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+		assert.Equal(t, codeLimitExceeded, problem.Code)
+		assert.Equal(t, "transaction would take the sender over the limit of 1000 USD per 1h (950.00 already sent)", problem.Detail)
+		require.NotNil(t, problem.Breach)
+		assert.Equal(t, "USD/1h", problem.Breach.Limit.Name())
+		assert.Equal(t, limits.ExceededAmount, problem.Breach.Exceeded)
+		assert.Equal(t, 3, problem.Breach.Spent.Count)
+		assert.WithinDuration(t, time.Now().Add(-time.Hour), since, time.Minute)
+		mockDB.AssertNotCalled(t, "CreateTransaction", mock.Anything)
+	})
+
+	t.Run("limit check fails", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := newLimitedHandler(t, mockDB, "USD/1h=10")
+
+		mockDB.On("GetSenderLimits", "alice").Return(nil, errors.New("database error"))
+
+		rr := serveAccounts(handler, "POST", "/transactions", body)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		mockDB.AssertNotCalled(t, "CreateTransaction", mock.Anything)
+	})
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// requestIDHeader is the header used to propagate request IDs between clients, proxies and the service.
+const requestIDHeader = "X-Request-ID"
+
+// contextKey is the type of context keys defined by this package.
+type contextKey int
+
+const (
+	// requestIDKey stores the request ID in the request context
+	requestIDKey contextKey = iota
+)
+
+// requestIDMiddleware assigns every request an ID, reusing one supplied by the client if present.
+// The ID is echoed in the X-Request-ID response header and included in problem responses.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.NewString()
+		}
+
+		w.Header().Set(requestIDHeader, requestID)
+		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requestIDFromContext returns the request ID assigned by requestIDMiddleware, or "" if there is none.
+func requestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// parsePageSize parses the page_size query parameter, falling back to the default
+// for missing or invalid values and capping it at maxPageSize.
+func parsePageSize(param string) int {
+	pageSize, err := strconv.Atoi(param)
+	if err != nil || pageSize < 1 {
+		return defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		return maxPageSize
+	}
+	return pageSize
+}
+
+// pageCount returns the number of pages needed to show total items.
+func pageCount(total, pageSize int) int {
+	return (total + pageSize - 1) / pageSize
+}
+
+// pageLink builds an RFC 8288 link value pointing at the current request with some query parameters replaced.
+// A replacement with an empty value removes the parameter.
+func pageLink(r *http.Request, rel string, params map[string]string) string {
+	query := r.URL.Query()
+	for key, value := range params {
+		if value == "" {
+			query.Del(key)
+		} else {
+			query.Set(key, value)
+		}
+	}
+
+	target := r.URL.Path
+	if encoded := query.Encode(); encoded != "" {
+		target += "?" + encoded
+	}
+	return fmt.Sprintf("<%s>; rel=%q", target, rel)
+}
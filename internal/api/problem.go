@@ -0,0 +1,151 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/abadojack/gapstack/internal/limits"
+)
+
+// problemContentType is the media type of RFC 7807 problem details responses.
+const problemContentType = "application/problem+json"
+
+// Stable machine-readable error codes returned in problem responses.
+// Clients should branch on these rather than on the human-readable detail.
+const (
+	codeInvalidRequestBody    = "invalid_request_body"
+	codeValidationFailed      = "validation_failed"
+	codeMissingID             = "missing_id"
+	codeNotFound              = "not_found"
+	codeConflict              = "conflict"
+	codeIdempotencyMismatch   = "idempotency_key_mismatch"
+	codeIdempotencyInProgress = "idempotency_key_in_progress"
+	codeInvalidHeader         = "invalid_header"
+	codeInsufficientFunds     = "insufficient_funds"
+	codeCurrencyMismatch      = "currency_mismatch"
+	codeLimitExceeded         = "limit_exceeded"
+	codeConversionDisabled    = "conversion_disabled"
+	codeRateUnavailable       = "rate_unavailable"
+	codeQuoteExpired          = "quote_expired"
+	codeBatchRejected         = "batch_rejected"
+	codeInternal              = "internal_error"
+)
+
+// Field validation codes used in FieldError.Code.
+const (
+	fieldRequired    = "required"
+	fieldInvalid     = "invalid"
+	fieldTooLong     = "too_long"
+	fieldOutOfRange  = "out_of_range"
+	fieldPrecision   = "precision"
+	fieldUnsupported = "unsupported"
+)
+
+// Problem is an RFC 7807 problem details response body.
+// It extends the standard members with a stable error code, the request ID and per-field errors.
+type Problem struct {
+	// Type is a URI identifying the problem type
+	Type string `json:"type"`
+	// Title is a short human-readable summary of the problem type
+	Title string `json:"title"`
+	// Status is the HTTP status code of the response
+	Status int `json:"status"`
+	// Detail is a human-readable explanation specific to this occurrence
+	Detail string `json:"detail,omitempty"`
+	// Instance is the request path that produced the problem
+	Instance string `json:"instance,omitempty"`
+	// Code is a stable machine-readable error code
+	Code string `json:"code"`
+	// RequestID correlates the response with server logs
+	RequestID string `json:"request_id,omitempty"`
+	// Errors lists the individual field validation failures, if any
+	Errors []FieldError `json:"errors,omitempty"`
+	// Breach names the spending limit a rejected transaction would exceed, for limit_exceeded problems
+	Breach *limits.Breach `json:"breach,omitempty"`
+	// Results lists the failed items of a batch, for batch_rejected problems
+	Results []batchResult `json:"results,omitempty"`
+}
+
+// FieldError describes a validation failure of a single request field.
+type FieldError struct {
+	// Field is the JSON name of the offending field
+	Field string `json:"field"`
+	// Code is a stable machine-readable reason such as "required" or "too_long"
+	Code string `json:"code"`
+	// Message is a human-readable description of the failure
+	Message string `json:"message"`
+}
+
+// ValidationErrors collects every field validation failure of a request.
+type ValidationErrors []FieldError
+
+// add records a validation failure for a field.
+func (v *ValidationErrors) add(field, code, message string) {
+	*v = append(*v, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Error joins all failure messages into a single string.
+func (v ValidationErrors) Error() string {
+	messages := make([]string, len(v))
+	for i, fieldErr := range v {
+		messages[i] = fieldErr.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// writeProblem writes an RFC 7807 problem details response.
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	writeProblemBody(w, *newProblem(r, status, code, detail))
+}
+
+// writeValidationProblem writes a 400 problem response listing every failed field.
+func writeValidationProblem(w http.ResponseWriter, r *http.Request, errs ValidationErrors) {
+	writeProblemBody(w, *validationProblem(r, errs))
+}
+
+// writeLimitProblem writes a 422 problem response naming the spending limit a transaction would exceed.
+func writeLimitProblem(w http.ResponseWriter, r *http.Request, breach *limits.Breach) {
+	writeProblemBody(w, *limitProblem(r, breach))
+}
+
+// newProblem builds the problem details of a failed request.
+// Problems are built separately from writing them so that a batch can report one per item.
+func newProblem(r *http.Request, status int, code, detail string) *Problem {
+	return &Problem{
+		Type:      "urn:gapstack:problem:" + code,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      code,
+		RequestID: requestIDFromContext(r.Context()),
+	}
+}
+
+// validationProblem builds a 400 problem listing every failed field.
+func validationProblem(r *http.Request, errs ValidationErrors) *Problem {
+	problem := newProblem(r, http.StatusBadRequest, codeValidationFailed, errs.Error())
+	problem.Errors = errs
+	return problem
+}
+
+// limitProblem builds a 422 problem naming the spending limit a transaction would exceed.
+func limitProblem(r *http.Request, breach *limits.Breach) *Problem {
+	problem := newProblem(r, http.StatusUnprocessableEntity, codeLimitExceeded, breach.Error())
+	problem.Breach = breach
+	return problem
+}
+
+// writeProblemBody encodes a problem with the problem+json content type.
+func writeProblemBody(w http.ResponseWriter, problem Problem) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(problem); err != nil {
+		log.Println(err)
+	}
+}
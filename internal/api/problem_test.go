@@ -0,0 +1,72 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationProblem(t *testing.T) {
+	mockDB := new(MockDB)
+	handler := NewHandler(mockDB)
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	body := bytes.NewReader([]byte(`{"amount": "0", "currency": "usdx", "sender": "user-1"}`))
+	req := httptest.NewRequest("POST", "/transactions", body)
+	req.Header.Set("X-Request-ID", "req-123")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, problemContentType, rr.Header().Get("Content-Type"))
+	assert.Equal(t, "req-123", rr.Header().Get("X-Request-ID"))
+
+	var problem Problem
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Equal(t, codeValidationFailed, problem.Code)
+	assert.Equal(t, "req-123", problem.RequestID)
+	assert.Equal(t, "/transactions", problem.Instance)
+	assert.Equal(t, []FieldError{
+		{Field: "amount", Code: fieldOutOfRange, Message: "amount must be greater than 0"},
+		{Field: "currency", Code: fieldInvalid, Message: "currency must be a valid 3-letter ISO code (e.g., USD, EUR, GBP)"},
+		{Field: "receiver", Code: fieldRequired, Message: "receiver is required"},
+	}, problem.Errors)
+}
+
+func TestProblem_GeneratedRequestID(t *testing.T) {
+	mockDB := new(MockDB)
+	handler := NewHandler(mockDB)
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	req := httptest.NewRequest("PUT", "/transactions/txn-123", bytes.NewReader([]byte(`{`)))
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var problem Problem
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+	assert.Equal(t, codeInvalidRequestBody, problem.Code)
+	assert.Equal(t, "invalid request body", problem.Detail)
+	assert.NotEmpty(t, problem.RequestID)
+	assert.Equal(t, rr.Header().Get("X-Request-ID"), problem.RequestID)
+}
+
+func TestValidationErrors_Error(t *testing.T) {
+	var errs ValidationErrors
+	errs.add("sender", fieldRequired, "sender is required")
+	errs.add("receiver", fieldRequired, "receiver is required")
+
+	assert.Equal(t, "validation failed: sender is required; receiver is required", errs.Error())
+}
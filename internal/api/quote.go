@@ -0,0 +1,206 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/currency"
+	"github.com/abadojack/gapstack/internal/db"
+	"github.com/abadojack/gapstack/internal/fx"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// maxQuoteIDLength is the longest quote ID accepted
+	maxQuoteIDLength = 64
+)
+
+// maxTargetAmount is the largest converted amount that can be stored.
+var maxTargetAmount = models.MustParseAmount("999999999999999")
+
+// conversionRequest holds the fields of a transaction request that ask for a cross-currency transfer.
+// They sit alongside the transaction's own fields in the request body.
+type conversionRequest struct {
+	// TargetCurrency is the currency the receiver is credited in
+	TargetCurrency string `json:"target_currency"`
+	// QuoteID names a quote whose locked rate is used instead of the current one
+	QuoteID string `json:"quote_id"`
+}
+
+// quoteRequest is the body of a request for a quote.
+type quoteRequest struct {
+	Amount         models.Amount `json:"amount"`
+	Currency       string        `json:"currency"`
+	TargetCurrency string        `json:"target_currency"`
+}
+
+// CreateQuote handles POST requests for a quote that locks the current exchange rate for converting an amount
+// until the quote expires. The quote can then be used by a single transaction of the same amount and currencies.
+func (h *Handler) CreateQuote(w http.ResponseWriter, r *http.Request) {
+	var request quoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		log.Println(err)
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidRequestBody, "invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	var errs ValidationErrors
+	validateMoney(&request.Amount, &request.Currency, h.Currencies, &errs)
+	if target, ok := validateCurrency("target_currency", request.TargetCurrency, h.Currencies, &errs); ok {
+		request.TargetCurrency = target.Code
+		if request.TargetCurrency == request.Currency {
+			errs.add("target_currency", fieldInvalid, "target_currency must be different from currency")
+		}
+	}
+	if len(errs) > 0 {
+		writeValidationProblem(w, r, errs)
+		return
+	}
+
+	if h.FX == nil {
+		writeProblem(w, r, http.StatusNotImplemented, codeConversionDisabled, "currency conversion is not enabled")
+		return
+	}
+	quote, err := h.FX.Quote(r.Context(), request.Amount, request.Currency, request.TargetCurrency)
+	if err != nil {
+		writeProblemBody(w, *conversionProblem(r, err))
+		return
+	}
+	if !isStorableTargetAmount(quote.TargetAmount) {
+		writeValidationProblem(w, r, targetAmountErrors(quote.TargetAmount, quote.TargetCurrency))
+		return
+	}
+
+	if err := h.DB.CreateQuote(r.Context(), quote); err != nil {
+		log.Println(err)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error creating quote")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, quote)
+}
+
+// GetQuote handles GET requests for a quote by ID, including whether it has been used.
+func (h *Handler) GetQuote(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		writeProblem(w, r, http.StatusBadRequest, codeMissingID, "missing quote id")
+		return
+	}
+
+	quote, err := h.DB.GetQuote(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			writeProblem(w, r, http.StatusNotFound, codeNotFound, "quote not found")
+			return
+		}
+		log.Println(err)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error retrieving quote")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, quote)
+}
+
+// validateConversionRequest checks the conversion fields of a transaction request, normalizing the target
+// currency to upper case.
+func validateConversionRequest(request *conversionRequest, allowed *currency.Set) ValidationErrors {
+	var errs ValidationErrors
+	if request.TargetCurrency != "" {
+		if target, ok := validateCurrency("target_currency", request.TargetCurrency, allowed, &errs); ok {
+			request.TargetCurrency = target.Code
+		}
+	}
+	if len(request.QuoteID) > maxQuoteIDLength {
+		errs.add("quote_id", fieldTooLong, fmt.Sprintf("quote_id must be %d characters or less", maxQuoteIDLength))
+	}
+	return errs
+}
+
+// convertTransaction works out the conversion a transaction request asks for, either at the rate locked by
+// its quote or at the current rate, and returns the problem if there is none to be had.
+// It returns a nil conversion for requests in a single currency.
+func (h *Handler) convertTransaction(r *http.Request, transaction models.Transaction, request conversionRequest) (*models.Conversion, *Problem) {
+	if request.QuoteID == "" && (request.TargetCurrency == "" || strings.EqualFold(request.TargetCurrency, transaction.Currency)) {
+		return nil, nil
+	}
+	if h.FX == nil {
+		return nil, newProblem(r, http.StatusNotImplemented, codeConversionDisabled, "currency conversion is not enabled")
+	}
+
+	if request.QuoteID == "" {
+		conversion, err := h.FX.Convert(r.Context(), transaction.Amount, transaction.Currency, request.TargetCurrency)
+		if err != nil {
+			return nil, conversionProblem(r, err)
+		}
+		if !isStorableTargetAmount(conversion.TargetAmount) {
+			return nil, validationProblem(r, targetAmountErrors(conversion.TargetAmount, conversion.TargetCurrency))
+		}
+		return conversion, nil
+	}
+
+	quote, err := h.DB.GetQuote(r.Context(), request.QuoteID)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			var errs ValidationErrors
+			errs.add("quote_id", fieldInvalid, "quote does not exist")
+			return nil, validationProblem(r, errs)
+		}
+		log.Println(err)
+		return nil, newProblem(r, http.StatusInternalServerError, codeInternal, "error retrieving quote")
+	}
+
+	// A quote locks the rate for one exact conversion only
+	if !strings.EqualFold(quote.SourceCurrency, transaction.Currency) || quote.SourceAmount.Cmp(transaction.Amount) != 0 ||
+		(request.TargetCurrency != "" && !strings.EqualFold(quote.TargetCurrency, request.TargetCurrency)) {
+		var errs ValidationErrors
+		errs.add("quote_id", fieldInvalid, fmt.Sprintf("quote is for converting %s %s to %s",
+			quote.SourceAmount, quote.SourceCurrency, quote.TargetCurrency))
+		return nil, validationProblem(r, errs)
+	}
+	if quote.TransactionID != "" {
+		return nil, newProblem(r, http.StatusConflict, codeConflict, "quote has already been used")
+	}
+	if h.FX.Expired(*quote) {
+		return nil, newProblem(r, http.StatusUnprocessableEntity, codeQuoteExpired, "quote expired at "+quote.ExpiresAt.Format(time.RFC3339))
+	}
+	return quote.Conversion(), nil
+}
+
+// conversionProblem builds the problem of a failed conversion. A pair the provider has no rate for is the
+// client's problem; any other failure means the rate source is unavailable.
+func conversionProblem(r *http.Request, err error) *Problem {
+	log.Println(err)
+	if errors.Is(err, fx.ErrRateUnavailable) {
+		return newProblem(r, http.StatusUnprocessableEntity, codeRateUnavailable, "no exchange rate is available for the currency pair")
+	}
+	if errors.Is(err, models.ErrInvalidAmount) {
+		var errs ValidationErrors
+		errs.add("amount", fieldOutOfRange, "amount is too large to convert")
+		return validationProblem(r, errs)
+	}
+	return newProblem(r, http.StatusServiceUnavailable, codeRateUnavailable, "exchange rates are unavailable, try again later")
+}
+
+// isStorableTargetAmount reports whether a converted amount can be credited and stored.
+func isStorableTargetAmount(amount models.Amount) bool {
+	return amount.Sign() > 0 && amount.Cmp(maxTargetAmount) <= 0
+}
+
+// targetAmountErrors reports a converted amount that cannot be credited or stored.
+func targetAmountErrors(amount models.Amount, currency string) ValidationErrors {
+	var errs ValidationErrors
+	if amount.Sign() <= 0 {
+		errs.add("amount", fieldOutOfRange, fmt.Sprintf("amount is too small to convert to %s", currency))
+	} else {
+		errs.add("amount", fieldOutOfRange, fmt.Sprintf("amount is too large to convert to %s", currency))
+	}
+	return errs
+}
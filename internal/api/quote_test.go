@@ -0,0 +1,251 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/db"
+	"github.com/abadojack/gapstack/internal/fx"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newFXHandler creates a Handler that converts between USD and KES at a static rate.
+func newFXHandler(mockDB *MockDB) *Handler {
+	handler := NewHandler(mockDB)
+	handler.FX = fx.NewConverter(fx.NewStaticRates(map[string]models.Rate{"USD/KES": models.MustParseRate("129.155")}), time.Minute)
+	return handler
+}
+
+func TestHandler_CreateQuote(t *testing.T) {
+	t.Run("locks the current rate", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("CreateQuote", mock.MatchedBy(func(quote models.Quote) bool {
+			return quote.ID != "" && quote.TargetAmount.String() == "1291.55" && quote.ExpiresAt.Sub(quote.CreatedAt) == time.Minute
+		})).Return(nil)
+
+		rr := serveAccounts(newFXHandler(mockDB), "POST", "/quotes", []byte(`{"amount": "10", "currency": "usd", "target_currency": "KES"}`))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var quote models.Quote
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &quote))
+		assert.Equal(t, "USD", quote.SourceCurrency)
+		assert.Equal(t, "10.00", quote.SourceAmount.String())
+		assert.Equal(t, "KES", quote.TargetCurrency)
+		assert.Equal(t, "1291.55", quote.TargetAmount.String())
+		assert.Equal(t, "129.155", quote.Rate.String())
+		assert.Equal(t, "static", quote.Provider)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		mockDB := new(MockDB)
+
+		rr := serveAccounts(newFXHandler(mockDB), "POST", "/quotes", []byte(`{"amount": "10.001", "currency": "USD", "target_currency": "usd"}`))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		var problem Problem
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+		require.Len(t, problem.Errors, 2)
+		assert.Equal(t, "amount", problem.Errors[0].Field)
+		assert.Equal(t, fieldPrecision, problem.Errors[0].Code)
+		assert.Equal(t, "target_currency", problem.Errors[1].Field)
+		mockDB.AssertNotCalled(t, "CreateQuote", mock.Anything)
+	})
+
+	t.Run("unknown pair", func(t *testing.T) {
+		mockDB := new(MockDB)
+
+		rr := serveAccounts(newFXHandler(mockDB), "POST", "/quotes", []byte(`{"amount": "10", "currency": "USD", "target_currency": "EUR"}`))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.Contains(t, rr.Body.String(), codeRateUnavailable)
+	})
+
+	t.Run("conversion disabled", func(t *testing.T) {
+		mockDB := new(MockDB)
+
+		rr := serveAccounts(NewHandler(mockDB), "POST", "/quotes", []byte(`{"amount": "10", "currency": "USD", "target_currency": "KES"}`))
+
+		assert.Equal(t, http.StatusNotImplemented, rr.Code)
+		assert.Contains(t, rr.Body.String(), codeConversionDisabled)
+	})
+}
+
+func TestHandler_GetQuote(t *testing.T) {
+	t.Run("found", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("GetQuote", "quote-1").Return(&models.Quote{ID: "quote-1", TransactionID: "txn-1"}, nil)
+
+		rr := serveAccounts(NewHandler(mockDB), "GET", "/quotes/quote-1", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"transaction_id":"txn-1"`)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("GetQuote", "missing").Return(nil, db.ErrNotFound)
+
+		rr := serveAccounts(NewHandler(mockDB), "GET", "/quotes/missing", nil)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestHandler_CreateTransaction_Conversion(t *testing.T) {
+	quote := func(expiresIn time.Duration) *models.Quote {
+		return &models.Quote{
+			ID:             "quote-1",
+			SourceCurrency: "USD",
+			SourceAmount:   models.MustParseAmount("10.00"),
+			TargetCurrency: "KES",
+			TargetAmount:   models.MustParseAmount("1290.00"),
+			Rate:           models.MustParseRate("129"),
+			Provider:       "static",
+			ExpiresAt:      time.Now().Add(expiresIn),
+		}
+	}
+
+	t.Run("converts at the current rate", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("CreateTransaction", mock.MatchedBy(func(transaction models.Transaction) bool {
+			return transaction.Conversion != nil && transaction.Conversion.TargetAmount.String() == "1291.55"
+		})).Return(nil)
+
+		rr := serveAccounts(newFXHandler(mockDB), "POST", "/transactions",
+			[]byte(`{"amount": "10.00", "currency": "USD", "sender": "alice", "receiver": "bob", "target_currency": "kes"}`))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var response models.Transaction
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		require.NotNil(t, response.Conversion)
+		assert.Equal(t, "10.00", response.Amount.String())
+		assert.Equal(t, models.Conversion{
+			TargetCurrency: "KES",
+			TargetAmount:   models.MustParseAmount("1291.55"),
+			Rate:           models.MustParseRate("129.155"),
+		}, *response.Conversion)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("the same currency is not converted", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("CreateTransaction", mock.MatchedBy(func(transaction models.Transaction) bool {
+			return transaction.Conversion == nil
+		})).Return(nil)
+
+		rr := serveAccounts(NewHandler(mockDB), "POST", "/transactions",
+			[]byte(`{"amount": "10.00", "currency": "USD", "sender": "alice", "receiver": "bob", "target_currency": "USD",
+				"conversion": {"target_currency": "KES", "target_amount": "1000000", "rate": "100000"}}`))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("uses a quote's locked rate", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("GetQuote", "quote-1").Return(quote(time.Minute), nil)
+		mockDB.On("CreateTransaction", mock.MatchedBy(func(transaction models.Transaction) bool {
+			return transaction.Conversion != nil && *transaction.Conversion == *quote(0).Conversion()
+		})).Return(nil)
+
+		rr := serveAccounts(newFXHandler(mockDB), "POST", "/transactions",
+			[]byte(`{"amount": "10", "currency": "usd", "sender": "alice", "receiver": "bob", "quote_id": "quote-1"}`))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"target_amount":"1290.00"`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("quote problems", func(t *testing.T) {
+		used := quote(time.Minute)
+		used.TransactionID = "txn-0"
+
+		tests := []struct {
+			name       string
+			quote      *models.Quote
+			err        error
+			body       string
+			wantStatus int
+			wantCode   string
+		}{
+			{"expired", quote(-time.Second), nil, `"amount": "10.00", "currency": "USD"`, http.StatusUnprocessableEntity, codeQuoteExpired},
+			{"used", used, nil, `"amount": "10.00", "currency": "USD"`, http.StatusConflict, codeConflict},
+			{"different amount", quote(time.Minute), nil, `"amount": "10.01", "currency": "USD"`, http.StatusBadRequest, codeValidationFailed},
+			{"different target", quote(time.Minute), nil, `"amount": "10.00", "currency": "USD", "target_currency": "EUR"`, http.StatusBadRequest, codeValidationFailed},
+			{"missing", nil, db.ErrNotFound, `"amount": "10.00", "currency": "USD"`, http.StatusBadRequest, codeValidationFailed},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockDB := new(MockDB)
+				mockDB.On("GetQuote", "quote-1").Return(tt.quote, tt.err)
+
+				rr := serveAccounts(newFXHandler(mockDB), "POST", "/transactions",
+					[]byte(`{`+tt.body+`, "sender": "alice", "receiver": "bob", "quote_id": "quote-1"}`))
+
+				assert.Equal(t, tt.wantStatus, rr.Code)
+				assert.Contains(t, rr.Body.String(), tt.wantCode)
+				mockDB.AssertNotCalled(t, "CreateTransaction", mock.Anything)
+			})
+		}
+	})
+
+	t.Run("a quote used concurrently is a conflict", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("GetQuote", "quote-1").Return(quote(time.Minute), nil)
+		mockDB.On("CreateTransaction", mock.Anything).Return(db.ErrConflict)
+
+		rr := serveAccounts(newFXHandler(mockDB), "POST", "/transactions",
+			[]byte(`{"amount": "10.00", "currency": "USD", "sender": "alice", "receiver": "bob", "quote_id": "quote-1"}`))
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("rate source unavailable", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+		handler.FX = fx.NewConverter(unavailableRates{}, time.Minute)
+
+		rr := serveAccounts(handler, "POST", "/transactions",
+			[]byte(`{"amount": "10.00", "currency": "USD", "sender": "alice", "receiver": "bob", "target_currency": "KES"}`))
+
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		mockDB.AssertNotCalled(t, "CreateTransaction", mock.Anything)
+	})
+
+	t.Run("conversion disabled", func(t *testing.T) {
+		mockDB := new(MockDB)
+
+		rr := serveAccounts(NewHandler(mockDB), "POST", "/transactions",
+			[]byte(`{"amount": "10.00", "currency": "USD", "sender": "alice", "receiver": "bob", "target_currency": "KES"}`))
+
+		assert.Equal(t, http.StatusNotImplemented, rr.Code)
+	})
+
+	t.Run("exchange accounts are reserved", func(t *testing.T) {
+		mockDB := new(MockDB)
+
+		rr := serveAccounts(newFXHandler(mockDB), "POST", "/transactions",
+			[]byte(`{"amount": "10.00", "currency": "USD", "sender": "FX:USD", "receiver": "bob"}`))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"field":"sender"`)
+	})
+}
+
+// unavailableRates is a rate provider whose service is down.
+type unavailableRates struct{}
+
+func (unavailableRates) Name() string { return "down" }
+
+func (unavailableRates) Rate(ctx context.Context, source, target string) (models.Rate, error) {
+	return models.Rate{}, errors.New("connection refused")
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+const (
+	// defaultTopSenders is the number of senders ranked per currency when no limit is given
+	defaultTopSenders = 5
+	// maxTopSenders is the largest number of senders ranked per currency
+	maxTopSenders = 100
+	// defaultFailureThreshold is the number of failures a sender must exceed when no threshold is given
+	defaultFailureThreshold = 3
+	// maxFailureThreshold is the largest failure threshold accepted
+	maxFailureThreshold = 1000000
+)
+
+// defaultReportWindow is the period top senders are ranked over when no window is given.
+var defaultReportWindow = models.LimitWindow(30 * 24 * time.Hour)
+
+// GetSenderTotals handles GET requests for the total of each sender's completed transactions.
+// Totals are given per currency, ordered by currency and then largest first; currency restricts them to one.
+func (h *Handler) GetSenderTotals(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var errs ValidationErrors
+	rejectUnknownParams(query, map[string]bool{"currency": true}, &errs)
+	currency := parseCurrencyParam(query, &errs)
+	if len(errs) > 0 {
+		writeValidationProblem(w, r, errs)
+		return
+	}
+
+	totals, err := h.DB.GetSenderTotals(r.Context(), currency)
+	if err != nil {
+		log.Println(err)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error getting sender totals")
+		return
+	}
+	if totals == nil {
+		totals = []models.SenderTotal{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"totals": totals})
+}
+
+// GetTopSenders handles GET requests for the senders with the largest volume of completed transactions
+// over a rolling window (30d by default). Senders are ranked separately in each currency and the first
+// limit (5 by default) of every ranking are returned.
+func (h *Handler) GetTopSenders(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var errs ValidationErrors
+	rejectUnknownParams(query, map[string]bool{"window": true, "limit": true, "currency": true}, &errs)
+	currency := parseCurrencyParam(query, &errs)
+
+	window := defaultReportWindow
+	if value := query.Get("window"); value != "" {
+		var err error
+		if window, err = models.ParseLimitWindow(value); err != nil {
+			errs.add("window", fieldInvalid, "window must be a whole number of minutes, hours or days up to 366d, such as 30d")
+		}
+	}
+	limit := parseIntParam(query, "limit", defaultTopSenders, 1, maxTopSenders, &errs)
+	if len(errs) > 0 {
+		writeValidationProblem(w, r, errs)
+		return
+	}
+
+	since := time.Now().UTC().Add(-window.Duration())
+	senders, err := h.DB.GetTopSenders(r.Context(), currency, since, limit)
+	if err != nil {
+		log.Println(err)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error getting top senders")
+		return
+	}
+	if senders == nil {
+		senders = []models.SenderTotal{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"window":  window,
+		"since":   since,
+		"limit":   limit,
+		"senders": senders,
+	})
+}
+
+// GetFailureHeavySenders handles GET requests for the senders with more than threshold failed transactions
+// (3 by default), most failures first.
+func (h *Handler) GetFailureHeavySenders(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var errs ValidationErrors
+	rejectUnknownParams(query, map[string]bool{"threshold": true}, &errs)
+	threshold := parseIntParam(query, "threshold", defaultFailureThreshold, 0, maxFailureThreshold, &errs)
+	if len(errs) > 0 {
+		writeValidationProblem(w, r, errs)
+		return
+	}
+
+	senders, err := h.DB.GetFailureHeavySenders(r.Context(), threshold)
+	if err != nil {
+		log.Println(err)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error getting failure-heavy senders")
+		return
+	}
+	if senders == nil {
+		senders = []models.SenderFailures{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"threshold": threshold,
+		"senders":   senders,
+	})
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_GetSenderTotals(t *testing.T) {
+	t.Run("totals per currency", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("GetSenderTotals", "").Return([]models.SenderTotal{
+			{Sender: "carol", Currency: "EUR", Count: 1, Total: models.MustParseAmount("40.00")},
+			{Sender: "alice", Currency: "USD", Count: 2, Total: models.MustParseAmount("50.00")},
+		}, nil)
+
+		rr := serveAccounts(NewHandler(mockDB), "GET", "/reports/totals-by-sender", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"totals": [
+			{"sender": "carol", "currency": "EUR", "count": 1, "total": "40.00"},
+			{"sender": "alice", "currency": "USD", "count": 2, "total": "50.00"}
+		]}`, rr.Body.String())
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("one currency", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("GetSenderTotals", "USD").Return(nil, nil)
+
+		rr := serveAccounts(NewHandler(mockDB), "GET", "/reports/totals-by-sender?currency=usd", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"totals": []}`, rr.Body.String())
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		mockDB := new(MockDB)
+
+		rr := serveAccounts(NewHandler(mockDB), "GET", "/reports/totals-by-sender?currency=dollars&status=failed", nil)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		var problem Problem
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+		assert.Equal(t, codeValidationFailed, problem.Code)
+		assert.Len(t, problem.Errors, 2)
+		mockDB.AssertNotCalled(t, "GetSenderTotals", mock.Anything)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("GetSenderTotals", "").Return(nil, errors.New("database error"))
+
+		rr := serveAccounts(NewHandler(mockDB), "GET", "/reports/totals-by-sender", nil)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestHandler_GetTopSenders(t *testing.T) {
+	t.Run("defaults to the top 5 over 30 days", func(t *testing.T) {
+		mockDB := new(MockDB)
+		before := time.Now()
+		mockDB.On("GetTopSenders", "", mock.MatchedBy(func(since time.Time) bool {
+			return !since.Before(before.Add(-30*24*time.Hour)) && !since.After(time.Now().Add(-30*24*time.Hour))
+		}), 5).Return([]models.SenderTotal{
+			{Sender: "bob", Currency: "USD", Count: 1, Total: models.MustParseAmount("70.00")},
+		}, nil)
+
+		rr := serveAccounts(NewHandler(mockDB), "GET", "/reports/top-senders", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response struct {
+			Window  string               `json:"window"`
+			Limit   int                  `json:"limit"`
+			Senders []models.SenderTotal `json:"senders"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "30d", response.Window)
+		assert.Equal(t, 5, response.Limit)
+		require.Len(t, response.Senders, 1)
+		assert.Equal(t, "bob", response.Senders[0].Sender)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("window, limit and currency", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("GetTopSenders", "EUR", mock.AnythingOfType("time.Time"), 10).Return(nil, nil)
+
+		rr := serveAccounts(NewHandler(mockDB), "GET", "/reports/top-senders?window=24h&limit=10&currency=EUR", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"window":"24h"`)
+		assert.Contains(t, rr.Body.String(), `"senders":[]`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, target := range []string{
+			"/reports/top-senders?window=30x",
+			"/reports/top-senders?window=400d",
+			"/reports/top-senders?limit=0",
+			"/reports/top-senders?limit=101",
+			"/reports/top-senders?limit=five",
+		} {
+			mockDB := new(MockDB)
+
+			rr := serveAccounts(NewHandler(mockDB), "GET", target, nil)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code, target)
+			mockDB.AssertNotCalled(t, "GetTopSenders", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("GetTopSenders", "", mock.Anything, 5).Return(nil, errors.New("database error"))
+
+		rr := serveAccounts(NewHandler(mockDB), "GET", "/reports/top-senders", nil)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
+
+func TestHandler_GetFailureHeavySenders(t *testing.T) {
+	t.Run("default threshold", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("GetFailureHeavySenders", 3).Return([]models.SenderFailures{{Sender: "erin", FailedCount: 7}}, nil)
+
+		rr := serveAccounts(NewHandler(mockDB), "GET", "/reports/failure-heavy-senders", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"threshold": 3, "senders": [{"sender": "erin", "failed_count": 7}]}`, rr.Body.String())
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("custom threshold", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("GetFailureHeavySenders", 0).Return(nil, nil)
+
+		rr := serveAccounts(NewHandler(mockDB), "GET", "/reports/failure-heavy-senders?threshold=0", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"threshold": 0, "senders": []}`, rr.Body.String())
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("negative threshold", func(t *testing.T) {
+		mockDB := new(MockDB)
+
+		rr := serveAccounts(NewHandler(mockDB), "GET", "/reports/failure-heavy-senders?threshold=-1", nil)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		var problem Problem
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+		require.Len(t, problem.Errors, 1)
+		assert.Equal(t, "threshold", problem.Errors[0].Field)
+		assert.Equal(t, fieldOutOfRange, problem.Errors[0].Code)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("GetFailureHeavySenders", 3).Return(nil, errors.New("database error"))
+
+		rr := serveAccounts(NewHandler(mockDB), "GET", "/reports/failure-heavy-senders", nil)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	})
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/db"
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+const (
+	// streamBatchSize is the number of events a stream reads from the outbox at once
+	streamBatchSize = 100
+	// streamPollInterval is how often a stream re-reads the outbox without being woken,
+	// which picks up events written by other server processes
+	streamPollInterval = 2 * time.Second
+	// streamHeartbeatInterval is how long a stream may stay silent before a comment is sent to keep proxies from closing it
+	streamHeartbeatInterval = 15 * time.Second
+	// streamGapTimeout is how long a stream waits for a missing event ID to commit before it takes the ID for
+	// a rolled back event and moves past it. Event IDs are handed out when an event is written, not when its
+	// transaction commits, so this must be well over the time any transaction that writes an event stays open.
+	streamGapTimeout = time.Minute
+	// lastEventIDHeader is the request header an EventSource sends when it reconnects
+	lastEventIDHeader = "Last-Event-ID"
+)
+
+// streamQueryParams is the set of query parameters accepted by the transaction stream.
+var streamQueryParams = map[string]bool{
+	"status": true, "sender": true, "currency": true, "last_event_id": true,
+}
+
+// StreamTransactions handles GET requests for a Server-Sent Events stream of transaction events.
+// Each SSE event carries an outbox event: its id is the event's position in the outbox, its event
+// field the event type, and its data the JSON event as delivered to webhooks.
+// The status, sender and currency parameters narrow the stream to events whose transaction matches.
+// A client resumes after the last event it saw with the Last-Event-ID header, which browsers send on
+// reconnect, or the last_event_id parameter; without either the stream starts with the next event.
+func (h *Handler) StreamTransactions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var errs ValidationErrors
+	rejectUnknownParams(query, streamQueryParams, &errs)
+	filterQuery := url.Values{}
+	for _, param := range []string{"status", "sender", "currency"} {
+		if query.Has(param) {
+			filterQuery[param] = query[param]
+		}
+	}
+	filter, filterErrs := parseTransactionFilter(filterQuery)
+	errs = append(errs, filterErrs...)
+
+	lastEventID := int64(-1)
+	if value := query.Get("last_event_id"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id < 0 {
+			errs.add("last_event_id", fieldInvalid, "last_event_id must be an event ID")
+		}
+		lastEventID = id
+	}
+	if len(errs) > 0 {
+		writeValidationProblem(w, r, errs)
+		return
+	}
+
+	// The header wins over the parameter: it is what the browser saw last before reconnecting
+	if value := r.Header.Get(lastEventIDHeader); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id < 0 {
+			writeProblem(w, r, http.StatusBadRequest, codeInvalidHeader, "Last-Event-ID must be an event ID")
+			return
+		}
+		lastEventID = id
+	}
+
+	// Subscribe before reading the outbox so that no write between the two goes unnoticed
+	wake, unsubscribe := h.Events.Subscribe(1)
+	defer unsubscribe()
+
+	if lastEventID < 0 {
+		var err error
+		lastEventID, err = h.DB.LastOutboxEventID(r.Context())
+		if err != nil {
+			log.Println(err)
+			writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error opening transaction stream")
+			return
+		}
+	}
+
+	// The stream outlives the server's write timeout, so lift it for this response where supported
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Ask nginx-style proxies not to buffer the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		log.Println(err)
+		return
+	}
+
+	poll := time.NewTicker(streamPollInterval)
+	defer poll.Stop()
+	lastWrite := time.Now()
+	for {
+		sent, err := h.streamEvents(r.Context(), w, filter, &lastEventID)
+		if err != nil {
+			// The client reconnects with Last-Event-ID and carries on where this stream stopped
+			if r.Context().Err() == nil {
+				log.Println(err)
+			}
+			return
+		}
+		if sent == 0 && time.Since(lastWrite) >= streamHeartbeatInterval {
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			sent = 1
+		}
+		if sent > 0 {
+			if err := rc.Flush(); err != nil {
+				return
+			}
+			lastWrite = time.Now()
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case _, ok := <-wake:
+			// The broker is closed when the server shuts down
+			if !ok {
+				return
+			}
+		case <-poll.C:
+		}
+	}
+}
+
+// streamEvents writes every outbox event after *lastEventID that matches the filter, advancing *lastEventID
+// past every event read, and returns the number of events written.
+// It stops at a gap in the event IDs until the missing event commits or streamGapTimeout has passed since
+// the event after the gap was written, so that neither this stream nor a client resuming from
+// *lastEventID skips an event that commits after a later one.
+func (h *Handler) streamEvents(ctx context.Context, w io.Writer, filter db.TransactionFilter, lastEventID *int64) (int, error) {
+	sent := 0
+	for {
+		events, err := h.DB.ListOutboxEvents(ctx, *lastEventID, streamBatchSize)
+		if err != nil {
+			return sent, fmt.Errorf("failed to read transaction events: %w", err)
+		}
+
+		for _, event := range events {
+			if event.ID > *lastEventID+1 && time.Since(event.CreatedAt) < streamGapTimeout {
+				return sent, nil
+			}
+			*lastEventID = event.ID
+
+			var data models.TransactionEventData
+			if err := json.Unmarshal(event.Data, &data); err != nil {
+				log.Printf("skipping malformed transaction event %d: %v", event.ID, err)
+				continue
+			}
+			if !filter.Matches(data.Transaction) {
+				continue
+			}
+
+			payload, err := json.Marshal(event)
+			if err != nil {
+				return sent, err
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, payload); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+
+		if len(events) < streamBatchSize {
+			return sent, nil
+		}
+	}
+}
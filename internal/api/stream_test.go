@@ -0,0 +1,299 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/db"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sseEvent is a single event read from a Server-Sent Events stream.
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// openStream connects to the transaction stream of a server and returns a channel of its events.
+func openStream(t *testing.T, server *httptest.Server, target string, lastEventID string) <-chan sseEvent {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+target, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set(lastEventIDHeader, lastEventID)
+	}
+
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer resp.Body.Close()
+		defer close(events)
+
+		var event sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			field, value, _ := strings.Cut(scanner.Text(), ": ")
+			switch field {
+			case "id":
+				event.ID = value
+			case "event":
+				event.Event = value
+			case "data":
+				event.Data = value
+			case "":
+				if event.ID != "" {
+					events <- event
+				}
+				event = sseEvent{}
+			}
+		}
+	}()
+	return events
+}
+
+// nextEvent waits briefly for the next event on a stream.
+func nextEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "stream ended")
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a stream event")
+		return sseEvent{}
+	}
+}
+
+func newStreamServer(t *testing.T) (*Handler, *httptest.Server) {
+	handler := NewHandler(db.NewMemoryDB())
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(func() {
+		handler.Events.Close()
+		server.Close()
+	})
+	return handler, server
+}
+
+func postTransaction(t *testing.T, server *httptest.Server, sender, currency string) models.Transaction {
+	t.Helper()
+
+	body := `{"amount":"10.00","currency":"` + currency + `","sender":"` + sender + `","receiver":"bob"}`
+	resp, err := server.Client().Post(server.URL+"/transactions", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var transaction models.Transaction
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&transaction))
+	return transaction
+}
+
+func putStatus(t *testing.T, server *httptest.Server, id string, status models.Status) {
+	t.Helper()
+
+	req, err := http.NewRequest("PUT", server.URL+"/transactions/"+id, bytes.NewReader([]byte(`{"status":"`+string(status)+`"}`)))
+	require.NoError(t, err)
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+// outboxDB is a database whose outbox the test fills, so that events can commit out of ID order.
+type outboxDB struct {
+	db.DB
+	mu     sync.Mutex
+	events []models.OutboxEvent
+}
+
+// commit makes an event visible, as if the transaction that wrote it had just committed.
+func (o *outboxDB) commit(t *testing.T, id int64, createdAt time.Time) {
+	t.Helper()
+	event, err := models.NewTransactionEvent(models.EventTransactionCreated, models.TransactionEventData{
+		Transaction: models.Transaction{ID: "transaction-" + strconv.FormatInt(id, 10), Status: models.StatusPending},
+	})
+	require.NoError(t, err)
+	event.ID, event.CreatedAt = id, createdAt
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, event)
+	slices.SortFunc(o.events, func(a, b models.OutboxEvent) int { return int(a.ID - b.ID) })
+}
+
+func (o *outboxDB) ListOutboxEvents(ctx context.Context, afterID int64, limit int) ([]models.OutboxEvent, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var events []models.OutboxEvent
+	for _, event := range o.events {
+		if event.ID > afterID && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func TestHandler_StreamTransactions(t *testing.T) {
+	t.Run("live events for created and updated transactions", func(t *testing.T) {
+		_, server := newStreamServer(t)
+		before := postTransaction(t, server, "alice", "USD")
+
+		events := openStream(t, server, "/transactions/stream", "")
+		created := postTransaction(t, server, "alice", "USD")
+		putStatus(t, server, created.ID, models.StatusProcessing)
+
+		event := nextEvent(t, events)
+		assert.Equal(t, "2", event.ID, "events written before the stream opened are skipped")
+		assert.Equal(t, models.EventTransactionCreated, event.Event)
+		var outbox models.OutboxEvent
+		require.NoError(t, json.Unmarshal([]byte(event.Data), &outbox))
+		assert.Equal(t, created.ID, outbox.TransactionID)
+		assert.NotEqual(t, before.ID, outbox.TransactionID)
+
+		event = nextEvent(t, events)
+		assert.Equal(t, "3", event.ID)
+		assert.Equal(t, models.EventTransactionStatusChanged, event.Event)
+		var data struct {
+			Data models.TransactionEventData `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(event.Data), &data))
+		assert.Equal(t, models.StatusProcessing, data.Data.Transaction.Status)
+		assert.Equal(t, models.StatusPending, data.Data.PreviousStatus)
+	})
+
+	t.Run("filters and resume from Last-Event-ID", func(t *testing.T) {
+		_, server := newStreamServer(t)
+		first := postTransaction(t, server, "alice", "USD")
+		postTransaction(t, server, "carol", "USD")
+		postTransaction(t, server, "alice", "EUR")
+		putStatus(t, server, first.ID, models.StatusFailed)
+
+		events := openStream(t, server, "/transactions/stream?sender=alice&currency=usd&status=pending,failed", "0")
+
+		event := nextEvent(t, events)
+		assert.Equal(t, "1", event.ID)
+		assert.Equal(t, models.EventTransactionCreated, event.Event)
+		event = nextEvent(t, events)
+		assert.Equal(t, "4", event.ID)
+		assert.Equal(t, models.EventTransactionStatusChanged, event.Event)
+
+		// The query parameter is a fallback for the header
+		events = openStream(t, server, "/transactions/stream?last_event_id=3", "")
+		assert.Equal(t, "4", nextEvent(t, events).ID)
+		events = openStream(t, server, "/transactions/stream?last_event_id=0", "3")
+		assert.Equal(t, "4", nextEvent(t, events).ID)
+	})
+
+	t.Run("events committed out of ID order", func(t *testing.T) {
+		outbox := &outboxDB{DB: db.NewMemoryDB()}
+		handler := NewHandler(outbox)
+		router := mux.NewRouter()
+		handler.RegisterRoutes(router)
+		server := httptest.NewServer(router)
+		t.Cleanup(func() {
+			handler.Events.Close()
+			server.Close()
+		})
+		events := openStream(t, server, "/transactions/stream", "0")
+
+		// Event 2 commits while event 1 is still being written
+		outbox.commit(t, 2, time.Now())
+		handler.Events.Publish("transaction-2")
+		select {
+		case event := <-events:
+			t.Fatalf("event %s was sent before event 1 committed", event.ID)
+		case <-time.After(200 * time.Millisecond):
+		}
+
+		outbox.commit(t, 1, time.Now())
+		handler.Events.Publish("transaction-1")
+		assert.Equal(t, "1", nextEvent(t, events).ID)
+		assert.Equal(t, "2", nextEvent(t, events).ID)
+
+		// A client resuming after event 2 was sent gets the events after it
+		resumed := openStream(t, server, "/transactions/stream", "2")
+
+		// Event 3 never commits: event 4 is held back until it is older than any transaction could be
+		outbox.commit(t, 4, time.Now().Add(-streamGapTimeout))
+		handler.Events.Publish("transaction-4")
+		assert.Equal(t, "4", nextEvent(t, events).ID)
+		assert.Equal(t, "4", nextEvent(t, resumed).ID)
+	})
+
+	t.Run("closing the broker ends the stream", func(t *testing.T) {
+		handler, server := newStreamServer(t)
+		events := openStream(t, server, "/transactions/stream", "")
+
+		handler.Events.Close()
+
+		select {
+		case _, ok := <-events:
+			assert.False(t, ok)
+		case <-time.After(5 * time.Second):
+			t.Fatal("stream did not end")
+		}
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			target string
+			header string
+			code   string
+		}{
+			{"unknown parameter", "/transactions/stream?receiver=bob", "", codeValidationFailed},
+			{"invalid status", "/transactions/stream?status=lost", "", codeValidationFailed},
+			{"invalid currency", "/transactions/stream?currency=dollars", "", codeValidationFailed},
+			{"invalid last_event_id", "/transactions/stream?last_event_id=-1", "", codeValidationFailed},
+			{"invalid Last-Event-ID", "/transactions/stream", "abc", codeInvalidHeader},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockDB := new(MockDB)
+				handler := NewHandler(mockDB)
+				router := mux.NewRouter()
+				handler.RegisterRoutes(router)
+
+				req := httptest.NewRequest("GET", tt.target, nil)
+				if tt.header != "" {
+					req.Header.Set(lastEventIDHeader, tt.header)
+				}
+				rr := httptest.NewRecorder()
+				router.ServeHTTP(rr, req)
+
+				assert.Equal(t, http.StatusBadRequest, rr.Code)
+				var problem Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				assert.Equal(t, tt.code, problem.Code)
+				mockDB.AssertNotCalled(t, "LastOutboxEventID")
+			})
+		}
+	})
+}
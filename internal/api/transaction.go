@@ -3,16 +3,28 @@
 package api
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/abadojack/gapstack/internal/currency"
 	"github.com/abadojack/gapstack/internal/db"
+	"github.com/abadojack/gapstack/internal/fx"
+	"github.com/abadojack/gapstack/internal/iso20022"
+	"github.com/abadojack/gapstack/internal/limits"
 	"github.com/abadojack/gapstack/internal/models"
+	"github.com/abadojack/gapstack/internal/pubsub"
+	"github.com/abadojack/gapstack/internal/screening"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
@@ -20,86 +32,358 @@ import (
 const (
 	// defaultPageSize is the default number of transactions to return per page
 	defaultPageSize = 10
+	// maxPageSize is the largest number of transactions returned per page
+	maxPageSize = 100
+	// defaultIdempotencyTTL is how long an Idempotency-Key is remembered by default
+	defaultIdempotencyTTL = 24 * time.Hour
+	// idempotencyLease is how long an Idempotency-Key stays reserved for a request in flight. A request that
+	// outlives it may lose the key to a retry, in which case it fails without creating its transaction.
+	idempotencyLease = time.Minute
+	// maxIdempotencyKeyLength is the longest Idempotency-Key header value accepted
+	maxIdempotencyKeyLength = 255
+	// idempotencyKeyHeader is the request header carrying the client's idempotency key
+	idempotencyKeyHeader = "Idempotency-Key"
+	// actorHeader is the request header naming who requested a status change, for the transaction history
+	actorHeader = "X-Actor"
+	// defaultActor is recorded in the transaction history when a request does not name its actor
+	defaultActor = "api"
 )
 
 // Handler contains the HTTP handlers for transaction operations.
 // It holds a reference to the database interface for data persistence.
 type Handler struct {
 	DB db.DB
+	// IdempotencyTTL is how long a used Idempotency-Key replays its original response
+	IdempotencyTTL time.Duration
+	// Events is told the ID of every transaction written through the handler, to wake its event streams
+	Events *pubsub.Broker[string]
+	// Screener checks every new transaction before it is stored; nil accepts transactions unscreened
+	Screener screening.Screener
+	// Limiter enforces per-sender spending limits on new transactions; nil disables them
+	Limiter *limits.Limiter
+	// FX converts cross-currency transactions and issues quotes; nil disables currency conversion
+	FX *fx.Converter
+	// Currencies restricts new transactions, quotes, accounts and limits to a subset of currencies;
+	// nil allows every ISO 4217 currency in use
+	Currencies *currency.Set
+	// Agent is the financial institution the service acts as in the ISO 20022 messages it writes
+	Agent iso20022.Agent
 }
 
 // NewHandler creates a new Handler instance with the provided database interface.
 func NewHandler(db db.DB) *Handler {
 	return &Handler{
-		DB: db,
+		DB:             db,
+		IdempotencyTTL: defaultIdempotencyTTL,
+		Events:         pubsub.NewBroker[string](),
 	}
 }
 
 // RegisterRoutes sets up all the HTTP routes for the transaction API.
-// It registers endpoints for CRUD operations on transactions.
+// It registers endpoints for CRUD operations on transactions and their exchange as ISO 20022 messages, read
+// access to accounts and their ledgers, a live stream of transaction events, exchange rate quotes, aggregate
+// reports on senders, the administration of spending limits, and the management of webhook endpoints and their
+// deliveries, and assigns every request an ID.
 func (h *Handler) RegisterRoutes(r *mux.Router) {
+	r.Use(requestIDMiddleware)
+
 	r.HandleFunc("/transactions", h.CreateTransaction).Methods("POST")
 	r.HandleFunc("/transactions", h.ListTransactions).Methods("GET")
+	r.HandleFunc("/transactions/batch", h.CreateTransactionBatch).Methods("POST")
+	r.HandleFunc("/transactions/export", h.ExportTransactions).Methods("GET")
+	r.HandleFunc("/transactions/import", h.ImportTransactions).Methods("POST")
+	r.HandleFunc("/transactions/pain.001", h.CreatePain001Transactions).Methods("POST")
+	// Registered before /transactions/{id} so that "stream" is not taken for an ID
+	r.HandleFunc("/transactions/stream", h.StreamTransactions).Methods("GET")
 	r.HandleFunc("/transactions/{id}", h.GetTransaction).Methods("GET")
 	r.HandleFunc("/transactions/{id}", h.UpdateTransaction).Methods("PUT")
+	r.HandleFunc("/transactions/{id}/history", h.GetTransactionHistory).Methods("GET")
+	r.HandleFunc("/transactions/{id}/pacs.008", h.GetTransactionPacs008).Methods("GET")
+
+	r.HandleFunc("/accounts", h.CreateAccount).Methods("POST")
+	r.HandleFunc("/accounts/{id}", h.GetAccount).Methods("GET")
+	r.HandleFunc("/accounts/{id}/balance", h.GetAccountBalance).Methods("GET")
+	r.HandleFunc("/accounts/{id}/entries", h.ListAccountEntries).Methods("GET")
+
+	r.HandleFunc("/quotes", h.CreateQuote).Methods("POST")
+	r.HandleFunc("/quotes/{id}", h.GetQuote).Methods("GET")
+
+	r.HandleFunc("/reports/totals-by-sender", h.GetSenderTotals).Methods("GET")
+	r.HandleFunc("/reports/top-senders", h.GetTopSenders).Methods("GET")
+	r.HandleFunc("/reports/failure-heavy-senders", h.GetFailureHeavySenders).Methods("GET")
+
+	r.HandleFunc("/admin/limits", h.ListDefaultLimits).Methods("GET")
+	r.HandleFunc("/admin/limits/{sender}", h.GetSenderLimits).Methods("GET")
+	r.HandleFunc("/admin/limits/{sender}", h.SetSenderLimits).Methods("PUT")
+	r.HandleFunc("/admin/limits/{sender}", h.DeleteSenderLimits).Methods("DELETE")
+
+	r.HandleFunc("/webhooks", h.CreateWebhook).Methods("POST")
+	r.HandleFunc("/webhooks", h.ListWebhooks).Methods("GET")
+	r.HandleFunc("/webhooks/{id}", h.GetWebhook).Methods("GET")
+	r.HandleFunc("/webhooks/{id}", h.UpdateWebhook).Methods("PUT")
+	r.HandleFunc("/webhooks/{id}", h.DeleteWebhook).Methods("DELETE")
+	r.HandleFunc("/webhooks/{id}/deliveries", h.ListWebhookDeliveries).Methods("GET")
+	r.HandleFunc("/webhooks/{id}/deliveries/{delivery_id}/redeliver", h.RedeliverWebhookDelivery).Methods("POST")
 }
 
 // CreateTransaction handles POST requests to create a new transaction.
-// It validates the input, sets the default status to pending, and stores the transaction.
+// It validates the input, checks the sender's spending limits and screens the transaction if a Limiter
+// and Screener are set, and stores it. A transaction over a limit is rejected with 422 and not stored.
+// A request with a target_currency other than its currency is converted at the current rate, or at the rate
+// locked by its quote_id, and the receiver is credited the converted amount.
+// A transaction starts out pending; one that screening holds for review starts out held,
+// and one that screening rejects is stored as failed with the screening reason as its failure reason.
+// Requests carrying an Idempotency-Key header are executed at most once: the key is reserved before the
+// request runs and stored with the response in the same SQL transaction as the new transaction. A retry with
+// the same body replays the original response, or is refused with 409 while the original is still in flight,
+// and a different body under the same key is rejected.
 func (h *Handler) CreateTransaction(w http.ResponseWriter, r *http.Request) {
-	var transaction models.Transaction
-
-	// Decode request body into transaction struct
-	if err := json.NewDecoder(r.Body).Decode(&transaction); err != nil {
+	// Read the raw body so it can be fingerprinted for idempotency checks
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
 		log.Println(err)
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidRequestBody, "invalid request body")
 		return
 	}
 	defer r.Body.Close()
 
-	// Input validation
-	if err := validateTransaction(transaction); err != nil {
-		log.Println(err)
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	// Reserve the idempotency key, or replay or reject requests that reuse it
+	var reservation *models.IdempotencyRecord
+	if idempotencyKey := r.Header.Get(idempotencyKeyHeader); idempotencyKey != "" {
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			writeProblem(w, r, http.StatusBadRequest, codeInvalidHeader, "idempotency key must be 255 characters or less")
+			return
+		}
+
+		record, ok := h.reserveIdempotencyKey(w, r, idempotencyKey, requestFingerprint(body))
+		if !ok {
+			return
+		}
+		reservation = &record
+
+		// Free the key for a retry unless the transaction was created and the key completed with it.
+		// Detach from cancellation: if the client has already gone away, its retry still needs the key.
+		defer func() {
+			if reservation == nil {
+				return
+			}
+			if err := h.DB.ReleaseIdempotencyKey(context.WithoutCancel(r.Context()), *reservation); err != nil {
+				log.Println(err)
+			}
+		}()
+	}
+
+	transaction, problem := h.prepareTransaction(r, body)
+	if problem != nil {
+		writeProblemBody(w, *problem)
 		return
 	}
 
-	// Default status = pending
-	transaction.Status = models.StatusPending
-	transaction.ID = uuid.NewString()
-	transaction.CreatedAt = time.Now()
+	if h.Limiter != nil {
+		breach, err := h.Limiter.Check(r.Context(), transaction)
+		if err != nil {
+			log.Println(err)
+			writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error checking spending limits")
+			return
+		}
+		if breach != nil {
+			writeLimitProblem(w, r, breach)
+			return
+		}
+	}
 
-	// Store transaction in database
-	if err := h.DB.CreateTransaction(transaction); err != nil {
+	if problem := h.screenTransaction(r, &transaction); problem != nil {
+		writeProblemBody(w, *problem)
+		return
+	}
+
+	response, err := json.Marshal(transaction)
+	if err != nil {
 		log.Println(err)
-		http.Error(w, "error creating transaction", http.StatusInternalServerError)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error encoding transaction")
 		return
 	}
 
+	// Store transaction in database, together with the response retries with the same key replay
+	if reservation != nil {
+		record := *reservation
+		record.Status = models.IdempotencyCompleted
+		record.ResponseCode = http.StatusCreated
+		record.ResponseBody = response
+		record.ExpiresAt = record.CreatedAt.Add(h.IdempotencyTTL)
+		err = h.DB.CreateIdempotentTransaction(r.Context(), transaction, record)
+	} else {
+		err = h.DB.CreateTransaction(r.Context(), transaction)
+	}
+	if errors.Is(err, db.ErrNotFound) {
+		log.Println(err)
+		writeProblem(w, r, http.StatusConflict, codeIdempotencyInProgress, "idempotency key was taken over by a retry of this request")
+		return
+	}
+	if err != nil {
+		writeProblemBody(w, *createProblem(r, err))
+		return
+	}
+	reservation = nil
+	h.Events.Publish(transaction.ID)
+
 	// Respond with created transaction
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(transaction); err != nil {
+	if _, err := w.Write(response); err != nil {
 		log.Println(err)
-		http.Error(w, "error encoding transaction", http.StatusInternalServerError)
-		return
 	}
 }
 
+// reserveIdempotencyKey reserves an idempotency key for a request with the given body fingerprint and returns
+// the reservation. If the key is already held the request is answered here and false is returned: a finished
+// request with the same body has its response replayed, one still in flight is refused with 409, and a
+// different body is refused with 422.
+func (h *Handler) reserveIdempotencyKey(w http.ResponseWriter, r *http.Request, key, fingerprint string) (models.IdempotencyRecord, bool) {
+	// idempotency_keys timestamps hold whole seconds, and the creation time identifies the reservation
+	now := time.Now().UTC().Truncate(time.Second)
+	reservation := models.IdempotencyRecord{
+		Key:         key,
+		RequestHash: fingerprint,
+		Status:      models.IdempotencyPending,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(idempotencyLease),
+	}
+	err := h.DB.ReserveIdempotencyKey(r.Context(), reservation)
+	if err == nil {
+		return reservation, true
+	}
+	if !errors.Is(err, db.ErrDuplicate) {
+		log.Println(err)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error reserving idempotency key")
+		return models.IdempotencyRecord{}, false
+	}
+
+	record, err := h.DB.GetIdempotencyRecord(r.Context(), key)
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		// The holder's reservation lapsed between the two queries
+		writeProblem(w, r, http.StatusConflict, codeIdempotencyInProgress, "a request with this idempotency key is still in progress")
+	case err != nil:
+		log.Println(err)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error checking idempotency key")
+	case record.RequestHash != fingerprint:
+		writeProblem(w, r, http.StatusUnprocessableEntity, codeIdempotencyMismatch, "idempotency key was already used with a different request body")
+	case record.Status == models.IdempotencyPending:
+		writeProblem(w, r, http.StatusConflict, codeIdempotencyInProgress, "a request with this idempotency key is still in progress")
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(record.ResponseCode)
+		if _, err := w.Write(record.ResponseBody); err != nil {
+			log.Println(err)
+		}
+	}
+	return models.IdempotencyRecord{}, false
+}
+
+// prepareTransaction decodes and validates a request to create a transaction and works out its conversion,
+// returning the problem if the request cannot be accepted. The transaction is given its ID, creation time
+// and pending status; it still has to be checked against the spending limits and screened.
+func (h *Handler) prepareTransaction(r *http.Request, body []byte) (models.Transaction, *Problem) {
+	// Decode request body into transaction struct
+	var transaction models.Transaction
+	if err := json.Unmarshal(body, &transaction); err != nil {
+		log.Println(err)
+		return transaction, newProblem(r, http.StatusBadRequest, codeInvalidRequestBody, "invalid request body")
+	}
+
+	var conversion conversionRequest
+	if err := json.Unmarshal(body, &conversion); err != nil {
+		log.Println(err)
+		return transaction, newProblem(r, http.StatusBadRequest, codeInvalidRequestBody, "invalid request body")
+	}
+
+	return h.acceptTransaction(r, transaction, conversion, nil)
+}
+
+// acceptTransaction validates a decoded request to create a transaction and readies it like prepareTransaction.
+// errs holds the failures already found decoding the request; the validation of those fields is left out.
+func (h *Handler) acceptTransaction(r *http.Request, transaction models.Transaction, conversion conversionRequest, errs ValidationErrors) (models.Transaction, *Problem) {
+	// Input validation
+	decoded := len(errs)
+	for _, fieldErr := range append(validateTransaction(&transaction, h.Currencies), validateConversionRequest(&conversion, h.Currencies)...) {
+		if !slices.ContainsFunc(errs[:decoded], func(e FieldError) bool { return e.Field == fieldErr.Field }) {
+			errs = append(errs, fieldErr)
+		}
+	}
+	if len(errs) > 0 {
+		log.Println(errs)
+		return transaction, validationProblem(r, errs)
+	}
+
+	// Default status = pending; the status, failure reason and screening decision are never taken from the client
+	transaction.Status = models.StatusPending
+	transaction.FailureReason = ""
+	transaction.Screening = nil
+	transaction.ID = uuid.NewString()
+	transaction.CreatedAt = time.Now()
+
+	// The conversion is worked out here, never taken from the client
+	var problem *Problem
+	transaction.Conversion, problem = h.convertTransaction(r, transaction, conversion)
+	return transaction, problem
+}
+
+// screenTransaction screens a transaction if a Screener is set, recording the decision and the status it
+// leads to. A transaction that screening rejects is given the screening reason as its failure reason.
+func (h *Handler) screenTransaction(r *http.Request, transaction *models.Transaction) *Problem {
+	if h.Screener == nil {
+		return nil
+	}
+
+	decision, err := h.Screener.Screen(r.Context(), *transaction)
+	if err != nil {
+		log.Println(err)
+		return newProblem(r, http.StatusInternalServerError, codeInternal, "error screening transaction")
+	}
+	transaction.Screening = &decision
+	transaction.Status = decision.Decision.Status()
+	if decision.Decision == models.ScreeningReject {
+		transaction.FailureReason = decision.Reason
+		if transaction.FailureReason == "" {
+			transaction.FailureReason = "rejected by screening"
+		}
+	}
+	return nil
+}
+
+// createProblem builds the problem of a transaction the database refused to store.
+func createProblem(r *http.Request, err error) *Problem {
+	log.Println(err)
+	if errors.Is(err, db.ErrDuplicate) {
+		return newProblem(r, http.StatusConflict, codeConflict, "transaction already exists")
+	}
+	if errors.Is(err, db.ErrConflict) {
+		return newProblem(r, http.StatusConflict, codeConflict, "quote has already been used")
+	}
+	return newProblem(r, http.StatusInternalServerError, codeInternal, "error creating transaction")
+}
+
 // GetTransaction handles GET requests to retrieve a single transaction by ID.
 // It extracts the ID from the URL path and returns the transaction data.
 func (h *Handler) GetTransaction(w http.ResponseWriter, r *http.Request) {
 	// Extract transaction ID from URL path
 	id := mux.Vars(r)["id"]
 	if id == "" {
-		http.Error(w, "missing transaction id", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, codeMissingID, "missing transaction id")
 		return
 	}
 
 	// Retrieve transaction from database
-	transaction, err := h.DB.GetTransaction(id)
+	transaction, err := h.DB.GetTransaction(r.Context(), id)
 	if err != nil {
-		http.Error(w, "error getting transaction", http.StatusInternalServerError)
+		if errors.Is(err, db.ErrNotFound) {
+			writeProblem(w, r, http.StatusNotFound, codeNotFound, "transaction not found")
+			return
+		}
+		log.Println(err)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error getting transaction")
 		return
 	}
 
@@ -107,74 +391,196 @@ func (h *Handler) GetTransaction(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(transaction); err != nil {
-		http.Error(w, "error encoding transaction", http.StatusInternalServerError)
-		return
+		log.Println(err)
 	}
 }
 
 // ListTransactions handles GET requests to retrieve a paginated list of transactions.
-// It supports query parameters for pagination: page and page_size.
+// By default it uses keyset pagination: responses carry opaque next_cursor and prev_cursor tokens
+// that are passed back in the cursor query parameter. Supplying page selects the legacy offset mode.
+// In both modes page_size is capped at maxPageSize, navigation links are sent in an RFC 8288 Link header,
+// and the total number of matches is reported unless include_total=false.
+// Results can be narrowed and ordered with the query parameters understood by parseTransactionFilter.
 func (h *Handler) ListTransactions(w http.ResponseWriter, r *http.Request) {
-	// Parse pagination query params
-	pageParam := r.URL.Query().Get("page")
-	pageSizeParam := r.URL.Query().Get("page_size")
+	query := r.URL.Query()
+	pageSize := parsePageSize(query.Get("page_size"))
+
+	filter, errs := parseTransactionFilter(query)
+	includeTotal := true
+	if value := query.Get("include_total"); value != "" {
+		var err error
+		if includeTotal, err = strconv.ParseBool(value); err != nil {
+			errs.add("include_total", fieldInvalid, "include_total must be true or false")
+		}
+	}
+	if len(errs) > 0 {
+		writeValidationProblem(w, r, errs)
+		return
+	}
 
+	// Counting honours the same filter as the listing; skipping it saves a second query on large tables
+	var total *int
+	if includeTotal {
+		count, err := h.DB.CountTransactions(r.Context(), filter)
+		if err != nil {
+			log.Println(err)
+			writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error counting transactions")
+			return
+		}
+		total = &count
+	}
+
+	if query.Has("page") {
+		h.listTransactionsByOffset(w, r, filter, pageSize, total)
+		return
+	}
+	h.listTransactionsByCursor(w, r, filter, pageSize, total)
+}
+
+// listTransactionsByOffset serves the legacy page/page_size mode using LIMIT/OFFSET.
+func (h *Handler) listTransactionsByOffset(w http.ResponseWriter, r *http.Request, filter db.TransactionFilter, pageSize int, total *int) {
 	// Parse page number with validation
-	page, err := strconv.Atoi(pageParam)
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
 	if err != nil || page < 1 {
 		log.Println(err)
 		page = 1
 	}
 
-	// Parse page size with validation
-	pageSize, err := strconv.Atoi(pageSizeParam)
-	if err != nil || pageSize < 1 {
-		log.Println(err)
-		pageSize = defaultPageSize // default page size
-	}
-
 	// Calculate offset for database query
 	offset := (page - 1) * pageSize
 
-	// Retrieve transactions from database
-	transactions, err := h.DB.GetAllTransactions(pageSize, offset)
+	// Retrieve transactions from database, with one extra row to learn whether another page exists
+	transactions, err := h.DB.GetAllTransactions(r.Context(), filter, pageSize+1, offset)
 	if err != nil {
 		log.Println(err)
-		http.Error(w, "error getting transactions", http.StatusInternalServerError)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error getting transactions")
 		return
 	}
+	hasMore := len(transactions) > pageSize
+	if hasMore {
+		transactions = transactions[:pageSize]
+	}
 
 	// Build paginated response
 	response := map[string]interface{}{
 		"page":         page,
-		"page_size":    len(transactions),
+		"page_size":    pageSize,
+		"has_more":     hasMore,
 		"transactions": transactions,
 	}
 
-	// Return paginated response
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
+	links := []string{pageLink(r, "first", map[string]string{"page": "1"})}
+	if page > 1 {
+		links = append(links, pageLink(r, "prev", map[string]string{"page": strconv.Itoa(page - 1)}))
+	}
+	if hasMore {
+		links = append(links, pageLink(r, "next", map[string]string{"page": strconv.Itoa(page + 1)}))
+	}
+	if total != nil {
+		totalPages := pageCount(*total, pageSize)
+		response["total_count"] = *total
+		response["total_pages"] = totalPages
+		links = append(links, pageLink(r, "last", map[string]string{"page": strconv.Itoa(max(totalPages, 1))}))
+	}
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+	writeJSON(w, http.StatusOK, response)
+}
+
+// listTransactionsByCursor serves keyset pagination over the (sort key, id) ordering.
+func (h *Handler) listTransactionsByCursor(w http.ResponseWriter, r *http.Request, filter db.TransactionFilter, pageSize int, total *int) {
+	var cursor *db.Cursor
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		var err error
+		cursor, err = decodeCursor(token, filter)
+		if err != nil {
+			var errs ValidationErrors
+			errs.add("cursor", fieldInvalid, "cursor is not a valid pagination token for this sort order")
+			writeValidationProblem(w, r, errs)
+			return
+		}
+	}
+	backward := cursor != nil && cursor.Before
+
+	// Fetch one extra row to learn whether another page exists in the direction of travel
+	transactions, err := h.DB.GetTransactionsAfter(r.Context(), filter, cursor, pageSize+1)
+	if err != nil {
 		log.Println(err)
-		http.Error(w, "error encoding response", http.StatusInternalServerError)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error getting transactions")
 		return
 	}
+	more := len(transactions) > pageSize
+	if more {
+		if backward {
+			transactions = transactions[1:]
+		} else {
+			transactions = transactions[:pageSize]
+		}
+	}
+
+	// A page reached by moving forward always has a predecessor, and vice versa
+	var nextCursor, prevCursor interface{}
+	links := []string{pageLink(r, "first", map[string]string{"cursor": ""})}
+	if len(transactions) > 0 {
+		first, last := transactions[0], transactions[len(transactions)-1]
+		if more || backward {
+			token := encodeCursor(db.CursorFor(last, false), filter)
+			nextCursor = token
+			links = append(links, pageLink(r, "next", map[string]string{"cursor": token}))
+		}
+		if (more && backward) || (cursor != nil && !backward) {
+			token := encodeCursor(db.CursorFor(first, true), filter)
+			prevCursor = token
+			links = append(links, pageLink(r, "prev", map[string]string{"cursor": token}))
+		}
+	}
+
+	response := map[string]interface{}{
+		"page_size":    pageSize,
+		"has_more":     nextCursor != nil,
+		"transactions": transactions,
+		"next_cursor":  nextCursor,
+		"prev_cursor":  prevCursor,
+	}
+	if total != nil {
+		response["total_count"] = *total
+		response["total_pages"] = pageCount(*total, pageSize)
+	}
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+	writeJSON(w, http.StatusOK, response)
+}
+
+// writeJSON encodes a successful JSON response.
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Println(err)
+	}
 }
 
 // updateRequest represents the request body for updating a transaction status.
 type updateRequest struct {
 	Status models.Status `json:"status"`
+	// FailureReason optionally explains a move to failed; it is rejected with any other status
+	FailureReason string `json:"failure_reason"`
 }
 
 // UpdateTransaction handles PUT requests to update a transaction's status.
-// Only completed and failed statuses are allowed for updates.
+// The requested status must be reachable in the transaction state machine;
+// transitions that are illegal from the transaction's current status are rejected with 409.
+// A transaction held by screening is released by moving it to pending, or rejected by moving it to failed.
+// Every change is recorded in the transaction's history together with the X-Actor header, if any.
+// Completing or reversing a transaction posts it to the ledger; a posting that would overdraw
+// the debited account, or that does not match its currency, is rejected with 422.
 func (h *Handler) UpdateTransaction(w http.ResponseWriter, r *http.Request) {
 	// Extract transaction ID from URL
 	vars := mux.Vars(r)
 	id := vars["id"]
 	if id == "" {
 		log.Println("missing transaction id")
-		http.Error(w, "missing transaction id", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, codeMissingID, "missing transaction id")
 		return
 	}
 
@@ -182,91 +588,193 @@ func (h *Handler) UpdateTransaction(w http.ResponseWriter, r *http.Request) {
 	var req updateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Println(err)
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidRequestBody, "invalid request body")
 		return
 	}
 	defer r.Body.Close()
 
-	// Validate status - only allow completed or failed
-	if (req.Status != models.StatusFailed) && (req.Status != models.StatusCompleted) {
-		log.Println("invalid status requested")
-		http.Error(w, "invalid request body", http.StatusBadRequest)
+	actor := r.Header.Get(actorHeader)
+	if actor == "" {
+		actor = defaultActor
+	}
+	if len(actor) > models.MaxActorLength {
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidHeader, fmt.Sprintf("actor must be %d characters or less", models.MaxActorLength))
+		return
+	}
+
+	if errs := validateUpdate(req); len(errs) > 0 {
+		log.Println(errs)
+		writeValidationProblem(w, r, errs)
 		return
 	}
 
 	// Update transaction in database
-	if err := h.DB.UpdateTransaction(id, req.Status); err != nil {
+	change := models.StatusChange{Status: req.Status, Reason: req.FailureReason, Actor: actor}
+	if err := h.DB.UpdateTransaction(r.Context(), id, change); err != nil {
 		log.Println(err)
-		http.Error(w, "error updating transaction", http.StatusInternalServerError)
+		if errors.Is(err, db.ErrNotFound) {
+			writeProblem(w, r, http.StatusNotFound, codeNotFound, "transaction not found")
+			return
+		}
+		if errors.Is(err, db.ErrConflict) {
+			writeProblem(w, r, http.StatusConflict, codeConflict, err.Error())
+			return
+		}
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			writeProblem(w, r, http.StatusUnprocessableEntity, codeInsufficientFunds, err.Error())
+			return
+		}
+		if errors.Is(err, db.ErrCurrencyMismatch) {
+			writeProblem(w, r, http.StatusUnprocessableEntity, codeCurrencyMismatch, err.Error())
+			return
+		}
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error updating transaction")
 		return
 	}
+	h.Events.Publish(id)
 
 	// Return success response (no content)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// validateTransaction performs comprehensive input validation on transaction data.
-// It checks all required fields, validates formats, and ensures business rules are followed.
-func validateTransaction(transaction models.Transaction) error {
-	var errors []string
-
-	// Validate amount
-	if transaction.Amount <= 0 {
-		errors = append(errors, "amount must be greater than 0")
+// GetTransactionHistory handles GET requests for a transaction's status changes, oldest first.
+func (h *Handler) GetTransactionHistory(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	events, err := h.DB.GetTransactionHistory(r.Context(), id)
+	if err != nil {
+		log.Println(err)
+		if errors.Is(err, db.ErrNotFound) {
+			writeProblem(w, r, http.StatusNotFound, codeNotFound, "transaction not found")
+			return
+		}
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error getting transaction history")
+		return
 	}
-	if transaction.Amount > 99999999.99 {
-		errors = append(errors, "amount must be less than 100,000,000")
+	if events == nil {
+		events = []models.TransactionEvent{}
 	}
 
-	// Validate currency
-	if transaction.Currency == "" {
-		errors = append(errors, "currency is required")
-	} else if !isValidCurrency(transaction.Currency) {
-		errors = append(errors, "currency must be a valid 3-letter ISO code (e.g., USD, EUR, GBP)")
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"transaction_id": id,
+		"events":         events,
+	})
+}
+
+// validateUpdate checks the fields of a status update request.
+func validateUpdate(req updateRequest) ValidationErrors {
+	var errs ValidationErrors
+
+	// The status must be a known status that some transition leads to
+	if !req.Status.IsValid() || len(models.SourceStatuses(req.Status)) == 0 {
+		errs.add("status", fieldInvalid, fmt.Sprintf("status %q cannot be set", req.Status))
+	}
+
+	if req.FailureReason != "" && req.Status != models.StatusFailed {
+		errs.add("failure_reason", fieldInvalid, "failure_reason is only allowed when status is failed")
+	} else if len(req.FailureReason) > models.MaxFailureReasonLength {
+		errs.add("failure_reason", fieldTooLong, fmt.Sprintf("failure_reason must be %d characters or less", models.MaxFailureReasonLength))
 	}
 
+	return errs
+}
+
+// maxAmount is the largest transaction amount accepted by the API.
+var maxAmount = models.MustParseAmount("99999999.99")
+
+// validateTransaction performs comprehensive input validation on transaction data.
+// It checks all required fields, validates formats, and ensures business rules are followed.
+// Every failure is reported against the field it concerns; an empty result means the transaction is valid.
+// On success the currency is normalized to upper case and the amount to the minor units of the currency.
+func validateTransaction(transaction *models.Transaction, allowed *currency.Set) ValidationErrors {
+	var errs ValidationErrors
+
+	// Validate amount and currency
+	validateMoney(&transaction.Amount, &transaction.Currency, allowed, &errs)
+
 	// Validate sender
 	if transaction.Sender == "" {
-		errors = append(errors, "sender is required")
+		errs.add("sender", fieldRequired, "sender is required")
 	} else if len(transaction.Sender) > 255 {
-		errors = append(errors, "sender must be 255 characters or less")
+		errs.add("sender", fieldTooLong, "sender must be 255 characters or less")
+	} else if models.IsExchangeAccount(transaction.Sender) {
+		errs.add("sender", fieldInvalid, "sender must not start with fx:, which is reserved for currency exchange accounts")
 	}
 
 	// Validate receiver
 	if transaction.Receiver == "" {
-		errors = append(errors, "receiver is required")
+		errs.add("receiver", fieldRequired, "receiver is required")
 	} else if len(transaction.Receiver) > 255 {
-		errors = append(errors, "receiver must be 255 characters or less")
+		errs.add("receiver", fieldTooLong, "receiver must be 255 characters or less")
+	} else if models.IsExchangeAccount(transaction.Receiver) {
+		errs.add("receiver", fieldInvalid, "receiver must not start with fx:, which is reserved for currency exchange accounts")
 	}
 
 	// Check if sender and receiver are different
 	if transaction.Sender == transaction.Receiver {
-		errors = append(errors, "sender and receiver must be different")
+		errs.add("receiver", fieldInvalid, "sender and receiver must be different")
+	}
+
+	return errs
+}
+
+// validateMoney checks that an amount is within the accepted range and that its currency is one the service
+// accepts, and normalizes the currency to upper case and the amount to the minor units of the currency.
+// Failures are reported against the amount and currency fields.
+func validateMoney(amount *models.Amount, code *string, allowed *currency.Set, errs *ValidationErrors) {
+	if amount.Sign() <= 0 {
+		errs.add("amount", fieldOutOfRange, "amount must be greater than 0")
+	}
+	if amount.Cmp(maxAmount) > 0 {
+		errs.add("amount", fieldOutOfRange, "amount must be less than 100,000,000")
 	}
 
-	if len(errors) > 0 {
-		return fmt.Errorf("validation failed: %s", strings.Join(errors, "; "))
+	c, ok := validateCurrency("currency", *code, allowed, errs)
+	if !ok {
+		return
 	}
+	*code = c.Code
 
-	return nil
+	// Reject amounts with more decimal places than the currency allows instead of truncating
+	rescaled, err := amount.Rescale(c.Exponent)
+	if err != nil {
+		errs.add("amount", fieldPrecision, fmt.Sprintf("amount must have at most %d decimal places for %s", c.Exponent, c.Code))
+	} else {
+		*amount = rescaled
+	}
 }
 
-// isValidCurrency checks if the currency code is valid according to ISO 4217 standards.
-// It validates that the currency is a 3-letter uppercase code from a predefined list.
-func isValidCurrency(currency string) bool {
-	validCurrencies := map[string]bool{
-		"USD": true, "EUR": true, "GBP": true, "JPY": true, "CAD": true,
-		"AUD": true, "CHF": true, "CNY": true, "SEK": true, "NZD": true,
-		"MXN": true, "SGD": true, "HKD": true, "NOK": true, "TRY": true,
-		"RUB": true, "INR": true, "BRL": true, "ZAR": true, "KRW": true,
-		"KES": true,
+// validateCurrency checks that a currency code, in any case, names an ISO 4217 currency that is in use, has
+// minor units and is allowed by the operator. Failures are reported against field.
+func validateCurrency(field, code string, allowed *currency.Set, errs *ValidationErrors) (currency.Currency, bool) {
+	name := field[strings.LastIndex(field, ".")+1:]
+	c, ok := currency.Lookup(strings.ToUpper(code))
+	switch {
+	case code == "":
+		errs.add(field, fieldRequired, name+" is required")
+	case !ok:
+		errs.add(field, fieldInvalid, name+" must be a valid 3-letter ISO code (e.g., USD, EUR, GBP)")
+	case c.Withdrawn:
+		errs.add(field, fieldInvalid, fmt.Sprintf("%s has been withdrawn from ISO 4217", c.Code))
+	case c.Exponent == currency.NoMinorUnits:
+		errs.add(field, fieldInvalid, fmt.Sprintf("%s has no minor units and cannot be used for amounts", c.Code))
+	case !allowed.Allows(c.Code):
+		errs.add(field, fieldUnsupported, fmt.Sprintf("%s is not supported", c.Code))
+	default:
+		return c, true
 	}
+	return currency.Currency{}, false
+}
 
-	// Check if it's a 3-letter uppercase code
-	if len(currency) != 3 {
-		return false
-	}
+// requestFingerprint returns the hex-encoded SHA-256 digest of a request body.
+func requestFingerprint(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
 
-	return validCurrencies[strings.ToUpper(currency)]
+// isValidCurrency reports whether a code, in any case, is in the ISO 4217 table. Withdrawn currencies and
+// those the operator does not allow are valid too, so that data stored in them can still be queried.
+func isValidCurrency(code string) bool {
+	_, ok := currency.Lookup(strings.ToUpper(code))
+	return ok
 }
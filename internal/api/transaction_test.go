@@ -3,14 +3,19 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/abadojack/gapstack/internal/currency"
+	"github.com/abadojack/gapstack/internal/db"
 	"github.com/abadojack/gapstack/internal/models"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
@@ -18,27 +23,67 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// defaultFilter is the filter the list endpoint builds when no filter parameters are given
+var defaultFilter = db.TransactionFilter{SortBy: db.SortByCreatedAt}
+
 // MockDB implements the db.DB interface for testing
 type MockDB struct {
 	mock.Mock
+	// lastCtx is the context passed to the most recent GetTransaction call
+	lastCtx context.Context
 }
 
-func (m *MockDB) CreateTransaction(transaction models.Transaction) error {
+func (m *MockDB) CreateTransaction(ctx context.Context, transaction models.Transaction) error {
 	args := m.Called(transaction)
 	return args.Error(0)
 }
 
-func (m *MockDB) UpdateTransaction(id string, status models.Status) error {
-	args := m.Called(id, status)
+func (m *MockDB) CreateTransactions(ctx context.Context, transactions []models.Transaction) error {
+	args := m.Called(transactions)
+	return args.Error(0)
+}
+
+func (m *MockDB) UpdateTransaction(ctx context.Context, id string, change models.StatusChange) error {
+	args := m.Called(id, change)
 	return args.Error(0)
 }
 
-func (m *MockDB) GetAllTransactions(limit, offset int) ([]models.Transaction, error) {
-	args := m.Called(limit, offset)
+func (m *MockDB) GetTransactionHistory(ctx context.Context, id string) ([]models.TransactionEvent, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TransactionEvent), args.Error(1)
+}
+
+func (m *MockDB) GetAllTransactions(ctx context.Context, filter db.TransactionFilter, limit, offset int) ([]models.Transaction, error) {
+	args := m.Called(filter, limit, offset)
+	return args.Get(0).([]models.Transaction), args.Error(1)
+}
+
+func (m *MockDB) GetTransactionsAfter(ctx context.Context, filter db.TransactionFilter, cursor *db.Cursor, limit int) ([]models.Transaction, error) {
+	args := m.Called(filter, cursor, limit)
 	return args.Get(0).([]models.Transaction), args.Error(1)
 }
 
-func (m *MockDB) GetTransaction(id string) (*models.Transaction, error) {
+// EachTransaction calls fn with the transactions the expectation returns, then returns its error.
+func (m *MockDB) EachTransaction(ctx context.Context, filter db.TransactionFilter, fn func(models.Transaction) error) error {
+	args := m.Called(filter)
+	for _, transaction := range args.Get(0).([]models.Transaction) {
+		if err := fn(transaction); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockDB) CountTransactions(ctx context.Context, filter db.TransactionFilter) (int, error) {
+	args := m.Called(filter)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockDB) GetTransaction(ctx context.Context, id string) (*models.Transaction, error) {
+	m.lastCtx = ctx
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -46,6 +91,171 @@ func (m *MockDB) GetTransaction(id string) (*models.Transaction, error) {
 	return args.Get(0).(*models.Transaction), args.Error(1)
 }
 
+func (m *MockDB) GetIdempotencyRecord(ctx context.Context, key string) (*models.IdempotencyRecord, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IdempotencyRecord), args.Error(1)
+}
+
+func (m *MockDB) ReserveIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) error {
+	args := m.Called(record)
+	return args.Error(0)
+}
+
+func (m *MockDB) ReleaseIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) error {
+	args := m.Called(record)
+	return args.Error(0)
+}
+
+func (m *MockDB) CreateIdempotentTransaction(ctx context.Context, transaction models.Transaction, record models.IdempotencyRecord) error {
+	args := m.Called(transaction, record)
+	return args.Error(0)
+}
+
+func (m *MockDB) CreateAccount(ctx context.Context, account models.Account) error {
+	args := m.Called(account)
+	return args.Error(0)
+}
+
+func (m *MockDB) GetAccount(ctx context.Context, id string) (*models.Account, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Account), args.Error(1)
+}
+
+func (m *MockDB) GetAccountBalance(ctx context.Context, id string) (*models.Balance, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Balance), args.Error(1)
+}
+
+func (m *MockDB) GetLedgerEntries(ctx context.Context, accountID string, afterID int64, limit int) ([]models.LedgerEntry, error) {
+	args := m.Called(accountID, afterID, limit)
+	return args.Get(0).([]models.LedgerEntry), args.Error(1)
+}
+
+func (m *MockDB) CreateWebhookEndpoint(ctx context.Context, endpoint models.WebhookEndpoint) error {
+	args := m.Called(endpoint)
+	return args.Error(0)
+}
+
+func (m *MockDB) GetWebhookEndpoint(ctx context.Context, id string) (*models.WebhookEndpoint, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockDB) ListWebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	args := m.Called()
+	return args.Get(0).([]models.WebhookEndpoint), args.Error(1)
+}
+
+func (m *MockDB) UpdateWebhookEndpoint(ctx context.Context, endpoint models.WebhookEndpoint) error {
+	args := m.Called(endpoint)
+	return args.Error(0)
+}
+
+func (m *MockDB) DeleteWebhookEndpoint(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockDB) ListWebhookDeliveries(ctx context.Context, endpointID string, status models.DeliveryStatus, afterID int64, limit int) ([]models.WebhookDelivery, error) {
+	args := m.Called(endpointID, status, afterID, limit)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockDB) RedeliverWebhookDelivery(ctx context.Context, endpointID string, deliveryID int64) error {
+	args := m.Called(endpointID, deliveryID)
+	return args.Error(0)
+}
+
+func (m *MockDB) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.PendingDelivery, error) {
+	args := m.Called(now, lease, limit)
+	return args.Get(0).([]models.PendingDelivery), args.Error(1)
+}
+
+func (m *MockDB) RecordDeliveryAttempt(ctx context.Context, deliveryID int64, attempt models.DeliveryAttempt) error {
+	args := m.Called(deliveryID, attempt)
+	return args.Error(0)
+}
+
+func (m *MockDB) ListOutboxEvents(ctx context.Context, afterID int64, limit int) ([]models.OutboxEvent, error) {
+	args := m.Called(afterID, limit)
+	return args.Get(0).([]models.OutboxEvent), args.Error(1)
+}
+
+func (m *MockDB) LastOutboxEventID(ctx context.Context) (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDB) GetSpendingTotal(ctx context.Context, sender, currency string, since time.Time) (*models.SpendingTotal, error) {
+	args := m.Called(sender, currency, since)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SpendingTotal), args.Error(1)
+}
+
+func (m *MockDB) GetSenderLimits(ctx context.Context, sender string) ([]models.SpendingLimit, error) {
+	args := m.Called(sender)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SpendingLimit), args.Error(1)
+}
+
+func (m *MockDB) SetSenderLimits(ctx context.Context, sender string, limits []models.SpendingLimit) error {
+	args := m.Called(sender, limits)
+	return args.Error(0)
+}
+
+func (m *MockDB) GetSenderTotals(ctx context.Context, currency string) ([]models.SenderTotal, error) {
+	args := m.Called(currency)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SenderTotal), args.Error(1)
+}
+
+func (m *MockDB) GetTopSenders(ctx context.Context, currency string, since time.Time, limit int) ([]models.SenderTotal, error) {
+	args := m.Called(currency, since, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SenderTotal), args.Error(1)
+}
+
+func (m *MockDB) GetFailureHeavySenders(ctx context.Context, threshold int) ([]models.SenderFailures, error) {
+	args := m.Called(threshold)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.SenderFailures), args.Error(1)
+}
+
+func (m *MockDB) CreateQuote(ctx context.Context, quote models.Quote) error {
+	args := m.Called(quote)
+	return args.Error(0)
+}
+
+func (m *MockDB) GetQuote(ctx context.Context, id string) (*models.Quote, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Quote), args.Error(1)
+}
+
 func (m *MockDB) Close() error {
 	args := m.Called()
 	return args.Error(0)
@@ -58,7 +268,7 @@ func TestHandler_CreateTransaction(t *testing.T) {
 
 		// Create a transaction for testing (without ID and CreatedAt since they're generated)
 		transactionInput := models.Transaction{
-			Amount:   100.50,
+			Amount:   models.MustParseAmount("100.50"),
 			Currency: "USD",
 			Sender:   "user-1",
 			Receiver: "user-2",
@@ -157,7 +367,7 @@ func TestHandler_CreateTransaction(t *testing.T) {
 
 		transaction := models.Transaction{
 			ID:       "txn-123",
-			Amount:   -100.50,
+			Amount:   models.MustParseAmount("-100.50"),
 			Currency: "USD",
 			Sender:   "user-1",
 			Receiver: "user-2",
@@ -182,7 +392,7 @@ func TestHandler_CreateTransaction(t *testing.T) {
 
 		transaction := models.Transaction{
 			ID:       "txn-123",
-			Amount:   100.50,
+			Amount:   models.MustParseAmount("100.50"),
 			Currency: "INVALID",
 			Sender:   "user-1",
 			Receiver: "user-2",
@@ -207,7 +417,7 @@ func TestHandler_CreateTransaction(t *testing.T) {
 
 		transaction := models.Transaction{
 			ID:       "txn-123",
-			Amount:   100.50,
+			Amount:   models.MustParseAmount("100.50"),
 			Currency: "USD",
 			Sender:   "user-1",
 			Receiver: "user-1",
@@ -232,7 +442,7 @@ func TestHandler_CreateTransaction(t *testing.T) {
 
 		transaction := models.Transaction{
 			ID:       "txn-123",
-			Amount:   100000000.00,
+			Amount:   models.MustParseAmount("100000000.00"),
 			Currency: "USD",
 			Sender:   "user-1",
 			Receiver: "user-2",
@@ -251,12 +461,69 @@ func TestHandler_CreateTransaction(t *testing.T) {
 		assert.Contains(t, rr.Body.String(), "amount must be less than 100,000,000")
 	})
 
+	t.Run("too many decimal places for currency", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		body := bytes.NewReader([]byte(`{"amount": "12.345", "currency": "USD", "sender": "user-1", "receiver": "user-2"}`))
+
+		req := httptest.NewRequest("POST", "/transactions", body)
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.CreateTransaction(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "amount must have at most 2 decimal places for USD")
+		mockDB.AssertNotCalled(t, "CreateTransaction", mock.Anything)
+	})
+
+	t.Run("amount normalized to currency minor units", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("CreateTransaction", mock.MatchedBy(func(tx models.Transaction) bool {
+			return tx.Amount == models.NewAmount(12345, 3) && tx.Currency == "BHD"
+		})).Return(nil)
+
+		// JSON numbers are parsed from their literal text, never through float64
+		body := bytes.NewReader([]byte(`{"amount": 12.345, "currency": "BHD", "sender": "user-1", "receiver": "user-2"}`))
+
+		req := httptest.NewRequest("POST", "/transactions", body)
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.CreateTransaction(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"amount":"12.345"`)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("duplicate transaction", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("CreateTransaction", mock.AnythingOfType("models.Transaction")).Return(db.ErrDuplicate)
+
+		body := bytes.NewReader([]byte(`{"amount": "100.50", "currency": "USD", "sender": "user-1", "receiver": "user-2"}`))
+
+		req := httptest.NewRequest("POST", "/transactions", body)
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		handler.CreateTransaction(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		mockDB.AssertExpectations(t)
+	})
+
 	t.Run("database error", func(t *testing.T) {
 		mockDB := new(MockDB)
 		handler := NewHandler(mockDB)
 
 		transactionInput := models.Transaction{
-			Amount:   100.50,
+			Amount:   models.MustParseAmount("100.50"),
 			Currency: "USD",
 			Sender:   "user-1",
 			Receiver: "user-2",
@@ -288,110 +555,510 @@ func TestHandler_CreateTransaction(t *testing.T) {
 	})
 }
 
-func TestHandler_GetTransaction(t *testing.T) {
-	t.Run("successful get", func(t *testing.T) {
+func TestHandler_CreateTransaction_Idempotency(t *testing.T) {
+	body := []byte(`{"amount": "100.50", "currency": "USD", "sender": "user-1", "receiver": "user-2"}`)
+	reservation := mock.MatchedBy(func(record models.IdempotencyRecord) bool {
+		return record.Key == "key-1" &&
+			record.RequestHash == requestFingerprint(body) &&
+			record.Status == models.IdempotencyPending &&
+			record.CreatedAt.Equal(record.CreatedAt.Truncate(time.Second)) &&
+			record.ExpiresAt.Sub(record.CreatedAt) == idempotencyLease
+	})
+
+	t.Run("first request stores response with the transaction", func(t *testing.T) {
 		mockDB := new(MockDB)
 		handler := NewHandler(mockDB)
 
-		transaction := &models.Transaction{
-			ID:       "txn-123",
-			Amount:   100.50,
-			Currency: "USD",
-			Sender:   "user-1",
-			Receiver: "user-2",
-			Status:   models.StatusCompleted,
-		}
-
-		mockDB.On("GetTransaction", "txn-123").Return(transaction, nil)
+		var reserved models.IdempotencyRecord
+		mockDB.On("ReserveIdempotencyKey", reservation).Run(func(args mock.Arguments) {
+			reserved = args.Get(0).(models.IdempotencyRecord)
+		}).Return(nil)
+		mockDB.On("CreateIdempotentTransaction", mock.AnythingOfType("models.Transaction"), mock.MatchedBy(func(record models.IdempotencyRecord) bool {
+			return record.Key == "key-1" &&
+				record.RequestHash == requestFingerprint(body) &&
+				record.Status == models.IdempotencyCompleted &&
+				record.ResponseCode == http.StatusCreated &&
+				record.CreatedAt.Equal(reserved.CreatedAt) &&
+				record.ExpiresAt.Sub(record.CreatedAt) == defaultIdempotencyTTL
+		})).Return(nil)
 
-		req := httptest.NewRequest("GET", "/transactions/txn-123", nil)
+		req := httptest.NewRequest("POST", "/transactions", bytes.NewReader(body))
+		req.Header.Set("Idempotency-Key", "key-1")
 		rr := httptest.NewRecorder()
 
-		// Create router and set up the route
-		router := mux.NewRouter()
-		router.HandleFunc("/transactions/{id}", handler.GetTransaction).Methods("GET")
+		handler.CreateTransaction(rr, req)
 
-		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		stored := mockDB.Calls[1].Arguments.Get(1).(models.IdempotencyRecord)
+		assert.JSONEq(t, string(stored.ResponseBody), rr.Body.String())
+		mockDB.AssertExpectations(t)
+		mockDB.AssertNotCalled(t, "CreateTransaction", mock.Anything)
+		mockDB.AssertNotCalled(t, "ReleaseIdempotencyKey", mock.Anything)
+	})
 
-		assert.Equal(t, http.StatusOK, rr.Code)
+	t.Run("retry replays original response", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
 
-		var response models.Transaction
-		err := json.Unmarshal(rr.Body.Bytes(), &response)
-		assert.NoError(t, err)
-		assert.EqualValues(t, *transaction, response)
+		original := []byte(`{"id":"txn-123","status":"pending"}`)
+		mockDB.On("ReserveIdempotencyKey", reservation).Return(fmt.Errorf("%w: idempotency key key-1", db.ErrDuplicate))
+		mockDB.On("GetIdempotencyRecord", "key-1").Return(&models.IdempotencyRecord{
+			Key:          "key-1",
+			RequestHash:  requestFingerprint(body),
+			Status:       models.IdempotencyCompleted,
+			ResponseCode: http.StatusCreated,
+			ResponseBody: original,
+		}, nil)
+
+		req := httptest.NewRequest("POST", "/transactions", bytes.NewReader(body))
+		req.Header.Set("Idempotency-Key", "key-1")
+		rr := httptest.NewRecorder()
+
+		handler.CreateTransaction(rr, req)
 
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, string(original), rr.Body.String())
+		assert.Equal(t, "true", rr.Header().Get("Idempotent-Replayed"))
+		mockDB.AssertNotCalled(t, "CreateIdempotentTransaction", mock.Anything, mock.Anything)
+		mockDB.AssertNotCalled(t, "ReleaseIdempotencyKey", mock.Anything)
 		mockDB.AssertExpectations(t)
 	})
 
-	t.Run("transaction not found", func(t *testing.T) {
+	t.Run("retry while the original is in flight", func(t *testing.T) {
 		mockDB := new(MockDB)
 		handler := NewHandler(mockDB)
 
-		// The actual implementation returns nil, nil for not found
-		mockDB.On("GetTransaction", "non-existent").Return(nil, nil)
+		mockDB.On("ReserveIdempotencyKey", reservation).Return(fmt.Errorf("%w: idempotency key key-1", db.ErrDuplicate))
+		mockDB.On("GetIdempotencyRecord", "key-1").Return(&models.IdempotencyRecord{
+			Key:         "key-1",
+			RequestHash: requestFingerprint(body),
+			Status:      models.IdempotencyPending,
+		}, nil)
 
-		req := httptest.NewRequest("GET", "/transactions/non-existent", nil)
+		req := httptest.NewRequest("POST", "/transactions", bytes.NewReader(body))
+		req.Header.Set("Idempotency-Key", "key-1")
 		rr := httptest.NewRecorder()
 
-		router := mux.NewRouter()
-		router.HandleFunc("/transactions/{id}", handler.GetTransaction).Methods("GET")
+		handler.CreateTransaction(rr, req)
 
-		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		var problem Problem
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+		assert.Equal(t, codeIdempotencyInProgress, problem.Code)
+		mockDB.AssertNotCalled(t, "CreateIdempotentTransaction", mock.Anything, mock.Anything)
+		mockDB.AssertNotCalled(t, "ReleaseIdempotencyKey", mock.Anything)
+		mockDB.AssertExpectations(t)
+	})
 
-		assert.Equal(t, http.StatusOK, rr.Code)
+	t.Run("reused key with different body", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("ReserveIdempotencyKey", reservation).Return(fmt.Errorf("%w: idempotency key key-1", db.ErrDuplicate))
+		mockDB.On("GetIdempotencyRecord", "key-1").Return(&models.IdempotencyRecord{
+			Key:          "key-1",
+			RequestHash:  requestFingerprint([]byte(`{"amount": "1.00"}`)),
+			Status:       models.IdempotencyCompleted,
+			ResponseCode: http.StatusCreated,
+		}, nil)
+
+		req := httptest.NewRequest("POST", "/transactions", bytes.NewReader(body))
+		req.Header.Set("Idempotency-Key", "key-1")
+		rr := httptest.NewRecorder()
 
-		// Should return null for non-existent transaction
-		assert.Equal(t, "null", strings.TrimSpace(rr.Body.String()))
+		handler.CreateTransaction(rr, req)
 
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		mockDB.AssertNotCalled(t, "CreateIdempotentTransaction", mock.Anything, mock.Anything)
 		mockDB.AssertExpectations(t)
 	})
 
-	t.Run("database error", func(t *testing.T) {
+	t.Run("rejected request releases the key", func(t *testing.T) {
 		mockDB := new(MockDB)
 		handler := NewHandler(mockDB)
 
-		mockDB.On("GetTransaction", "txn-123").Return(nil, errors.New("database error"))
+		invalid := []byte(`{"amount": "-1", "currency": "USD", "sender": "user-1", "receiver": "user-2"}`)
+		var reserved models.IdempotencyRecord
+		mockDB.On("ReserveIdempotencyKey", mock.AnythingOfType("models.IdempotencyRecord")).Run(func(args mock.Arguments) {
+			reserved = args.Get(0).(models.IdempotencyRecord)
+		}).Return(nil)
+		mockDB.On("ReleaseIdempotencyKey", mock.MatchedBy(func(record models.IdempotencyRecord) bool {
+			return record.Key == reserved.Key && record.CreatedAt.Equal(reserved.CreatedAt)
+		})).Return(nil)
 
-		req := httptest.NewRequest("GET", "/transactions/txn-123", nil)
+		req := httptest.NewRequest("POST", "/transactions", bytes.NewReader(invalid))
+		req.Header.Set("Idempotency-Key", "key-1")
 		rr := httptest.NewRecorder()
 
-		router := mux.NewRouter()
-		router.HandleFunc("/transactions/{id}", handler.GetTransaction).Methods("GET")
+		handler.CreateTransaction(rr, req)
 
-		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockDB.AssertNotCalled(t, "CreateIdempotentTransaction", mock.Anything, mock.Anything)
+		mockDB.AssertExpectations(t)
+	})
 
-		assert.Equal(t, http.StatusInternalServerError, rr.Code)
-		assert.Contains(t, rr.Body.String(), "error getting transaction")
+	t.Run("failure to store the response fails the request", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("ReserveIdempotencyKey", reservation).Return(nil)
+		mockDB.On("CreateIdempotentTransaction", mock.AnythingOfType("models.Transaction"), mock.Anything).Return(errors.New("database error"))
+		mockDB.On("ReleaseIdempotencyKey", reservation).Return(nil)
+
+		req := httptest.NewRequest("POST", "/transactions", bytes.NewReader(body))
+		req.Header.Set("Idempotency-Key", "key-1")
+		rr := httptest.NewRecorder()
+
+		handler.CreateTransaction(rr, req)
 
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
 		mockDB.AssertExpectations(t)
 	})
 
-	t.Run("missing transaction id", func(t *testing.T) {
+	t.Run("reservation taken over by a retry", func(t *testing.T) {
 		mockDB := new(MockDB)
 		handler := NewHandler(mockDB)
 
-		req := httptest.NewRequest("GET", "/transactions/", nil)
-		rr := httptest.NewRecorder()
+		mockDB.On("ReserveIdempotencyKey", reservation).Return(nil)
+		mockDB.On("CreateIdempotentTransaction", mock.AnythingOfType("models.Transaction"), mock.Anything).
+			Return(fmt.Errorf("%w: idempotency key key-1 is no longer reserved", db.ErrNotFound))
+		mockDB.On("ReleaseIdempotencyKey", reservation).Return(nil)
 
-		router := mux.NewRouter()
-		router.HandleFunc("/transactions/{id}", handler.GetTransaction).Methods("GET")
+		req := httptest.NewRequest("POST", "/transactions", bytes.NewReader(body))
+		req.Header.Set("Idempotency-Key", "key-1")
+		rr := httptest.NewRecorder()
 
-		router.ServeHTTP(rr, req)
+		handler.CreateTransaction(rr, req)
 
-		// The actual implementation returns 400 for missing ID, but mux returns 404 for route mismatch
-		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Contains(t, rr.Body.String(), codeIdempotencyInProgress)
+		mockDB.AssertExpectations(t)
 	})
-}
 
-func TestHandler_ListTransactions(t *testing.T) {
-	t.Run("successful list with pagination", func(t *testing.T) {
+	t.Run("reservation error", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("ReserveIdempotencyKey", reservation).Return(errors.New("database error"))
+
+		req := httptest.NewRequest("POST", "/transactions", bytes.NewReader(body))
+		req.Header.Set("Idempotency-Key", "key-1")
+		rr := httptest.NewRecorder()
+
+		handler.CreateTransaction(rr, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		mockDB.AssertNotCalled(t, "CreateIdempotentTransaction", mock.Anything, mock.Anything)
+	})
+
+	t.Run("concurrent requests create one transaction", func(t *testing.T) {
+		handler := NewHandler(db.NewMemoryDB())
+
+		const requests = 8
+		codes := make([]int, requests)
+		var wg sync.WaitGroup
+		for i := range requests {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				req := httptest.NewRequest("POST", "/transactions", bytes.NewReader(body))
+				req.Header.Set("Idempotency-Key", "key-1")
+				rr := httptest.NewRecorder()
+				handler.CreateTransaction(rr, req)
+				codes[i] = rr.Code
+			}()
+		}
+		wg.Wait()
+
+		for _, code := range codes {
+			assert.Contains(t, []int{http.StatusCreated, http.StatusConflict}, code)
+		}
+		count, err := handler.DB.CountTransactions(context.Background(), db.TransactionFilter{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, count)
+	})
+}
+
+// stubScreener returns the same decision for every transaction it screens.
+type stubScreener struct {
+	decision models.Screening
+	err      error
+	screened []models.Transaction
+}
+
+func (s *stubScreener) Name() string {
+	return "stub"
+}
+
+func (s *stubScreener) Screen(ctx context.Context, transaction models.Transaction) (models.Screening, error) {
+	s.screened = append(s.screened, transaction)
+	return s.decision, s.err
+}
+
+func TestHandler_CreateTransaction_Screening(t *testing.T) {
+	// The client cannot choose the status, failure reason or screening decision
+	body := []byte(`{"amount": "100.50", "currency": "USD", "sender": "user-1", "receiver": "user-2",
+		"status": "completed", "failure_reason": "none", "screening": {"decision": "approve"}}`)
+
+	tests := []struct {
+		name          string
+		decision      models.Screening
+		status        models.Status
+		failureReason string
+	}{
+		{
+			name:     "approved transactions are pending",
+			decision: models.Screening{Decision: models.ScreeningApprove},
+			status:   models.StatusPending,
+		},
+		{
+			name:     "transactions held for review are held",
+			decision: models.Screening{Decision: models.ScreeningReview, Screener: "amount_threshold", Reason: "large amount"},
+			status:   models.StatusHeld,
+		},
+		{
+			name:          "rejected transactions are failed",
+			decision:      models.Screening{Decision: models.ScreeningReject, Screener: "blocklist", Reason: "sender is on the blocklist"},
+			status:        models.StatusFailed,
+			failureReason: "sender is on the blocklist",
+		},
+		{
+			name:          "rejections without a reason get one",
+			decision:      models.Screening{Decision: models.ScreeningReject, Screener: "remote"},
+			status:        models.StatusFailed,
+			failureReason: "rejected by screening",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			screener := &stubScreener{decision: tt.decision}
+			handler := NewHandler(mockDB)
+			handler.Screener = screener
+
+			mockDB.On("CreateTransaction", mock.MatchedBy(func(tx models.Transaction) bool {
+				return tx.Status == tt.status &&
+					tx.FailureReason == tt.failureReason &&
+					tx.Screening != nil && *tx.Screening == tt.decision
+			})).Return(nil)
+
+			req := httptest.NewRequest("POST", "/transactions", bytes.NewReader(body))
+			rr := httptest.NewRecorder()
+
+			handler.CreateTransaction(rr, req)
+
+			assert.Equal(t, http.StatusCreated, rr.Code)
+			var response models.Transaction
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			assert.Equal(t, tt.status, response.Status)
+			assert.Equal(t, tt.failureReason, response.FailureReason)
+			assert.Equal(t, &tt.decision, response.Screening)
+
+			// The screener sees the validated transaction with its assigned ID
+			require.Len(t, screener.screened, 1)
+			assert.Equal(t, response.ID, screener.screened[0].ID)
+			assert.Nil(t, screener.screened[0].Screening)
+			mockDB.AssertExpectations(t)
+		})
+	}
+
+	t.Run("screening error", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+		handler.Screener = &stubScreener{err: errors.New("screening failed")}
+
+		req := httptest.NewRequest("POST", "/transactions", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		handler.CreateTransaction(rr, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		mockDB.AssertNotCalled(t, "CreateTransaction", mock.Anything)
+	})
+
+	t.Run("invalid transactions are not screened", func(t *testing.T) {
+		mockDB := new(MockDB)
+		screener := &stubScreener{decision: models.Screening{Decision: models.ScreeningApprove}}
+		handler := NewHandler(mockDB)
+		handler.Screener = screener
+
+		req := httptest.NewRequest("POST", "/transactions", bytes.NewReader([]byte(`{"amount": "-1", "currency": "USD"}`)))
+		rr := httptest.NewRecorder()
+
+		handler.CreateTransaction(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Empty(t, screener.screened)
+	})
+
+	t.Run("without a screener the decision is left empty", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("CreateTransaction", mock.MatchedBy(func(tx models.Transaction) bool {
+			return tx.Status == models.StatusPending && tx.FailureReason == "" && tx.Screening == nil
+		})).Return(nil)
+
+		req := httptest.NewRequest("POST", "/transactions", bytes.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		handler.CreateTransaction(rr, req)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.NotContains(t, rr.Body.String(), `"screening"`)
+		mockDB.AssertExpectations(t)
+	})
+}
+
+func TestHandler_CreateTransaction_Currencies(t *testing.T) {
+	t.Run("normalizes the code to upper case", func(t *testing.T) {
+		mockDB := new(MockDB)
+		mockDB.On("CreateTransaction", mock.MatchedBy(func(tx models.Transaction) bool {
+			return tx.Currency == "KWD" && tx.Amount.String() == "1.500"
+		})).Return(nil)
+
+		rr := serveAccounts(NewHandler(mockDB), "POST", "/transactions", []byte(`{"amount": "1.5", "currency": "kwd", "sender": "user-1", "receiver": "user-2"}`))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		var response models.Transaction
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "KWD", response.Currency)
+		mockDB.AssertExpectations(t)
+	})
+
+	tests := []struct {
+		name       string
+		currency   string
+		currencies string
+		code       string
+		message    string
+	}{
+		{"withdrawn", "DEM", "", fieldInvalid, "DEM has been withdrawn from ISO 4217"},
+		{"no minor units", "XAU", "", fieldInvalid, "XAU has no minor units and cannot be used for amounts"},
+		{"not allowed", "gbp", "USD,EUR", fieldUnsupported, "GBP is not supported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDB := new(MockDB)
+			handler := NewHandler(mockDB)
+			var err error
+			handler.Currencies, err = currency.ParseSet(tt.currencies)
+			require.NoError(t, err)
+
+			rr := serveAccounts(handler, "POST", "/transactions", []byte(`{"amount": "10", "currency": "`+tt.currency+`", "sender": "user-1", "receiver": "user-2"}`))
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			var problem Problem
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+			assert.Equal(t, []FieldError{{Field: "currency", Code: tt.code, Message: tt.message}}, problem.Errors)
+			mockDB.AssertNotCalled(t, "CreateTransaction", mock.Anything)
+		})
+	}
+}
+
+func TestHandler_GetTransaction(t *testing.T) {
+	t.Run("successful get", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		transaction := &models.Transaction{
+			ID:       "txn-123",
+			Amount:   models.MustParseAmount("100.50"),
+			Currency: "USD",
+			Sender:   "user-1",
+			Receiver: "user-2",
+			Status:   models.StatusCompleted,
+		}
+
+		mockDB.On("GetTransaction", "txn-123").Return(transaction, nil)
+
+		req := httptest.NewRequest("GET", "/transactions/txn-123", nil)
+		rr := httptest.NewRecorder()
+
+		// Create router and set up the route
+		router := mux.NewRouter()
+		router.HandleFunc("/transactions/{id}", handler.GetTransaction).Methods("GET")
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response models.Transaction
+		err := json.Unmarshal(rr.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.EqualValues(t, *transaction, response)
+
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("transaction not found", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("GetTransaction", "non-existent").Return(nil, fmt.Errorf("%w: transaction non-existent", db.ErrNotFound))
+
+		req := httptest.NewRequest("GET", "/transactions/non-existent", nil)
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/transactions/{id}", handler.GetTransaction).Methods("GET")
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), "transaction not found")
+
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("GetTransaction", "txn-123").Return(nil, errors.New("database error"))
+
+		req := httptest.NewRequest("GET", "/transactions/txn-123", nil)
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/transactions/{id}", handler.GetTransaction).Methods("GET")
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Contains(t, rr.Body.String(), "error getting transaction")
+
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("missing transaction id", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		req := httptest.NewRequest("GET", "/transactions/", nil)
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/transactions/{id}", handler.GetTransaction).Methods("GET")
+
+		router.ServeHTTP(rr, req)
+
+		// The actual implementation returns 400 for missing ID, but mux returns 404 for route mismatch
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestHandler_ListTransactions(t *testing.T) {
+	t.Run("successful list with pagination", func(t *testing.T) {
 		mockDB := new(MockDB)
 		handler := NewHandler(mockDB)
 
 		transactions := []models.Transaction{
 			{
 				ID:        "txn-1",
-				Amount:    100.50,
+				Amount:    models.MustParseAmount("100.50"),
 				Currency:  "USD",
 				Sender:    "user-1",
 				Receiver:  "user-2",
@@ -400,7 +1067,7 @@ func TestHandler_ListTransactions(t *testing.T) {
 			},
 			{
 				ID:        "txn-2",
-				Amount:    200.75,
+				Amount:    models.MustParseAmount("200.75"),
 				Currency:  "EUR",
 				Sender:    "user-3",
 				Receiver:  "user-4",
@@ -409,7 +1076,8 @@ func TestHandler_ListTransactions(t *testing.T) {
 			},
 		}
 
-		mockDB.On("GetAllTransactions", 10, 0).Return(transactions, nil)
+		mockDB.On("CountTransactions", defaultFilter).Return(2, nil)
+		mockDB.On("GetAllTransactions", defaultFilter, 11, 0).Return(transactions, nil)
 
 		req := httptest.NewRequest("GET", "/transactions?page=1&page_size=10", nil)
 		rr := httptest.NewRecorder()
@@ -423,7 +1091,10 @@ func TestHandler_ListTransactions(t *testing.T) {
 		assert.NoError(t, err)
 
 		assert.Equal(t, float64(1), response["page"])
-		assert.Equal(t, float64(2), response["page_size"]) // Should be actual count, not requested size
+		assert.Equal(t, float64(10), response["page_size"]) // Requested size, not the number of results
+		assert.Equal(t, float64(2), response["total_count"])
+		assert.Equal(t, float64(1), response["total_pages"])
+		assert.Equal(t, false, response["has_more"])
 
 		// Verify transactions are in response
 		transactionsData, ok := response["transactions"].([]interface{})
@@ -433,28 +1104,19 @@ func TestHandler_ListTransactions(t *testing.T) {
 		mockDB.AssertExpectations(t)
 	})
 
-	t.Run("successful list with default pagination", func(t *testing.T) {
+	t.Run("page size is capped", func(t *testing.T) {
 		mockDB := new(MockDB)
 		handler := NewHandler(mockDB)
 
-		transactions := []models.Transaction{}
-
-		mockDB.On("GetAllTransactions", defaultPageSize, 0).Return(transactions, nil)
+		mockDB.On("CountTransactions", defaultFilter).Return(0, nil)
+		mockDB.On("GetAllTransactions", defaultFilter, maxPageSize+1, 0).Return([]models.Transaction{}, nil)
 
-		req := httptest.NewRequest("GET", "/transactions", nil)
+		req := httptest.NewRequest("GET", "/transactions?page=1&page_size=100000", nil)
 		rr := httptest.NewRecorder()
 
 		handler.ListTransactions(rr, req)
 
 		assert.Equal(t, http.StatusOK, rr.Code)
-
-		var response map[string]interface{}
-		err := json.Unmarshal(rr.Body.Bytes(), &response)
-		assert.NoError(t, err)
-
-		assert.Equal(t, float64(1), response["page"])
-		assert.Equal(t, float64(0), response["page_size"]) // Should be actual count
-
 		mockDB.AssertExpectations(t)
 	})
 
@@ -465,7 +1127,8 @@ func TestHandler_ListTransactions(t *testing.T) {
 		transactions := []models.Transaction{}
 
 		// Should use defaults for invalid page/page_size
-		mockDB.On("GetAllTransactions", defaultPageSize, 0).Return(transactions, nil)
+		mockDB.On("CountTransactions", defaultFilter).Return(0, nil)
+		mockDB.On("GetAllTransactions", defaultFilter, defaultPageSize+1, 0).Return(transactions, nil)
 
 		req := httptest.NewRequest("GET", "/transactions?page=invalid&page_size=invalid", nil)
 		rr := httptest.NewRecorder()
@@ -479,7 +1142,8 @@ func TestHandler_ListTransactions(t *testing.T) {
 		assert.NoError(t, err)
 
 		assert.Equal(t, float64(1), response["page"])
-		assert.Equal(t, float64(0), response["page_size"]) // Should be actual count
+		assert.Equal(t, float64(defaultPageSize), response["page_size"])
+		assert.Equal(t, float64(0), response["total_pages"])
 
 		mockDB.AssertExpectations(t)
 	})
@@ -488,7 +1152,8 @@ func TestHandler_ListTransactions(t *testing.T) {
 		mockDB := new(MockDB)
 		handler := NewHandler(mockDB)
 
-		mockDB.On("GetAllTransactions", 10, 0).Return([]models.Transaction{}, errors.New("database error"))
+		mockDB.On("CountTransactions", defaultFilter).Return(5, nil)
+		mockDB.On("GetAllTransactions", defaultFilter, 11, 0).Return([]models.Transaction{}, errors.New("database error"))
 
 		req := httptest.NewRequest("GET", "/transactions?page=1&page_size=10", nil)
 		rr := httptest.NewRecorder()
@@ -502,6 +1167,226 @@ func TestHandler_ListTransactions(t *testing.T) {
 	})
 }
 
+func TestHandler_ListTransactions_Metadata(t *testing.T) {
+	makeTransactions := func(n int) []models.Transaction {
+		transactions := make([]models.Transaction, n)
+		for i := range transactions {
+			transactions[i] = models.Transaction{ID: fmt.Sprintf("txn-%d", i+1), CreatedAt: time.Now()}
+		}
+		return transactions
+	}
+
+	t.Run("middle page links and counts", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		filter := db.TransactionFilter{Statuses: []models.Status{models.StatusFailed}, SortBy: db.SortByCreatedAt}
+		mockDB.On("CountTransactions", filter).Return(25, nil)
+		mockDB.On("GetAllTransactions", filter, 11, 10).Return(makeTransactions(11), nil)
+
+		req := httptest.NewRequest("GET", "/transactions?page=2&page_size=10&status=failed", nil)
+		rr := httptest.NewRecorder()
+
+		handler.ListTransactions(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, float64(25), response["total_count"])
+		assert.Equal(t, float64(3), response["total_pages"])
+		assert.Equal(t, true, response["has_more"])
+		assert.Len(t, response["transactions"], 10)
+
+		assert.Equal(t, `</transactions?page=1&page_size=10&status=failed>; rel="first", `+
+			`</transactions?page=1&page_size=10&status=failed>; rel="prev", `+
+			`</transactions?page=3&page_size=10&status=failed>; rel="next", `+
+			`</transactions?page=3&page_size=10&status=failed>; rel="last"`, rr.Header().Get("Link"))
+
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("total can be skipped", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("GetTransactionsAfter", defaultFilter, (*db.Cursor)(nil), 3).Return(makeTransactions(2), nil)
+
+		req := httptest.NewRequest("GET", "/transactions?page_size=2&include_total=false", nil)
+		rr := httptest.NewRecorder()
+
+		handler.ListTransactions(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.NotContains(t, response, "total_count")
+		assert.NotContains(t, response, "total_pages")
+		assert.Equal(t, false, response["has_more"])
+		assert.Equal(t, `</transactions?include_total=false&page_size=2>; rel="first"`, rr.Header().Get("Link"))
+
+		mockDB.AssertNotCalled(t, "CountTransactions", mock.Anything)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("cursor page links", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("CountTransactions", defaultFilter).Return(5, nil)
+		mockDB.On("GetTransactionsAfter", defaultFilter, (*db.Cursor)(nil), 3).Return(makeTransactions(3), nil)
+
+		req := httptest.NewRequest("GET", "/transactions?page_size=2", nil)
+		rr := httptest.NewRecorder()
+
+		handler.ListTransactions(rr, req)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, float64(5), response["total_count"])
+		assert.Equal(t, float64(3), response["total_pages"])
+		assert.Equal(t, true, response["has_more"])
+		assert.Contains(t, rr.Header().Get("Link"), `</transactions?cursor=`+response["next_cursor"].(string)+`&page_size=2>; rel="next"`)
+
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("invalid include_total", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		req := httptest.NewRequest("GET", "/transactions?include_total=maybe", nil)
+		rr := httptest.NewRecorder()
+
+		handler.ListTransactions(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("count error", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("CountTransactions", defaultFilter).Return(0, errors.New("database error"))
+
+		req := httptest.NewRequest("GET", "/transactions", nil)
+		rr := httptest.NewRecorder()
+
+		handler.ListTransactions(rr, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Contains(t, rr.Body.String(), "error counting transactions")
+	})
+}
+
+func TestHandler_ListTransactions_Cursor(t *testing.T) {
+	createdAt := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	makeTransactions := func(n int) []models.Transaction {
+		transactions := make([]models.Transaction, n)
+		for i := range transactions {
+			transactions[i] = models.Transaction{
+				ID:        fmt.Sprintf("txn-%d", i+1),
+				Amount:    models.MustParseAmount("1.00"),
+				Currency:  "USD",
+				Sender:    "user-1",
+				Receiver:  "user-2",
+				Status:    models.StatusPending,
+				CreatedAt: createdAt.Add(time.Duration(i) * time.Second),
+			}
+		}
+		return transactions
+	}
+	list := func(handler *Handler, query string) (int, map[string]interface{}) {
+		req := httptest.NewRequest("GET", "/transactions"+query, nil)
+		rr := httptest.NewRecorder()
+		handler.ListTransactions(rr, req)
+
+		var response map[string]interface{}
+		_ = json.Unmarshal(rr.Body.Bytes(), &response)
+		return rr.Code, response
+	}
+
+	t.Run("first page uses keyset pagination by default", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+		mockDB.On("CountTransactions", defaultFilter).Return(0, nil).Maybe()
+
+		// One extra row is requested to detect a following page
+		mockDB.On("GetTransactionsAfter", defaultFilter, (*db.Cursor)(nil), 3).Return(makeTransactions(3), nil)
+
+		code, response := list(handler, "?page_size=2")
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Len(t, response["transactions"], 2)
+		assert.Nil(t, response["prev_cursor"])
+		require.NotNil(t, response["next_cursor"])
+
+		cursor, err := decodeCursor(response["next_cursor"].(string), defaultFilter)
+		require.NoError(t, err)
+		assert.Equal(t, "txn-2", cursor.ID)
+		assert.True(t, cursor.CreatedAt.Equal(createdAt.Add(time.Second)))
+		assert.False(t, cursor.Before)
+
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("last page has no next cursor", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+		mockDB.On("CountTransactions", defaultFilter).Return(0, nil).Maybe()
+
+		after := db.Cursor{CreatedAt: createdAt, ID: "txn-1"}
+		mockDB.On("GetTransactionsAfter", defaultFilter, &after, 3).Return(makeTransactions(2)[1:], nil)
+
+		code, response := list(handler, "?page_size=2&cursor="+encodeCursor(after, defaultFilter))
+
+		assert.Equal(t, http.StatusOK, code)
+		assert.Len(t, response["transactions"], 1)
+		assert.Nil(t, response["next_cursor"])
+		require.NotNil(t, response["prev_cursor"])
+
+		cursor, err := decodeCursor(response["prev_cursor"].(string), defaultFilter)
+		require.NoError(t, err)
+		assert.Equal(t, "txn-2", cursor.ID)
+		assert.True(t, cursor.Before)
+
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("backward page", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+		mockDB.On("CountTransactions", defaultFilter).Return(0, nil).Maybe()
+
+		before := db.Cursor{CreatedAt: createdAt.Add(3 * time.Second), ID: "txn-4", Before: true}
+		mockDB.On("GetTransactionsAfter", defaultFilter, &before, 3).Return(makeTransactions(3), nil)
+
+		code, response := list(handler, "?page_size=2&cursor="+encodeCursor(before, defaultFilter))
+
+		assert.Equal(t, http.StatusOK, code)
+		transactions := response["transactions"].([]interface{})
+		require.Len(t, transactions, 2)
+		assert.Equal(t, "txn-2", transactions[0].(map[string]interface{})["id"])
+		assert.NotNil(t, response["prev_cursor"])
+		assert.NotNil(t, response["next_cursor"])
+
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+		mockDB.On("CountTransactions", defaultFilter).Return(0, nil).Maybe()
+
+		code, response := list(handler, "?cursor=not-a-cursor")
+
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, codeValidationFailed, response["code"])
+		mockDB.AssertNotCalled(t, "GetTransactionsAfter", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
 func TestHandler_UpdateTransaction(t *testing.T) {
 	t.Run("successful update", func(t *testing.T) {
 		mockDB := new(MockDB)
@@ -511,7 +1396,7 @@ func TestHandler_UpdateTransaction(t *testing.T) {
 			Status: models.StatusCompleted,
 		}
 
-		mockDB.On("UpdateTransaction", "txn-123", models.StatusCompleted).Return(nil)
+		mockDB.On("UpdateTransaction", "txn-123", models.StatusChange{Status: models.StatusCompleted, Actor: "api"}).Return(nil)
 
 		body, err := json.Marshal(updateReq)
 		require.NoError(t, err)
@@ -577,9 +1462,9 @@ func TestHandler_UpdateTransaction(t *testing.T) {
 		mockDB := new(MockDB)
 		handler := NewHandler(mockDB)
 
-		// Test with pending status (not allowed for updates)
+		// Test with held status (only screening holds transactions)
 		invalidReq := updateRequest{
-			Status: models.StatusPending,
+			Status: models.StatusHeld,
 		}
 
 		body, err := json.Marshal(invalidReq)
@@ -595,7 +1480,82 @@ func TestHandler_UpdateTransaction(t *testing.T) {
 		router.ServeHTTP(rr, req)
 
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
-		assert.Contains(t, rr.Body.String(), "invalid request body")
+
+		var problem Problem
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+		assert.Equal(t, codeValidationFailed, problem.Code)
+		assert.Equal(t, []FieldError{{Field: "status", Code: fieldInvalid, Message: `status "held" cannot be set`}}, problem.Errors)
+	})
+
+	t.Run("transaction not found", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("UpdateTransaction", "non-existent", models.StatusChange{Status: models.StatusCompleted, Actor: "api"}).Return(db.ErrNotFound)
+
+		body := bytes.NewReader([]byte(`{"status": "completed"}`))
+
+		req := httptest.NewRequest("PUT", "/transactions/non-existent", body)
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/transactions/{id}", handler.UpdateTransaction).Methods("PUT")
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Contains(t, rr.Body.String(), "transaction not found")
+
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("illegal transition", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		updateReq := updateRequest{
+			Status: models.StatusFailed,
+		}
+
+		mockDB.On("UpdateTransaction", "txn-123", models.StatusChange{Status: models.StatusFailed, Actor: "api"}).
+			Return(fmt.Errorf("%w: %w", db.ErrConflict, models.ValidateTransition(models.StatusCompleted, models.StatusFailed)))
+
+		body, err := json.Marshal(updateReq)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("PUT", "/transactions/txn-123", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/transactions/{id}", handler.UpdateTransaction).Methods("PUT")
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Contains(t, rr.Body.String(), "completed -> failed")
+
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("unknown status", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		body := bytes.NewReader([]byte(`{"status": "settled"}`))
+
+		req := httptest.NewRequest("PUT", "/transactions/txn-123", body)
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/transactions/{id}", handler.UpdateTransaction).Methods("PUT")
+
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockDB.AssertNotCalled(t, "UpdateTransaction", mock.Anything, mock.Anything)
 	})
 
 	t.Run("database error", func(t *testing.T) {
@@ -606,7 +1566,7 @@ func TestHandler_UpdateTransaction(t *testing.T) {
 			Status: models.StatusCompleted,
 		}
 
-		mockDB.On("UpdateTransaction", "txn-123", models.StatusCompleted).Return(errors.New("database error"))
+		mockDB.On("UpdateTransaction", "txn-123", models.StatusChange{Status: models.StatusCompleted, Actor: "api"}).Return(errors.New("database error"))
 
 		body, err := json.Marshal(updateReq)
 		require.NoError(t, err)
@@ -627,6 +1587,148 @@ func TestHandler_UpdateTransaction(t *testing.T) {
 	})
 }
 
+func TestHandler_UpdateTransaction_History(t *testing.T) {
+	serve := func(handler *Handler, body string, actor string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/transactions/txn-123", bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		if actor != "" {
+			req.Header.Set(actorHeader, actor)
+		}
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/transactions/{id}", handler.UpdateTransaction).Methods("PUT")
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("failure reason and actor are passed on", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		change := models.StatusChange{Status: models.StatusFailed, Reason: "card declined", Actor: "ops@example.com"}
+		mockDB.On("UpdateTransaction", "txn-123", change).Return(nil)
+
+		rr := serve(handler, `{"status": "failed", "failure_reason": "card declined"}`, "ops@example.com")
+
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("failure reason requires failed status", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		rr := serve(handler, `{"status": "completed", "failure_reason": "card declined"}`, "")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), "failure_reason")
+		mockDB.AssertNotCalled(t, "UpdateTransaction", mock.Anything, mock.Anything)
+	})
+
+	t.Run("failure reason too long", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		reason := strings.Repeat("x", models.MaxFailureReasonLength+1)
+		rr := serve(handler, `{"status": "failed", "failure_reason": "`+reason+`"}`, "")
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), fieldTooLong)
+		mockDB.AssertNotCalled(t, "UpdateTransaction", mock.Anything, mock.Anything)
+	})
+
+	t.Run("actor too long", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		rr := serve(handler, `{"status": "failed"}`, strings.Repeat("a", models.MaxActorLength+1))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Contains(t, rr.Body.String(), codeInvalidHeader)
+		mockDB.AssertNotCalled(t, "UpdateTransaction", mock.Anything, mock.Anything)
+	})
+}
+
+func TestHandler_GetTransactionHistory(t *testing.T) {
+	serve := func(handler *Handler, id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/transactions/"+id+"/history", nil)
+		rr := httptest.NewRecorder()
+
+		router := mux.NewRouter()
+		router.HandleFunc("/transactions/{id}/history", handler.GetTransactionHistory).Methods("GET")
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("lists events", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+		events := []models.TransactionEvent{
+			{ID: 1, TransactionID: "txn-123", FromStatus: models.StatusPending, ToStatus: models.StatusProcessing, Actor: "api", CreatedAt: createdAt},
+			{ID: 2, TransactionID: "txn-123", FromStatus: models.StatusProcessing, ToStatus: models.StatusFailed, Reason: "card declined", Actor: "ops", CreatedAt: createdAt},
+		}
+		mockDB.On("GetTransactionHistory", "txn-123").Return(events, nil)
+
+		rr := serve(handler, "txn-123")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		var response struct {
+			TransactionID string                    `json:"transaction_id"`
+			Events        []models.TransactionEvent `json:"events"`
+		}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Equal(t, "txn-123", response.TransactionID)
+		assert.Equal(t, events, response.Events)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("no changes yet", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("GetTransactionHistory", "txn-123").Return(nil, nil)
+
+		rr := serve(handler, "txn-123")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"events":[]`)
+	})
+
+	t.Run("transaction not found", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("GetTransactionHistory", "missing").Return(nil, db.ErrNotFound)
+
+		rr := serve(handler, "missing")
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestHandler_PropagatesRequestContext(t *testing.T) {
+	mockDB := new(MockDB)
+	handler := NewHandler(mockDB)
+
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "marker")
+
+	mockDB.On("GetTransaction", "txn-123").Return(&models.Transaction{ID: "txn-123"}, nil)
+
+	req := httptest.NewRequest("GET", "/transactions/txn-123", nil).WithContext(ctx)
+	rr := httptest.NewRecorder()
+
+	router := mux.NewRouter()
+	router.HandleFunc("/transactions/{id}", handler.GetTransaction).Methods("GET")
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "marker", mockDB.lastCtx.Value(ctxKey{}))
+}
+
 func TestHandler_RegisterRoutes(t *testing.T) {
 	mockDB := new(MockDB)
 	handler := NewHandler(mockDB)
@@ -658,6 +1760,16 @@ func TestHandler_RegisterRoutes(t *testing.T) {
 		"GET /transactions",
 		"GET /transactions/{id}",
 		"PUT /transactions/{id}",
+		"GET /transactions/{id}/history",
+		"POST /quotes",
+		"GET /quotes/{id}",
+		"GET /reports/totals-by-sender",
+		"GET /reports/top-senders",
+		"GET /reports/failure-heavy-senders",
+		"GET /admin/limits",
+		"GET /admin/limits/{sender}",
+		"PUT /admin/limits/{sender}",
+		"DELETE /admin/limits/{sender}",
 	}
 
 	for _, expectedRoute := range expectedRoutes {
@@ -0,0 +1,284 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/abadojack/gapstack/internal/db"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const (
+	// minWebhookSecretLength is the shortest signing secret a client may choose for an endpoint
+	minWebhookSecretLength = 16
+	// maxWebhookSecretLength is the longest signing secret a client may choose for an endpoint
+	maxWebhookSecretLength = 255
+	// webhookSecretPrefix marks generated signing secrets so that they are recognisable in configuration
+	webhookSecretPrefix = "whsec_"
+)
+
+// webhookRequest is the body of a webhook endpoint registration or update.
+type webhookRequest struct {
+	URL string `json:"url"`
+	// Secret is the signing secret; if omitted on registration one is generated. It cannot be changed.
+	Secret string `json:"secret"`
+	// Enabled defaults to true when omitted
+	Enabled *bool `json:"enabled"`
+}
+
+// CreateWebhook handles POST requests to register a webhook endpoint.
+// The endpoint receives every transaction event written after it is registered.
+// The response is the only one that includes the endpoint's signing secret.
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Println(err)
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidRequestBody, "invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	errs := validateWebhook(req)
+	if req.Secret != "" && len(req.Secret) < minWebhookSecretLength {
+		errs.add("secret", fieldInvalid, "secret must be at least 16 characters")
+	} else if len(req.Secret) > maxWebhookSecretLength {
+		errs.add("secret", fieldTooLong, "secret must be 255 characters or less")
+	}
+	if len(errs) > 0 {
+		writeValidationProblem(w, r, errs)
+		return
+	}
+
+	endpoint := models.WebhookEndpoint{
+		ID:      uuid.New().String(),
+		URL:     req.URL,
+		Secret:  req.Secret,
+		Enabled: req.Enabled == nil || *req.Enabled,
+	}
+	if endpoint.Secret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			log.Println(err)
+			writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error generating webhook secret")
+			return
+		}
+		endpoint.Secret = secret
+	}
+
+	if err := h.DB.CreateWebhookEndpoint(r.Context(), endpoint); err != nil {
+		log.Println(err)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error creating webhook")
+		return
+	}
+
+	// Read the endpoint back for its creation time; the secret is only returned here
+	created, err := h.DB.GetWebhookEndpoint(r.Context(), endpoint.ID)
+	if err != nil {
+		writeWebhookError(w, r, err, "error getting webhook")
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// ListWebhooks handles GET requests for every registered webhook endpoint, without their secrets.
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	endpoints, err := h.DB.ListWebhookEndpoints(r.Context())
+	if err != nil {
+		log.Println(err)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error listing webhooks")
+		return
+	}
+	for i := range endpoints {
+		endpoints[i].Secret = ""
+	}
+	if endpoints == nil {
+		endpoints = []models.WebhookEndpoint{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"webhooks": endpoints})
+}
+
+// GetWebhook handles GET requests to retrieve a single webhook endpoint, without its secret.
+func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	endpoint, err := h.DB.GetWebhookEndpoint(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeWebhookError(w, r, err, "error getting webhook")
+		return
+	}
+	endpoint.Secret = ""
+
+	writeJSON(w, http.StatusOK, endpoint)
+}
+
+// UpdateWebhook handles PUT requests to replace a webhook endpoint's URL and enabled flag.
+// Disabling an endpoint stops new events from being queued for it and pauses its pending deliveries.
+// The signing secret cannot be changed; register a new endpoint to rotate it.
+func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Println(err)
+		writeProblem(w, r, http.StatusBadRequest, codeInvalidRequestBody, "invalid request body")
+		return
+	}
+	defer r.Body.Close()
+
+	errs := validateWebhook(req)
+	if req.Secret != "" {
+		errs.add("secret", fieldInvalid, "secret cannot be changed; register a new endpoint to rotate it")
+	}
+	if len(errs) > 0 {
+		writeValidationProblem(w, r, errs)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	endpoint := models.WebhookEndpoint{ID: id, URL: req.URL, Enabled: req.Enabled == nil || *req.Enabled}
+	if err := h.DB.UpdateWebhookEndpoint(r.Context(), endpoint); err != nil {
+		writeWebhookError(w, r, err, "error updating webhook")
+		return
+	}
+
+	updated, err := h.DB.GetWebhookEndpoint(r.Context(), id)
+	if err != nil {
+		writeWebhookError(w, r, err, "error getting webhook")
+		return
+	}
+	updated.Secret = ""
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// DeleteWebhook handles DELETE requests to remove a webhook endpoint and its delivery history.
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := h.DB.DeleteWebhookEndpoint(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeWebhookError(w, r, err, "error deleting webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListWebhookDeliveries handles GET requests for a webhook endpoint's deliveries, oldest first.
+// The optional status parameter narrows the list, e.g. status=dead for the dead letters.
+// Pages are chained with the opaque next_cursor token; page_size is capped at maxPageSize.
+func (h *Handler) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	pageSize := parsePageSize(query.Get("page_size"))
+
+	var errs ValidationErrors
+	status := models.DeliveryStatus(query.Get("status"))
+	if status != "" && !status.IsValid() {
+		errs.add("status", fieldInvalid, "status must be one of: pending, delivered, dead")
+	}
+	var afterID int64
+	if token := query.Get("cursor"); token != "" {
+		var err error
+		afterID, err = strconv.ParseInt(token, 10, 64)
+		if err != nil || afterID < 0 {
+			errs.add("cursor", fieldInvalid, "cursor is not a valid pagination token")
+		}
+	}
+	if len(errs) > 0 {
+		writeValidationProblem(w, r, errs)
+		return
+	}
+
+	endpointID := mux.Vars(r)["id"]
+	deliveries, err := h.DB.ListWebhookDeliveries(r.Context(), endpointID, status, afterID, pageSize+1)
+	if err != nil {
+		writeWebhookError(w, r, err, "error listing webhook deliveries")
+		return
+	}
+	hasMore := len(deliveries) > pageSize
+	if hasMore {
+		deliveries = deliveries[:pageSize]
+	}
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+
+	var nextCursor interface{}
+	links := []string{pageLink(r, "first", map[string]string{"cursor": ""})}
+	if hasMore {
+		token := strconv.FormatInt(deliveries[len(deliveries)-1].ID, 10)
+		nextCursor = token
+		links = append(links, pageLink(r, "next", map[string]string{"cursor": token}))
+	}
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"webhook_id":  endpointID,
+		"page_size":   pageSize,
+		"has_more":    hasMore,
+		"deliveries":  deliveries,
+		"next_cursor": nextCursor,
+	})
+}
+
+// RedeliverWebhookDelivery handles POST requests to attempt a delivery again, such as a dead letter
+// once its endpoint is fixed. The delivery is queued straight away with a fresh set of attempts.
+func (h *Handler) RedeliverWebhookDelivery(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	deliveryID, err := strconv.ParseInt(vars["delivery_id"], 10, 64)
+	if err != nil {
+		writeProblem(w, r, http.StatusNotFound, codeNotFound, "webhook delivery not found")
+		return
+	}
+
+	if err := h.DB.RedeliverWebhookDelivery(r.Context(), vars["id"], deliveryID); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			writeProblem(w, r, http.StatusNotFound, codeNotFound, "webhook delivery not found")
+			return
+		}
+		log.Println(err)
+		writeProblem(w, r, http.StatusInternalServerError, codeInternal, "error redelivering webhook")
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"delivery_id": deliveryID,
+		"status":      models.DeliveryPending,
+	})
+}
+
+// writeWebhookError maps a webhook endpoint lookup failure to a problem response.
+func writeWebhookError(w http.ResponseWriter, r *http.Request, err error, detail string) {
+	if errors.Is(err, db.ErrNotFound) {
+		writeProblem(w, r, http.StatusNotFound, codeNotFound, "webhook not found")
+		return
+	}
+	log.Println(err)
+	writeProblem(w, r, http.StatusInternalServerError, codeInternal, detail)
+}
+
+// validateWebhook checks the URL of a webhook registration or update.
+func validateWebhook(req webhookRequest) ValidationErrors {
+	var errs ValidationErrors
+
+	if req.URL == "" {
+		errs.add("url", fieldRequired, "url is required")
+	} else if len(req.URL) > models.MaxWebhookURLLength {
+		errs.add("url", fieldTooLong, "url must be 2048 characters or less")
+	} else if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs.add("url", fieldInvalid, "url must be an absolute http or https URL")
+	}
+
+	return errs
+}
+
+// generateWebhookSecret returns a random signing secret with 256 bits of entropy.
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + hex.EncodeToString(b), nil
+}
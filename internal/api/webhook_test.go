@@ -0,0 +1,238 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/abadojack/gapstack/internal/db"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler_CreateWebhook(t *testing.T) {
+	t.Run("generates a secret and returns it once", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		created := &models.WebhookEndpoint{}
+		mockDB.On("CreateWebhookEndpoint", mock.MatchedBy(func(endpoint models.WebhookEndpoint) bool {
+			*created = endpoint
+			return endpoint.URL == "https://example.com/hook" && endpoint.Enabled &&
+				strings.HasPrefix(endpoint.Secret, webhookSecretPrefix) && len(endpoint.Secret) == len(webhookSecretPrefix)+64
+		})).Return(nil)
+		mockDB.On("GetWebhookEndpoint", mock.Anything).Return(created, nil)
+
+		rr := serveAccounts(handler, "POST", "/webhooks", []byte(`{"url":"https://example.com/hook"}`))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		var endpoint models.WebhookEndpoint
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &endpoint))
+		assert.Equal(t, created.ID, endpoint.ID)
+		assert.Equal(t, created.Secret, endpoint.Secret)
+		assert.True(t, endpoint.Enabled)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("keeps a client secret and enabled flag", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("CreateWebhookEndpoint", mock.MatchedBy(func(endpoint models.WebhookEndpoint) bool {
+			return endpoint.Secret == "0123456789abcdef" && !endpoint.Enabled
+		})).Return(nil)
+		mockDB.On("GetWebhookEndpoint", mock.Anything).Return(&models.WebhookEndpoint{ID: "wh-1", Secret: "0123456789abcdef"}, nil)
+
+		rr := serveAccounts(handler, "POST", "/webhooks", []byte(`{"url":"http://hooks.internal/tx","secret":"0123456789abcdef","enabled":false}`))
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			body  string
+			field string
+			code  string
+		}{
+			{"missing url", `{}`, "url", fieldRequired},
+			{"relative url", `{"url":"/hook"}`, "url", fieldInvalid},
+			{"unsupported scheme", `{"url":"ftp://example.com/hook"}`, "url", fieldInvalid},
+			{"url too long", `{"url":"https://example.com/` + strings.Repeat("a", models.MaxWebhookURLLength) + `"}`, "url", fieldTooLong},
+			{"short secret", `{"url":"https://example.com","secret":"short"}`, "secret", fieldInvalid},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockDB := new(MockDB)
+				handler := NewHandler(mockDB)
+
+				rr := serveAccounts(handler, "POST", "/webhooks", []byte(tt.body))
+
+				assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+				var problem Problem
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+				require.Len(t, problem.Errors, 1)
+				assert.Equal(t, tt.field, problem.Errors[0].Field)
+				assert.Equal(t, tt.code, problem.Errors[0].Code)
+				mockDB.AssertNotCalled(t, "CreateWebhookEndpoint", mock.Anything)
+			})
+		}
+	})
+}
+
+func TestHandler_ReadWebhooks(t *testing.T) {
+	endpoint := models.WebhookEndpoint{ID: "wh-1", URL: "https://example.com/hook", Secret: "whsec_1", Enabled: true}
+
+	t.Run("list hides secrets", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("ListWebhookEndpoints").Return([]models.WebhookEndpoint{endpoint}, nil)
+
+		rr := serveAccounts(handler, "GET", "/webhooks", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "whsec_1")
+		assert.Contains(t, rr.Body.String(), `"id":"wh-1"`)
+	})
+
+	t.Run("get hides the secret", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		stored := endpoint
+		mockDB.On("GetWebhookEndpoint", "wh-1").Return(&stored, nil)
+
+		rr := serveAccounts(handler, "GET", "/webhooks/wh-1", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "secret")
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("GetWebhookEndpoint", "missing").Return(nil, fmt.Errorf("%w: webhook endpoint missing", db.ErrNotFound))
+
+		rr := serveAccounts(handler, "GET", "/webhooks/missing", nil)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestHandler_UpdateWebhook(t *testing.T) {
+	t.Run("replaces url and enabled flag", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("UpdateWebhookEndpoint", models.WebhookEndpoint{ID: "wh-1", URL: "https://example.com/new", Enabled: false}).Return(nil)
+		mockDB.On("GetWebhookEndpoint", "wh-1").
+			Return(&models.WebhookEndpoint{ID: "wh-1", URL: "https://example.com/new", Secret: "whsec_1"}, nil)
+
+		rr := serveAccounts(handler, "PUT", "/webhooks/wh-1", []byte(`{"url":"https://example.com/new","enabled":false}`))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "whsec_1")
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("secret cannot be changed", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		rr := serveAccounts(handler, "PUT", "/webhooks/wh-1", []byte(`{"url":"https://example.com","secret":"0123456789abcdef"}`))
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		mockDB.AssertNotCalled(t, "UpdateWebhookEndpoint", mock.Anything)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("UpdateWebhookEndpoint", mock.Anything).Return(fmt.Errorf("%w: webhook endpoint missing", db.ErrNotFound))
+
+		rr := serveAccounts(handler, "PUT", "/webhooks/missing", []byte(`{"url":"https://example.com"}`))
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestHandler_DeleteWebhook(t *testing.T) {
+	mockDB := new(MockDB)
+	handler := NewHandler(mockDB)
+
+	mockDB.On("DeleteWebhookEndpoint", "wh-1").Return(nil)
+	mockDB.On("DeleteWebhookEndpoint", "missing").Return(fmt.Errorf("%w: webhook endpoint missing", db.ErrNotFound))
+
+	assert.Equal(t, http.StatusNoContent, serveAccounts(handler, "DELETE", "/webhooks/wh-1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, serveAccounts(handler, "DELETE", "/webhooks/missing", nil).Code)
+}
+
+func TestHandler_ListWebhookDeliveries(t *testing.T) {
+	t.Run("filters by status and pages by cursor", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("ListWebhookDeliveries", "wh-1", models.DeliveryDead, int64(4), 3).Return([]models.WebhookDelivery{
+			{ID: 5, Status: models.DeliveryDead},
+			{ID: 8, Status: models.DeliveryDead},
+			{ID: 9, Status: models.DeliveryDead},
+		}, nil)
+
+		rr := serveAccounts(handler, "GET", "/webhooks/wh-1/deliveries?status=dead&cursor=4&page_size=2", nil)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+		assert.Len(t, response["deliveries"], 2)
+		assert.Equal(t, true, response["has_more"])
+		assert.Equal(t, "8", response["next_cursor"])
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		rr := serveAccounts(handler, "GET", "/webhooks/wh-1/deliveries?status=lost", nil)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("endpoint not found", func(t *testing.T) {
+		mockDB := new(MockDB)
+		handler := NewHandler(mockDB)
+
+		mockDB.On("ListWebhookDeliveries", "missing", models.DeliveryStatus(""), int64(0), defaultPageSize+1).
+			Return([]models.WebhookDelivery(nil), fmt.Errorf("%w: webhook endpoint missing", db.ErrNotFound))
+
+		rr := serveAccounts(handler, "GET", "/webhooks/missing/deliveries", nil)
+
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
+
+func TestHandler_RedeliverWebhookDelivery(t *testing.T) {
+	mockDB := new(MockDB)
+	handler := NewHandler(mockDB)
+
+	mockDB.On("RedeliverWebhookDelivery", "wh-1", int64(4)).Return(nil)
+	mockDB.On("RedeliverWebhookDelivery", "wh-1", int64(5)).Return(fmt.Errorf("%w: webhook delivery 5", db.ErrNotFound))
+
+	rr := serveAccounts(handler, "POST", "/webhooks/wh-1/deliveries/4/redeliver", nil)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	assert.JSONEq(t, `{"delivery_id":4,"status":"pending"}`, rr.Body.String())
+
+	assert.Equal(t, http.StatusNotFound, serveAccounts(handler, "POST", "/webhooks/wh-1/deliveries/5/redeliver", nil).Code)
+	assert.Equal(t, http.StatusNotFound, serveAccounts(handler, "POST", "/webhooks/wh-1/deliveries/abc/redeliver", nil).Code)
+}
@@ -0,0 +1,140 @@
+// Package currency is the registry of ISO 4217 currencies: their numeric codes, minor units and names, and
+// whether they are still in use. The table is generated from iso4217.csv; edit the file and run go generate
+// to change it.
+package currency
+
+//go:generate go run gen.go
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NoMinorUnits is the exponent of codes, such as gold (XAU), that have no minor unit and so cannot be used
+// for amounts.
+const NoMinorUnits = -1
+
+// Currency is an entry of the ISO 4217 table.
+type Currency struct {
+	// Code is the 3-letter alphabetic code, such as "USD"
+	Code string
+	// Numeric is the 3-digit numeric code, such as "840"
+	Numeric string
+	// Exponent is the number of minor-unit digits, such as 2 for cents, or NoMinorUnits
+	Exponent int
+	// Name is the English name of the currency
+	Name string
+	// Withdrawn reports whether the code has been withdrawn from use
+	Withdrawn bool
+}
+
+// Usable reports whether amounts can be sent in the currency: it is in use and has minor units.
+func (c Currency) Usable() bool {
+	return !c.Withdrawn && c.Exponent != NoMinorUnits
+}
+
+var (
+	// byCode indexes the table by alphabetic code
+	byCode = make(map[string]Currency, len(table))
+	// byNumeric indexes the table by numeric code; a code in use wins over withdrawn codes that shared it
+	byNumeric = make(map[string]Currency, len(table))
+)
+
+func init() {
+	for _, currency := range table {
+		byCode[currency.Code] = currency
+		if existing, ok := byNumeric[currency.Numeric]; !ok || (existing.Withdrawn && !currency.Withdrawn) {
+			byNumeric[currency.Numeric] = currency
+		}
+	}
+}
+
+// Lookup returns the currency with an upper-case alphabetic code, whether it is in use or withdrawn.
+func Lookup(code string) (Currency, bool) {
+	currency, ok := byCode[code]
+	return currency, ok
+}
+
+// LookupNumeric returns the currency with a 3-digit numeric code, preferring the one in use when a
+// numeric code was reassigned.
+func LookupNumeric(numeric string) (Currency, bool) {
+	currency, ok := byNumeric[numeric]
+	return currency, ok
+}
+
+// Exponent returns the number of minor-unit digits of an upper-case currency code. The second return value
+// reports whether the code is known and has minor units; withdrawn codes still have an exponent so that
+// amounts stored in them can be read.
+func Exponent(code string) (int, bool) {
+	currency, ok := byCode[code]
+	if !ok || currency.Exponent == NoMinorUnits {
+		return 0, false
+	}
+	return currency.Exponent, true
+}
+
+// All returns every currency in the table, in code order.
+func All() []Currency {
+	currencies := make([]Currency, len(table))
+	copy(currencies, table)
+	sort.Slice(currencies, func(i, j int) bool { return currencies[i].Code < currencies[j].Code })
+	return currencies
+}
+
+// Set is the subset of currencies an operator allows the service to accept.
+// A nil *Set allows every usable currency.
+type Set struct {
+	codes map[string]bool
+}
+
+// NewSet creates a Set of the given codes, in any case. Every code must be a usable currency.
+func NewSet(codes ...string) (*Set, error) {
+	s := &Set{codes: make(map[string]bool, len(codes))}
+	for _, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		currency, ok := byCode[code]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("unknown currency %q", code)
+		case currency.Withdrawn:
+			return nil, fmt.Errorf("currency %s has been withdrawn", code)
+		case currency.Exponent == NoMinorUnits:
+			return nil, fmt.Errorf("currency %s has no minor units", code)
+		}
+		s.codes[code] = true
+	}
+	return s, nil
+}
+
+// ParseSet parses a comma-separated list of currency codes such as "USD,EUR,KES" into a Set.
+// An empty list returns a nil Set, which allows every usable currency.
+func ParseSet(list string) (*Set, error) {
+	if strings.TrimSpace(list) == "" {
+		return nil, nil
+	}
+	return NewSet(strings.Split(list, ",")...)
+}
+
+// Allows reports whether the service accepts a currency code, in any case.
+func (s *Set) Allows(code string) bool {
+	code = strings.ToUpper(code)
+	if s != nil {
+		return s.codes[code]
+	}
+	currency, ok := byCode[code]
+	return ok && currency.Usable()
+}
+
+// Codes returns the allowed codes in order, or nil for a nil Set.
+func (s *Set) Codes() []string {
+	if s == nil {
+		return nil
+	}
+	codes := make([]string, 0, len(s.codes))
+	for code := range s.codes {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
@@ -0,0 +1,133 @@
+package currency
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTable_MatchesDataFile(t *testing.T) {
+	file, err := os.Open("iso4217.csv")
+	require.NoError(t, err)
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	require.NoError(t, err)
+
+	rows := records[1:]
+	require.Len(t, table, len(rows), "table.go is stale; run go generate ./internal/currency")
+	for i, row := range rows {
+		assert.Equal(t, row[0], table[i].Code, "table.go is stale; run go generate ./internal/currency")
+		assert.Equal(t, row[1], table[i].Numeric, row[0])
+		exponent := NoMinorUnits
+		if row[2] != "N.A." {
+			exponent, err = strconv.Atoi(row[2])
+			require.NoError(t, err, row[0])
+		}
+		assert.Equal(t, exponent, table[i].Exponent, row[0])
+		assert.Equal(t, row[3], table[i].Name, row[0])
+		assert.Equal(t, row[4] == "withdrawn", table[i].Withdrawn, row[0])
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		code     string
+		numeric  string
+		exponent int
+		usable   bool
+	}{
+		{"USD", "840", 2, true},
+		{"JPY", "392", 0, true},
+		{"BHD", "048", 3, true},
+		{"CLF", "990", 4, true},
+		{"XAU", "959", NoMinorUnits, false},
+		{"DEM", "276", 2, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			currency, ok := Lookup(tt.code)
+			require.True(t, ok)
+			assert.Equal(t, tt.numeric, currency.Numeric)
+			assert.Equal(t, tt.exponent, currency.Exponent)
+			assert.Equal(t, tt.usable, currency.Usable())
+		})
+	}
+
+	_, ok := Lookup("usd")
+	assert.False(t, ok, "codes are upper case")
+	_, ok = Lookup("ABC")
+	assert.False(t, ok)
+}
+
+func TestLookupNumeric(t *testing.T) {
+	currency, ok := LookupNumeric("978")
+	require.True(t, ok)
+	assert.Equal(t, "EUR", currency.Code)
+
+	currency, ok = LookupNumeric("532")
+	require.True(t, ok)
+	assert.Equal(t, "XCG", currency.Code, "the code in use wins over a withdrawn one")
+
+	_, ok = LookupNumeric("000")
+	assert.False(t, ok)
+}
+
+func TestExponent(t *testing.T) {
+	exponent, ok := Exponent("KWD")
+	assert.True(t, ok)
+	assert.Equal(t, 3, exponent)
+
+	exponent, ok = Exponent("HRK")
+	assert.True(t, ok, "withdrawn currencies keep their exponent")
+	assert.Equal(t, 2, exponent)
+
+	_, ok = Exponent("XDR")
+	assert.False(t, ok)
+	_, ok = Exponent("XYZ")
+	assert.False(t, ok)
+}
+
+func TestAll(t *testing.T) {
+	all := All()
+	assert.Len(t, all, len(table))
+	for i := 1; i < len(all); i++ {
+		assert.Less(t, all[i-1].Code, all[i].Code)
+	}
+}
+
+func TestSet(t *testing.T) {
+	t.Run("nil allows every usable currency", func(t *testing.T) {
+		set, err := ParseSet(" ")
+		require.NoError(t, err)
+		assert.Nil(t, set)
+		assert.True(t, set.Allows("usd"))
+		assert.True(t, set.Allows("KWD"))
+		assert.False(t, set.Allows("XAU"))
+		assert.False(t, set.Allows("DEM"))
+		assert.False(t, set.Allows("ABC"))
+		assert.Nil(t, set.Codes())
+	})
+
+	t.Run("allow list", func(t *testing.T) {
+		set, err := ParseSet("usd, KES,EUR")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"EUR", "KES", "USD"}, set.Codes())
+		assert.True(t, set.Allows("Usd"))
+		assert.False(t, set.Allows("GBP"))
+	})
+
+	t.Run("invalid codes", func(t *testing.T) {
+		for _, list := range []string{"USD,ABC", "DEM", "XAU", "USD,,EUR"} {
+			_, err := ParseSet(list)
+			assert.Error(t, err, list)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+//go:build ignore
+
+// gen generates table.go from iso4217.csv. Run it with `go generate ./internal/currency`.
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"go/format"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+var (
+	codePattern    = regexp.MustCompile(`^[A-Z]{3}$`)
+	numericPattern = regexp.MustCompile(`^[0-9]{3}$`)
+)
+
+func main() {
+	file, err := os.Open("iso4217.csv")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 5
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by gen.go from iso4217.csv; DO NOT EDIT.\n\n")
+	out.WriteString("package currency\n\n")
+	out.WriteString("// table lists every currency in iso4217.csv, in file order.\n")
+	out.WriteString("var table = []Currency{\n")
+
+	seen := make(map[string]bool)
+	for line := 0; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if line == 0 {
+			// Header
+			continue
+		}
+
+		code, numeric, minorUnits, name, status := record[0], record[1], record[2], record[3], record[4]
+		if !codePattern.MatchString(code) || seen[code] {
+			log.Fatalf("%s: invalid or duplicate code", code)
+		}
+		seen[code] = true
+		if !numericPattern.MatchString(numeric) {
+			log.Fatalf("%s: invalid numeric code %q", code, numeric)
+		}
+
+		exponent := "NoMinorUnits"
+		if minorUnits != "N.A." {
+			n, err := strconv.Atoi(minorUnits)
+			if err != nil || n < 0 || n > 4 {
+				log.Fatalf("%s: invalid minor units %q", code, minorUnits)
+			}
+			exponent = strconv.Itoa(n)
+		}
+
+		var withdrawn bool
+		switch status {
+		case "active":
+		case "withdrawn":
+			withdrawn = true
+		default:
+			log.Fatalf("%s: invalid status %q", code, status)
+		}
+
+		fmt.Fprintf(&out, "\t{Code: %q, Numeric: %q, Exponent: %s, Name: %q, Withdrawn: %t},\n", code, numeric, exponent, name, withdrawn)
+	}
+	out.WriteString("}\n")
+
+	source, err := format.Source(out.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("table.go", source, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,234 @@
+// Code generated by gen.go from iso4217.csv; DO NOT EDIT.
+
+package currency
+
+// table lists every currency in iso4217.csv, in file order.
+var table = []Currency{
+	{Code: "AED", Numeric: "784", Exponent: 2, Name: "UAE Dirham", Withdrawn: false},
+	{Code: "AFN", Numeric: "971", Exponent: 2, Name: "Afghani", Withdrawn: false},
+	{Code: "ALL", Numeric: "008", Exponent: 2, Name: "Lek", Withdrawn: false},
+	{Code: "AMD", Numeric: "051", Exponent: 2, Name: "Armenian Dram", Withdrawn: false},
+	{Code: "AOA", Numeric: "973", Exponent: 2, Name: "Kwanza", Withdrawn: false},
+	{Code: "ARS", Numeric: "032", Exponent: 2, Name: "Argentine Peso", Withdrawn: false},
+	{Code: "AUD", Numeric: "036", Exponent: 2, Name: "Australian Dollar", Withdrawn: false},
+	{Code: "AWG", Numeric: "533", Exponent: 2, Name: "Aruban Florin", Withdrawn: false},
+	{Code: "AZN", Numeric: "944", Exponent: 2, Name: "Azerbaijan Manat", Withdrawn: false},
+	{Code: "BAM", Numeric: "977", Exponent: 2, Name: "Convertible Mark", Withdrawn: false},
+	{Code: "BBD", Numeric: "052", Exponent: 2, Name: "Barbados Dollar", Withdrawn: false},
+	{Code: "BDT", Numeric: "050", Exponent: 2, Name: "Taka", Withdrawn: false},
+	{Code: "BGN", Numeric: "975", Exponent: 2, Name: "Bulgarian Lev", Withdrawn: false},
+	{Code: "BHD", Numeric: "048", Exponent: 3, Name: "Bahraini Dinar", Withdrawn: false},
+	{Code: "BIF", Numeric: "108", Exponent: 0, Name: "Burundi Franc", Withdrawn: false},
+	{Code: "BMD", Numeric: "060", Exponent: 2, Name: "Bermudian Dollar", Withdrawn: false},
+	{Code: "BND", Numeric: "096", Exponent: 2, Name: "Brunei Dollar", Withdrawn: false},
+	{Code: "BOB", Numeric: "068", Exponent: 2, Name: "Boliviano", Withdrawn: false},
+	{Code: "BOV", Numeric: "984", Exponent: 2, Name: "Mvdol", Withdrawn: false},
+	{Code: "BRL", Numeric: "986", Exponent: 2, Name: "Brazilian Real", Withdrawn: false},
+	{Code: "BSD", Numeric: "044", Exponent: 2, Name: "Bahamian Dollar", Withdrawn: false},
+	{Code: "BTN", Numeric: "064", Exponent: 2, Name: "Ngultrum", Withdrawn: false},
+	{Code: "BWP", Numeric: "072", Exponent: 2, Name: "Pula", Withdrawn: false},
+	{Code: "BYN", Numeric: "933", Exponent: 2, Name: "Belarusian Ruble", Withdrawn: false},
+	{Code: "BZD", Numeric: "084", Exponent: 2, Name: "Belize Dollar", Withdrawn: false},
+	{Code: "CAD", Numeric: "124", Exponent: 2, Name: "Canadian Dollar", Withdrawn: false},
+	{Code: "CDF", Numeric: "976", Exponent: 2, Name: "Congolese Franc", Withdrawn: false},
+	{Code: "CHE", Numeric: "947", Exponent: 2, Name: "WIR Euro", Withdrawn: false},
+	{Code: "CHF", Numeric: "756", Exponent: 2, Name: "Swiss Franc", Withdrawn: false},
+	{Code: "CHW", Numeric: "948", Exponent: 2, Name: "WIR Franc", Withdrawn: false},
+	{Code: "CLF", Numeric: "990", Exponent: 4, Name: "Unidad de Fomento", Withdrawn: false},
+	{Code: "CLP", Numeric: "152", Exponent: 0, Name: "Chilean Peso", Withdrawn: false},
+	{Code: "CNY", Numeric: "156", Exponent: 2, Name: "Yuan Renminbi", Withdrawn: false},
+	{Code: "COP", Numeric: "170", Exponent: 2, Name: "Colombian Peso", Withdrawn: false},
+	{Code: "COU", Numeric: "970", Exponent: 2, Name: "Unidad de Valor Real", Withdrawn: false},
+	{Code: "CRC", Numeric: "188", Exponent: 2, Name: "Costa Rican Colon", Withdrawn: false},
+	{Code: "CUC", Numeric: "931", Exponent: 2, Name: "Peso Convertible", Withdrawn: false},
+	{Code: "CUP", Numeric: "192", Exponent: 2, Name: "Cuban Peso", Withdrawn: false},
+	{Code: "CVE", Numeric: "132", Exponent: 2, Name: "Cabo Verde Escudo", Withdrawn: false},
+	{Code: "CZK", Numeric: "203", Exponent: 2, Name: "Czech Koruna", Withdrawn: false},
+	{Code: "DJF", Numeric: "262", Exponent: 0, Name: "Djibouti Franc", Withdrawn: false},
+	{Code: "DKK", Numeric: "208", Exponent: 2, Name: "Danish Krone", Withdrawn: false},
+	{Code: "DOP", Numeric: "214", Exponent: 2, Name: "Dominican Peso", Withdrawn: false},
+	{Code: "DZD", Numeric: "012", Exponent: 2, Name: "Algerian Dinar", Withdrawn: false},
+	{Code: "EGP", Numeric: "818", Exponent: 2, Name: "Egyptian Pound", Withdrawn: false},
+	{Code: "ERN", Numeric: "232", Exponent: 2, Name: "Nakfa", Withdrawn: false},
+	{Code: "ETB", Numeric: "230", Exponent: 2, Name: "Ethiopian Birr", Withdrawn: false},
+	{Code: "EUR", Numeric: "978", Exponent: 2, Name: "Euro", Withdrawn: false},
+	{Code: "FJD", Numeric: "242", Exponent: 2, Name: "Fiji Dollar", Withdrawn: false},
+	{Code: "FKP", Numeric: "238", Exponent: 2, Name: "Falkland Islands Pound", Withdrawn: false},
+	{Code: "GBP", Numeric: "826", Exponent: 2, Name: "Pound Sterling", Withdrawn: false},
+	{Code: "GEL", Numeric: "981", Exponent: 2, Name: "Lari", Withdrawn: false},
+	{Code: "GHS", Numeric: "936", Exponent: 2, Name: "Ghana Cedi", Withdrawn: false},
+	{Code: "GIP", Numeric: "292", Exponent: 2, Name: "Gibraltar Pound", Withdrawn: false},
+	{Code: "GMD", Numeric: "270", Exponent: 2, Name: "Dalasi", Withdrawn: false},
+	{Code: "GNF", Numeric: "324", Exponent: 0, Name: "Guinean Franc", Withdrawn: false},
+	{Code: "GTQ", Numeric: "320", Exponent: 2, Name: "Quetzal", Withdrawn: false},
+	{Code: "GYD", Numeric: "328", Exponent: 2, Name: "Guyana Dollar", Withdrawn: false},
+	{Code: "HKD", Numeric: "344", Exponent: 2, Name: "Hong Kong Dollar", Withdrawn: false},
+	{Code: "HNL", Numeric: "340", Exponent: 2, Name: "Lempira", Withdrawn: false},
+	{Code: "HTG", Numeric: "332", Exponent: 2, Name: "Gourde", Withdrawn: false},
+	{Code: "HUF", Numeric: "348", Exponent: 2, Name: "Forint", Withdrawn: false},
+	{Code: "IDR", Numeric: "360", Exponent: 2, Name: "Rupiah", Withdrawn: false},
+	{Code: "ILS", Numeric: "376", Exponent: 2, Name: "New Israeli Sheqel", Withdrawn: false},
+	{Code: "INR", Numeric: "356", Exponent: 2, Name: "Indian Rupee", Withdrawn: false},
+	{Code: "IQD", Numeric: "368", Exponent: 3, Name: "Iraqi Dinar", Withdrawn: false},
+	{Code: "IRR", Numeric: "364", Exponent: 2, Name: "Iranian Rial", Withdrawn: false},
+	{Code: "ISK", Numeric: "352", Exponent: 0, Name: "Iceland Krona", Withdrawn: false},
+	{Code: "JMD", Numeric: "388", Exponent: 2, Name: "Jamaican Dollar", Withdrawn: false},
+	{Code: "JOD", Numeric: "400", Exponent: 3, Name: "Jordanian Dinar", Withdrawn: false},
+	{Code: "JPY", Numeric: "392", Exponent: 0, Name: "Yen", Withdrawn: false},
+	{Code: "KES", Numeric: "404", Exponent: 2, Name: "Kenyan Shilling", Withdrawn: false},
+	{Code: "KGS", Numeric: "417", Exponent: 2, Name: "Som", Withdrawn: false},
+	{Code: "KHR", Numeric: "116", Exponent: 2, Name: "Riel", Withdrawn: false},
+	{Code: "KMF", Numeric: "174", Exponent: 0, Name: "Comorian Franc", Withdrawn: false},
+	{Code: "KPW", Numeric: "408", Exponent: 2, Name: "North Korean Won", Withdrawn: false},
+	{Code: "KRW", Numeric: "410", Exponent: 0, Name: "Won", Withdrawn: false},
+	{Code: "KWD", Numeric: "414", Exponent: 3, Name: "Kuwaiti Dinar", Withdrawn: false},
+	{Code: "KYD", Numeric: "136", Exponent: 2, Name: "Cayman Islands Dollar", Withdrawn: false},
+	{Code: "KZT", Numeric: "398", Exponent: 2, Name: "Tenge", Withdrawn: false},
+	{Code: "LAK", Numeric: "418", Exponent: 2, Name: "Lao Kip", Withdrawn: false},
+	{Code: "LBP", Numeric: "422", Exponent: 2, Name: "Lebanese Pound", Withdrawn: false},
+	{Code: "LKR", Numeric: "144", Exponent: 2, Name: "Sri Lanka Rupee", Withdrawn: false},
+	{Code: "LRD", Numeric: "430", Exponent: 2, Name: "Liberian Dollar", Withdrawn: false},
+	{Code: "LSL", Numeric: "426", Exponent: 2, Name: "Loti", Withdrawn: false},
+	{Code: "LYD", Numeric: "434", Exponent: 3, Name: "Libyan Dinar", Withdrawn: false},
+	{Code: "MAD", Numeric: "504", Exponent: 2, Name: "Moroccan Dirham", Withdrawn: false},
+	{Code: "MDL", Numeric: "498", Exponent: 2, Name: "Moldovan Leu", Withdrawn: false},
+	{Code: "MGA", Numeric: "969", Exponent: 2, Name: "Malagasy Ariary", Withdrawn: false},
+	{Code: "MKD", Numeric: "807", Exponent: 2, Name: "Denar", Withdrawn: false},
+	{Code: "MMK", Numeric: "104", Exponent: 2, Name: "Kyat", Withdrawn: false},
+	{Code: "MNT", Numeric: "496", Exponent: 2, Name: "Tugrik", Withdrawn: false},
+	{Code: "MOP", Numeric: "446", Exponent: 2, Name: "Pataca", Withdrawn: false},
+	{Code: "MRU", Numeric: "929", Exponent: 2, Name: "Ouguiya", Withdrawn: false},
+	{Code: "MUR", Numeric: "480", Exponent: 2, Name: "Mauritius Rupee", Withdrawn: false},
+	{Code: "MVR", Numeric: "462", Exponent: 2, Name: "Rufiyaa", Withdrawn: false},
+	{Code: "MWK", Numeric: "454", Exponent: 2, Name: "Malawi Kwacha", Withdrawn: false},
+	{Code: "MXN", Numeric: "484", Exponent: 2, Name: "Mexican Peso", Withdrawn: false},
+	{Code: "MXV", Numeric: "979", Exponent: 2, Name: "Mexican Unidad de Inversion (UDI)", Withdrawn: false},
+	{Code: "MYR", Numeric: "458", Exponent: 2, Name: "Malaysian Ringgit", Withdrawn: false},
+	{Code: "MZN", Numeric: "943", Exponent: 2, Name: "Mozambique Metical", Withdrawn: false},
+	{Code: "NAD", Numeric: "516", Exponent: 2, Name: "Namibia Dollar", Withdrawn: false},
+	{Code: "NGN", Numeric: "566", Exponent: 2, Name: "Naira", Withdrawn: false},
+	{Code: "NIO", Numeric: "558", Exponent: 2, Name: "Cordoba Oro", Withdrawn: false},
+	{Code: "NOK", Numeric: "578", Exponent: 2, Name: "Norwegian Krone", Withdrawn: false},
+	{Code: "NPR", Numeric: "524", Exponent: 2, Name: "Nepalese Rupee", Withdrawn: false},
+	{Code: "NZD", Numeric: "554", Exponent: 2, Name: "New Zealand Dollar", Withdrawn: false},
+	{Code: "OMR", Numeric: "512", Exponent: 3, Name: "Rial Omani", Withdrawn: false},
+	{Code: "PAB", Numeric: "590", Exponent: 2, Name: "Balboa", Withdrawn: false},
+	{Code: "PEN", Numeric: "604", Exponent: 2, Name: "Sol", Withdrawn: false},
+	{Code: "PGK", Numeric: "598", Exponent: 2, Name: "Kina", Withdrawn: false},
+	{Code: "PHP", Numeric: "608", Exponent: 2, Name: "Philippine Peso", Withdrawn: false},
+	{Code: "PKR", Numeric: "586", Exponent: 2, Name: "Pakistan Rupee", Withdrawn: false},
+	{Code: "PLN", Numeric: "985", Exponent: 2, Name: "Zloty", Withdrawn: false},
+	{Code: "PYG", Numeric: "600", Exponent: 0, Name: "Guarani", Withdrawn: false},
+	{Code: "QAR", Numeric: "634", Exponent: 2, Name: "Qatari Rial", Withdrawn: false},
+	{Code: "RON", Numeric: "946", Exponent: 2, Name: "Romanian Leu", Withdrawn: false},
+	{Code: "RSD", Numeric: "941", Exponent: 2, Name: "Serbian Dinar", Withdrawn: false},
+	{Code: "RUB", Numeric: "643", Exponent: 2, Name: "Russian Ruble", Withdrawn: false},
+	{Code: "RWF", Numeric: "646", Exponent: 0, Name: "Rwanda Franc", Withdrawn: false},
+	{Code: "SAR", Numeric: "682", Exponent: 2, Name: "Saudi Riyal", Withdrawn: false},
+	{Code: "SBD", Numeric: "090", Exponent: 2, Name: "Solomon Islands Dollar", Withdrawn: false},
+	{Code: "SCR", Numeric: "690", Exponent: 2, Name: "Seychelles Rupee", Withdrawn: false},
+	{Code: "SDG", Numeric: "938", Exponent: 2, Name: "Sudanese Pound", Withdrawn: false},
+	{Code: "SEK", Numeric: "752", Exponent: 2, Name: "Swedish Krona", Withdrawn: false},
+	{Code: "SGD", Numeric: "702", Exponent: 2, Name: "Singapore Dollar", Withdrawn: false},
+	{Code: "SHP", Numeric: "654", Exponent: 2, Name: "Saint Helena Pound", Withdrawn: false},
+	{Code: "SLE", Numeric: "925", Exponent: 2, Name: "Leone", Withdrawn: false},
+	{Code: "SOS", Numeric: "706", Exponent: 2, Name: "Somali Shilling", Withdrawn: false},
+	{Code: "SRD", Numeric: "968", Exponent: 2, Name: "Surinam Dollar", Withdrawn: false},
+	{Code: "SSP", Numeric: "728", Exponent: 2, Name: "South Sudanese Pound", Withdrawn: false},
+	{Code: "STN", Numeric: "930", Exponent: 2, Name: "Dobra", Withdrawn: false},
+	{Code: "SVC", Numeric: "222", Exponent: 2, Name: "El Salvador Colon", Withdrawn: false},
+	{Code: "SYP", Numeric: "760", Exponent: 2, Name: "Syrian Pound", Withdrawn: false},
+	{Code: "SZL", Numeric: "748", Exponent: 2, Name: "Lilangeni", Withdrawn: false},
+	{Code: "THB", Numeric: "764", Exponent: 2, Name: "Baht", Withdrawn: false},
+	{Code: "TJS", Numeric: "972", Exponent: 2, Name: "Somoni", Withdrawn: false},
+	{Code: "TMT", Numeric: "934", Exponent: 2, Name: "Turkmenistan New Manat", Withdrawn: false},
+	{Code: "TND", Numeric: "788", Exponent: 3, Name: "Tunisian Dinar", Withdrawn: false},
+	{Code: "TOP", Numeric: "776", Exponent: 2, Name: "Pa'anga", Withdrawn: false},
+	{Code: "TRY", Numeric: "949", Exponent: 2, Name: "Turkish Lira", Withdrawn: false},
+	{Code: "TTD", Numeric: "780", Exponent: 2, Name: "Trinidad and Tobago Dollar", Withdrawn: false},
+	{Code: "TWD", Numeric: "901", Exponent: 2, Name: "New Taiwan Dollar", Withdrawn: false},
+	{Code: "TZS", Numeric: "834", Exponent: 2, Name: "Tanzanian Shilling", Withdrawn: false},
+	{Code: "UAH", Numeric: "980", Exponent: 2, Name: "Hryvnia", Withdrawn: false},
+	{Code: "UGX", Numeric: "800", Exponent: 0, Name: "Uganda Shilling", Withdrawn: false},
+	{Code: "USD", Numeric: "840", Exponent: 2, Name: "US Dollar", Withdrawn: false},
+	{Code: "USN", Numeric: "997", Exponent: 2, Name: "US Dollar (Next day)", Withdrawn: false},
+	{Code: "UYI", Numeric: "940", Exponent: 0, Name: "Uruguay Peso en Unidades Indexadas (UI)", Withdrawn: false},
+	{Code: "UYU", Numeric: "858", Exponent: 2, Name: "Peso Uruguayo", Withdrawn: false},
+	{Code: "UYW", Numeric: "927", Exponent: 4, Name: "Unidad Previsional", Withdrawn: false},
+	{Code: "UZS", Numeric: "860", Exponent: 2, Name: "Uzbekistan Sum", Withdrawn: false},
+	{Code: "VED", Numeric: "926", Exponent: 2, Name: "Bolivar Soberano", Withdrawn: false},
+	{Code: "VES", Numeric: "928", Exponent: 2, Name: "Bolivar Soberano", Withdrawn: false},
+	{Code: "VND", Numeric: "704", Exponent: 0, Name: "Dong", Withdrawn: false},
+	{Code: "VUV", Numeric: "548", Exponent: 0, Name: "Vatu", Withdrawn: false},
+	{Code: "WST", Numeric: "882", Exponent: 2, Name: "Tala", Withdrawn: false},
+	{Code: "XAF", Numeric: "950", Exponent: 0, Name: "CFA Franc BEAC", Withdrawn: false},
+	{Code: "XAG", Numeric: "961", Exponent: NoMinorUnits, Name: "Silver", Withdrawn: false},
+	{Code: "XAU", Numeric: "959", Exponent: NoMinorUnits, Name: "Gold", Withdrawn: false},
+	{Code: "XBA", Numeric: "955", Exponent: NoMinorUnits, Name: "Bond Markets Unit European Composite Unit (EURCO)", Withdrawn: false},
+	{Code: "XBB", Numeric: "956", Exponent: NoMinorUnits, Name: "Bond Markets Unit European Monetary Unit (E.M.U.-6)", Withdrawn: false},
+	{Code: "XBC", Numeric: "957", Exponent: NoMinorUnits, Name: "Bond Markets Unit European Unit of Account 9 (E.U.A.-9)", Withdrawn: false},
+	{Code: "XBD", Numeric: "958", Exponent: NoMinorUnits, Name: "Bond Markets Unit European Unit of Account 17 (E.U.A.-17)", Withdrawn: false},
+	{Code: "XCD", Numeric: "951", Exponent: 2, Name: "East Caribbean Dollar", Withdrawn: false},
+	{Code: "XCG", Numeric: "532", Exponent: 2, Name: "Caribbean Guilder", Withdrawn: false},
+	{Code: "XDR", Numeric: "960", Exponent: NoMinorUnits, Name: "SDR (Special Drawing Right)", Withdrawn: false},
+	{Code: "XOF", Numeric: "952", Exponent: 0, Name: "CFA Franc BCEAO", Withdrawn: false},
+	{Code: "XPD", Numeric: "964", Exponent: NoMinorUnits, Name: "Palladium", Withdrawn: false},
+	{Code: "XPF", Numeric: "953", Exponent: 0, Name: "CFP Franc", Withdrawn: false},
+	{Code: "XPT", Numeric: "962", Exponent: NoMinorUnits, Name: "Platinum", Withdrawn: false},
+	{Code: "XSU", Numeric: "994", Exponent: NoMinorUnits, Name: "Sucre", Withdrawn: false},
+	{Code: "XTS", Numeric: "963", Exponent: NoMinorUnits, Name: "Codes specifically reserved for testing purposes", Withdrawn: false},
+	{Code: "XUA", Numeric: "965", Exponent: NoMinorUnits, Name: "ADB Unit of Account", Withdrawn: false},
+	{Code: "XXX", Numeric: "999", Exponent: NoMinorUnits, Name: "The codes assigned for transactions where no currency is involved", Withdrawn: false},
+	{Code: "YER", Numeric: "886", Exponent: 2, Name: "Yemeni Rial", Withdrawn: false},
+	{Code: "ZAR", Numeric: "710", Exponent: 2, Name: "Rand", Withdrawn: false},
+	{Code: "ZMW", Numeric: "967", Exponent: 2, Name: "Zambian Kwacha", Withdrawn: false},
+	{Code: "ZWG", Numeric: "924", Exponent: 2, Name: "Zimbabwe Gold", Withdrawn: false},
+	{Code: "ADP", Numeric: "020", Exponent: 0, Name: "Andorran Peseta", Withdrawn: true},
+	{Code: "AFA", Numeric: "004", Exponent: 2, Name: "Afghani", Withdrawn: true},
+	{Code: "ANG", Numeric: "532", Exponent: 2, Name: "Netherlands Antillean Guilder", Withdrawn: true},
+	{Code: "ATS", Numeric: "040", Exponent: 2, Name: "Schilling", Withdrawn: true},
+	{Code: "AZM", Numeric: "031", Exponent: 2, Name: "Azerbaijanian Manat", Withdrawn: true},
+	{Code: "BEF", Numeric: "056", Exponent: 0, Name: "Belgian Franc", Withdrawn: true},
+	{Code: "BGL", Numeric: "100", Exponent: 2, Name: "Lev", Withdrawn: true},
+	{Code: "BYR", Numeric: "974", Exponent: 0, Name: "Belarusian Ruble", Withdrawn: true},
+	{Code: "CSD", Numeric: "891", Exponent: 2, Name: "Serbian Dinar", Withdrawn: true},
+	{Code: "CYP", Numeric: "196", Exponent: 2, Name: "Cyprus Pound", Withdrawn: true},
+	{Code: "DEM", Numeric: "276", Exponent: 2, Name: "Deutsche Mark", Withdrawn: true},
+	{Code: "EEK", Numeric: "233", Exponent: 2, Name: "Kroon", Withdrawn: true},
+	{Code: "ESP", Numeric: "724", Exponent: 0, Name: "Spanish Peseta", Withdrawn: true},
+	{Code: "FIM", Numeric: "246", Exponent: 2, Name: "Markka", Withdrawn: true},
+	{Code: "FRF", Numeric: "250", Exponent: 2, Name: "French Franc", Withdrawn: true},
+	{Code: "GHC", Numeric: "288", Exponent: 2, Name: "Cedi", Withdrawn: true},
+	{Code: "GRD", Numeric: "300", Exponent: 0, Name: "Drachma", Withdrawn: true},
+	{Code: "HRK", Numeric: "191", Exponent: 2, Name: "Kuna", Withdrawn: true},
+	{Code: "IEP", Numeric: "372", Exponent: 2, Name: "Irish Pound", Withdrawn: true},
+	{Code: "ITL", Numeric: "380", Exponent: 0, Name: "Italian Lira", Withdrawn: true},
+	{Code: "LTL", Numeric: "440", Exponent: 2, Name: "Lithuanian Litas", Withdrawn: true},
+	{Code: "LUF", Numeric: "442", Exponent: 0, Name: "Luxembourg Franc", Withdrawn: true},
+	{Code: "LVL", Numeric: "428", Exponent: 2, Name: "Latvian Lats", Withdrawn: true},
+	{Code: "MGF", Numeric: "450", Exponent: 0, Name: "Malagasy Franc", Withdrawn: true},
+	{Code: "MRO", Numeric: "478", Exponent: 2, Name: "Ouguiya", Withdrawn: true},
+	{Code: "MTL", Numeric: "470", Exponent: 2, Name: "Maltese Lira", Withdrawn: true},
+	{Code: "MZM", Numeric: "508", Exponent: 2, Name: "Mozambique Metical", Withdrawn: true},
+	{Code: "NLG", Numeric: "528", Exponent: 2, Name: "Netherlands Guilder", Withdrawn: true},
+	{Code: "PTE", Numeric: "620", Exponent: 0, Name: "Portuguese Escudo", Withdrawn: true},
+	{Code: "ROL", Numeric: "642", Exponent: 2, Name: "Leu", Withdrawn: true},
+	{Code: "RUR", Numeric: "810", Exponent: 2, Name: "Russian Ruble", Withdrawn: true},
+	{Code: "SDD", Numeric: "736", Exponent: 2, Name: "Sudanese Dinar", Withdrawn: true},
+	{Code: "SIT", Numeric: "705", Exponent: 2, Name: "Tolar", Withdrawn: true},
+	{Code: "SKK", Numeric: "703", Exponent: 2, Name: "Slovak Koruna", Withdrawn: true},
+	{Code: "SLL", Numeric: "694", Exponent: 2, Name: "Leone", Withdrawn: true},
+	{Code: "SRG", Numeric: "740", Exponent: 2, Name: "Surinam Guilder", Withdrawn: true},
+	{Code: "STD", Numeric: "678", Exponent: 2, Name: "Dobra", Withdrawn: true},
+	{Code: "TMM", Numeric: "795", Exponent: 2, Name: "Turkmenistan Manat", Withdrawn: true},
+	{Code: "TRL", Numeric: "792", Exponent: 0, Name: "Old Turkish Lira", Withdrawn: true},
+	{Code: "VEB", Numeric: "862", Exponent: 2, Name: "Bolivar", Withdrawn: true},
+	{Code: "VEF", Numeric: "937", Exponent: 2, Name: "Bolivar", Withdrawn: true},
+	{Code: "XEU", Numeric: "954", Exponent: NoMinorUnits, Name: "European Currency Unit (E.C.U)", Withdrawn: true},
+	{Code: "YUM", Numeric: "891", Exponent: 2, Name: "New Dinar", Withdrawn: true},
+	{Code: "ZMK", Numeric: "894", Exponent: 2, Name: "Zambian Kwacha", Withdrawn: true},
+	{Code: "ZWD", Numeric: "716", Exponent: 2, Name: "Zimbabwe Dollar", Withdrawn: true},
+	{Code: "ZWL", Numeric: "932", Exponent: 2, Name: "Zimbabwe Dollar", Withdrawn: true},
+	{Code: "ZWN", Numeric: "942", Exponent: 2, Name: "Zimbabwe Dollar", Withdrawn: true},
+	{Code: "ZWR", Numeric: "935", Exponent: 2, Name: "Zimbabwe Dollar", Withdrawn: true},
+}
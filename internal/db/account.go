@@ -0,0 +1,241 @@
+// Package db implements the database operations for the transaction service.
+// This file contains accounts and the double-entry ledger their balances are derived from.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/abadojack/gapstack/internal/currency"
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// CreateAccount opens a new account.
+// Returns ErrDuplicate if an account with the same ID already exists.
+func (db *DBImpl) CreateAccount(ctx context.Context, account models.Account) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return insertAccount(ctx, db.DB, account)
+}
+
+// insertAccount inserts an account, leaving created_at to the column default.
+func insertAccount(ctx context.Context, q querier, account models.Account) error {
+	query := "INSERT INTO accounts(id, currency, allow_overdraft) VALUES (?, ?, ?)"
+
+	_, err := q.ExecContext(ctx, query, account.ID, account.Currency, account.AllowOverdraft)
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return fmt.Errorf("%w: account %s already exists", ErrDuplicate, account.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// GetAccount retrieves a single account by its ID.
+// Returns ErrNotFound if no account has the given ID.
+func (db *DBImpl) GetAccount(ctx context.Context, id string) (*models.Account, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectAccount(ctx, db.DB, id)
+}
+
+// selectAccount selects a single account by its ID, returning ErrNotFound if there is none.
+func selectAccount(ctx context.Context, q querier, id string) (*models.Account, error) {
+	query := "SELECT id, currency, allow_overdraft, created_at FROM accounts WHERE id = ?"
+
+	var account models.Account
+	err := q.QueryRowContext(ctx, query, id).Scan(&account.ID, &account.Currency, &account.AllowOverdraft, &account.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: account %s", ErrNotFound, id)
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetAccountBalance derives an account's balance by summing its ledger entries.
+// Returns ErrNotFound if no account has the given ID.
+func (db *DBImpl) GetAccountBalance(ctx context.Context, id string) (*models.Balance, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectBalance(ctx, db.DB, id)
+}
+
+// selectBalance selects an account and derives its balance from the ledger.
+func selectBalance(ctx context.Context, q querier, id string) (*models.Balance, error) {
+	account, err := selectAccount(ctx, q, id)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := accountBalance(ctx, q, account.ID, account.Currency)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Balance{AccountID: account.ID, Currency: account.Currency, Amount: amount}, nil
+}
+
+// GetLedgerEntries retrieves up to limit of an account's ledger entries with IDs greater than afterID.
+// Entries are returned in posting order, so the last entry's ID is the cursor for the following page.
+// Returns ErrNotFound if no account has the given ID.
+func (db *DBImpl) GetLedgerEntries(ctx context.Context, accountID string, afterID int64, limit int) ([]models.LedgerEntry, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectLedgerEntries(ctx, db.DB, accountID, afterID, limit)
+}
+
+// selectLedgerEntries selects a page of an account's ledger entries, returning ErrNotFound if there is no such account.
+func selectLedgerEntries(ctx context.Context, q querier, accountID string, afterID int64, limit int) ([]models.LedgerEntry, error) {
+	if _, err := selectAccount(ctx, q, accountID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, account_id, transaction_id, direction, amount, currency, created_at
+		FROM ledger_entries
+		WHERE account_id = ? AND id > ?
+		ORDER BY id ASC
+		LIMIT ?
+	`
+	rows, err := q.QueryContext(ctx, query, accountID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []models.LedgerEntry
+	for rows.Next() {
+		var entry models.LedgerEntry
+		err := rows.Scan(&entry.ID, &entry.AccountID, &entry.TransactionID, &entry.Direction, &entry.Amount, &entry.Currency, &entry.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Amount, err = currencyAmount(entry.Amount, entry.Currency); err != nil {
+			return nil, fmt.Errorf("ledger entry %d: %w", entry.ID, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// openLedgerAccounts opens, in the entries' currency, any account named by the entries that does not exist yet.
+// Currency exchange accounts hold the service's own position and are opened with overdraft allowed.
+// conflictClause is the dialect's suffix that turns the insert into a no-op for existing accounts.
+func openLedgerAccounts(ctx context.Context, q querier, entries []models.LedgerEntry, conflictClause string) error {
+	var values []string
+	var args []interface{}
+	for _, entry := range entries {
+		values = append(values, "(?, ?, ?)")
+		args = append(args, entry.AccountID, entry.Currency, models.IsExchangeAccount(entry.AccountID))
+	}
+	query := "INSERT INTO accounts(id, currency, allow_overdraft) VALUES " + strings.Join(values, ", ") + " " + conflictClause
+	_, err := q.ExecContext(ctx, query, args...)
+	return err
+}
+
+// postLedgerEntries writes a balanced set of entries inside an open SQL transaction.
+// The accounts must already exist; they are locked in ID order so that concurrent postings
+// against the same accounts serialize without deadlocking.
+// A debit that would take an account without overdraft below zero fails with ErrInsufficientFunds.
+func postLedgerEntries(ctx context.Context, q querier, entries []models.LedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// Lock the accounts so their balances cannot change until the postings commit
+	ids := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.AccountID
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	query := "SELECT id, currency, allow_overdraft FROM accounts WHERE id IN (" + placeholders + ") ORDER BY id FOR UPDATE"
+	rows, err := q.QueryContext(ctx, query, ids...)
+	if err != nil {
+		return err
+	}
+	accounts := make(map[string]models.Account, len(entries))
+	for rows.Next() {
+		var account models.Account
+		if err := rows.Scan(&account.ID, &account.Currency, &account.AllowOverdraft); err != nil {
+			rows.Close()
+			return err
+		}
+		accounts[account.ID] = account
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		account, ok := accounts[entry.AccountID]
+		if !ok {
+			return fmt.Errorf("%w: account %s", ErrNotFound, entry.AccountID)
+		}
+		if !strings.EqualFold(account.Currency, entry.Currency) {
+			return fmt.Errorf("%w: account %s holds %s, not %s", ErrCurrencyMismatch, account.ID, account.Currency, entry.Currency)
+		}
+		if entry.Direction != models.DirectionDebit || account.AllowOverdraft {
+			continue
+		}
+
+		balance, err := accountBalance(ctx, q, account.ID, account.Currency)
+		if err != nil {
+			return err
+		}
+		if balance.Cmp(entry.Amount) < 0 {
+			return fmt.Errorf("%w: account %s has %s %s, needs %s", ErrInsufficientFunds, account.ID, balance, account.Currency, entry.Amount)
+		}
+	}
+
+	var values []string
+	var args []interface{}
+	for _, entry := range entries {
+		values = append(values, "(?, ?, ?, ?, ?)")
+		args = append(args, entry.TransactionID, entry.AccountID, entry.Direction, entry.Amount, entry.Currency)
+	}
+	query = "INSERT INTO ledger_entries(transaction_id, account_id, direction, amount, currency) VALUES " + strings.Join(values, ", ")
+	_, err = q.ExecContext(ctx, query, args...)
+	return err
+}
+
+// accountBalance sums an account's credits minus its debits, in the minor units of its currency.
+func accountBalance(ctx context.Context, q querier, accountID, currency string) (models.Amount, error) {
+	query := `
+		SELECT COALESCE(SUM(CASE WHEN direction = 'credit' THEN amount ELSE -amount END), 0)
+		FROM ledger_entries
+		WHERE account_id = ?
+	`
+	var balance models.Amount
+	if err := q.QueryRowContext(ctx, query, accountID).Scan(&balance); err != nil {
+		return models.Amount{}, err
+	}
+	amount, err := currencyAmount(balance, currency)
+	if err != nil {
+		return models.Amount{}, fmt.Errorf("account %s balance: %w", accountID, err)
+	}
+	return amount, nil
+}
+
+// currencyAmount normalizes an amount read from a DECIMAL column to the minor units of its currency.
+// Amounts in currencies without a known exponent are returned unchanged.
+func currencyAmount(amount models.Amount, code string) (models.Amount, error) {
+	exponent, ok := currency.Exponent(strings.ToUpper(code))
+	if !ok {
+		return amount, nil
+	}
+	return amount.Rescale(exponent)
+}
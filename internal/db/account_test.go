@@ -0,0 +1,254 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateAccount(t *testing.T) {
+	t.Run("successful creation", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectExec("INSERT INTO accounts\\(id, currency, allow_overdraft\\) VALUES \\(\\?, \\?, \\?\\)").
+			WithArgs("treasury", "USD", true).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err = mockDB.CreateAccount(context.Background(), models.Account{ID: "treasury", Currency: "USD", AllowOverdraft: true})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("duplicate account", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectExec("INSERT INTO accounts").
+			WillReturnError(&mysql.MySQLError{Number: mysqlErrDupEntry, Message: "Duplicate entry 'alice' for key 'PRIMARY'"})
+
+		err = mockDB.CreateAccount(context.Background(), models.Account{ID: "alice", Currency: "USD"})
+		assert.ErrorIs(t, err, ErrDuplicate)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetAccountBalance(t *testing.T) {
+	accountQuery := "SELECT id, currency, allow_overdraft, created_at FROM accounts WHERE id = \\?"
+	balanceQuery := "SELECT COALESCE\\(SUM\\(CASE WHEN direction = 'credit' THEN amount ELSE -amount END\\), 0\\)"
+
+	t.Run("sums the ledger", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectQuery(accountQuery).
+			WithArgs("alice").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "currency", "allow_overdraft", "created_at"}).
+				AddRow("alice", "USD", false, time.Time{}))
+		mock.ExpectQuery(balanceQuery).
+			WithArgs("alice").
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("42.5000"))
+
+		balance, err := mockDB.GetAccountBalance(context.Background(), "alice")
+		require.NoError(t, err)
+		assert.Equal(t, "alice", balance.AccountID)
+		assert.Equal(t, "42.50", balance.Amount.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("account not found", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectQuery(accountQuery).
+			WithArgs("nobody").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "currency", "allow_overdraft", "created_at"}))
+
+		_, err = mockDB.GetAccountBalance(context.Background(), "nobody")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetLedgerEntries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mockDB := &DBImpl{DB: db}
+
+	mock.ExpectQuery("SELECT id, currency, allow_overdraft, created_at FROM accounts WHERE id = \\?").
+		WithArgs("alice").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "currency", "allow_overdraft", "created_at"}).
+			AddRow("alice", "USD", false, time.Time{}))
+	mock.ExpectQuery("FROM ledger_entries\\s+WHERE account_id = \\? AND id > \\?\\s+ORDER BY id ASC\\s+LIMIT \\?").
+		WithArgs("alice", int64(10), 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "account_id", "transaction_id", "direction", "amount", "currency", "created_at"}).
+			AddRow(11, "alice", "txn-1", models.DirectionCredit, "100.0000", "USD", time.Time{}).
+			AddRow(12, "alice", "txn-2", models.DirectionDebit, "25.5000", "USD", time.Time{}))
+
+	entries, err := mockDB.GetLedgerEntries(context.Background(), "alice", 10, 5)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, int64(11), entries[0].ID)
+	assert.Equal(t, "100.00", entries[0].Amount.String())
+	assert.Equal(t, models.DirectionDebit, entries[1].Direction)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateTransaction_PostsToLedger(t *testing.T) {
+	statusQuery := "SELECT status FROM transactions WHERE id = \\? FOR UPDATE"
+	updateQuery := "UPDATE transactions SET status = \\? WHERE id = \\?"
+	selectQuery := "SELECT id, amount, currency, sender, receiver, status, failure_reason, screening_decision, screening_screener, screening_reason, target_currency, target_amount, fx_rate, quote_id, created_at FROM transactions WHERE id = \\?"
+	openQuery := "INSERT INTO accounts\\(id, currency, allow_overdraft\\) VALUES \\(\\?, \\?, \\?\\), \\(\\?, \\?, \\?\\) ON DUPLICATE KEY UPDATE id = id"
+	lockQuery := "SELECT id, currency, allow_overdraft FROM accounts WHERE id IN \\(\\?, \\?\\) ORDER BY id FOR UPDATE"
+	balanceQuery := "FROM ledger_entries\\s+WHERE account_id = \\?"
+	postQuery := "INSERT INTO ledger_entries\\(transaction_id, account_id, direction, amount, currency\\) VALUES " +
+		"\\(\\?, \\?, \\?, \\?, \\?\\), \\(\\?, \\?, \\?, \\?, \\?\\)"
+	eventQuery := "INSERT INTO transaction_events"
+	complete := models.StatusChange{Status: models.StatusCompleted, Actor: "api"}
+
+	transactionRow := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "failure_reason", "screening_decision", "screening_screener", "screening_reason", "target_currency", "target_amount", "fx_rate", "quote_id", "created_at"}).
+			AddRow("txn-1", "10.0000", "USD", "alice", "bob", models.StatusCompleted, nil, nil, nil, nil, nil, nil, nil, nil, time.Time{})
+	}
+	accountRows := func(aliceCurrency string, aliceOverdraft bool) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "currency", "allow_overdraft"}).
+			AddRow("alice", aliceCurrency, aliceOverdraft).
+			AddRow("bob", "USD", false)
+	}
+
+	t.Run("completion debits sender and credits receiver", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		amount := models.MustParseAmount("10.00")
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(statusQuery).WithArgs("txn-1").
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.StatusProcessing))
+		mock.ExpectExec(updateQuery).
+			WithArgs(models.StatusCompleted, "txn-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(selectQuery).WithArgs("txn-1").WillReturnRows(transactionRow())
+		mock.ExpectExec(openQuery).WithArgs("alice", "USD", false, "bob", "USD", false).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(lockQuery).WithArgs("alice", "bob").WillReturnRows(accountRows("USD", false))
+		mock.ExpectQuery(balanceQuery).WithArgs("alice").
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("10.0000"))
+		mock.ExpectExec(postQuery).
+			WithArgs("txn-1", "alice", models.DirectionDebit, amount, "USD", "txn-1", "bob", models.DirectionCredit, amount, "USD").
+			WillReturnResult(sqlmock.NewResult(2, 2))
+		mock.ExpectExec(eventQuery).
+			WithArgs("txn-1", models.StatusProcessing, models.StatusCompleted, nil, "api").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectOutboxEvent(mock, mysqlDialect, models.EventTransactionStatusChanged, "txn-1")
+		mock.ExpectCommit()
+
+		err = mockDB.UpdateTransaction(context.Background(), "txn-1", complete)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("overdraft accounts skip the balance check", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(statusQuery).WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.StatusPending))
+		mock.ExpectExec(updateQuery).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(selectQuery).WithArgs("txn-1").WillReturnRows(transactionRow())
+		mock.ExpectExec(openQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(lockQuery).WillReturnRows(accountRows("USD", true))
+		mock.ExpectExec(postQuery).WillReturnResult(sqlmock.NewResult(2, 2))
+		mock.ExpectExec(eventQuery).WillReturnResult(sqlmock.NewResult(1, 1))
+		expectOutboxEvent(mock, mysqlDialect, models.EventTransactionStatusChanged, "txn-1")
+		mock.ExpectCommit()
+
+		err = mockDB.UpdateTransaction(context.Background(), "txn-1", complete)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("overdraw is rejected and rolled back", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(statusQuery).WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.StatusPending))
+		mock.ExpectExec(updateQuery).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(selectQuery).WithArgs("txn-1").WillReturnRows(transactionRow())
+		mock.ExpectExec(openQuery).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(lockQuery).WillReturnRows(accountRows("USD", false))
+		mock.ExpectQuery(balanceQuery).WithArgs("alice").
+			WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow("9.9900"))
+		mock.ExpectRollback()
+
+		err = mockDB.UpdateTransaction(context.Background(), "txn-1", complete)
+		assert.ErrorIs(t, err, ErrInsufficientFunds)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("currency mismatch is rejected", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(statusQuery).WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.StatusPending))
+		mock.ExpectExec(updateQuery).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(selectQuery).WithArgs("txn-1").WillReturnRows(transactionRow())
+		mock.ExpectExec(openQuery).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(lockQuery).WillReturnRows(accountRows("EUR", true))
+		mock.ExpectRollback()
+
+		err = mockDB.UpdateTransaction(context.Background(), "txn-1", complete)
+		assert.ErrorIs(t, err, ErrCurrencyMismatch)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("illegal transition posts nothing", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(statusQuery).
+			WithArgs("txn-1").
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.StatusFailed))
+		mock.ExpectRollback()
+
+		err = mockDB.UpdateTransaction(context.Background(), "txn-1", models.StatusChange{Status: models.StatusReversed, Actor: "api"})
+		assert.ErrorIs(t, err, ErrConflict)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -1,5 +1,5 @@
 // Package db provides database connectivity and configuration management.
-// It handles MySQL connections, environment variable loading, and connection pooling.
+// It handles MySQL and PostgreSQL connections, environment variable loading, and connection pooling.
 package db
 
 import (
@@ -9,6 +9,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/abadojack/gapstack/internal/models"
@@ -18,15 +19,80 @@ import (
 
 // DB defines the interface for database operations.
 // This interface allows for easy testing by providing mock implementations.
+// Every operation accepts a context so that client disconnects and server shutdown cancel in-flight queries.
 type DB interface {
 	// CreateTransaction inserts a new transaction into the database
-	CreateTransaction(transaction models.Transaction) error
-	// UpdateTransaction updates the status of an existing transaction
-	UpdateTransaction(id string, status models.Status) error
-	// GetAllTransactions retrieves a paginated list of all transactions
-	GetAllTransactions(limit, offset int) ([]models.Transaction, error)
+	CreateTransaction(ctx context.Context, transaction models.Transaction) error
+	// CreateTransactions inserts a batch of new transactions, either all of them or none
+	CreateTransactions(ctx context.Context, transactions []models.Transaction) error
+	// UpdateTransaction moves an existing transaction to a new status and records the change in its history
+	UpdateTransaction(ctx context.Context, id string, change models.StatusChange) error
+	// GetTransactionHistory retrieves every status change of a transaction, oldest first
+	GetTransactionHistory(ctx context.Context, id string) ([]models.TransactionEvent, error)
+	// GetAllTransactions retrieves a filtered, offset-paginated list of transactions
+	GetAllTransactions(ctx context.Context, filter TransactionFilter, limit, offset int) ([]models.Transaction, error)
+	// GetTransactionsAfter retrieves the page of filtered transactions adjacent to a keyset cursor
+	GetTransactionsAfter(ctx context.Context, filter TransactionFilter, cursor *Cursor, limit int) ([]models.Transaction, error)
+	// EachTransaction calls a function with every transaction matching a filter, reading them as it goes
+	EachTransaction(ctx context.Context, filter TransactionFilter, fn func(models.Transaction) error) error
+	// CountTransactions returns the number of transactions matching a filter
+	CountTransactions(ctx context.Context, filter TransactionFilter) (int, error)
 	// GetTransaction retrieves a single transaction by its ID
-	GetTransaction(id string) (*models.Transaction, error)
+	GetTransaction(ctx context.Context, id string) (*models.Transaction, error)
+	// GetIdempotencyRecord retrieves an unexpired idempotency record by its key
+	GetIdempotencyRecord(ctx context.Context, key string) (*models.IdempotencyRecord, error)
+	// ReserveIdempotencyKey claims an idempotency key as pending for a request that is about to run
+	ReserveIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) error
+	// ReleaseIdempotencyKey deletes a pending reservation so that the key can be used again
+	ReleaseIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) error
+	// CreateIdempotentTransaction inserts a new transaction and completes its reserved idempotency key atomically
+	CreateIdempotentTransaction(ctx context.Context, transaction models.Transaction, record models.IdempotencyRecord) error
+	// CreateAccount opens a new account
+	CreateAccount(ctx context.Context, account models.Account) error
+	// GetAccount retrieves a single account by its ID
+	GetAccount(ctx context.Context, id string) (*models.Account, error)
+	// GetAccountBalance derives an account's balance from its ledger entries
+	GetAccountBalance(ctx context.Context, id string) (*models.Balance, error)
+	// GetLedgerEntries retrieves an account's ledger entries with IDs greater than afterID, oldest first
+	GetLedgerEntries(ctx context.Context, accountID string, afterID int64, limit int) ([]models.LedgerEntry, error)
+	// CreateWebhookEndpoint registers an endpoint to receive outbox events
+	CreateWebhookEndpoint(ctx context.Context, endpoint models.WebhookEndpoint) error
+	// GetWebhookEndpoint retrieves a single webhook endpoint by its ID
+	GetWebhookEndpoint(ctx context.Context, id string) (*models.WebhookEndpoint, error)
+	// ListWebhookEndpoints retrieves every webhook endpoint in registration order
+	ListWebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error)
+	// UpdateWebhookEndpoint replaces a webhook endpoint's URL and enabled flag
+	UpdateWebhookEndpoint(ctx context.Context, endpoint models.WebhookEndpoint) error
+	// DeleteWebhookEndpoint removes a webhook endpoint and its deliveries
+	DeleteWebhookEndpoint(ctx context.Context, id string) error
+	// ListWebhookDeliveries retrieves an endpoint's deliveries with IDs greater than afterID, oldest first
+	ListWebhookDeliveries(ctx context.Context, endpointID string, status models.DeliveryStatus, afterID int64, limit int) ([]models.WebhookDelivery, error)
+	// RedeliverWebhookDelivery queues a delivery to be attempted again straight away
+	RedeliverWebhookDelivery(ctx context.Context, endpointID string, deliveryID int64) error
+	// ClaimWebhookDeliveries leases a batch of due webhook deliveries to the caller
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.PendingDelivery, error)
+	// RecordDeliveryAttempt stores the outcome of an attempt to deliver a claimed webhook
+	RecordDeliveryAttempt(ctx context.Context, deliveryID int64, attempt models.DeliveryAttempt) error
+	// ListOutboxEvents retrieves a page of transaction events in the order they were written
+	ListOutboxEvents(ctx context.Context, afterID int64, limit int) ([]models.OutboxEvent, error)
+	// LastOutboxEventID returns the ID of the latest transaction event
+	LastOutboxEventID(ctx context.Context) (int64, error)
+	// GetSpendingTotal counts and sums a sender's transactions in a currency created since a point in time
+	GetSpendingTotal(ctx context.Context, sender, currency string, since time.Time) (*models.SpendingTotal, error)
+	// GetSenderLimits retrieves a sender's overrides of the default spending limits
+	GetSenderLimits(ctx context.Context, sender string) ([]models.SpendingLimit, error)
+	// SetSenderLimits replaces a sender's overrides of the default spending limits
+	SetSenderLimits(ctx context.Context, sender string, limits []models.SpendingLimit) error
+	// GetSenderTotals counts and sums each sender's completed transactions per currency
+	GetSenderTotals(ctx context.Context, currency string) ([]models.SenderTotal, error)
+	// GetTopSenders returns the senders with the largest completed volume since a point in time, per currency
+	GetTopSenders(ctx context.Context, currency string, since time.Time, limit int) ([]models.SenderTotal, error)
+	// GetFailureHeavySenders returns the senders with more than a threshold of failed transactions
+	GetFailureHeavySenders(ctx context.Context, threshold int) ([]models.SenderFailures, error)
+	// CreateQuote stores a newly issued exchange rate quote
+	CreateQuote(ctx context.Context, quote models.Quote) error
+	// GetQuote retrieves a single exchange rate quote by its ID
+	GetQuote(ctx context.Context, id string) (*models.Quote, error)
 	// Close closes the database connection
 	Close() error
 }
@@ -35,15 +101,97 @@ type DB interface {
 // It wraps a sql.DB instance and provides transaction-specific operations.
 type DBImpl struct {
 	DB *sql.DB
+	// QueryTimeout bounds every individual query; zero means queries are only bounded by the caller's context
+	QueryTimeout time.Duration
 }
 
 // Ensure DBImpl implements the DB interface at compile time
 var _ DB = (*DBImpl)(nil)
 
+// querier is the subset of *sql.DB and *sql.Tx used by queries that may run inside a SQL transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// dialect describes how a SQL server differs from the MySQL syntax the shared queries are written in.
+type dialect struct {
+	// bind adapts a connection or transaction to the server's placeholder syntax
+	bind func(querier) querier
+	// ignoreDuplicateAccount is appended to an accounts insert so that existing accounts are left alone
+	ignoreDuplicateAccount string
+	// lastInsertID selects the ID generated by the session's latest insert into an identity column
+	lastInsertID string
+	// migrations is the directory of the embedded migrations for this server
+	migrations string
+	// createMigrationsTable creates the schema_migrations table if it does not exist
+	createMigrationsTable string
+	// lockMigrations blocks until it holds a session lock that serializes migrators, then returns 1;
+	// unlockMigrations releases it
+	lockMigrations, unlockMigrations string
+	// transactionalDDL reports whether schema changes can be rolled back with a SQL transaction
+	transactionalDDL bool
+}
+
+// mysqlDialect runs the shared queries unchanged.
+var mysqlDialect = dialect{
+	bind:                   func(q querier) querier { return q },
+	ignoreDuplicateAccount: "ON DUPLICATE KEY UPDATE id = id",
+	lastInsertID:           "SELECT LAST_INSERT_ID()",
+	migrations:             "migrations/mysql",
+	createMigrationsTable: `
+		CREATE TABLE IF NOT EXISTS schema_migrations
+		(
+			version    BIGINT       PRIMARY KEY,
+			name       VARCHAR(255) NOT NULL,
+			checksum   CHAR(64)     NOT NULL,
+			applied_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+		)
+	`,
+	lockMigrations:   "SELECT GET_LOCK('gapstack_schema_migrations', 60)",
+	unlockMigrations: "SELECT RELEASE_LOCK('gapstack_schema_migrations')",
+}
+
+// Supported values of the STORAGE environment variable.
+const (
+	// StorageMySQL stores data in MySQL (the default)
+	StorageMySQL = "mysql"
+	// StoragePostgres stores data in PostgreSQL
+	StoragePostgres = "postgres"
+	// StorageMemory keeps data in process memory; it is lost on exit and meant for local demos
+	StorageMemory = "memory"
+)
+
+// Open returns the storage backend selected by the STORAGE environment variable.
+// Variables from a .env file are loaded first, so STORAGE may be set there too.
+// If DB_AUTO_MIGRATE is true, pending schema migrations are applied before a SQL backend is returned.
+func Open() (DB, error) {
+	loadEnvFile()
+
+	switch storage := getEnv("STORAGE", StorageMySQL); storage {
+	case StorageMySQL, StoragePostgres:
+		if getEnvAsBool("DB_AUTO_MIGRATE", false) {
+			if err := migrateOnOpen(context.Background()); err != nil {
+				return nil, fmt.Errorf("failed to migrate database: %w", err)
+			}
+		}
+		if storage == StoragePostgres {
+			return NewPostgresDB()
+		}
+		return NewDB()
+	case StorageMemory:
+		log.Println("Warning: using in-memory storage; all data is lost on exit")
+		return NewMemoryDB(), nil
+	default:
+		return nil, fmt.Errorf("unknown STORAGE %q: must be %q, %q or %q", storage, StorageMySQL, StoragePostgres, StorageMemory)
+	}
+}
+
 // NewDB creates a new database connection and returns the DB interface.
 // It loads configuration from environment variables and establishes a connection to MySQL.
 func NewDB() (DB, error) {
-	config, err := loadConfig()
+	config, err := loadConfig("3306")
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
@@ -53,7 +201,7 @@ func NewDB() (DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	return &DBImpl{DB: sqlDB}, nil
+	return &DBImpl{DB: sqlDB, QueryTimeout: config.QueryTimeout}, nil
 }
 
 // NewDBWithInstance creates a DB instance with an existing sql.DB.
@@ -64,33 +212,33 @@ func NewDBWithInstance(sqlDB *sql.DB) DB {
 
 // Config holds database connection configuration parameters.
 type Config struct {
-	// DBUser is the MySQL username
+	// DBUser is the database username
 	DBUser string
-	// DBPassword is the MySQL password
+	// DBPassword is the database password
 	DBPassword string
-	// DBHost is the MySQL host address
+	// DBHost is the database host address
 	DBHost string
-	// DBPort is the MySQL port number
+	// DBPort is the database port number
 	DBPort string
-	// DBName is the MySQL database name
+	// DBName is the database name
 	DBName string
+	// SSLMode is the PostgreSQL sslmode connection parameter; it is ignored for MySQL
+	SSLMode string
 	// MaxOpenConns is the maximum number of open connections to the database
 	MaxOpenConns int
 	// MaxIdleConns is the maximum number of idle connections in the pool
 	MaxIdleConns int
 	// ConnMaxLifetime is the maximum amount of time a connection may be reused
 	ConnMaxLifetime time.Duration
+	// QueryTimeout is the maximum amount of time a single query may run
+	QueryTimeout time.Duration
 }
 
 // loadConfig loads database configuration from environment variables.
 // It first tries to load from a .env file, then falls back to system environment variables.
-func loadConfig() (*Config, error) {
-	// Load environment variables from .env file
-	err := godotenv.Load()
-	if err != nil {
-		log.Printf("Warning: No .env file found or error loading .env: %v", err)
-		log.Println("Using system environment variables only")
-	}
+// defaultPort is the server's standard port, used when DB_PORT is unset.
+func loadConfig(defaultPort string) (*Config, error) {
+	loadEnvFile()
 
 	// Required environment variables
 	dbUser := os.Getenv("DB_USER")
@@ -105,7 +253,7 @@ func loadConfig() (*Config, error) {
 
 	// Optional environment variables with defaults
 	dbHost := getEnv("DB_HOST", "localhost")
-	dbPort := getEnv("DB_PORT", "3306")
+	dbPort := getEnv("DB_PORT", defaultPort)
 	dbName := getEnv("DB_NAME", "transactions_db")
 
 	return &Config{
@@ -114,12 +262,28 @@ func loadConfig() (*Config, error) {
 		DBHost:          dbHost,
 		DBPort:          dbPort,
 		DBName:          dbName,
+		SSLMode:         getEnv("DB_SSLMODE", "disable"),
 		MaxOpenConns:    getEnvAsInt("DB_MAX_OPEN_CONNS", 25),
 		MaxIdleConns:    getEnvAsInt("DB_MAX_IDLE_CONNS", 25),
 		ConnMaxLifetime: time.Duration(getEnvAsInt("DB_CONN_MAX_LIFETIME_MINUTES", 5)) * time.Minute,
+		QueryTimeout:    time.Duration(getEnvAsInt("DB_QUERY_TIMEOUT_SECONDS", 5)) * time.Second,
 	}, nil
 }
 
+// envFileOnce ensures the .env file is only loaded, and its absence only reported, once per process.
+var envFileOnce sync.Once
+
+// loadEnvFile loads environment variables from a .env file in the working directory, if there is one.
+// Variables already set in the environment take precedence.
+func loadEnvFile() {
+	envFileOnce.Do(func() {
+		if err := godotenv.Load(); err != nil {
+			log.Printf("Warning: No .env file found or error loading .env: %v", err)
+			log.Println("Using system environment variables only")
+		}
+	})
+}
+
 // connectDB establishes a connection to the MySQL database using the provided configuration.
 // It sets up connection pooling and verifies the connection is working.
 func connectDB(config *Config) (*sql.DB, error) {
@@ -127,7 +291,12 @@ func connectDB(config *Config) (*sql.DB, error) {
 	connStr := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true&charset=utf8mb4&collation=utf8mb4_unicode_ci&timeout=5s",
 		config.DBUser, config.DBPassword, config.DBHost, config.DBPort, config.DBName)
 
-	db, err := sql.Open("mysql", connStr)
+	return openPool("mysql", connStr, config)
+}
+
+// openPool opens a connection pool for a driver, applies the pool settings and verifies the connection.
+func openPool(driver, connStr string, config *Config) (*sql.DB, error) {
+	db, err := sql.Open(driver, connStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -149,6 +318,20 @@ func connectDB(config *Config) (*sql.DB, error) {
 	return db, nil
 }
 
+// withTimeout derives a context bounded by the configured per-query timeout.
+// The returned cancel function must always be called to release resources.
+func (db *DBImpl) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withQueryTimeout(ctx, db.QueryTimeout)
+}
+
+// withQueryTimeout derives a context bounded by timeout, or only by ctx if timeout is not positive.
+func withQueryTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
 // Close closes the database connection.
 func (db *DBImpl) Close() error {
 	if db.DB != nil {
@@ -184,3 +367,20 @@ func getEnvAsInt(key string, defaultValue int) int {
 
 	return value
 }
+
+// getEnvAsBool retrieves an environment variable as a boolean with a default value.
+// If the environment variable cannot be parsed as a boolean, it returns the default value.
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		log.Printf("Warning: Invalid value for %s: %s, using default: %t", key, valueStr, defaultValue)
+		return defaultValue
+	}
+
+	return value
+}
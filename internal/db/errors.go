@@ -0,0 +1,39 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrNotFound is returned when the requested record does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is returned when a write cannot be applied because of the current state of a record,
+	// such as an illegal status transition or a concurrent modification.
+	ErrConflict = errors.New("conflict")
+	// ErrDuplicate is returned when an insert collides with an existing record's unique key.
+	ErrDuplicate = errors.New("duplicate")
+	// ErrInsufficientFunds is returned when a debit would take an account without overdraft below zero.
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrCurrencyMismatch is returned when a posting's currency differs from its account's currency.
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+const (
+	// mysqlErrDupEntry is the MySQL server error number for a duplicate unique key (ER_DUP_ENTRY).
+	mysqlErrDupEntry = 1062
+	// pqUniqueViolation is the PostgreSQL SQLSTATE for a duplicate unique key (unique_violation).
+	pqUniqueViolation = "23505"
+)
+
+// isDuplicateKeyError reports whether err is a MySQL or PostgreSQL unique key violation.
+func isDuplicateKeyError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlErrDupEntry
+	}
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pqUniqueViolation
+}
@@ -0,0 +1,62 @@
+// Package db implements the database operations for the transaction service.
+// This file contains the audit trail of transaction status changes.
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// GetTransactionHistory retrieves every status change of a transaction, oldest first.
+// Returns ErrNotFound if no transaction has the given ID.
+func (db *DBImpl) GetTransactionHistory(ctx context.Context, id string) ([]models.TransactionEvent, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectTransactionHistory(ctx, db.DB, id)
+}
+
+// insertTransactionEvent records a status change, leaving the ID and created_at to the column defaults.
+func insertTransactionEvent(ctx context.Context, q querier, event models.TransactionEvent) error {
+	query := "INSERT INTO transaction_events(transaction_id, from_status, to_status, reason, actor) VALUES (?, ?, ?, ?, ?)"
+
+	_, err := q.ExecContext(ctx, query, event.TransactionID, event.FromStatus, event.ToStatus, nullString(event.Reason), event.Actor)
+	return err
+}
+
+// selectTransactionHistory selects a transaction's events, returning ErrNotFound if there is no such transaction.
+func selectTransactionHistory(ctx context.Context, q querier, id string) ([]models.TransactionEvent, error) {
+	if _, err := selectTransaction(ctx, q, id); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, transaction_id, from_status, to_status, reason, actor, created_at
+		FROM transaction_events
+		WHERE transaction_id = ?
+		ORDER BY id ASC
+	`
+	rows, err := q.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.TransactionEvent
+	for rows.Next() {
+		var event models.TransactionEvent
+		var reason sql.NullString
+		if err := rows.Scan(&event.ID, &event.TransactionID, &event.FromStatus, &event.ToStatus, &reason, &event.Actor, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		event.Reason = reason.String
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTransactionHistory(t *testing.T) {
+	selectQuery := "SELECT id, amount, currency, sender, receiver, status, failure_reason, screening_decision, screening_screener, screening_reason, target_currency, target_amount, fx_rate, quote_id, created_at FROM transactions WHERE id = \\?"
+	historyQuery := "SELECT id, transaction_id, from_status, to_status, reason, actor, created_at\\s+FROM transaction_events\\s+WHERE transaction_id = \\?\\s+ORDER BY id ASC"
+
+	t.Run("events in order", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		createdAt := time.Now()
+
+		mock.ExpectQuery(selectQuery).WithArgs("txn-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "failure_reason", "screening_decision", "screening_screener", "screening_reason", "target_currency", "target_amount", "fx_rate", "quote_id", "created_at"}).
+				AddRow("txn-1", "10.0000", "USD", "alice", "bob", models.StatusFailed, "card declined", nil, nil, nil, nil, nil, nil, nil, createdAt))
+		mock.ExpectQuery(historyQuery).WithArgs("txn-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "transaction_id", "from_status", "to_status", "reason", "actor", "created_at"}).
+				AddRow(1, "txn-1", models.StatusPending, models.StatusProcessing, nil, "api", createdAt).
+				AddRow(2, "txn-1", models.StatusProcessing, models.StatusFailed, "card declined", "ops", createdAt))
+
+		events, err := mockDB.GetTransactionHistory(context.Background(), "txn-1")
+		require.NoError(t, err)
+		assert.Equal(t, []models.TransactionEvent{
+			{ID: 1, TransactionID: "txn-1", FromStatus: models.StatusPending, ToStatus: models.StatusProcessing, Actor: "api", CreatedAt: createdAt},
+			{ID: 2, TransactionID: "txn-1", FromStatus: models.StatusProcessing, ToStatus: models.StatusFailed, Reason: "card declined", Actor: "ops", CreatedAt: createdAt},
+		}, events)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("transaction not found", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectQuery(selectQuery).WithArgs("nope").WillReturnError(sql.ErrNoRows)
+
+		_, err = mockDB.GetTransactionHistory(context.Background(), "nope")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
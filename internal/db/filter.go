@@ -0,0 +1,156 @@
+package db
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// SortField identifies a column that transaction listings can be ordered by.
+type SortField string
+
+const (
+	// SortByCreatedAt orders transactions by creation time (the default)
+	SortByCreatedAt SortField = "created_at"
+	// SortByAmount orders transactions by amount
+	SortByAmount SortField = "amount"
+)
+
+// IsValid reports whether the field is a supported sort column.
+func (f SortField) IsValid() bool {
+	return f == SortByCreatedAt || f == SortByAmount
+}
+
+// TransactionFilter narrows and orders a transaction listing.
+// Zero-valued fields do not restrict the result; all set fields must match.
+type TransactionFilter struct {
+	// Statuses restricts results to any of the given statuses
+	Statuses []models.Status
+	// Currency restricts results to a single currency code
+	Currency string
+	// Sender restricts results to a single sender
+	Sender string
+	// Receiver restricts results to a single receiver
+	Receiver string
+	// MinAmount is the inclusive lower bound on the amount
+	MinAmount *models.Amount
+	// MaxAmount is the inclusive upper bound on the amount
+	MaxAmount *models.Amount
+	// CreatedFrom is the inclusive lower bound on the creation time
+	CreatedFrom *time.Time
+	// CreatedTo is the exclusive upper bound on the creation time
+	CreatedTo *time.Time
+	// SortBy is the column to order by; ties are always broken by ID. Defaults to SortByCreatedAt.
+	SortBy SortField
+	// Descending reverses the sort order
+	Descending bool
+}
+
+// sortColumn returns the column the filter orders by.
+func (f TransactionFilter) sortColumn() string {
+	if f.SortBy == SortByAmount {
+		return "amount"
+	}
+	return "created_at"
+}
+
+// queryBuilder accumulates SQL conditions and their arguments.
+// Column names only ever come from constants in this package; user input is always bound as an argument.
+type queryBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// where adds a condition with its placeholder arguments.
+func (b *queryBuilder) where(condition string, args ...interface{}) {
+	b.conditions = append(b.conditions, condition)
+	b.args = append(b.args, args...)
+}
+
+// clause returns the WHERE clause for all accumulated conditions, or "" if there are none.
+func (b *queryBuilder) clause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// apply adds the filter's conditions to the builder.
+func (f TransactionFilter) apply(b *queryBuilder) {
+	if len(f.Statuses) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(f.Statuses)), ", ")
+		args := make([]interface{}, len(f.Statuses))
+		for i, status := range f.Statuses {
+			args[i] = status
+		}
+		b.where("status IN ("+placeholders+")", args...)
+	}
+	if f.Currency != "" {
+		b.where("currency = ?", f.Currency)
+	}
+	if f.Sender != "" {
+		b.where("sender = ?", f.Sender)
+	}
+	if f.Receiver != "" {
+		b.where("receiver = ?", f.Receiver)
+	}
+	if f.MinAmount != nil {
+		b.where("amount >= ?", *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		b.where("amount <= ?", *f.MaxAmount)
+	}
+	if f.CreatedFrom != nil {
+		b.where("created_at >= ?", *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		b.where("created_at < ?", *f.CreatedTo)
+	}
+}
+
+// Matches reports whether a transaction satisfies every condition of the filter.
+// It is the in-memory counterpart of apply and must stay in step with it.
+func (f TransactionFilter) Matches(transaction models.Transaction) bool {
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, transaction.Status) {
+		return false
+	}
+	if f.Currency != "" && transaction.Currency != f.Currency {
+		return false
+	}
+	if f.Sender != "" && transaction.Sender != f.Sender {
+		return false
+	}
+	if f.Receiver != "" && transaction.Receiver != f.Receiver {
+		return false
+	}
+	if f.MinAmount != nil && transaction.Amount.Cmp(*f.MinAmount) < 0 {
+		return false
+	}
+	if f.MaxAmount != nil && transaction.Amount.Cmp(*f.MaxAmount) > 0 {
+		return false
+	}
+	if f.CreatedFrom != nil && transaction.CreatedAt.Before(*f.CreatedFrom) {
+		return false
+	}
+	if f.CreatedTo != nil && !transaction.CreatedAt.Before(*f.CreatedTo) {
+		return false
+	}
+	return true
+}
+
+// compare orders two transactions by the filter's sort column and then by ID, both ascending.
+// Descending is not applied; callers reverse the result when needed.
+func (f TransactionFilter) compare(a, b models.Transaction) int {
+	var order int
+	if f.SortBy == SortByAmount {
+		order = a.Amount.Cmp(b.Amount)
+	} else {
+		order = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if order != 0 {
+		return order
+	}
+	return strings.Compare(a.ID, b.ID)
+}
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransactionFilter_Apply(t *testing.T) {
+	from := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	minAmount := models.MustParseAmount("10.00")
+	maxAmount := models.MustParseAmount("500.00")
+
+	filter := TransactionFilter{
+		Statuses:    []models.Status{models.StatusPending, models.StatusFailed},
+		Currency:    "USD",
+		Sender:      "user-1",
+		Receiver:    "user-2",
+		MinAmount:   &minAmount,
+		MaxAmount:   &maxAmount,
+		CreatedFrom: &from,
+		CreatedTo:   &to,
+	}
+
+	var builder queryBuilder
+	filter.apply(&builder)
+
+	assert.Equal(t, " WHERE status IN (?, ?) AND currency = ? AND sender = ? AND receiver = ?"+
+		" AND amount >= ? AND amount <= ? AND created_at >= ? AND created_at < ?", builder.clause())
+	assert.Equal(t, []interface{}{
+		models.StatusPending, models.StatusFailed, "USD", "user-1", "user-2", minAmount, maxAmount, from, to,
+	}, builder.args)
+}
+
+func TestTransactionFilter_EmptyClause(t *testing.T) {
+	var builder queryBuilder
+	TransactionFilter{}.apply(&builder)
+
+	assert.Equal(t, "", builder.clause())
+	assert.Empty(t, builder.args)
+}
+
+func TestGetTransactionsAfter_Filtered(t *testing.T) {
+	columns := []string{"id", "amount", "currency", "sender", "receiver", "status", "failure_reason", "screening_decision", "screening_screener", "screening_reason", "target_currency", "target_amount", "fx_rate", "quote_id", "created_at"}
+
+	t.Run("descending amount sort seeks below the cursor", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		filter := TransactionFilter{Currency: "USD", SortBy: SortByAmount, Descending: true}
+		cursor := &Cursor{Amount: models.MustParseAmount("50.00"), ID: "txn-5"}
+
+		mock.ExpectQuery("FROM transactions WHERE currency = \\? AND \\(amount < \\? OR \\(amount = \\? AND id < \\?\\)\\) ORDER BY amount DESC, id DESC LIMIT \\?").
+			WithArgs("USD", "50.00", "50.00", "txn-5", 10).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("txn-4", "40.00", "USD", "user-1", "user-2", models.StatusPending, nil, nil, nil, nil, nil, nil, nil, nil, time.Time{}))
+
+		transactions, err := mockDB.GetTransactionsAfter(context.Background(), filter, cursor, 10)
+		assert.NoError(t, err)
+		assert.Len(t, transactions, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("backward page of a descending sort reads ascending", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		filter := TransactionFilter{SortBy: SortByAmount, Descending: true}
+		cursor := &Cursor{Amount: models.MustParseAmount("50.00"), ID: "txn-5", Before: true}
+
+		mock.ExpectQuery("FROM transactions WHERE \\(amount > \\? OR \\(amount = \\? AND id > \\?\\)\\) ORDER BY amount ASC, id ASC LIMIT \\?").
+			WithArgs("50.00", "50.00", "txn-5", 10).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("txn-6", "60.00", "USD", "user-1", "user-2", models.StatusPending, nil, nil, nil, nil, nil, nil, nil, nil, time.Time{}).
+				AddRow("txn-7", "70.00", "USD", "user-1", "user-2", models.StatusPending, nil, nil, nil, nil, nil, nil, nil, nil, time.Time{}))
+
+		transactions, err := mockDB.GetTransactionsAfter(context.Background(), filter, cursor, 10)
+		assert.NoError(t, err)
+		require.Len(t, transactions, 2)
+		assert.Equal(t, "txn-7", transactions[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("offset listing with filter", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		filter := TransactionFilter{Statuses: []models.Status{models.StatusCompleted}, SortBy: SortByCreatedAt, Descending: true}
+
+		mock.ExpectQuery("FROM transactions WHERE status IN \\(\\?\\) ORDER BY created_at DESC, id DESC LIMIT \\? OFFSET \\?").
+			WithArgs(models.StatusCompleted, 10, 20).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		transactions, err := mockDB.GetAllTransactions(context.Background(), filter, 10, 20)
+		assert.NoError(t, err)
+		assert.Empty(t, transactions)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCountTransactions(t *testing.T) {
+	t.Run("counts matching transactions", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		filter := TransactionFilter{Sender: "user-1", SortBy: SortByAmount, Descending: true}
+
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM transactions WHERE sender = \\?$").
+			WithArgs("user-1").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+		count, err := mockDB.CountTransactions(context.Background(), filter)
+		assert.NoError(t, err)
+		assert.Equal(t, 42, count)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM transactions").
+			WillReturnError(assert.AnError)
+
+		_, err = mockDB.CountTransactions(context.Background(), TransactionFilter{})
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,123 @@
+// Package db implements the database operations for the transaction service.
+// This file contains the storage of idempotency keys used to deduplicate client retries.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// GetIdempotencyRecord retrieves an unexpired idempotency record by its key.
+// Returns ErrNotFound if the key has never been used or its expiry window has passed.
+func (db *DBImpl) GetIdempotencyRecord(ctx context.Context, key string) (*models.IdempotencyRecord, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectIdempotencyRecord(ctx, db.DB, key)
+}
+
+// selectIdempotencyRecord selects an unexpired idempotency record, returning ErrNotFound if there is none.
+func selectIdempotencyRecord(ctx context.Context, q querier, key string) (*models.IdempotencyRecord, error) {
+	query := `
+		SELECT idempotency_key, request_hash, status, response_code, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE idempotency_key = ? AND expires_at > ?
+	`
+	row := q.QueryRowContext(ctx, query, key, time.Now())
+
+	var record models.IdempotencyRecord
+	err := row.Scan(
+		&record.Key,
+		&record.RequestHash,
+		&record.Status,
+		&record.ResponseCode,
+		&record.ResponseBody,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: idempotency key %s", ErrNotFound, key)
+		}
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// ReserveIdempotencyKey claims an idempotency key for a request that is about to run, storing it as pending
+// with the request's fingerprint until record.ExpiresAt. An expired record under the same key is replaced.
+// The reservation is identified by its key and CreatedAt, which must be a whole second since
+// the timestamp columns hold no fractions.
+// Returns ErrDuplicate if the key is held by an unexpired record, whether pending or completed.
+func (db *DBImpl) ReserveIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return reserveIdempotencyKey(ctx, db.DB, record)
+}
+
+// reserveIdempotencyKey deletes an expired record under the key and inserts a pending one in its place.
+// Two requests racing for the same key are settled by the primary key: the second insert fails.
+func reserveIdempotencyKey(ctx context.Context, q querier, record models.IdempotencyRecord) error {
+	query := "DELETE FROM idempotency_keys WHERE idempotency_key = ? AND expires_at <= ?"
+	if _, err := q.ExecContext(ctx, query, record.Key, record.CreatedAt); err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO idempotency_keys (idempotency_key, request_hash, status, response_code, response_body, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := q.ExecContext(ctx, query, record.Key, record.RequestHash, models.IdempotencyPending, 0, []byte{}, record.CreatedAt, record.ExpiresAt)
+	if isDuplicateKeyError(err) {
+		return fmt.Errorf("%w: idempotency key %s", ErrDuplicate, record.Key)
+	}
+	return err
+}
+
+// ReleaseIdempotencyKey deletes a pending reservation made by ReserveIdempotencyKey so that the key can be
+// used again straight away, as it is when a request fails before creating anything.
+// A reservation that has since been completed or replaced is left alone.
+func (db *DBImpl) ReleaseIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return releaseIdempotencyKey(ctx, db.DB, record)
+}
+
+// releaseIdempotencyKey deletes a pending reservation identified by its key and creation time.
+func releaseIdempotencyKey(ctx context.Context, q querier, record models.IdempotencyRecord) error {
+	query := "DELETE FROM idempotency_keys WHERE idempotency_key = ? AND status = ? AND created_at = ?"
+	_, err := q.ExecContext(ctx, query, record.Key, models.IdempotencyPending, record.CreatedAt)
+	return err
+}
+
+// completeIdempotencyKey stores the response of a request in its pending reservation, identified by its key
+// and creation time, and extends it to record.ExpiresAt.
+// Returns ErrNotFound if the reservation has lapsed and been released or taken over by another request.
+func completeIdempotencyKey(ctx context.Context, q querier, record models.IdempotencyRecord) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status = ?, response_code = ?, response_body = ?, expires_at = ?
+		WHERE idempotency_key = ? AND status = ? AND created_at = ?
+	`
+	result, err := q.ExecContext(ctx, query, models.IdempotencyCompleted, record.ResponseCode, record.ResponseBody, record.ExpiresAt,
+		record.Key, models.IdempotencyPending, record.CreatedAt)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return fmt.Errorf("%w: idempotency key %s is no longer reserved", ErrNotFound, record.Key)
+	}
+	return nil
+}
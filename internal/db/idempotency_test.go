@@ -0,0 +1,206 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetIdempotencyRecord(t *testing.T) {
+	t.Run("record found", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		createdAt := time.Now()
+		expected := &models.IdempotencyRecord{
+			Key:          "key-1",
+			RequestHash:  "abc123",
+			Status:       models.IdempotencyCompleted,
+			ResponseCode: 201,
+			ResponseBody: []byte(`{"id":"txn-123"}`),
+			CreatedAt:    createdAt,
+			ExpiresAt:    createdAt.Add(24 * time.Hour),
+		}
+
+		rows := sqlmock.NewRows([]string{"idempotency_key", "request_hash", "status", "response_code", "response_body", "created_at", "expires_at"}).
+			AddRow(expected.Key, expected.RequestHash, expected.Status, expected.ResponseCode, expected.ResponseBody, expected.CreatedAt, expected.ExpiresAt)
+
+		mock.ExpectQuery("SELECT (.+) FROM idempotency_keys WHERE idempotency_key = \\? AND expires_at > \\?").
+			WithArgs("key-1", sqlmock.AnyArg()).
+			WillReturnRows(rows)
+
+		record, err := mockDB.GetIdempotencyRecord(context.Background(), "key-1")
+		assert.NoError(t, err)
+		assert.Equal(t, expected, record)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("record missing or expired", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectQuery("SELECT (.+) FROM idempotency_keys").
+			WithArgs("key-1", sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+
+		record, err := mockDB.GetIdempotencyRecord(context.Background(), "key-1")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, record)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestReserveIdempotencyKey(t *testing.T) {
+	createdAt := time.Now().Truncate(time.Second)
+	record := models.IdempotencyRecord{
+		Key:         "key-1",
+		RequestHash: "abc123",
+		CreatedAt:   createdAt,
+		ExpiresAt:   createdAt.Add(time.Minute),
+	}
+
+	t.Run("successful reservation", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectExec("DELETE FROM idempotency_keys WHERE idempotency_key = \\? AND expires_at <= \\?").
+			WithArgs(record.Key, record.CreatedAt).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO idempotency_keys").
+			WithArgs(record.Key, record.RequestHash, models.IdempotencyPending, 0, []byte{}, record.CreatedAt, record.ExpiresAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err = mockDB.ReserveIdempotencyKey(context.Background(), record)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("key already held", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectExec("DELETE FROM idempotency_keys").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO idempotency_keys").
+			WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'key-1' for key 'PRIMARY'"})
+
+		err = mockDB.ReserveIdempotencyKey(context.Background(), record)
+		assert.ErrorIs(t, err, ErrDuplicate)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("reservation fails", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		expectedErr := errors.New("database error")
+		mock.ExpectExec("DELETE FROM idempotency_keys").WillReturnError(expectedErr)
+
+		err = mockDB.ReserveIdempotencyKey(context.Background(), record)
+		assert.Equal(t, expectedErr, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestReleaseIdempotencyKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mockDB := &DBImpl{DB: db}
+	record := models.IdempotencyRecord{Key: "key-1", CreatedAt: time.Now().Truncate(time.Second)}
+
+	mock.ExpectExec("DELETE FROM idempotency_keys WHERE idempotency_key = \\? AND status = \\? AND created_at = \\?").
+		WithArgs(record.Key, models.IdempotencyPending, record.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, mockDB.ReleaseIdempotencyKey(context.Background(), record))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateIdempotentTransaction(t *testing.T) {
+	transaction := models.Transaction{
+		ID:       "txn-123",
+		Amount:   models.MustParseAmount("100.50"),
+		Currency: "USD",
+		Sender:   "user-1",
+		Receiver: "user-2",
+		Status:   models.StatusPending,
+	}
+	createdAt := time.Now().Truncate(time.Second)
+	record := models.IdempotencyRecord{
+		Key:          "key-1",
+		RequestHash:  "abc123",
+		Status:       models.IdempotencyCompleted,
+		ResponseCode: 201,
+		ResponseBody: []byte(`{"id":"txn-123"}`),
+		CreatedAt:    createdAt,
+		ExpiresAt:    createdAt.Add(24 * time.Hour),
+	}
+	expectCreate := func(mock sqlmock.Sqlmock) {
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO transactions").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery("SELECT (.+) FROM transactions WHERE id = \\?").
+			WithArgs(transaction.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "failure_reason", "screening_decision", "screening_screener", "screening_reason", "target_currency", "target_amount", "fx_rate", "quote_id", "created_at"}).
+				AddRow(transaction.ID, "100.5000", transaction.Currency, transaction.Sender, transaction.Receiver, transaction.Status, nil, nil, nil, nil, nil, nil, nil, nil, time.Now()))
+		expectOutboxEvent(mock, mysqlDialect, models.EventTransactionCreated, transaction.ID)
+	}
+
+	t.Run("completes the key with the transaction", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		expectCreate(mock)
+		mock.ExpectExec("UPDATE idempotency_keys SET status = \\?, response_code = \\?, response_body = \\?, expires_at = \\? "+
+			"WHERE idempotency_key = \\? AND status = \\? AND created_at = \\?").
+			WithArgs(models.IdempotencyCompleted, record.ResponseCode, record.ResponseBody, record.ExpiresAt,
+				record.Key, models.IdempotencyPending, record.CreatedAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err = mockDB.CreateIdempotentTransaction(context.Background(), transaction, record)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("lapsed reservation rolls back", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		expectCreate(mock)
+		mock.ExpectExec("UPDATE idempotency_keys").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err = mockDB.CreateIdempotentTransaction(context.Background(), transaction, record)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,132 @@
+// Package db implements the database operations for the transaction service.
+// This file contains the spending totals that limits are checked against and the per-sender limit overrides.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// GetSpendingTotal counts and sums a sender's transactions in a currency created at or after since.
+// Failed transactions moved no money and are left out; every other status counts, including held ones.
+// currency must be an upper-case ISO 4217 code, the form transactions store it in; the comparison is exact so that
+// idx_transactions_sender_currency_created_at serves it.
+func (db *DBImpl) GetSpendingTotal(ctx context.Context, sender, currency string, since time.Time) (*models.SpendingTotal, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectSpendingTotal(ctx, db.DB, sender, currency, since)
+}
+
+// selectSpendingTotal aggregates a sender's transactions in a currency since a point in time.
+func selectSpendingTotal(ctx context.Context, q querier, sender, currency string, since time.Time) (*models.SpendingTotal, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(amount), 0)
+		FROM transactions
+		WHERE sender = ? AND currency = ? AND status <> ? AND created_at >= ?
+	`
+	var total models.SpendingTotal
+	var amount models.Amount
+	err := q.QueryRowContext(ctx, query, sender, currency, models.StatusFailed, since).Scan(&total.Count, &amount)
+	if err != nil {
+		return nil, err
+	}
+	if total.Amount, err = currencyAmount(amount, currency); err != nil {
+		return nil, err
+	}
+	return &total, nil
+}
+
+// GetSenderLimits retrieves a sender's overrides of the default spending limits, ordered by currency, then window.
+// A sender without overrides has none; this is not an error.
+func (db *DBImpl) GetSenderLimits(ctx context.Context, sender string) ([]models.SpendingLimit, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectSenderLimits(ctx, db.DB, sender)
+}
+
+// selectSenderLimits selects every limit override of a sender.
+func selectSenderLimits(ctx context.Context, q querier, sender string) ([]models.SpendingLimit, error) {
+	query := `
+		SELECT currency, window_seconds, max_count, max_amount
+		FROM sender_limits
+		WHERE sender = ?
+		ORDER BY currency ASC, window_seconds ASC
+	`
+	rows, err := q.QueryContext(ctx, query, sender)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var limits []models.SpendingLimit
+	for rows.Next() {
+		var limit models.SpendingLimit
+		var windowSeconds int64
+		var maxCount sql.NullInt64
+		var maxAmount sql.Null[models.Amount]
+		if err := rows.Scan(&limit.Currency, &windowSeconds, &maxCount, &maxAmount); err != nil {
+			return nil, err
+		}
+		limit.Window = models.LimitWindow(time.Duration(windowSeconds) * time.Second)
+		limit.MaxCount = int(maxCount.Int64)
+		if maxAmount.Valid {
+			amount, err := currencyAmount(maxAmount.V, limit.Currency)
+			if err != nil {
+				return nil, err
+			}
+			limit.MaxAmount = &amount
+		}
+		limits = append(limits, limit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return limits, nil
+}
+
+// SetSenderLimits replaces every limit override of a sender in one SQL transaction.
+// An empty list removes the sender's overrides, leaving the default limits in force.
+func (db *DBImpl) SetSenderLimits(ctx context.Context, sender string, limits []models.SpendingLimit) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return replaceSenderLimits(ctx, db.DB, mysqlDialect, sender, limits)
+}
+
+// replaceSenderLimits deletes a sender's overrides and inserts the new ones.
+func replaceSenderLimits(ctx context.Context, sqlDB *sql.DB, d dialect, sender string, limits []models.SpendingLimit) error {
+	sqlTx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+	q := d.bind(sqlTx)
+
+	if _, err := q.ExecContext(ctx, "DELETE FROM sender_limits WHERE sender = ?", sender); err != nil {
+		return err
+	}
+
+	query := "INSERT INTO sender_limits(sender, currency, window_seconds, max_count, max_amount) VALUES (?, ?, ?, ?, ?)"
+	for _, limit := range limits {
+		var maxCount sql.NullInt64
+		if limit.MaxCount > 0 {
+			maxCount = sql.NullInt64{Int64: int64(limit.MaxCount), Valid: true}
+		}
+		var maxAmount sql.Null[models.Amount]
+		if limit.MaxAmount != nil {
+			maxAmount = sql.Null[models.Amount]{V: *limit.MaxAmount, Valid: true}
+		}
+		windowSeconds := int64(limit.Window.Duration() / time.Second)
+		if _, err := q.ExecContext(ctx, query, sender, limit.Currency, windowSeconds, maxCount, maxAmount); err != nil {
+			return err
+		}
+	}
+
+	return sqlTx.Commit()
+}
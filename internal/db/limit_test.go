@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSpendingTotal(t *testing.T) {
+	totalQuery := "SELECT COUNT\\(\\*\\), COALESCE\\(SUM\\(amount\\), 0\\) FROM transactions " +
+		"WHERE sender = \\? AND currency = \\? AND status <> \\? AND created_at >= \\?"
+	since := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("counts and sums", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectQuery(totalQuery).
+			WithArgs("alice", "USD", models.StatusFailed, since).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "sum"}).AddRow(3, "150.5000"))
+
+		total, err := mockDB.GetSpendingTotal(context.Background(), "alice", "USD", since)
+		require.NoError(t, err)
+		assert.Equal(t, 3, total.Count)
+		assert.Equal(t, "150.50", total.Amount.String(), "the sum is normalized to the currency exponent")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("query fails", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectQuery(totalQuery).WillReturnError(errors.New("database error"))
+
+		_, err = mockDB.GetSpendingTotal(context.Background(), "alice", "USD", since)
+		assert.EqualError(t, err, "database error")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetSenderLimits(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mockDB := &DBImpl{DB: db}
+
+	mock.ExpectQuery("SELECT currency, window_seconds, max_count, max_amount FROM sender_limits WHERE sender = \\? ORDER BY currency ASC, window_seconds ASC").
+		WithArgs("alice").
+		WillReturnRows(sqlmock.NewRows([]string{"currency", "window_seconds", "max_count", "max_amount"}).
+			AddRow("EUR", 3600, nil, nil).
+			AddRow("USD", 86400, 100, "20000.0000"))
+
+	limits, err := mockDB.GetSenderLimits(context.Background(), "alice")
+	require.NoError(t, err)
+	amount := models.MustParseAmount("20000.00")
+	assert.Equal(t, []models.SpendingLimit{
+		{Currency: "EUR", Window: models.LimitWindow(time.Hour)},
+		{Currency: "USD", Window: models.LimitWindow(24 * time.Hour), MaxCount: 100, MaxAmount: &amount},
+	}, limits)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetSenderLimits(t *testing.T) {
+	amount := models.MustParseAmount("500.00")
+	limits := []models.SpendingLimit{
+		{Currency: "USD", Window: models.LimitWindow(time.Hour), MaxCount: 10},
+		{Currency: "EUR", Window: models.LimitWindow(30 * 24 * time.Hour), MaxAmount: &amount},
+	}
+	insert := "INSERT INTO sender_limits\\(sender, currency, window_seconds, max_count, max_amount\\) VALUES \\(\\?, \\?, \\?, \\?, \\?\\)"
+
+	t.Run("replaces the overrides", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM sender_limits WHERE sender = \\?").WithArgs("alice").WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(insert).WithArgs("alice", "USD", int64(3600), int64(10), nil).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insert).WithArgs("alice", "EUR", int64(2592000), nil, "500.00").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err = mockDB.SetSenderLimits(context.Background(), "alice", limits)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("a failed insert rolls back the delete", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM sender_limits").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insert).WillReturnError(errors.New("database error"))
+		mock.ExpectRollback()
+
+		err = mockDB.SetSenderLimits(context.Background(), "alice", limits)
+		assert.EqualError(t, err, "database error")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
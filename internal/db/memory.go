@@ -0,0 +1,1139 @@
+// Package db implements the database operations for the transaction service.
+// This file contains an in-memory implementation of the DB interface for local demos and tests.
+package db
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// MemoryDB is an in-memory implementation of the DB interface.
+// It reproduces the observable behaviour of DBImpl against MySQL: ordering and tie-breaking,
+// pagination, error sentinels, the compare-and-set status machine, idempotency expiry and
+// ledger postings. String comparisons are exact, whereas MySQL's default collation ignores case.
+// All data is lost when the process exits. It is safe for concurrent use.
+type MemoryDB struct {
+	mu           sync.RWMutex
+	transactions map[string]models.Transaction
+	idempotency  map[string]models.IdempotencyRecord
+	accounts     map[string]models.Account
+	entries      []models.LedgerEntry
+	events       []models.TransactionEvent
+	outbox       []models.OutboxEvent
+	endpoints    map[string]models.WebhookEndpoint
+	deliveries   []models.WebhookDelivery
+	// lastDeliveryID is the ID of the latest delivery; deleting an endpoint removes deliveries, so it is not len(deliveries)
+	lastDeliveryID int64
+	senderLimits   map[string][]models.SpendingLimit
+	quotes         map[string]models.Quote
+}
+
+// Ensure MemoryDB implements the DB interface at compile time
+var _ DB = (*MemoryDB)(nil)
+
+// NewMemoryDB creates an empty in-memory database.
+func NewMemoryDB() *MemoryDB {
+	return &MemoryDB{
+		transactions: make(map[string]models.Transaction),
+		idempotency:  make(map[string]models.IdempotencyRecord),
+		accounts:     make(map[string]models.Account),
+		endpoints:    make(map[string]models.WebhookEndpoint),
+		senderLimits: make(map[string][]models.SpendingLimit),
+		quotes:       make(map[string]models.Quote),
+	}
+}
+
+// timestamp returns the current time at the microsecond precision of a TIMESTAMP(6) column.
+func (m *MemoryDB) timestamp() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// CreateTransaction stores a new transaction, stamping it with the current time as MySQL's column default does,
+// and writes its transaction.created event to the outbox.
+// Returns ErrDuplicate if a transaction with the same ID already exists.
+func (m *MemoryDB) CreateTransaction(ctx context.Context, transaction models.Transaction) error {
+	return m.CreateTransactions(ctx, []models.Transaction{transaction})
+}
+
+// CreateTransactions stores a batch of new transactions as CreateTransaction does. Every transaction is checked
+// before any is stored, so either all of them are stored or none are, as with DBImpl.CreateTransactions.
+func (m *MemoryDB) CreateTransactions(ctx context.Context, transactions []models.Transaction) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.createTransactions(transactions, nil)
+}
+
+// CreateIdempotentTransaction stores a new transaction as CreateTransaction does and completes the
+// idempotency key reserved for it, atomically, as DBImpl.CreateIdempotentTransaction does.
+func (m *MemoryDB) CreateIdempotentTransaction(ctx context.Context, transaction models.Transaction, record models.IdempotencyRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.createTransactions([]models.Transaction{transaction}, &record)
+}
+
+// createTransactions checks and stores transactions, completing record's idempotency key if set.
+// Nothing is stored unless everything can be. The caller must hold the write lock.
+func (m *MemoryDB) createTransactions(transactions []models.Transaction, record *models.IdempotencyRecord) error {
+	for _, transaction := range transactions {
+		if !transaction.Status.IsValid() {
+			return fmt.Errorf("invalid status %q", transaction.Status)
+		}
+	}
+
+	// The transactions are stamped and their pointers copied, so work on a copy of the caller's slice
+	transactions = slices.Clone(transactions)
+	staged := make(map[string]bool, len(transactions))
+	quotes := make(map[string]models.Quote)
+	events := make([]models.OutboxEvent, len(transactions))
+	for i := range transactions {
+		transaction := &transactions[i]
+		if _, ok := m.transactions[transaction.ID]; ok || staged[transaction.ID] {
+			return fmt.Errorf("%w: transaction %s already exists", ErrDuplicate, transaction.ID)
+		}
+		staged[transaction.ID] = true
+
+		transaction.CreatedAt = m.timestamp()
+		if transaction.Screening != nil {
+			screening := *transaction.Screening
+			transaction.Screening = &screening
+		}
+		if transaction.Conversion != nil {
+			conversion := *transaction.Conversion
+			transaction.Conversion = &conversion
+			if conversion.QuoteID != "" {
+				quote, ok := quotes[conversion.QuoteID]
+				if !ok {
+					quote, ok = m.quotes[conversion.QuoteID]
+				}
+				if !ok || quote.TransactionID != "" {
+					return fmt.Errorf("%w: quote %s does not exist or has already been used", ErrConflict, conversion.QuoteID)
+				}
+				quote.TransactionID = transaction.ID
+				quotes[quote.ID] = quote
+			}
+		}
+
+		event, err := models.NewTransactionEvent(models.EventTransactionCreated, models.TransactionEventData{Transaction: m.read(*transaction)})
+		if err != nil {
+			return err
+		}
+		events[i] = event
+	}
+
+	if record != nil {
+		if err := m.completeIdempotencyKey(*record); err != nil {
+			return err
+		}
+	}
+	for i, transaction := range transactions {
+		m.transactions[transaction.ID] = transaction
+		m.recordOutboxEvent(events[i])
+	}
+	for id, quote := range quotes {
+		m.quotes[id] = quote
+	}
+	return nil
+}
+
+// UpdateTransaction moves an existing transaction to a new status, enforcing the same state machine,
+// error sentinels, history and outbox events and atomic ledger postings as DBImpl.UpdateTransaction.
+func (m *MemoryDB) UpdateTransaction(ctx context.Context, id string, change models.StatusChange) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	transaction, ok := m.transactions[id]
+	if !ok {
+		return fmt.Errorf("%w: transaction %s", ErrNotFound, id)
+	}
+	if err := models.ValidateTransition(transaction.Status, change.Status); err != nil {
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+
+	from := transaction.Status
+	transaction.Status = change.Status
+	if change.Status == models.StatusFailed {
+		transaction.FailureReason = change.Reason
+	}
+	outboxEvent, err := models.NewTransactionEvent(models.EventTransactionStatusChanged, models.TransactionEventData{
+		Transaction:    m.read(transaction),
+		PreviousStatus: from,
+		Reason:         change.Reason,
+		Actor:          change.Actor,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := m.postLedgerEntries(models.LedgerEntries(m.read(transaction), change.Status)); err != nil {
+		return err
+	}
+
+	m.events = append(m.events, models.TransactionEvent{
+		ID:            int64(len(m.events) + 1),
+		TransactionID: id,
+		FromStatus:    from,
+		ToStatus:      change.Status,
+		Reason:        change.Reason,
+		Actor:         change.Actor,
+		CreatedAt:     m.timestamp(),
+	})
+	m.transactions[id] = transaction
+	m.recordOutboxEvent(outboxEvent)
+	return nil
+}
+
+// GetTransactionHistory retrieves every status change of a transaction, oldest first.
+// Returns ErrNotFound if no transaction has the given ID.
+func (m *MemoryDB) GetTransactionHistory(ctx context.Context, id string) ([]models.TransactionEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.transactions[id]; !ok {
+		return nil, fmt.Errorf("%w: transaction %s", ErrNotFound, id)
+	}
+
+	var events []models.TransactionEvent
+	for _, event := range m.events {
+		if event.TransactionID == id {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// GetAllTransactions retrieves a filtered, offset-paginated list of transactions.
+func (m *MemoryDB) GetAllTransactions(ctx context.Context, filter TransactionFilter, limit, offset int) ([]models.Transaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	matches := m.sorted(filter, nil)
+	if offset >= len(matches) {
+		return nil, nil
+	}
+	matches = matches[offset:]
+	return matches[:min(limit, len(matches))], nil
+}
+
+// GetTransactionsAfter retrieves up to limit filtered transactions adjacent to the cursor,
+// with the same ordering and direction rules as DBImpl.GetTransactionsAfter.
+func (m *MemoryDB) GetTransactionsAfter(ctx context.Context, filter TransactionFilter, cursor *Cursor, limit int) ([]models.Transaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	matches := m.sorted(filter, cursor)
+	if cursor == nil || !cursor.Before {
+		return matches[:min(limit, len(matches))], nil
+	}
+	// The page before the cursor is the tail of everything that precedes it
+	return matches[max(len(matches)-limit, 0):], nil
+}
+
+// EachTransaction calls fn with every transaction matching the filter, in the filter's order.
+// The matches are copied under the read lock and fn is called after it is released, so fn may take its time.
+func (m *MemoryDB) EachTransaction(ctx context.Context, filter TransactionFilter, fn func(models.Transaction) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	matches := m.sorted(filter, nil)
+	m.mu.RUnlock()
+
+	for _, transaction := range matches {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(transaction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CountTransactions returns the number of transactions matching the filter.
+func (m *MemoryDB) CountTransactions(ctx context.Context, filter TransactionFilter) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := 0
+	for _, transaction := range m.transactions {
+		if filter.Matches(m.read(transaction)) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetTransaction retrieves a single transaction by its ID.
+// Returns ErrNotFound if no transaction is found with the given ID.
+func (m *MemoryDB) GetTransaction(ctx context.Context, id string) (*models.Transaction, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	transaction, ok := m.transactions[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: transaction %s", ErrNotFound, id)
+	}
+	transaction = m.read(transaction)
+	return &transaction, nil
+}
+
+// GetIdempotencyRecord retrieves an unexpired idempotency record by its key.
+// Returns ErrNotFound if the key has never been used or its expiry window has passed.
+func (m *MemoryDB) GetIdempotencyRecord(ctx context.Context, key string) (*models.IdempotencyRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	record, ok := m.idempotency[key]
+	if !ok || !record.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: idempotency key %s", ErrNotFound, key)
+	}
+	record.ResponseBody = slices.Clone(record.ResponseBody)
+	return &record, nil
+}
+
+// ReserveIdempotencyKey claims an idempotency key for a request that is about to run, replacing an expired
+// record under the same key. Returns ErrDuplicate if the key is held by an unexpired record.
+func (m *MemoryDB) ReserveIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// TIMESTAMP columns hold whole seconds, and MySQL rounds rather than truncates
+	record.CreatedAt = record.CreatedAt.UTC().Round(time.Second)
+	record.ExpiresAt = record.ExpiresAt.UTC().Round(time.Second)
+
+	if existing, ok := m.idempotency[record.Key]; ok && existing.ExpiresAt.After(record.CreatedAt) {
+		return fmt.Errorf("%w: idempotency key %s", ErrDuplicate, record.Key)
+	}
+	record.Status = models.IdempotencyPending
+	record.ResponseCode = 0
+	record.ResponseBody = []byte{}
+	m.idempotency[record.Key] = record
+	return nil
+}
+
+// ReleaseIdempotencyKey deletes a pending reservation made by ReserveIdempotencyKey, leaving a reservation
+// that has since been completed or replaced alone.
+func (m *MemoryDB) ReleaseIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.isReserved(record) {
+		delete(m.idempotency, record.Key)
+	}
+	return nil
+}
+
+// completeIdempotencyKey stores a response in its pending reservation, returning ErrNotFound if the
+// reservation has lapsed. The caller must hold the write lock.
+func (m *MemoryDB) completeIdempotencyKey(record models.IdempotencyRecord) error {
+	if !m.isReserved(record) {
+		return fmt.Errorf("%w: idempotency key %s is no longer reserved", ErrNotFound, record.Key)
+	}
+	existing := m.idempotency[record.Key]
+	existing.Status = models.IdempotencyCompleted
+	existing.ResponseCode = record.ResponseCode
+	existing.ResponseBody = slices.Clone(record.ResponseBody)
+	existing.ExpiresAt = record.ExpiresAt.UTC().Round(time.Second)
+	m.idempotency[record.Key] = existing
+	return nil
+}
+
+// isReserved reports whether record's key is held by the pending reservation created at record.CreatedAt.
+func (m *MemoryDB) isReserved(record models.IdempotencyRecord) bool {
+	existing, ok := m.idempotency[record.Key]
+	return ok && existing.Status == models.IdempotencyPending &&
+		existing.CreatedAt.Equal(record.CreatedAt.UTC().Round(time.Second))
+}
+
+// CreateAccount opens a new account.
+// Returns ErrDuplicate if an account with the same ID already exists.
+func (m *MemoryDB) CreateAccount(ctx context.Context, account models.Account) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.accounts[account.ID]; ok {
+		return fmt.Errorf("%w: account %s already exists", ErrDuplicate, account.ID)
+	}
+
+	account.CreatedAt = m.timestamp()
+	m.accounts[account.ID] = account
+	return nil
+}
+
+// GetAccount retrieves a single account by its ID.
+// Returns ErrNotFound if no account has the given ID.
+func (m *MemoryDB) GetAccount(ctx context.Context, id string) (*models.Account, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	account, ok := m.accounts[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: account %s", ErrNotFound, id)
+	}
+	return &account, nil
+}
+
+// GetAccountBalance derives an account's balance by summing its ledger entries.
+// Returns ErrNotFound if no account has the given ID.
+func (m *MemoryDB) GetAccountBalance(ctx context.Context, id string) (*models.Balance, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	account, ok := m.accounts[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: account %s", ErrNotFound, id)
+	}
+	amount, err := m.balance(account)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Balance{AccountID: account.ID, Currency: account.Currency, Amount: amount}, nil
+}
+
+// GetLedgerEntries retrieves up to limit of an account's ledger entries with IDs greater than afterID,
+// in posting order. Returns ErrNotFound if no account has the given ID.
+func (m *MemoryDB) GetLedgerEntries(ctx context.Context, accountID string, afterID int64, limit int) ([]models.LedgerEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.accounts[accountID]; !ok {
+		return nil, fmt.Errorf("%w: account %s", ErrNotFound, accountID)
+	}
+
+	var entries []models.LedgerEntry
+	for _, entry := range m.entries {
+		if len(entries) == limit {
+			break
+		}
+		if entry.AccountID == accountID && entry.ID > afterID {
+			amount, err := currencyAmount(decimalRoundTrip(entry.Amount), entry.Currency)
+			if err != nil {
+				return nil, fmt.Errorf("ledger entry %d: %w", entry.ID, err)
+			}
+			entry.Amount = amount
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// CreateWebhookEndpoint registers an endpoint for every event written from now on.
+// Returns ErrDuplicate if an endpoint with the same ID already exists.
+func (m *MemoryDB) CreateWebhookEndpoint(ctx context.Context, endpoint models.WebhookEndpoint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.endpoints[endpoint.ID]; ok {
+		return fmt.Errorf("%w: webhook endpoint %s already exists", ErrDuplicate, endpoint.ID)
+	}
+	endpoint.CreatedAt = m.timestamp()
+	m.endpoints[endpoint.ID] = endpoint
+	return nil
+}
+
+// GetWebhookEndpoint retrieves a single endpoint, including its secret, by its ID.
+// Returns ErrNotFound if no endpoint has the given ID.
+func (m *MemoryDB) GetWebhookEndpoint(ctx context.Context, id string) (*models.WebhookEndpoint, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	endpoint, ok := m.endpoints[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: webhook endpoint %s", ErrNotFound, id)
+	}
+	return &endpoint, nil
+}
+
+// ListWebhookEndpoints retrieves every endpoint, including its secret, in registration order.
+func (m *MemoryDB) ListWebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.sortedEndpoints(), nil
+}
+
+// UpdateWebhookEndpoint replaces an endpoint's URL and enabled flag; its secret and creation time are kept.
+// Returns ErrNotFound if no endpoint has the given ID.
+func (m *MemoryDB) UpdateWebhookEndpoint(ctx context.Context, endpoint models.WebhookEndpoint) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.endpoints[endpoint.ID]
+	if !ok {
+		return fmt.Errorf("%w: webhook endpoint %s", ErrNotFound, endpoint.ID)
+	}
+	stored.URL = endpoint.URL
+	stored.Enabled = endpoint.Enabled
+	m.endpoints[endpoint.ID] = stored
+	return nil
+}
+
+// DeleteWebhookEndpoint removes an endpoint together with its deliveries.
+// Returns ErrNotFound if no endpoint has the given ID.
+func (m *MemoryDB) DeleteWebhookEndpoint(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.endpoints[id]; !ok {
+		return fmt.Errorf("%w: webhook endpoint %s", ErrNotFound, id)
+	}
+	delete(m.endpoints, id)
+	m.deliveries = slices.DeleteFunc(m.deliveries, func(delivery models.WebhookDelivery) bool {
+		return delivery.EndpointID == id
+	})
+	return nil
+}
+
+// ListWebhookDeliveries retrieves up to limit of an endpoint's deliveries with IDs greater than afterID, oldest first.
+// An empty status matches deliveries in every state.
+// Returns ErrNotFound if no endpoint has the given ID.
+func (m *MemoryDB) ListWebhookDeliveries(ctx context.Context, endpointID string, status models.DeliveryStatus, afterID int64, limit int) ([]models.WebhookDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.endpoints[endpointID]; !ok {
+		return nil, fmt.Errorf("%w: webhook endpoint %s", ErrNotFound, endpointID)
+	}
+
+	var deliveries []models.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if len(deliveries) == limit {
+			break
+		}
+		if delivery.EndpointID == endpointID && delivery.ID > afterID && (status == "" || delivery.Status == status) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+// RedeliverWebhookDelivery queues a delivery to be attempted again straight away with a fresh set of attempts,
+// whatever its current state. Returns ErrNotFound if the endpoint has no delivery with the given ID.
+func (m *MemoryDB) RedeliverWebhookDelivery(ctx context.Context, endpointID string, deliveryID int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.deliveryIndex(deliveryID)
+	if i < 0 || m.deliveries[i].EndpointID != endpointID {
+		return fmt.Errorf("%w: webhook delivery %d", ErrNotFound, deliveryID)
+	}
+	m.deliveries[i].Status = models.DeliveryPending
+	m.deliveries[i].Attempts = 0
+	m.deliveries[i].NextAttemptAt = m.timestamp()
+	return nil
+}
+
+// ClaimWebhookDeliveries claims up to limit pending deliveries to enabled endpoints that are due at now,
+// earliest first, hiding each from other callers until now+lease as DBImpl.ClaimWebhookDeliveries does.
+func (m *MemoryDB) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.PendingDelivery, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var due []int
+	for i, delivery := range m.deliveries {
+		if delivery.Status == models.DeliveryPending && !delivery.NextAttemptAt.After(now) && m.endpoints[delivery.EndpointID].Enabled {
+			due = append(due, i)
+		}
+	}
+	slices.SortFunc(due, func(a, b int) int {
+		if c := m.deliveries[a].NextAttemptAt.Compare(m.deliveries[b].NextAttemptAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(m.deliveries[a].ID, m.deliveries[b].ID)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	// Like the SQL claim, the batch is returned in delivery order
+	slices.Sort(due)
+
+	leasedUntil := now.Add(lease).UTC().Truncate(time.Microsecond)
+	var claimed []models.PendingDelivery
+	for _, i := range due {
+		m.deliveries[i].NextAttemptAt = leasedUntil
+		delivery := m.deliveries[i]
+		endpoint := m.endpoints[delivery.EndpointID]
+		claimed = append(claimed, models.PendingDelivery{
+			Delivery: delivery,
+			Event:    m.outbox[delivery.EventID-1],
+			URL:      endpoint.URL,
+			Secret:   endpoint.Secret,
+		})
+	}
+	return claimed, nil
+}
+
+// RecordDeliveryAttempt stores the outcome of an attempt to deliver a claimed webhook and counts the attempt.
+// Returns ErrNotFound if the delivery no longer exists.
+func (m *MemoryDB) RecordDeliveryAttempt(ctx context.Context, deliveryID int64, attempt models.DeliveryAttempt) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.deliveryIndex(deliveryID)
+	if i < 0 {
+		return fmt.Errorf("%w: webhook delivery %d", ErrNotFound, deliveryID)
+	}
+
+	delivery := &m.deliveries[i]
+	delivery.Status = attempt.Status
+	delivery.Attempts++
+	delivery.NextAttemptAt = attempt.NextAttemptAt
+	if delivery.NextAttemptAt.IsZero() {
+		delivery.NextAttemptAt = attempt.AttemptedAt
+	}
+	delivery.NextAttemptAt = delivery.NextAttemptAt.UTC().Truncate(time.Microsecond)
+	delivery.LastResponseCode = attempt.ResponseCode
+	delivery.LastError = truncate(attempt.Error, models.MaxDeliveryErrorLength)
+	delivery.DeliveredAt = nil
+	if attempt.Status == models.DeliveryDelivered {
+		deliveredAt := attempt.AttemptedAt.UTC().Truncate(time.Microsecond)
+		delivery.DeliveredAt = &deliveredAt
+	}
+	return nil
+}
+
+// ListOutboxEvents retrieves up to limit outbox events with an ID greater than afterID, oldest first.
+func (m *MemoryDB) ListOutboxEvents(ctx context.Context, afterID int64, limit int) ([]models.OutboxEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// Event IDs are positions in the outbox, which is never trimmed
+	start := int(min(max(afterID, 0), int64(len(m.outbox))))
+	events := m.outbox[start:]
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return slices.Clone(events), nil
+}
+
+// LastOutboxEventID returns the ID of the latest outbox event, or 0 if the outbox is empty.
+func (m *MemoryDB) LastOutboxEventID(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return int64(len(m.outbox)), nil
+}
+
+// GetSpendingTotal counts and sums a sender's non-failed transactions in a currency created at or after since.
+// currency must be an upper-case ISO 4217 code, as with DBImpl.GetSpendingTotal.
+func (m *MemoryDB) GetSpendingTotal(ctx context.Context, sender, currency string, since time.Time) (*models.SpendingTotal, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var total models.SpendingTotal
+	var amount models.Amount
+	for _, transaction := range m.transactions {
+		if transaction.Sender != sender || transaction.Currency != currency ||
+			transaction.Status == models.StatusFailed || transaction.CreatedAt.Before(since) {
+			continue
+		}
+		total.Count++
+		var err error
+		if amount, err = amount.Add(m.read(transaction).Amount); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if total.Amount, err = currencyAmount(decimalRoundTrip(amount), currency); err != nil {
+		return nil, err
+	}
+	return &total, nil
+}
+
+// GetSenderLimits retrieves a sender's overrides of the default spending limits, ordered by currency, then window.
+func (m *MemoryDB) GetSenderLimits(ctx context.Context, sender string) ([]models.SpendingLimit, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return slices.Clone(m.senderLimits[sender]), nil
+}
+
+// SetSenderLimits replaces every limit override of a sender; an empty list removes them.
+func (m *MemoryDB) SetSenderLimits(ctx context.Context, sender string, limits []models.SpendingLimit) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(limits) == 0 {
+		delete(m.senderLimits, sender)
+		return nil
+	}
+	stored := make([]models.SpendingLimit, len(limits))
+	for i, limit := range limits {
+		if limit.MaxAmount != nil {
+			amount, err := currencyAmount(decimalRoundTrip(*limit.MaxAmount), limit.Currency)
+			if err != nil {
+				return err
+			}
+			limit.MaxAmount = &amount
+		}
+		stored[i] = limit
+	}
+	models.SortLimits(stored)
+	m.senderLimits[sender] = stored
+	return nil
+}
+
+// GetSenderTotals counts and sums each sender's completed transactions per currency, optionally in one currency only.
+func (m *MemoryDB) GetSenderTotals(ctx context.Context, currency string) ([]models.SenderTotal, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.senderTotals(currency, time.Time{})
+}
+
+// GetTopSenders returns, for each currency, the limit senders with the largest total of completed transactions
+// created at or after since.
+func (m *MemoryDB) GetTopSenders(ctx context.Context, currency string, since time.Time, limit int) ([]models.SenderTotal, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	totals, err := m.senderTotals(currency, since)
+	if err != nil {
+		return nil, err
+	}
+	// Totals are grouped by currency with the largest first, so each ranking is a run to cut short
+	var top []models.SenderTotal
+	ranked := 0
+	for i, total := range totals {
+		if i == 0 || total.Currency != totals[i-1].Currency {
+			ranked = 0
+		}
+		if ranked < limit {
+			top = append(top, total)
+		}
+		ranked++
+	}
+	return top, nil
+}
+
+// GetFailureHeavySenders returns the senders with more than threshold failed transactions, most failures first.
+func (m *MemoryDB) GetFailureHeavySenders(ctx context.Context, threshold int) ([]models.SenderFailures, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	failures := make(map[string]int)
+	for _, transaction := range m.transactions {
+		if transaction.Status == models.StatusFailed {
+			failures[transaction.Sender]++
+		}
+	}
+
+	var senders []models.SenderFailures
+	for sender, count := range failures {
+		if count > threshold {
+			senders = append(senders, models.SenderFailures{Sender: sender, FailedCount: count})
+		}
+	}
+	slices.SortFunc(senders, func(a, b models.SenderFailures) int {
+		return cmp.Or(cmp.Compare(b.FailedCount, a.FailedCount), strings.Compare(a.Sender, b.Sender))
+	})
+	return senders, nil
+}
+
+// CreateQuote stores a newly issued quote. Returns ErrDuplicate if a quote with the same ID already exists.
+func (m *MemoryDB) CreateQuote(ctx context.Context, quote models.Quote) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.quotes[quote.ID]; ok {
+		return fmt.Errorf("%w: quote %s already exists", ErrDuplicate, quote.ID)
+	}
+	quote.TransactionID = ""
+	quote.CreatedAt = quote.CreatedAt.UTC().Round(time.Microsecond)
+	quote.ExpiresAt = quote.ExpiresAt.UTC().Round(time.Microsecond)
+	m.quotes[quote.ID] = quote
+	return nil
+}
+
+// GetQuote retrieves a quote by its ID, whether or not it has expired or been used.
+// Returns ErrNotFound if no quote has the given ID.
+func (m *MemoryDB) GetQuote(ctx context.Context, id string) (*models.Quote, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	quote, ok := m.quotes[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: quote %s", ErrNotFound, id)
+	}
+	var err error
+	if quote.SourceAmount, err = currencyAmount(decimalRoundTrip(quote.SourceAmount), quote.SourceCurrency); err != nil {
+		return nil, err
+	}
+	if quote.TargetAmount, err = currencyAmount(decimalRoundTrip(quote.TargetAmount), quote.TargetCurrency); err != nil {
+		return nil, err
+	}
+	return &quote, nil
+}
+
+// Close releases nothing; it exists to satisfy the DB interface.
+func (m *MemoryDB) Close() error {
+	return nil
+}
+
+// sorted returns the filtered transactions in the filter's order, restricted to those adjacent to the cursor.
+// For a backward cursor the transactions preceding it are returned, still in the filter's order.
+// The caller must hold the read lock.
+func (m *MemoryDB) sorted(filter TransactionFilter, cursor *Cursor) []models.Transaction {
+	compare := func(a, b models.Transaction) int {
+		if filter.Descending {
+			return filter.compare(b, a)
+		}
+		return filter.compare(a, b)
+	}
+
+	var position models.Transaction
+	if cursor != nil {
+		position = models.Transaction{ID: cursor.ID, Amount: cursor.Amount, CreatedAt: cursor.CreatedAt}
+	}
+
+	var matches []models.Transaction
+	for _, transaction := range m.transactions {
+		transaction = m.read(transaction)
+		if !filter.Matches(transaction) {
+			continue
+		}
+		if cursor != nil {
+			order := compare(transaction, position)
+			if (cursor.Before && order >= 0) || (!cursor.Before && order <= 0) {
+				continue
+			}
+		}
+		matches = append(matches, transaction)
+	}
+
+	slices.SortFunc(matches, compare)
+	return matches
+}
+
+// recordOutboxEvent appends an event to the outbox and queues its delivery to every enabled endpoint,
+// in endpoint ID order. The caller must hold the write lock.
+func (m *MemoryDB) recordOutboxEvent(event models.OutboxEvent) {
+	now := m.timestamp()
+	event.ID = int64(len(m.outbox) + 1)
+	event.CreatedAt = now
+	m.outbox = append(m.outbox, event)
+
+	ids := slices.Sorted(maps.Keys(m.endpoints))
+	for _, id := range ids {
+		if !m.endpoints[id].Enabled {
+			continue
+		}
+		m.lastDeliveryID++
+		m.deliveries = append(m.deliveries, models.WebhookDelivery{
+			ID:            m.lastDeliveryID,
+			EventID:       event.ID,
+			EndpointID:    id,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+}
+
+// sortedEndpoints returns the endpoints in registration order. The caller must hold the read lock.
+func (m *MemoryDB) sortedEndpoints() []models.WebhookEndpoint {
+	var endpoints []models.WebhookEndpoint
+	for _, endpoint := range m.endpoints {
+		endpoints = append(endpoints, endpoint)
+	}
+	slices.SortFunc(endpoints, func(a, b models.WebhookEndpoint) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return endpoints
+}
+
+// deliveryIndex returns the position of a delivery in m.deliveries, or -1 if there is none with the ID.
+// The caller must hold a lock.
+func (m *MemoryDB) deliveryIndex(id int64) int {
+	i, found := slices.BinarySearchFunc(m.deliveries, id, func(delivery models.WebhookDelivery, id int64) int {
+		return cmp.Compare(delivery.ID, id)
+	})
+	if !found {
+		return -1
+	}
+	return i
+}
+
+// read returns a stored transaction as DBImpl would read it back, with the amount
+// normalized through the DECIMAL(19,4) column to the minor units of its currency.
+func (m *MemoryDB) read(transaction models.Transaction) models.Transaction {
+	if amount, err := currencyAmount(decimalRoundTrip(transaction.Amount), transaction.Currency); err == nil {
+		transaction.Amount = amount
+	}
+	if transaction.Conversion != nil {
+		conversion := *transaction.Conversion
+		if amount, err := currencyAmount(decimalRoundTrip(conversion.TargetAmount), conversion.TargetCurrency); err == nil {
+			conversion.TargetAmount = amount
+		}
+		transaction.Conversion = &conversion
+	}
+	return transaction
+}
+
+// balance sums an account's credits minus its debits. The caller must hold a lock.
+func (m *MemoryDB) balance(account models.Account) (models.Amount, error) {
+	var total models.Amount
+	for _, entry := range m.entries {
+		if entry.AccountID != account.ID {
+			continue
+		}
+		amount := entry.Amount
+		if entry.Direction == models.DirectionDebit {
+			amount = amount.Neg()
+		}
+
+		var err error
+		if total, err = total.Add(amount); err != nil {
+			return models.Amount{}, fmt.Errorf("account %s balance: %w", account.ID, err)
+		}
+	}
+
+	amount, err := currencyAmount(decimalRoundTrip(total), account.Currency)
+	if err != nil {
+		return models.Amount{}, fmt.Errorf("account %s balance: %w", account.ID, err)
+	}
+	return amount, nil
+}
+
+// postLedgerEntries validates and appends a balanced set of entries, opening accounts on first use.
+// Nothing is written unless every entry is accepted. The caller must hold the write lock.
+func (m *MemoryDB) postLedgerEntries(entries []models.LedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	now := m.timestamp()
+	opened := make(map[string]models.Account)
+	for _, entry := range entries {
+		account, ok := m.accounts[entry.AccountID]
+		if !ok {
+			if account, ok = opened[entry.AccountID]; !ok {
+				account = models.Account{ID: entry.AccountID, Currency: entry.Currency, AllowOverdraft: models.IsExchangeAccount(entry.AccountID), CreatedAt: now}
+				opened[account.ID] = account
+			}
+		}
+
+		if !strings.EqualFold(account.Currency, entry.Currency) {
+			return fmt.Errorf("%w: account %s holds %s, not %s", ErrCurrencyMismatch, account.ID, account.Currency, entry.Currency)
+		}
+		if entry.Direction != models.DirectionDebit || account.AllowOverdraft {
+			continue
+		}
+
+		balance, err := m.balance(account)
+		if err != nil {
+			return err
+		}
+		if balance.Cmp(entry.Amount) < 0 {
+			return fmt.Errorf("%w: account %s has %s %s, needs %s", ErrInsufficientFunds, account.ID, balance, account.Currency, entry.Amount)
+		}
+	}
+
+	for id, account := range opened {
+		m.accounts[id] = account
+	}
+	for _, entry := range entries {
+		entry.ID = int64(len(m.entries) + 1)
+		entry.CreatedAt = now
+		m.entries = append(m.entries, entry)
+	}
+	return nil
+}
+
+// senderTotals aggregates completed transactions created at or after since by sender and upper-case currency,
+// ordered by currency, then largest total first, then sender. The caller must hold the read lock.
+func (m *MemoryDB) senderTotals(currency string, since time.Time) ([]models.SenderTotal, error) {
+	type group struct{ sender, currency string }
+	totals := make(map[group]models.SenderTotal)
+	for _, transaction := range m.transactions {
+		code := strings.ToUpper(transaction.Currency)
+		if transaction.Status != models.StatusCompleted || (currency != "" && code != currency) ||
+			transaction.CreatedAt.Before(since) {
+			continue
+		}
+		key := group{transaction.Sender, code}
+		total := totals[key]
+		sum, err := total.Total.Add(m.read(transaction).Amount)
+		if err != nil {
+			return nil, err
+		}
+		totals[key] = models.SenderTotal{Sender: transaction.Sender, Currency: code, Count: total.Count + 1, Total: sum}
+	}
+
+	sorted := make([]models.SenderTotal, 0, len(totals))
+	for _, total := range totals {
+		amount, err := currencyAmount(decimalRoundTrip(total.Total), total.Currency)
+		if err != nil {
+			return nil, err
+		}
+		total.Total = amount
+		sorted = append(sorted, total)
+	}
+	slices.SortFunc(sorted, func(a, b models.SenderTotal) int {
+		return cmp.Or(strings.Compare(a.Currency, b.Currency), b.Total.Cmp(a.Total), strings.Compare(a.Sender, b.Sender))
+	})
+	return sorted, nil
+}
+
+// decimalRoundTrip returns the amount as it reads back from a DECIMAL column, without insignificant zeros.
+func decimalRoundTrip(amount models.Amount) models.Amount {
+	var stored models.Amount
+	if err := stored.Scan(amount.String()); err != nil {
+		return amount
+	}
+	return stored
+}
@@ -0,0 +1,429 @@
+// Package db implements the database operations for the transaction service.
+// This file contains the versioned schema migrations and the migrator that applies them.
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// migrationFiles holds the migrations of every supported server, one directory per dialect.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// ErrChecksumMismatch is returned when an applied migration's file has been edited since it ran.
+var ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+// migrationFileName matches migration files such as "0002_add_index.up.sql".
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a single versioned schema change.
+type Migration struct {
+	// Version orders migrations; it is the numeric prefix of the file names
+	Version int64
+	// Name describes the change; it is the rest of the file names
+	Name string
+	// Up applies the change
+	Up string
+	// Down reverts the change
+	Down string
+	// Checksum is the hex SHA-256 of Up, recorded when the migration is applied
+	Checksum string
+}
+
+// Migration states reported by Migrator.Status.
+const (
+	// MigrationApplied means the migration has run and its file is unchanged
+	MigrationApplied = "applied"
+	// MigrationPending means the migration has not run yet
+	MigrationPending = "pending"
+	// MigrationModified means the migration has run but its file has been edited since
+	MigrationModified = "modified"
+	// MigrationMissing means the migration has run but its file no longer exists
+	MigrationMissing = "missing"
+)
+
+// MigrationStatus describes where a single migration stands in a database.
+type MigrationStatus struct {
+	Version int64
+	Name    string
+	// State is one of MigrationApplied, MigrationPending, MigrationModified or MigrationMissing
+	State string
+	// AppliedAt is when the migration ran; it is zero for pending migrations
+	AppliedAt time.Time
+}
+
+// appliedMigration is a row of the schema_migrations table.
+type appliedMigration struct {
+	version   int64
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// Migrator applies and reverts the embedded migrations of one SQL server.
+// Every operation holds a server-side lock, so several instances migrating at boot take turns.
+type Migrator struct {
+	db         *sql.DB
+	dialect    dialect
+	migrations []Migration
+}
+
+// NewMigrator returns a migrator for an open connection to the given storage backend,
+// which must be StorageMySQL or StoragePostgres.
+func NewMigrator(sqlDB *sql.DB, storage string) (*Migrator, error) {
+	var d dialect
+	switch storage {
+	case StorageMySQL:
+		d = mysqlDialect
+	case StoragePostgres:
+		d = postgresDialect
+	default:
+		return nil, fmt.Errorf("storage %q has no migrations", storage)
+	}
+
+	migrations, err := loadMigrations(migrationFiles, d.migrations)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: sqlDB, dialect: d, migrations: migrations}, nil
+}
+
+// OpenMigrator connects to the SQL backend selected by the STORAGE environment variable and returns its migrator.
+// The migrator owns the connection; call Close when done.
+func OpenMigrator() (*Migrator, error) {
+	loadEnvFile()
+
+	storage := getEnv("STORAGE", StorageMySQL)
+	var (
+		config *Config
+		err    error
+		sqlDB  *sql.DB
+	)
+	switch storage {
+	case StorageMySQL:
+		if config, err = loadConfig("3306"); err == nil {
+			sqlDB, err = connectDB(config)
+		}
+	case StoragePostgres:
+		if config, err = loadConfig("5432"); err == nil {
+			sqlDB, err = connectPostgres(config)
+		}
+	default:
+		return nil, fmt.Errorf("storage %q has no migrations", storage)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	migrator, err := NewMigrator(sqlDB, storage)
+	if err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+	return migrator, nil
+}
+
+// migrateOnOpen applies pending migrations with a short-lived connection of its own.
+func migrateOnOpen(ctx context.Context) error {
+	migrator, err := OpenMigrator()
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	applied, err := migrator.Up(ctx)
+	for _, migration := range applied {
+		log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+	}
+	return err
+}
+
+// Close closes the migrator's database connection.
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
+
+// Up applies every pending migration in version order and returns those it applied.
+// It refuses to run if an applied migration has been edited or removed since it ran.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			record := func(q querier) error {
+				_, err := m.dialect.bind(q).ExecContext(ctx,
+					"INSERT INTO schema_migrations(version, name, checksum) VALUES (?, ?, ?)",
+					migration.Version, migration.Name, migration.Checksum)
+				return err
+			}
+			if err := m.apply(ctx, conn, migration.Up, record); err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the most recently applied migration and returns it, or returns nil if none has been applied.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var reverted *Migration
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.verify(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			forget := func(q querier) error {
+				_, err := m.dialect.bind(q).ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+				return err
+			}
+			if err := m.apply(ctx, conn, migration.Down, forget); err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = &migration
+			return nil
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status reports the state of every known and every applied migration, in version order.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		statuses = m.compare(applied)
+		return nil
+	})
+	return statuses, err
+}
+
+// locked runs fn on a single connection while holding the migration lock.
+// The schema_migrations table is created first if needed.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, m.dialect.lockMigrations).Scan(&locked); err != nil {
+		return fmt.Errorf("failed to lock migrations: %w", err)
+	}
+	if locked.Int64 != 1 {
+		return errors.New("failed to lock migrations: timed out waiting for another migrator")
+	}
+	defer func() {
+		// The lock is tied to the session, so it must be released even if ctx is done
+		if _, err := conn.ExecContext(context.Background(), m.dialect.unlockMigrations); err != nil {
+			log.Printf("error unlocking migrations: %v", err)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, m.dialect.createMigrationsTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return fn(conn)
+}
+
+// applied reads the schema_migrations table, keyed by version.
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]appliedMigration)
+	for rows.Next() {
+		var row appliedMigration
+		if err := rows.Scan(&row.version, &row.name, &row.checksum, &row.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[row.version] = row
+	}
+	return applied, rows.Err()
+}
+
+// verify reads the applied migrations and fails if any of them was edited or removed since it ran.
+func (m *Migrator) verify(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	applied, err := m.applied(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, status := range m.compare(applied) {
+		switch status.State {
+		case MigrationModified:
+			return nil, fmt.Errorf("%w: %04d_%s was edited after it was applied", ErrChecksumMismatch, status.Version, status.Name)
+		case MigrationMissing:
+			return nil, fmt.Errorf("migration %04d_%s was applied but its files no longer exist", status.Version, status.Name)
+		}
+	}
+	return applied, nil
+}
+
+// compare matches the applied migrations against the known ones.
+func (m *Migrator) compare(applied map[int64]appliedMigration) []MigrationStatus {
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	known := make(map[int64]bool, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name, State: MigrationPending}
+		if row, ok := applied[migration.Version]; ok {
+			status.AppliedAt = row.appliedAt
+			status.State = MigrationApplied
+			if row.checksum != migration.Checksum {
+				status.State = MigrationModified
+			}
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, row := range applied {
+		if !known[row.version] {
+			statuses = append(statuses, MigrationStatus{Version: row.version, Name: row.name, State: MigrationMissing, AppliedAt: row.appliedAt})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses
+}
+
+// apply runs a migration script followed by its bookkeeping.
+// Where the server supports transactional DDL both happen atomically. Elsewhere (MySQL) each statement
+// commits on its own, so a failing script leaves the statements before it applied and unrecorded.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, script string, bookkeeping func(querier) error) error {
+	statements := splitStatements(script)
+
+	if !m.dialect.transactionalDDL {
+		for _, statement := range statements {
+			if _, err := conn.ExecContext(ctx, statement); err != nil {
+				return err
+			}
+		}
+		return bookkeeping(conn)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	if err := bookkeeping(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// loadMigrations reads and pairs the up and down files in dir, sorted by version.
+// Every migration needs both files, and versions must be unique.
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected file %s in %s: migrations are named <version>_<name>.up.sql and <version>_<name>.down.sql", entry.Name(), dir)
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Checksum == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// splitStatements drops comment lines from a migration script and splits the rest into statements on semicolons.
+// Migration scripts must therefore not contain semicolons inside literals or procedure bodies.
+func splitStatements(script string) []string {
+	var code []string
+	for _, line := range strings.Split(script, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			code = append(code, line)
+		}
+	}
+
+	var statements []string
+	for _, statement := range strings.Split(strings.Join(code, "\n"), ";") {
+		if statement = strings.TrimSpace(statement); statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
@@ -0,0 +1,239 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("pairs and orders files", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"m/0002_add_index.up.sql":       {Data: []byte("CREATE INDEX i ON t (a)")},
+			"m/0002_add_index.down.sql":     {Data: []byte("DROP INDEX i ON t")},
+			"m/0001_create_schema.up.sql":   {Data: []byte("CREATE TABLE t (a INT)")},
+			"m/0001_create_schema.down.sql": {Data: []byte("DROP TABLE t")},
+		}
+
+		migrations, err := loadMigrations(fsys, "m")
+		require.NoError(t, err)
+		require.Len(t, migrations, 2)
+
+		assert.Equal(t, int64(1), migrations[0].Version)
+		assert.Equal(t, "create_schema", migrations[0].Name)
+		assert.Equal(t, "CREATE TABLE t (a INT)", migrations[0].Up)
+		assert.Equal(t, "DROP TABLE t", migrations[0].Down)
+		assert.Len(t, migrations[0].Checksum, 64)
+		assert.Equal(t, int64(2), migrations[1].Version)
+		assert.NotEqual(t, migrations[0].Checksum, migrations[1].Checksum)
+	})
+
+	t.Run("rejects a migration without a down file", func(t *testing.T) {
+		fsys := fstest.MapFS{"m/0001_create_schema.up.sql": {Data: []byte("CREATE TABLE t (a INT)")}}
+
+		_, err := loadMigrations(fsys, "m")
+		assert.ErrorContains(t, err, "needs both an up and a down file")
+	})
+
+	t.Run("rejects a reused version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"m/0001_a.up.sql":   {Data: []byte("SELECT 1")},
+			"m/0001_a.down.sql": {Data: []byte("SELECT 1")},
+			"m/0001_b.up.sql":   {Data: []byte("SELECT 2")},
+			"m/0001_b.down.sql": {Data: []byte("SELECT 2")},
+		}
+
+		_, err := loadMigrations(fsys, "m")
+		assert.ErrorContains(t, err, "is used by both")
+	})
+
+	t.Run("rejects stray files", func(t *testing.T) {
+		fsys := fstest.MapFS{"m/README.md": {Data: []byte("notes")}}
+
+		_, err := loadMigrations(fsys, "m")
+		assert.ErrorContains(t, err, "unexpected file")
+	})
+
+	t.Run("embedded migrations load for every dialect", func(t *testing.T) {
+		for _, d := range []dialect{mysqlDialect, postgresDialect} {
+			migrations, err := loadMigrations(migrationFiles, d.migrations)
+			require.NoError(t, err, d.migrations)
+			assert.NotEmpty(t, migrations, d.migrations)
+		}
+	})
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `
+-- A comment; with a semicolon
+CREATE TABLE a (id INT);
+
+CREATE TABLE b (id INT);
+-- trailing comment
+`
+	assert.Equal(t, []string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"}, splitStatements(script))
+}
+
+// newTestMigrator returns a MySQL migrator over two small migrations and a sqlmock connection.
+func newTestMigrator(t *testing.T) (*Migrator, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrations, err := loadMigrations(fstest.MapFS{
+		"m/0001_create_t.up.sql":   {Data: []byte("CREATE TABLE t (a INT)")},
+		"m/0001_create_t.down.sql": {Data: []byte("DROP TABLE t")},
+		"m/0002_create_u.up.sql":   {Data: []byte("CREATE TABLE u (a INT); CREATE INDEX i ON u (a)")},
+		"m/0002_create_u.down.sql": {Data: []byte("DROP TABLE u")},
+	}, "m")
+	require.NoError(t, err)
+
+	return &Migrator{db: sqlDB, dialect: mysqlDialect, migrations: migrations}, mock
+}
+
+// expectLocked expects the lock and schema_migrations setup around every migrator operation.
+func expectLocked(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT GET_LOCK`).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(1))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func TestMigrator_Up(t *testing.T) {
+	t.Run("applies pending migrations in order", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		appliedAt := time.Now()
+
+		expectLocked(mock)
+		mock.ExpectQuery(`SELECT version, name, checksum, applied_at FROM schema_migrations`).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).
+				AddRow(1, "create_t", migrator.migrations[0].Checksum, appliedAt))
+		mock.ExpectExec(`CREATE TABLE u`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`CREATE INDEX i ON u`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO schema_migrations\(version, name, checksum\) VALUES \(\?, \?, \?\)`).
+			WithArgs(int64(2), "create_u", migrator.migrations[1].Checksum).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`SELECT RELEASE_LOCK`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		applied, err := migrator.Up(context.Background())
+		require.NoError(t, err)
+		require.Len(t, applied, 1)
+		assert.Equal(t, int64(2), applied[0].Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("refuses to run when an applied migration was edited", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+
+		expectLocked(mock)
+		mock.ExpectQuery(`SELECT version, name, checksum, applied_at FROM schema_migrations`).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).
+				AddRow(1, "create_t", "stale", time.Now()))
+		mock.ExpectExec(`SELECT RELEASE_LOCK`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		applied, err := migrator.Up(context.Background())
+		assert.True(t, errors.Is(err, ErrChecksumMismatch))
+		assert.Empty(t, applied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("fails when the lock times out", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+
+		mock.ExpectQuery(`SELECT GET_LOCK`).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(0))
+
+		_, err := migrator.Up(context.Background())
+		assert.ErrorContains(t, err, "timed out")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMigrator_Down(t *testing.T) {
+	t.Run("reverts the latest applied migration", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+
+		expectLocked(mock)
+		rows := sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"})
+		for _, migration := range migrator.migrations {
+			rows.AddRow(migration.Version, migration.Name, migration.Checksum, time.Now())
+		}
+		mock.ExpectQuery(`SELECT version, name, checksum, applied_at FROM schema_migrations`).WillReturnRows(rows)
+		mock.ExpectExec(`DROP TABLE u`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`DELETE FROM schema_migrations WHERE version = \?`).
+			WithArgs(int64(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`SELECT RELEASE_LOCK`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		reverted, err := migrator.Down(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, reverted)
+		assert.Equal(t, int64(2), reverted.Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("does nothing when nothing is applied", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+
+		expectLocked(mock)
+		mock.ExpectQuery(`SELECT version, name, checksum, applied_at FROM schema_migrations`).
+			WillReturnRows(sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}))
+		mock.ExpectExec(`SELECT RELEASE_LOCK`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		reverted, err := migrator.Down(context.Background())
+		require.NoError(t, err)
+		assert.Nil(t, reverted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMigrator_Status(t *testing.T) {
+	migrator, mock := newTestMigrator(t)
+	appliedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	expectLocked(mock)
+	mock.ExpectQuery(`SELECT version, name, checksum, applied_at FROM schema_migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).
+			AddRow(1, "create_t", "stale", appliedAt).
+			AddRow(9, "removed", "abc", appliedAt))
+	mock.ExpectExec(`SELECT RELEASE_LOCK`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	statuses, err := migrator.Status(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []MigrationStatus{
+		{Version: 1, Name: "create_t", State: MigrationModified, AppliedAt: appliedAt},
+		{Version: 2, Name: "create_u", State: MigrationPending},
+		{Version: 9, Name: "removed", State: MigrationMissing, AppliedAt: appliedAt},
+	}, statuses)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpgradeLegacySchemaMigration(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles, mysqlDialect.migrations)
+	require.NoError(t, err)
+
+	var upgrade *Migration
+	for i := range migrations {
+		if migrations[i].Name == "upgrade_legacy_schema" {
+			upgrade = &migrations[i]
+		}
+	}
+	require.NotNil(t, upgrade)
+
+	// One ALTER TABLE for the columns, then SET, PREPARE, EXECUTE and DEALLOCATE for each of the five indexes
+	statements := splitStatements(upgrade.Up)
+	require.Len(t, statements, 21)
+	assert.True(t, strings.HasPrefix(statements[0], "ALTER TABLE transactions"))
+	for i := 1; i < len(statements); i += 4 {
+		assert.True(t, strings.HasPrefix(statements[i], "SET @ddl = IF("), statements[i])
+		assert.Equal(t, "PREPARE add_index FROM @ddl", statements[i+1])
+		assert.Equal(t, "EXECUTE add_index", statements[i+2])
+		assert.Equal(t, "DEALLOCATE PREPARE add_index", statements[i+3])
+	}
+}
@@ -0,0 +1,52 @@
+-- Baseline schema. Tables are only created if missing, so databases initialized from the
+-- former db/init.sql adopt this migration without changes.
+
+CREATE TABLE IF NOT EXISTS transactions
+(
+    id       VARCHAR(64) PRIMARY KEY,
+    amount   DECIMAL(19, 4)                          NOT NULL,
+    currency VARCHAR(10)                             NOT NULL,
+    sender   VARCHAR(255)                            NOT NULL,
+    receiver VARCHAR(255)                            NOT NULL,
+    status   ENUM ('pending', 'processing', 'completed', 'failed', 'reversed') NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_transactions_created_at_id (created_at, id),
+    INDEX idx_transactions_amount_id (amount, id),
+    INDEX idx_transactions_sender_created_at (sender, created_at),
+    INDEX idx_transactions_receiver_created_at (receiver, created_at),
+    INDEX idx_transactions_status_created_at (status, created_at)
+);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys
+(
+    idempotency_key VARCHAR(255) PRIMARY KEY,
+    request_hash    CHAR(64)   NOT NULL,
+    response_code   SMALLINT   NOT NULL,
+    response_body   MEDIUMBLOB NOT NULL,
+    created_at      TIMESTAMP  NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at      TIMESTAMP  NOT NULL,
+    INDEX idx_idempotency_keys_expires_at (expires_at)
+);
+
+CREATE TABLE IF NOT EXISTS accounts
+(
+    id              VARCHAR(255) PRIMARY KEY,
+    currency        VARCHAR(10)  NOT NULL,
+    allow_overdraft BOOLEAN      NOT NULL DEFAULT FALSE,
+    created_at      TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE TABLE IF NOT EXISTS ledger_entries
+(
+    id             BIGINT AUTO_INCREMENT PRIMARY KEY,
+    transaction_id VARCHAR(64)               NOT NULL,
+    account_id     VARCHAR(255)              NOT NULL,
+    direction      ENUM ('debit', 'credit') NOT NULL,
+    amount         DECIMAL(19, 4)            NOT NULL,
+    currency       VARCHAR(10)               NOT NULL,
+    created_at     TIMESTAMP(6)              NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_ledger_entries_account_id_id (account_id, id),
+    INDEX idx_ledger_entries_transaction_id (transaction_id),
+    CONSTRAINT fk_ledger_entries_account FOREIGN KEY (account_id) REFERENCES accounts (id),
+    CONSTRAINT fk_ledger_entries_transaction FOREIGN KEY (transaction_id) REFERENCES transactions (id)
+);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS transaction_events;
+
+ALTER TABLE transactions
+    DROP COLUMN failure_reason;
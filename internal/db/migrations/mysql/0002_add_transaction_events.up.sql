@@ -0,0 +1,15 @@
+ALTER TABLE transactions
+    ADD COLUMN failure_reason VARCHAR(512) NULL AFTER status;
+
+CREATE TABLE IF NOT EXISTS transaction_events
+(
+    id             BIGINT AUTO_INCREMENT PRIMARY KEY,
+    transaction_id VARCHAR(64)                                                   NOT NULL,
+    from_status    ENUM ('pending', 'processing', 'completed', 'failed', 'reversed') NOT NULL,
+    to_status      ENUM ('pending', 'processing', 'completed', 'failed', 'reversed') NOT NULL,
+    reason         VARCHAR(512)                                                  NULL,
+    actor          VARCHAR(255)                                                  NOT NULL,
+    created_at     TIMESTAMP(6)                                                  NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_transaction_events_transaction_id_id (transaction_id, id),
+    CONSTRAINT fk_transaction_events_transaction FOREIGN KEY (transaction_id) REFERENCES transactions (id)
+);
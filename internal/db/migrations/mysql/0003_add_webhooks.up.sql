@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS outbox_events
+(
+    id             BIGINT AUTO_INCREMENT PRIMARY KEY,
+    event_type     VARCHAR(64)  NOT NULL,
+    transaction_id VARCHAR(64)  NOT NULL,
+    payload        JSON         NOT NULL,
+    created_at     TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_outbox_events_transaction_id (transaction_id),
+    CONSTRAINT fk_outbox_events_transaction FOREIGN KEY (transaction_id) REFERENCES transactions (id)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_endpoints
+(
+    id         VARCHAR(64)   PRIMARY KEY,
+    url        VARCHAR(2048) NOT NULL,
+    secret     VARCHAR(255)  NOT NULL,
+    enabled    BOOLEAN       NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id                 BIGINT AUTO_INCREMENT PRIMARY KEY,
+    event_id           BIGINT                                NOT NULL,
+    endpoint_id        VARCHAR(64)                           NOT NULL,
+    status             ENUM ('pending', 'delivered', 'dead') NOT NULL DEFAULT 'pending',
+    attempts           INT                                   NOT NULL DEFAULT 0,
+    next_attempt_at    TIMESTAMP(6)                          NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    last_response_code SMALLINT                              NULL,
+    last_error         VARCHAR(1024)                         NULL,
+    delivered_at       TIMESTAMP(6)                          NULL,
+    created_at         TIMESTAMP(6)                          NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_webhook_deliveries_status_next_attempt_at (status, next_attempt_at),
+    INDEX idx_webhook_deliveries_endpoint_id_id (endpoint_id, id),
+    CONSTRAINT fk_webhook_deliveries_event FOREIGN KEY (event_id) REFERENCES outbox_events (id),
+    CONSTRAINT fk_webhook_deliveries_endpoint FOREIGN KEY (endpoint_id) REFERENCES webhook_endpoints (id) ON DELETE CASCADE
+);
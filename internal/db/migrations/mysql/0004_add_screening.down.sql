@@ -0,0 +1,11 @@
+-- Fails while any transaction is, or was ever, held: release or reject held transactions first.
+
+ALTER TABLE transaction_events
+    MODIFY COLUMN from_status ENUM ('pending', 'processing', 'completed', 'failed', 'reversed') NOT NULL,
+    MODIFY COLUMN to_status   ENUM ('pending', 'processing', 'completed', 'failed', 'reversed') NOT NULL;
+
+ALTER TABLE transactions
+    DROP COLUMN screening_reason,
+    DROP COLUMN screening_screener,
+    DROP COLUMN screening_decision,
+    MODIFY COLUMN status ENUM ('pending', 'processing', 'completed', 'failed', 'reversed') NOT NULL DEFAULT 'pending';
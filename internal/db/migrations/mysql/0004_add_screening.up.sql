@@ -0,0 +1,11 @@
+-- Screening decisions are stored on the transaction, and transactions held for review get their own status.
+
+ALTER TABLE transactions
+    MODIFY COLUMN status ENUM ('pending', 'processing', 'completed', 'failed', 'reversed', 'held') NOT NULL DEFAULT 'pending',
+    ADD COLUMN screening_decision ENUM ('approve', 'review', 'reject') NULL AFTER failure_reason,
+    ADD COLUMN screening_screener VARCHAR(64)                          NULL AFTER screening_decision,
+    ADD COLUMN screening_reason   VARCHAR(512)                         NULL AFTER screening_screener;
+
+ALTER TABLE transaction_events
+    MODIFY COLUMN from_status ENUM ('pending', 'processing', 'completed', 'failed', 'reversed', 'held') NOT NULL,
+    MODIFY COLUMN to_status   ENUM ('pending', 'processing', 'completed', 'failed', 'reversed', 'held') NOT NULL;
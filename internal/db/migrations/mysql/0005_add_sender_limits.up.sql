@@ -0,0 +1,12 @@
+-- Per-sender overrides of the default spending limits. A row without max_count or max_amount
+-- lifts the default limit with the same currency and window for that sender.
+CREATE TABLE IF NOT EXISTS sender_limits
+(
+    sender         VARCHAR(255)   NOT NULL,
+    currency       VARCHAR(10)    NOT NULL,
+    window_seconds BIGINT         NOT NULL,
+    max_count      INT            NULL,
+    max_amount     DECIMAL(19, 4) NULL,
+    created_at     TIMESTAMP(6)   NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (sender, currency, window_seconds)
+);
@@ -0,0 +1,23 @@
+-- Cross-currency transactions store the amount the receiver is credited and the rate applied.
+-- Quotes lock a rate for one transaction until they expire.
+
+ALTER TABLE transactions
+    ADD COLUMN target_currency VARCHAR(10)     NULL AFTER screening_reason,
+    ADD COLUMN target_amount   DECIMAL(19, 4)  NULL AFTER target_currency,
+    ADD COLUMN fx_rate         DECIMAL(19, 10) NULL AFTER target_amount,
+    ADD COLUMN quote_id        VARCHAR(64)     NULL AFTER fx_rate;
+
+CREATE TABLE IF NOT EXISTS quotes
+(
+    id              VARCHAR(64)     PRIMARY KEY,
+    source_currency VARCHAR(10)     NOT NULL,
+    source_amount   DECIMAL(19, 4)  NOT NULL,
+    target_currency VARCHAR(10)     NOT NULL,
+    target_amount   DECIMAL(19, 4)  NOT NULL,
+    rate            DECIMAL(19, 10) NOT NULL,
+    provider        VARCHAR(64)     NOT NULL,
+    transaction_id  VARCHAR(64)     NULL,
+    created_at      TIMESTAMP(6)    NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    expires_at      TIMESTAMP(6)    NOT NULL,
+    UNIQUE INDEX uq_quotes_transaction_id (transaction_id)
+);
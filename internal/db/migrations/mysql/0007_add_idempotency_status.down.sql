@@ -0,0 +1,4 @@
+DELETE FROM idempotency_keys WHERE status = 'pending';
+
+ALTER TABLE idempotency_keys
+    DROP COLUMN status;
@@ -0,0 +1,4 @@
+-- Idempotency keys are reserved as pending before their request runs and completed with the response
+-- in the same SQL transaction as the transaction it created. Existing rows all hold a response.
+ALTER TABLE idempotency_keys
+    ADD COLUMN status ENUM ('pending', 'completed') NOT NULL DEFAULT 'completed' AFTER request_hash;
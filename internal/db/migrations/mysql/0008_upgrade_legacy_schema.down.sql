@@ -0,0 +1,2 @@
+-- Nothing to revert: the up migration only brings legacy tables to the shape 0001_create_schema creates.
+DO 0;
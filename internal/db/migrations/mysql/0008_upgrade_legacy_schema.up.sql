@@ -0,0 +1,45 @@
+-- Brings a transactions table created by an old db/init.sql up to the baseline shape. 0001_create_schema
+-- leaves an existing table alone, and those scripts stored amounts as DECIMAL(10, 2), creation times in whole
+-- seconds and had none of the indexes below. The status enum is widened by 0004_add_screening.
+-- On a table created by 0001_create_schema every statement leaves the schema as it is.
+
+ALTER TABLE transactions
+    MODIFY COLUMN amount     DECIMAL(19, 4) NOT NULL,
+    MODIFY COLUMN created_at TIMESTAMP(6)   NOT NULL DEFAULT CURRENT_TIMESTAMP(6);
+
+-- MySQL has no ADD INDEX IF NOT EXISTS, so each index is added by a prepared statement only where it is missing.
+
+SET @ddl = IF((SELECT COUNT(*) FROM information_schema.statistics
+               WHERE table_schema = DATABASE() AND table_name = 'transactions' AND index_name = 'idx_transactions_created_at_id') = 0,
+              'ALTER TABLE transactions ADD INDEX idx_transactions_created_at_id (created_at, id)', 'DO 0');
+PREPARE add_index FROM @ddl;
+EXECUTE add_index;
+DEALLOCATE PREPARE add_index;
+
+SET @ddl = IF((SELECT COUNT(*) FROM information_schema.statistics
+               WHERE table_schema = DATABASE() AND table_name = 'transactions' AND index_name = 'idx_transactions_amount_id') = 0,
+              'ALTER TABLE transactions ADD INDEX idx_transactions_amount_id (amount, id)', 'DO 0');
+PREPARE add_index FROM @ddl;
+EXECUTE add_index;
+DEALLOCATE PREPARE add_index;
+
+SET @ddl = IF((SELECT COUNT(*) FROM information_schema.statistics
+               WHERE table_schema = DATABASE() AND table_name = 'transactions' AND index_name = 'idx_transactions_sender_created_at') = 0,
+              'ALTER TABLE transactions ADD INDEX idx_transactions_sender_created_at (sender, created_at)', 'DO 0');
+PREPARE add_index FROM @ddl;
+EXECUTE add_index;
+DEALLOCATE PREPARE add_index;
+
+SET @ddl = IF((SELECT COUNT(*) FROM information_schema.statistics
+               WHERE table_schema = DATABASE() AND table_name = 'transactions' AND index_name = 'idx_transactions_receiver_created_at') = 0,
+              'ALTER TABLE transactions ADD INDEX idx_transactions_receiver_created_at (receiver, created_at)', 'DO 0');
+PREPARE add_index FROM @ddl;
+EXECUTE add_index;
+DEALLOCATE PREPARE add_index;
+
+SET @ddl = IF((SELECT COUNT(*) FROM information_schema.statistics
+               WHERE table_schema = DATABASE() AND table_name = 'transactions' AND index_name = 'idx_transactions_status_created_at') = 0,
+              'ALTER TABLE transactions ADD INDEX idx_transactions_status_created_at (status, created_at)', 'DO 0');
+PREPARE add_index FROM @ddl;
+EXECUTE add_index;
+DEALLOCATE PREPARE add_index;
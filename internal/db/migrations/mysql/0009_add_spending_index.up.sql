@@ -0,0 +1,10 @@
+-- Spending totals look up a sender's transactions in one currency since a point in time. Currencies have been
+-- stored upper case since codes were checked against ISO 4217; older rows are brought into line so that totals
+-- can compare them exactly.
+
+UPDATE transactions
+SET currency = UPPER(currency)
+WHERE CAST(currency AS BINARY) <> CAST(UPPER(currency) AS BINARY);
+
+ALTER TABLE transactions
+    ADD INDEX idx_transactions_sender_currency_created_at (sender, currency, created_at);
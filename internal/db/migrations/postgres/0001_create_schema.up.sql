@@ -0,0 +1,54 @@
+-- PostgreSQL counterpart of mysql/0001_create_schema.up.sql.
+-- IDs use the "C" collation so that keyset pagination orders them bytewise, as MySQL does.
+
+CREATE TABLE IF NOT EXISTS transactions
+(
+    id         VARCHAR(64) COLLATE "C" PRIMARY KEY,
+    amount     NUMERIC(19, 4)          NOT NULL,
+    currency   VARCHAR(10)             NOT NULL,
+    sender     VARCHAR(255)            NOT NULL,
+    receiver   VARCHAR(255)            NOT NULL,
+    status     VARCHAR(16)             NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'processing', 'completed', 'failed', 'reversed')),
+    created_at TIMESTAMPTZ(6)          NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_created_at_id ON transactions (created_at, id);
+CREATE INDEX IF NOT EXISTS idx_transactions_amount_id ON transactions (amount, id);
+CREATE INDEX IF NOT EXISTS idx_transactions_sender_created_at ON transactions (sender, created_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_receiver_created_at ON transactions (receiver, created_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_status_created_at ON transactions (status, created_at);
+
+CREATE TABLE IF NOT EXISTS idempotency_keys
+(
+    idempotency_key VARCHAR(255) PRIMARY KEY,
+    request_hash    CHAR(64)       NOT NULL,
+    response_code   SMALLINT       NOT NULL,
+    response_body   BYTEA          NOT NULL,
+    created_at      TIMESTAMPTZ(0) NOT NULL DEFAULT CURRENT_TIMESTAMP(0),
+    expires_at      TIMESTAMPTZ(0) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+
+CREATE TABLE IF NOT EXISTS accounts
+(
+    id              VARCHAR(255) COLLATE "C" PRIMARY KEY,
+    currency        VARCHAR(10)              NOT NULL,
+    allow_overdraft BOOLEAN                  NOT NULL DEFAULT FALSE,
+    created_at      TIMESTAMPTZ(6)           NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE TABLE IF NOT EXISTS ledger_entries
+(
+    id             BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    transaction_id VARCHAR(64) COLLATE "C"  NOT NULL REFERENCES transactions (id),
+    account_id     VARCHAR(255) COLLATE "C" NOT NULL REFERENCES accounts (id),
+    direction      VARCHAR(6)               NOT NULL CHECK (direction IN ('debit', 'credit')),
+    amount         NUMERIC(19, 4)           NOT NULL,
+    currency       VARCHAR(10)              NOT NULL,
+    created_at     TIMESTAMPTZ(6)           NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account_id_id ON ledger_entries (account_id, id);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction_id ON ledger_entries (transaction_id);
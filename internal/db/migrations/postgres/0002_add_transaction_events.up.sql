@@ -0,0 +1,17 @@
+ALTER TABLE transactions
+    ADD COLUMN failure_reason VARCHAR(512) NULL;
+
+CREATE TABLE IF NOT EXISTS transaction_events
+(
+    id             BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    transaction_id VARCHAR(64) COLLATE "C" NOT NULL REFERENCES transactions (id),
+    from_status    VARCHAR(16)             NOT NULL
+        CHECK (from_status IN ('pending', 'processing', 'completed', 'failed', 'reversed')),
+    to_status      VARCHAR(16)             NOT NULL
+        CHECK (to_status IN ('pending', 'processing', 'completed', 'failed', 'reversed')),
+    reason         VARCHAR(512)            NULL,
+    actor          VARCHAR(255)            NOT NULL,
+    created_at     TIMESTAMPTZ(6)          NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_events_transaction_id_id ON transaction_events (transaction_id, id);
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS outbox_events
+(
+    id             BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    event_type     VARCHAR(64)             NOT NULL,
+    transaction_id VARCHAR(64) COLLATE "C" NOT NULL REFERENCES transactions (id),
+    payload        JSONB                   NOT NULL,
+    created_at     TIMESTAMPTZ(6)          NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_transaction_id ON outbox_events (transaction_id);
+
+CREATE TABLE IF NOT EXISTS webhook_endpoints
+(
+    id         VARCHAR(64) COLLATE "C" PRIMARY KEY,
+    url        VARCHAR(2048)           NOT NULL,
+    secret     VARCHAR(255)            NOT NULL,
+    enabled    BOOLEAN                 NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ(6)          NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id                 BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    event_id           BIGINT                  NOT NULL REFERENCES outbox_events (id),
+    endpoint_id        VARCHAR(64) COLLATE "C" NOT NULL REFERENCES webhook_endpoints (id) ON DELETE CASCADE,
+    status             VARCHAR(16)             NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts           INT                     NOT NULL DEFAULT 0,
+    next_attempt_at    TIMESTAMPTZ(6)          NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    last_response_code SMALLINT                NULL,
+    last_error         VARCHAR(1024)           NULL,
+    delivered_at       TIMESTAMPTZ(6)          NULL,
+    created_at         TIMESTAMPTZ(6)          NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status_next_attempt_at ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint_id_id ON webhook_deliveries (endpoint_id, id);
@@ -0,0 +1,17 @@
+-- Fails while any transaction is, or was ever, held: release or reject held transactions first.
+
+ALTER TABLE transaction_events
+    DROP CONSTRAINT transaction_events_from_status_check,
+    ADD CONSTRAINT transaction_events_from_status_check
+        CHECK (from_status IN ('pending', 'processing', 'completed', 'failed', 'reversed')),
+    DROP CONSTRAINT transaction_events_to_status_check,
+    ADD CONSTRAINT transaction_events_to_status_check
+        CHECK (to_status IN ('pending', 'processing', 'completed', 'failed', 'reversed'));
+
+ALTER TABLE transactions
+    DROP COLUMN screening_reason,
+    DROP COLUMN screening_screener,
+    DROP COLUMN screening_decision,
+    DROP CONSTRAINT transactions_status_check,
+    ADD CONSTRAINT transactions_status_check
+        CHECK (status IN ('pending', 'processing', 'completed', 'failed', 'reversed'));
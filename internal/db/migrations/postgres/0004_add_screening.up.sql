@@ -0,0 +1,18 @@
+-- Screening decisions are stored on the transaction, and transactions held for review get their own status.
+-- The status checks were created unnamed in 0001 and 0002, so they carry PostgreSQL's default names.
+
+ALTER TABLE transactions
+    DROP CONSTRAINT transactions_status_check,
+    ADD CONSTRAINT transactions_status_check
+        CHECK (status IN ('pending', 'processing', 'completed', 'failed', 'reversed', 'held')),
+    ADD COLUMN screening_decision VARCHAR(16)  NULL CHECK (screening_decision IN ('approve', 'review', 'reject')),
+    ADD COLUMN screening_screener VARCHAR(64)  NULL,
+    ADD COLUMN screening_reason   VARCHAR(512) NULL;
+
+ALTER TABLE transaction_events
+    DROP CONSTRAINT transaction_events_from_status_check,
+    ADD CONSTRAINT transaction_events_from_status_check
+        CHECK (from_status IN ('pending', 'processing', 'completed', 'failed', 'reversed', 'held')),
+    DROP CONSTRAINT transaction_events_to_status_check,
+    ADD CONSTRAINT transaction_events_to_status_check
+        CHECK (to_status IN ('pending', 'processing', 'completed', 'failed', 'reversed', 'held'));
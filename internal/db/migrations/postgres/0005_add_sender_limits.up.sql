@@ -0,0 +1,11 @@
+-- PostgreSQL counterpart of mysql/0005_add_sender_limits.up.sql.
+CREATE TABLE IF NOT EXISTS sender_limits
+(
+    sender         VARCHAR(255)   NOT NULL,
+    currency       VARCHAR(10)    NOT NULL,
+    window_seconds BIGINT         NOT NULL,
+    max_count      INT            NULL,
+    max_amount     NUMERIC(19, 4) NULL,
+    created_at     TIMESTAMPTZ(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (sender, currency, window_seconds)
+);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS quotes;
+
+ALTER TABLE transactions
+    DROP COLUMN quote_id,
+    DROP COLUMN fx_rate,
+    DROP COLUMN target_amount,
+    DROP COLUMN target_currency;
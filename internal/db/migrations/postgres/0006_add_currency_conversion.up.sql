@@ -0,0 +1,21 @@
+-- PostgreSQL counterpart of mysql/0006_add_currency_conversion.up.sql.
+
+ALTER TABLE transactions
+    ADD COLUMN target_currency VARCHAR(10)     NULL,
+    ADD COLUMN target_amount   NUMERIC(19, 4)  NULL,
+    ADD COLUMN fx_rate         NUMERIC(19, 10) NULL,
+    ADD COLUMN quote_id        VARCHAR(64)     NULL;
+
+CREATE TABLE IF NOT EXISTS quotes
+(
+    id              VARCHAR(64) PRIMARY KEY,
+    source_currency VARCHAR(10)     NOT NULL,
+    source_amount   NUMERIC(19, 4)  NOT NULL,
+    target_currency VARCHAR(10)     NOT NULL,
+    target_amount   NUMERIC(19, 4)  NOT NULL,
+    rate            NUMERIC(19, 10) NOT NULL,
+    provider        VARCHAR(64)     NOT NULL,
+    transaction_id  VARCHAR(64)     NULL UNIQUE,
+    created_at      TIMESTAMPTZ(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    expires_at      TIMESTAMPTZ(6)  NOT NULL
+);
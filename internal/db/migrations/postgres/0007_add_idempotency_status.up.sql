@@ -0,0 +1,4 @@
+-- PostgreSQL counterpart of mysql/0007_add_idempotency_status.up.sql.
+ALTER TABLE idempotency_keys
+    ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'completed'
+        CHECK (status IN ('pending', 'completed'));
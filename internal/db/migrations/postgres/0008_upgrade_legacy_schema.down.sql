@@ -0,0 +1,2 @@
+-- Intentionally a no-op, like the up migration.
+SELECT 1;
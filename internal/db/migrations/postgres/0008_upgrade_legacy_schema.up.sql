@@ -0,0 +1,4 @@
+-- Intentionally a no-op. Every change needs a MySQL and a PostgreSQL migration with the same version, and
+-- mysql/0008_upgrade_legacy_schema.up.sql upgrades tables created by the former MySQL-only db/init.sql.
+-- PostgreSQL databases were only ever created by 0001_create_schema, so there is nothing to upgrade here.
+SELECT 1;
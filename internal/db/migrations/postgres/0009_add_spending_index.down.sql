@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_transactions_sender_currency_created_at;
@@ -0,0 +1,7 @@
+-- PostgreSQL counterpart of mysql/0009_add_spending_index.up.sql.
+
+UPDATE transactions
+SET currency = UPPER(currency)
+WHERE currency <> UPPER(currency);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_sender_currency_created_at ON transactions (sender, currency, created_at);
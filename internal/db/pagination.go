@@ -0,0 +1,39 @@
+package db
+
+import (
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// Cursor is a position in the (sort key, id) ordering of transactions used for keyset pagination.
+// Unlike LIMIT/OFFSET, seeking from a cursor costs the same on every page and does not skip or
+// repeat rows when transactions are inserted concurrently.
+type Cursor struct {
+	// CreatedAt is the creation time of the transaction at the cursor position
+	CreatedAt time.Time
+	// Amount is the amount of the transaction at the cursor position, used when sorting by amount
+	Amount models.Amount
+	// ID is the ID of the transaction at the cursor position, breaking ties between equal sort keys
+	ID string
+	// Before selects the page preceding the position instead of the page following it
+	Before bool
+}
+
+// CursorFor returns the cursor positioned at a transaction.
+func CursorFor(transaction models.Transaction, before bool) Cursor {
+	return Cursor{
+		CreatedAt: transaction.CreatedAt,
+		Amount:    transaction.Amount,
+		ID:        transaction.ID,
+		Before:    before,
+	}
+}
+
+// sortValue returns the cursor's value for the filter's sort column.
+func (c Cursor) sortValue(filter TransactionFilter) interface{} {
+	if filter.SortBy == SortByAmount {
+		return c.Amount
+	}
+	return c.CreatedAt
+}
@@ -0,0 +1,452 @@
+// Package db implements the database operations for the transaction service.
+// This file contains the PostgreSQL implementation of the DB interface.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+	_ "github.com/lib/pq"
+)
+
+// PostgresDB is the PostgreSQL implementation of the DB interface.
+// It shares its queries with DBImpl, rewriting their placeholders for PostgreSQL,
+// and expects the schema created by the migrations in migrations/postgres.
+type PostgresDB struct {
+	DB *sql.DB
+	// QueryTimeout bounds every individual query; zero means queries are only bounded by the caller's context
+	QueryTimeout time.Duration
+}
+
+// Ensure PostgresDB implements the DB interface at compile time
+var _ DB = (*PostgresDB)(nil)
+
+// postgresDialect adapts the shared queries to PostgreSQL.
+var postgresDialect = dialect{
+	bind:                   func(q querier) querier { return postgresQuerier{q} },
+	ignoreDuplicateAccount: "ON CONFLICT (id) DO NOTHING",
+	lastInsertID:           "SELECT lastval()",
+	migrations:             "migrations/postgres",
+	createMigrationsTable: `
+		CREATE TABLE IF NOT EXISTS schema_migrations
+		(
+			version    BIGINT         PRIMARY KEY,
+			name       VARCHAR(255)   NOT NULL,
+			checksum   CHAR(64)       NOT NULL,
+			applied_at TIMESTAMPTZ(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+		)
+	`,
+	// Arbitrary application-wide key for the advisory lock
+	lockMigrations:   "SELECT 1 FROM pg_advisory_lock(7243061553)",
+	unlockMigrations: "SELECT pg_advisory_unlock(7243061553)",
+	transactionalDDL: true,
+}
+
+// NewPostgresDB creates a new PostgreSQL connection pool and returns the DB interface.
+// It reads the same DB_* environment variables as NewDB, with DB_PORT defaulting to 5432
+// and DB_SSLMODE (default "disable") selecting the TLS mode.
+func NewPostgresDB() (DB, error) {
+	config, err := loadConfig("5432")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	sqlDB, err := connectPostgres(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	return &PostgresDB{DB: sqlDB, QueryTimeout: config.QueryTimeout}, nil
+}
+
+// connectPostgres establishes a connection to the PostgreSQL database using the provided configuration.
+// Sessions run in UTC so that timestamps read back match those from the MySQL backend.
+func connectPostgres(config *Config) (*sql.DB, error) {
+	connURL := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(config.DBUser, config.DBPassword),
+		Host:     net.JoinHostPort(config.DBHost, config.DBPort),
+		Path:     "/" + config.DBName,
+		RawQuery: url.Values{"sslmode": {config.SSLMode}, "connect_timeout": {"5"}, "timezone": {"UTC"}}.Encode(),
+	}
+
+	return openPool("postgres", connURL.String(), config)
+}
+
+// withTimeout derives a context bounded by the configured per-query timeout.
+// The returned cancel function must always be called to release resources.
+func (db *PostgresDB) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return withQueryTimeout(ctx, db.QueryTimeout)
+}
+
+// conn returns the connection pool adapted to PostgreSQL placeholders.
+func (db *PostgresDB) conn() querier {
+	return postgresDialect.bind(db.DB)
+}
+
+// CreateTransaction inserts a new transaction into the database together with its outbox event.
+// The created_at timestamp is set by the column default.
+// Returns ErrDuplicate if a transaction with the same ID already exists.
+func (db *PostgresDB) CreateTransaction(ctx context.Context, transaction models.Transaction) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return createTransaction(ctx, db.DB, postgresDialect, transaction, nil)
+}
+
+// CreateIdempotentTransaction inserts a new transaction and completes the idempotency key reserved for it
+// in one SQL transaction. It behaves exactly like DBImpl.CreateIdempotentTransaction.
+func (db *PostgresDB) CreateIdempotentTransaction(ctx context.Context, transaction models.Transaction, record models.IdempotencyRecord) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return createTransaction(ctx, db.DB, postgresDialect, transaction, &record)
+}
+
+// CreateTransactions inserts a batch of new transactions with multi-row inserts in a single SQL transaction.
+// It behaves exactly like DBImpl.CreateTransactions.
+func (db *PostgresDB) CreateTransactions(ctx context.Context, transactions []models.Transaction) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return createTransactions(ctx, db.DB, postgresDialect, transactions)
+}
+
+// UpdateTransaction moves an existing transaction to a new status.
+// It behaves exactly like DBImpl.UpdateTransaction, including the history and outbox events and atomic ledger postings.
+func (db *PostgresDB) UpdateTransaction(ctx context.Context, id string, change models.StatusChange) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return updateTransaction(ctx, db.DB, postgresDialect, id, change)
+}
+
+// GetTransactionHistory retrieves every status change of a transaction, oldest first.
+// Returns ErrNotFound if no transaction has the given ID.
+func (db *PostgresDB) GetTransactionHistory(ctx context.Context, id string) ([]models.TransactionEvent, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectTransactionHistory(ctx, db.conn(), id)
+}
+
+// GetAllTransactions retrieves a filtered, offset-paginated list of transactions.
+func (db *PostgresDB) GetAllTransactions(ctx context.Context, filter TransactionFilter, limit, offset int) ([]models.Transaction, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return listTransactions(ctx, db.conn(), filter, limit, offset)
+}
+
+// GetTransactionsAfter retrieves up to limit filtered transactions adjacent to a keyset cursor.
+func (db *PostgresDB) GetTransactionsAfter(ctx context.Context, filter TransactionFilter, cursor *Cursor, limit int) ([]models.Transaction, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return listTransactionsAfter(ctx, db.conn(), filter, cursor, limit)
+}
+
+// EachTransaction calls fn with every transaction matching the filter, in the filter's order, as rows are read.
+// Like DBImpl.EachTransaction it is bounded by ctx only.
+func (db *PostgresDB) EachTransaction(ctx context.Context, filter TransactionFilter, fn func(models.Transaction) error) error {
+	return eachTransaction(ctx, db.conn(), filter, fn)
+}
+
+// CountTransactions returns the number of transactions matching the filter.
+func (db *PostgresDB) CountTransactions(ctx context.Context, filter TransactionFilter) (int, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return countTransactions(ctx, db.conn(), filter)
+}
+
+// GetTransaction retrieves a single transaction by its ID.
+// Returns ErrNotFound if no transaction is found with the given ID.
+func (db *PostgresDB) GetTransaction(ctx context.Context, id string) (*models.Transaction, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectTransaction(ctx, db.conn(), id)
+}
+
+// GetIdempotencyRecord retrieves an unexpired idempotency record by its key.
+// Returns ErrNotFound if the key has never been used or its expiry window has passed.
+func (db *PostgresDB) GetIdempotencyRecord(ctx context.Context, key string) (*models.IdempotencyRecord, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectIdempotencyRecord(ctx, db.conn(), key)
+}
+
+// ReserveIdempotencyKey claims an idempotency key for a request that is about to run.
+// It behaves exactly like DBImpl.ReserveIdempotencyKey.
+func (db *PostgresDB) ReserveIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return reserveIdempotencyKey(ctx, db.conn(), record)
+}
+
+// ReleaseIdempotencyKey deletes a pending reservation made by ReserveIdempotencyKey.
+// It behaves exactly like DBImpl.ReleaseIdempotencyKey.
+func (db *PostgresDB) ReleaseIdempotencyKey(ctx context.Context, record models.IdempotencyRecord) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return releaseIdempotencyKey(ctx, db.conn(), record)
+}
+
+// CreateAccount opens a new account.
+// Returns ErrDuplicate if an account with the same ID already exists.
+func (db *PostgresDB) CreateAccount(ctx context.Context, account models.Account) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return insertAccount(ctx, db.conn(), account)
+}
+
+// GetAccount retrieves a single account by its ID.
+// Returns ErrNotFound if no account has the given ID.
+func (db *PostgresDB) GetAccount(ctx context.Context, id string) (*models.Account, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectAccount(ctx, db.conn(), id)
+}
+
+// GetAccountBalance derives an account's balance by summing its ledger entries.
+// Returns ErrNotFound if no account has the given ID.
+func (db *PostgresDB) GetAccountBalance(ctx context.Context, id string) (*models.Balance, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectBalance(ctx, db.conn(), id)
+}
+
+// GetLedgerEntries retrieves up to limit of an account's ledger entries with IDs greater than afterID.
+// Returns ErrNotFound if no account has the given ID.
+func (db *PostgresDB) GetLedgerEntries(ctx context.Context, accountID string, afterID int64, limit int) ([]models.LedgerEntry, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectLedgerEntries(ctx, db.conn(), accountID, afterID, limit)
+}
+
+// CreateWebhookEndpoint registers an endpoint for every event written from now on.
+// Returns ErrDuplicate if an endpoint with the same ID already exists.
+func (db *PostgresDB) CreateWebhookEndpoint(ctx context.Context, endpoint models.WebhookEndpoint) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return insertWebhookEndpoint(ctx, db.conn(), endpoint)
+}
+
+// GetWebhookEndpoint retrieves a single endpoint, including its secret, by its ID.
+// Returns ErrNotFound if no endpoint has the given ID.
+func (db *PostgresDB) GetWebhookEndpoint(ctx context.Context, id string) (*models.WebhookEndpoint, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectWebhookEndpoint(ctx, db.conn(), id)
+}
+
+// ListWebhookEndpoints retrieves every endpoint, including its secret, in registration order.
+func (db *PostgresDB) ListWebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectWebhookEndpoints(ctx, db.conn())
+}
+
+// UpdateWebhookEndpoint replaces an endpoint's URL and enabled flag; its secret and creation time are kept.
+// Returns ErrNotFound if no endpoint has the given ID.
+func (db *PostgresDB) UpdateWebhookEndpoint(ctx context.Context, endpoint models.WebhookEndpoint) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return updateWebhookEndpoint(ctx, db.conn(), endpoint)
+}
+
+// DeleteWebhookEndpoint removes an endpoint together with its deliveries.
+// Returns ErrNotFound if no endpoint has the given ID.
+func (db *PostgresDB) DeleteWebhookEndpoint(ctx context.Context, id string) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return deleteWebhookEndpoint(ctx, db.conn(), id)
+}
+
+// ListWebhookDeliveries retrieves up to limit of an endpoint's deliveries with IDs greater than afterID.
+// Returns ErrNotFound if no endpoint has the given ID.
+func (db *PostgresDB) ListWebhookDeliveries(ctx context.Context, endpointID string, status models.DeliveryStatus, afterID int64, limit int) ([]models.WebhookDelivery, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectWebhookDeliveries(ctx, db.conn(), endpointID, status, afterID, limit)
+}
+
+// RedeliverWebhookDelivery queues a delivery to be attempted again straight away with a fresh set of attempts.
+// Returns ErrNotFound if the endpoint has no delivery with the given ID.
+func (db *PostgresDB) RedeliverWebhookDelivery(ctx context.Context, endpointID string, deliveryID int64) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return redeliverWebhookDelivery(ctx, db.conn(), endpointID, deliveryID)
+}
+
+// ClaimWebhookDeliveries claims up to limit due deliveries, leasing them until now+lease.
+// It behaves exactly like DBImpl.ClaimWebhookDeliveries.
+func (db *PostgresDB) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.PendingDelivery, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return claimWebhookDeliveries(ctx, db.DB, postgresDialect, now, lease, limit)
+}
+
+// RecordDeliveryAttempt stores the outcome of an attempt to deliver a claimed webhook and counts the attempt.
+// Returns ErrNotFound if the delivery no longer exists.
+func (db *PostgresDB) RecordDeliveryAttempt(ctx context.Context, deliveryID int64, attempt models.DeliveryAttempt) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return recordDeliveryAttempt(ctx, db.conn(), deliveryID, attempt)
+}
+
+// ListOutboxEvents retrieves up to limit outbox events with an ID greater than afterID, oldest first.
+func (db *PostgresDB) ListOutboxEvents(ctx context.Context, afterID int64, limit int) ([]models.OutboxEvent, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectOutboxEvents(ctx, db.conn(), afterID, limit)
+}
+
+// LastOutboxEventID returns the ID of the latest outbox event, or 0 if the outbox is empty.
+func (db *PostgresDB) LastOutboxEventID(ctx context.Context) (int64, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectLastOutboxEventID(ctx, db.conn())
+}
+
+// GetSpendingTotal counts and sums a sender's non-failed transactions in a currency created at or after since.
+func (db *PostgresDB) GetSpendingTotal(ctx context.Context, sender, currency string, since time.Time) (*models.SpendingTotal, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectSpendingTotal(ctx, db.conn(), sender, currency, since)
+}
+
+// GetSenderLimits retrieves a sender's overrides of the default spending limits, ordered by currency, then window.
+func (db *PostgresDB) GetSenderLimits(ctx context.Context, sender string) ([]models.SpendingLimit, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectSenderLimits(ctx, db.conn(), sender)
+}
+
+// SetSenderLimits replaces every limit override of a sender in one SQL transaction.
+func (db *PostgresDB) SetSenderLimits(ctx context.Context, sender string, limits []models.SpendingLimit) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return replaceSenderLimits(ctx, db.DB, postgresDialect, sender, limits)
+}
+
+// GetSenderTotals counts and sums each sender's completed transactions per currency, optionally in one currency only.
+func (db *PostgresDB) GetSenderTotals(ctx context.Context, currency string) ([]models.SenderTotal, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectSenderTotals(ctx, db.conn(), currency)
+}
+
+// GetTopSenders returns, for each currency, the limit senders with the largest total of completed transactions
+// created at or after since.
+func (db *PostgresDB) GetTopSenders(ctx context.Context, currency string, since time.Time, limit int) ([]models.SenderTotal, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectTopSenders(ctx, db.conn(), currency, since, limit)
+}
+
+// GetFailureHeavySenders returns the senders with more than threshold failed transactions, most failures first.
+func (db *PostgresDB) GetFailureHeavySenders(ctx context.Context, threshold int) ([]models.SenderFailures, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectFailureHeavySenders(ctx, db.conn(), threshold)
+}
+
+// CreateQuote stores a newly issued quote. Returns ErrDuplicate if a quote with the same ID already exists.
+func (db *PostgresDB) CreateQuote(ctx context.Context, quote models.Quote) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return insertQuote(ctx, db.conn(), quote)
+}
+
+// GetQuote retrieves a quote by its ID, whether or not it has expired or been used.
+func (db *PostgresDB) GetQuote(ctx context.Context, id string) (*models.Quote, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectQuote(ctx, db.conn(), id)
+}
+
+// Close closes the database connection.
+func (db *PostgresDB) Close() error {
+	if db.DB != nil {
+		return db.DB.Close()
+	}
+	return nil
+}
+
+// postgresQuerier runs queries written with MySQL's "?" placeholders against PostgreSQL.
+type postgresQuerier struct {
+	q querier
+}
+
+// ExecContext executes a query after rewriting its placeholders.
+func (p postgresQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return p.q.ExecContext(ctx, rebind(query), args...)
+}
+
+// QueryContext runs a query after rewriting its placeholders.
+func (p postgresQuerier) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return p.q.QueryContext(ctx, rebind(query), args...)
+}
+
+// QueryRowContext runs a single-row query after rewriting its placeholders.
+func (p postgresQuerier) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return p.q.QueryRowContext(ctx, rebind(query), args...)
+}
+
+// rebind rewrites "?" placeholders as PostgreSQL's numbered "$1", "$2", ... placeholders.
+// Question marks inside single-quoted string literals are left alone.
+func rebind(query string) string {
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+
+	n := 0
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == '?' && !quoted:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
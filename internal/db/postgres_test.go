@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{"no placeholders", "SELECT 1", "SELECT 1"},
+		{"numbers placeholders in order", "SELECT id FROM t WHERE a = ? AND b IN (?, ?) LIMIT ?", "SELECT id FROM t WHERE a = $1 AND b IN ($2, $3) LIMIT $4"},
+		{"ignores question marks in literals", "SELECT 'why?' FROM t WHERE a = ?", "SELECT 'why?' FROM t WHERE a = $1"},
+		{"handles escaped quotes", "SELECT 'it''s?' WHERE a = ?", "SELECT 'it''s?' WHERE a = $1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, rebind(tt.query))
+		})
+	}
+}
+
+func TestPostgresDB_CreateTransaction(t *testing.T) {
+	t.Run("rewrites placeholders", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		pgDB := &PostgresDB{DB: db}
+		transaction := models.Transaction{
+			ID:       "txn-1",
+			Amount:   models.MustParseAmount("10.00"),
+			Currency: "USD",
+			Sender:   "alice",
+			Receiver: "bob",
+			Status:   models.StatusPending,
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO transactions\(id, amount, currency, sender, receiver, status, failure_reason, `+
+			`screening_decision, screening_screener, screening_reason, target_currency, target_amount, fx_rate, quote_id\) `+
+			`VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8, \$9, \$10, \$11, \$12, \$13, \$14\)`).
+			WithArgs("txn-1", sqlmock.AnyArg(), "USD", "alice", "bob", models.StatusPending, nil, nil, nil, nil, nil, nil, nil, nil).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(`FROM transactions WHERE id = \$1`).
+			WithArgs("txn-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "failure_reason", "screening_decision", "screening_screener", "screening_reason", "target_currency", "target_amount", "fx_rate", "quote_id", "created_at"}).
+				AddRow("txn-1", "10.0000", "USD", "alice", "bob", models.StatusPending, nil, nil, nil, nil, nil, nil, nil, nil, time.Now()))
+		expectOutboxEvent(mock, postgresDialect, models.EventTransactionCreated, "txn-1")
+		mock.ExpectCommit()
+
+		assert.NoError(t, pgDB.CreateTransaction(context.Background(), transaction))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unique violation is a duplicate", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		pgDB := &PostgresDB{DB: db}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO transactions").
+			WillReturnError(&pq.Error{Code: pqUniqueViolation})
+		mock.ExpectRollback()
+
+		err = pgDB.CreateTransaction(context.Background(), models.Transaction{ID: "txn-1", Amount: models.MustParseAmount("1")})
+		assert.True(t, errors.Is(err, ErrDuplicate))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestPostgresDB_ReserveIdempotencyKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	pgDB := &PostgresDB{DB: db}
+	createdAt := time.Now().Truncate(time.Second)
+	record := models.IdempotencyRecord{
+		Key:         "key-1",
+		RequestHash: "abc123",
+		CreatedAt:   createdAt,
+		ExpiresAt:   createdAt.Add(time.Minute),
+	}
+
+	mock.ExpectExec(`DELETE FROM idempotency_keys WHERE idempotency_key = \$1 AND expires_at <= \$2`).
+		WithArgs(record.Key, record.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO idempotency_keys (.+) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7\)`).
+		WithArgs(record.Key, record.RequestHash, models.IdempotencyPending, 0, []byte{}, record.CreatedAt, record.ExpiresAt).
+		WillReturnError(&pq.Error{Code: pqUniqueViolation})
+
+	err = pgDB.ReserveIdempotencyKey(context.Background(), record)
+	assert.ErrorIs(t, err, ErrDuplicate)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,98 @@
+// Package db implements the database operations for the transaction service.
+// This file contains the storage of exchange rate quotes.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// quoteColumns lists the columns of a quote in the order scanQuote reads them.
+const quoteColumns = "id, source_currency, source_amount, target_currency, target_amount, rate, provider, " +
+	"transaction_id, created_at, expires_at"
+
+// CreateQuote stores a newly issued quote. Returns ErrDuplicate if a quote with the same ID already exists.
+func (db *DBImpl) CreateQuote(ctx context.Context, quote models.Quote) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return insertQuote(ctx, db.DB, quote)
+}
+
+// insertQuote inserts a quote, keeping its issue time so that it expires exactly when the client was told.
+func insertQuote(ctx context.Context, q querier, quote models.Quote) error {
+	query := "INSERT INTO quotes(id, source_currency, source_amount, target_currency, target_amount, rate, provider, " +
+		"created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	_, err := q.ExecContext(ctx, query, quote.ID, quote.SourceCurrency, quote.SourceAmount, quote.TargetCurrency,
+		quote.TargetAmount, quote.Rate, quote.Provider, quote.CreatedAt, quote.ExpiresAt)
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return fmt.Errorf("%w: quote %s already exists", ErrDuplicate, quote.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// GetQuote retrieves a quote by its ID, whether or not it has expired or been used.
+// Returns ErrNotFound if no quote has the given ID.
+func (db *DBImpl) GetQuote(ctx context.Context, id string) (*models.Quote, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectQuote(ctx, db.DB, id)
+}
+
+// selectQuote selects a single quote by its ID, returning ErrNotFound if there is none.
+func selectQuote(ctx context.Context, q querier, id string) (*models.Quote, error) {
+	var quote models.Quote
+	var transactionID sql.NullString
+	err := q.QueryRowContext(ctx, "SELECT "+quoteColumns+" FROM quotes WHERE id = ?", id).Scan(
+		&quote.ID,
+		&quote.SourceCurrency,
+		&quote.SourceAmount,
+		&quote.TargetCurrency,
+		&quote.TargetAmount,
+		&quote.Rate,
+		&quote.Provider,
+		&transactionID,
+		&quote.CreatedAt,
+		&quote.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: quote %s", ErrNotFound, id)
+		}
+		return nil, err
+	}
+	quote.TransactionID = transactionID.String
+
+	if quote.SourceAmount, err = currencyAmount(quote.SourceAmount, quote.SourceCurrency); err != nil {
+		return nil, fmt.Errorf("quote %s: %w", quote.ID, err)
+	}
+	if quote.TargetAmount, err = currencyAmount(quote.TargetAmount, quote.TargetCurrency); err != nil {
+		return nil, fmt.Errorf("quote %s: %w", quote.ID, err)
+	}
+	return &quote, nil
+}
+
+// useQuote marks a quote as used by a transaction inside an open SQL transaction.
+// A quote can only be used once; a second use returns an error wrapping ErrConflict.
+func useQuote(ctx context.Context, q querier, quoteID, transactionID string) error {
+	result, err := q.ExecContext(ctx, "UPDATE quotes SET transaction_id = ? WHERE id = ? AND transaction_id IS NULL", transactionID, quoteID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: quote %s does not exist or has already been used", ErrConflict, quoteID)
+	}
+	return nil
+}
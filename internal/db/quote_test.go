@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateQuote(t *testing.T) {
+	createdAt := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	quote := models.Quote{
+		ID:             "quote-1",
+		SourceCurrency: "USD",
+		SourceAmount:   models.MustParseAmount("10.00"),
+		TargetCurrency: "KES",
+		TargetAmount:   models.MustParseAmount("1291.55"),
+		Rate:           models.MustParseRate("129.155"),
+		Provider:       "static",
+		CreatedAt:      createdAt,
+		ExpiresAt:      createdAt.Add(time.Minute),
+	}
+	insertQuery := "INSERT INTO quotes\\(id, source_currency, source_amount, target_currency, target_amount, rate, provider, " +
+		"created_at, expires_at\\) VALUES \\(\\?, \\?, \\?, \\?, \\?, \\?, \\?, \\?, \\?\\)"
+
+	t.Run("stores the quote", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectExec(insertQuery).
+			WithArgs("quote-1", "USD", quote.SourceAmount, "KES", quote.TargetAmount, quote.Rate, "static", createdAt, quote.ExpiresAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, mockDB.CreateQuote(context.Background(), quote))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectExec(insertQuery).
+			WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'quote-1' for key 'PRIMARY'"})
+
+		assert.ErrorIs(t, mockDB.CreateQuote(context.Background(), quote), ErrDuplicate)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetQuote(t *testing.T) {
+	selectQuery := "SELECT id, source_currency, source_amount, target_currency, target_amount, rate, provider, " +
+		"transaction_id, created_at, expires_at FROM quotes WHERE id = \\?"
+	columns := []string{"id", "source_currency", "source_amount", "target_currency", "target_amount", "rate", "provider",
+		"transaction_id", "created_at", "expires_at"}
+
+	t.Run("found", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		createdAt := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+
+		mock.ExpectQuery(selectQuery).
+			WithArgs("quote-1").
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("quote-1", "USD", "10.0000", "JPY", "1497.0000", "149.7312000000", "static", "txn-1", createdAt, createdAt.Add(time.Minute)))
+
+		quote, err := mockDB.GetQuote(context.Background(), "quote-1")
+		require.NoError(t, err)
+		assert.Equal(t, "10.00", quote.SourceAmount.String())
+		assert.Equal(t, "1497", quote.TargetAmount.String(), "amounts are normalized to their currency's exponent")
+		assert.Equal(t, "149.7312", quote.Rate.String())
+		assert.Equal(t, "txn-1", quote.TransactionID)
+		assert.Equal(t, createdAt.Add(time.Minute), quote.ExpiresAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectQuery(selectQuery).WithArgs("missing").WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err = mockDB.GetQuote(context.Background(), "missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestCreateTransaction_UsesQuote(t *testing.T) {
+	useQuery := "UPDATE quotes SET transaction_id = \\? WHERE id = \\? AND transaction_id IS NULL"
+	transaction := models.Transaction{
+		ID:       "txn-1",
+		Amount:   models.MustParseAmount("10.00"),
+		Currency: "USD",
+		Sender:   "alice",
+		Receiver: "bob",
+		Status:   models.StatusPending,
+		Conversion: &models.Conversion{
+			TargetCurrency: "KES",
+			TargetAmount:   models.MustParseAmount("1291.55"),
+			Rate:           models.MustParseRate("129.155"),
+			QuoteID:        "quote-1",
+		},
+	}
+
+	t.Run("stores the conversion and marks the quote used", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO transactions").
+			WithArgs("txn-1", transaction.Amount, "USD", "alice", "bob", models.StatusPending, nil, nil, nil, nil,
+				"KES", transaction.Conversion.TargetAmount, transaction.Conversion.Rate, "quote-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(useQuery).WithArgs("txn-1", "quote-1").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT (.+) FROM transactions WHERE id = \\?").
+			WithArgs("txn-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "failure_reason", "screening_decision", "screening_screener", "screening_reason", "target_currency", "target_amount", "fx_rate", "quote_id", "created_at"}).
+				AddRow("txn-1", "10.0000", "USD", "alice", "bob", models.StatusPending, nil, nil, nil, nil, "KES", "1291.5500", "129.1550000000", "quote-1", time.Now()))
+		expectOutboxEvent(mock, mysqlDialect, models.EventTransactionCreated, "txn-1")
+		mock.ExpectCommit()
+
+		assert.NoError(t, mockDB.CreateTransaction(context.Background(), transaction))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("a used quote rolls the transaction back", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO transactions").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(useQuery).WithArgs("txn-1", "quote-1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err = mockDB.CreateTransaction(context.Background(), transaction)
+		assert.ErrorIs(t, err, ErrConflict)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
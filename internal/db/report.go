@@ -0,0 +1,138 @@
+// Package db implements the database operations for the transaction service.
+// This file contains the aggregate reports over transactions that db/queries.sql used to run by hand.
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// GetSenderTotals counts and sums each sender's completed transactions per currency, optionally in one currency only.
+// Totals are ordered by currency, then largest total first.
+func (db *DBImpl) GetSenderTotals(ctx context.Context, currency string) ([]models.SenderTotal, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectSenderTotals(ctx, db.DB, currency)
+}
+
+// selectSenderTotals aggregates completed transactions by sender and currency.
+// Currencies are grouped ignoring case, since transactions store the code as it was sent.
+func selectSenderTotals(ctx context.Context, q querier, currency string) ([]models.SenderTotal, error) {
+	query := `
+		SELECT sender, UPPER(currency), COUNT(*), SUM(amount)
+		FROM transactions
+		WHERE status = ?
+	`
+	args := []interface{}{models.StatusCompleted}
+	if currency != "" {
+		query += " AND UPPER(currency) = ?"
+		args = append(args, currency)
+	}
+	query += `
+		GROUP BY sender, UPPER(currency)
+		ORDER BY UPPER(currency) ASC, SUM(amount) DESC, sender ASC
+	`
+	return querySenderTotals(ctx, q, query, args...)
+}
+
+// GetTopSenders returns, for each currency, the limit senders with the largest total of completed transactions
+// created at or after since, optionally in one currency only. They are ordered by currency, then largest total first.
+func (db *DBImpl) GetTopSenders(ctx context.Context, currency string, since time.Time, limit int) ([]models.SenderTotal, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectTopSenders(ctx, db.DB, currency, since, limit)
+}
+
+// selectTopSenders ranks senders by volume within each currency and keeps the first limit of every ranking.
+// Ties are broken by sender so that the cut-off is stable.
+func selectTopSenders(ctx context.Context, q querier, currency string, since time.Time, limit int) ([]models.SenderTotal, error) {
+	filter := "status = ? AND created_at >= ?"
+	args := []interface{}{models.StatusCompleted, since}
+	if currency != "" {
+		filter += " AND UPPER(currency) = ?"
+		args = append(args, currency)
+	}
+	query := `
+		SELECT sender, currency, transaction_count, total
+		FROM (
+			SELECT sender, UPPER(currency) AS currency, COUNT(*) AS transaction_count, SUM(amount) AS total,
+				ROW_NUMBER() OVER (PARTITION BY UPPER(currency) ORDER BY SUM(amount) DESC, sender ASC) AS sender_rank
+			FROM transactions
+			WHERE ` + filter + `
+			GROUP BY sender, UPPER(currency)
+		) ranked
+		WHERE sender_rank <= ?
+		ORDER BY currency ASC, sender_rank ASC
+	`
+	return querySenderTotals(ctx, q, query, append(args, limit)...)
+}
+
+// querySenderTotals runs a query selecting sender, currency, count and total, and normalizes each total
+// to the minor units of its currency.
+func querySenderTotals(ctx context.Context, q querier, query string, args ...interface{}) ([]models.SenderTotal, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []models.SenderTotal
+	for rows.Next() {
+		var total models.SenderTotal
+		if err := rows.Scan(&total.Sender, &total.Currency, &total.Count, &total.Total); err != nil {
+			return nil, err
+		}
+		if total.Total, err = currencyAmount(total.Total, total.Currency); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
+
+// GetFailureHeavySenders returns the senders with more than threshold failed transactions, most failures first.
+func (db *DBImpl) GetFailureHeavySenders(ctx context.Context, threshold int) ([]models.SenderFailures, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectFailureHeavySenders(ctx, db.DB, threshold)
+}
+
+// selectFailureHeavySenders counts failed transactions by sender and keeps those above the threshold.
+func selectFailureHeavySenders(ctx context.Context, q querier, threshold int) ([]models.SenderFailures, error) {
+	query := `
+		SELECT sender, COUNT(*)
+		FROM transactions
+		WHERE status = ?
+		GROUP BY sender
+		HAVING COUNT(*) > ?
+		ORDER BY COUNT(*) DESC, sender ASC
+	`
+	rows, err := q.QueryContext(ctx, query, models.StatusFailed, threshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var senders []models.SenderFailures
+	for rows.Next() {
+		var sender models.SenderFailures
+		if err := rows.Scan(&sender.Sender, &sender.FailedCount); err != nil {
+			return nil, err
+		}
+		senders = append(senders, sender)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return senders, nil
+}
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSenderTotals(t *testing.T) {
+	totalsQuery := "SELECT sender, UPPER\\(currency\\), COUNT\\(\\*\\), SUM\\(amount\\) FROM transactions WHERE status = \\?"
+	columns := []string{"sender", "currency", "count", "total"}
+
+	t.Run("every currency", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectQuery(totalsQuery + " GROUP BY sender, UPPER\\(currency\\) ORDER BY UPPER\\(currency\\) ASC, SUM\\(amount\\) DESC, sender ASC").
+			WithArgs(models.StatusCompleted).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow("carol", "JPY", 2, "1500.0000").
+				AddRow("alice", "USD", 3, "150.5000"))
+
+		totals, err := mockDB.GetSenderTotals(context.Background(), "")
+		require.NoError(t, err)
+		require.Len(t, totals, 2)
+		assert.Equal(t, models.SenderTotal{Sender: "carol", Currency: "JPY", Count: 2, Total: models.MustParseAmount("1500")}, totals[0])
+		assert.Equal(t, "150.50", totals[1].Total.String(), "totals are normalized to the currency exponent")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("one currency", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectQuery(totalsQuery+" AND UPPER\\(currency\\) = \\? GROUP BY").
+			WithArgs(models.StatusCompleted, "USD").
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		totals, err := mockDB.GetSenderTotals(context.Background(), "USD")
+		require.NoError(t, err)
+		assert.Empty(t, totals)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("query fails", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectQuery(totalsQuery).WillReturnError(errors.New("database error"))
+
+		_, err = mockDB.GetSenderTotals(context.Background(), "")
+		assert.EqualError(t, err, "database error")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetTopSenders(t *testing.T) {
+	since := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mockDB := &DBImpl{DB: db}
+
+	mock.ExpectQuery("SELECT sender, currency, transaction_count, total FROM \\( "+
+		"SELECT sender, UPPER\\(currency\\) AS currency, COUNT\\(\\*\\) AS transaction_count, SUM\\(amount\\) AS total, "+
+		"ROW_NUMBER\\(\\) OVER \\(PARTITION BY UPPER\\(currency\\) ORDER BY SUM\\(amount\\) DESC, sender ASC\\) AS sender_rank "+
+		"FROM transactions WHERE status = \\? AND created_at >= \\? AND UPPER\\(currency\\) = \\? "+
+		"GROUP BY sender, UPPER\\(currency\\) \\) ranked WHERE sender_rank <= \\? ORDER BY currency ASC, sender_rank ASC").
+		WithArgs(models.StatusCompleted, since, "USD", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"sender", "currency", "transaction_count", "total"}).
+			AddRow("bob", "USD", 1, "70.0000").
+			AddRow("alice", "USD", 2, "50.0000"))
+
+	top, err := mockDB.GetTopSenders(context.Background(), "USD", since, 5)
+	require.NoError(t, err)
+	require.Len(t, top, 2)
+	assert.Equal(t, "bob", top[0].Sender)
+	assert.Equal(t, "70.00", top[0].Total.String())
+	assert.Equal(t, 2, top[1].Count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFailureHeavySenders(t *testing.T) {
+	failuresQuery := "SELECT sender, COUNT\\(\\*\\) FROM transactions WHERE status = \\? " +
+		"GROUP BY sender HAVING COUNT\\(\\*\\) > \\? ORDER BY COUNT\\(\\*\\) DESC, sender ASC"
+
+	t.Run("senders above the threshold", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectQuery(failuresQuery).
+			WithArgs(models.StatusFailed, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"sender", "count"}).AddRow("erin", 7).AddRow("frank", 4))
+
+		senders, err := mockDB.GetFailureHeavySenders(context.Background(), 3)
+		require.NoError(t, err)
+		assert.Equal(t, []models.SenderFailures{{Sender: "erin", FailedCount: 7}, {Sender: "frank", FailedCount: 4}}, senders)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("query fails", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectQuery(failuresQuery).WillReturnError(errors.New("database error"))
+
+		_, err = mockDB.GetFailureHeavySenders(context.Background(), 3)
+		assert.EqualError(t, err, "database error")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
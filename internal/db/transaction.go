@@ -1,92 +1,563 @@
 // Package db implements the database operations for the transaction service.
 // This file contains the CRUD operations for transactions using MySQL.
+// The unexported query functions are shared with the PostgreSQL backend.
 package db
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"log"
+	"strings"
 
 	"github.com/abadojack/gapstack/internal/models"
 )
 
 // CreateTransaction inserts a new transaction into the database.
 // The created_at timestamp is automatically set by MySQL using the DEFAULT CURRENT_TIMESTAMP.
-func (db *DBImpl) CreateTransaction(transaction models.Transaction) error {
-	query := "INSERT INTO transactions(id, amount, currency, sender, receiver, status) VALUES (?, ?, ?, ?, ?, ?)"
+// A transaction.created event is written to the outbox in the same SQL transaction.
+// Returns ErrDuplicate if a transaction with the same ID already exists.
+func (db *DBImpl) CreateTransaction(ctx context.Context, transaction models.Transaction) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
 
-	log.Println("TEST")
+	return createTransaction(ctx, db.DB, mysqlDialect, transaction, nil)
+}
+
+// CreateIdempotentTransaction inserts a new transaction as CreateTransaction does and, in the same SQL
+// transaction, completes the idempotency key reserved for the request with record's response and expiry.
+// Returns an error wrapping ErrNotFound, and stores nothing, if the reservation identified by record's key
+// and CreatedAt has lapsed.
+func (db *DBImpl) CreateIdempotentTransaction(ctx context.Context, transaction models.Transaction, record models.IdempotencyRecord) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return createTransaction(ctx, db.DB, mysqlDialect, transaction, &record)
+}
+
+// createTransaction inserts a transaction and its outbox event in one SQL transaction,
+// marking the quote its conversion was priced with as used and completing record's idempotency key if set.
+func createTransaction(ctx context.Context, sqlDB *sql.DB, d dialect, transaction models.Transaction, record *models.IdempotencyRecord) error {
+	sqlTx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+	q := d.bind(sqlTx)
+
+	if err := insertTransaction(ctx, q, transaction); err != nil {
+		return err
+	}
+	if transaction.Conversion != nil && transaction.Conversion.QuoteID != "" {
+		if err := useQuote(ctx, q, transaction.Conversion.QuoteID, transaction.ID); err != nil {
+			return err
+		}
+	}
+
+	// Read the row back so that the event carries the stored creation time
+	created, err := selectTransaction(ctx, q, transaction.ID)
+	if err != nil {
+		return err
+	}
+	event, err := models.NewTransactionEvent(models.EventTransactionCreated, models.TransactionEventData{Transaction: *created})
+	if err != nil {
+		return err
+	}
+	if err := recordOutboxEvent(ctx, q, d, event); err != nil {
+		return err
+	}
+	if record != nil {
+		if err := completeIdempotencyKey(ctx, q, *record); err != nil {
+			return err
+		}
+	}
+
+	return sqlTx.Commit()
+}
+
+// insertBatchSize is the largest number of transactions written by a single multi-row insert. It keeps
+// every statement well within the placeholder limits of MySQL and PostgreSQL.
+const insertBatchSize = 500
 
-	_, err := db.DB.Exec(query, transaction.ID, transaction.Amount, transaction.Currency, transaction.Sender, transaction.Receiver, transaction.Status)
+// CreateTransactions inserts a batch of new transactions in a single SQL transaction, so that either all of
+// them are stored or none are. Rows are written with multi-row inserts of up to insertBatchSize transactions,
+// and each transaction's transaction.created event is written to the outbox in the same SQL transaction.
+// Returns ErrDuplicate if any of the transactions already exists, and an error wrapping ErrConflict if a quote
+// one of them was priced with does not exist or has already been used.
+func (db *DBImpl) CreateTransactions(ctx context.Context, transactions []models.Transaction) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return createTransactions(ctx, db.DB, mysqlDialect, transactions)
+}
+
+// createTransactions inserts transactions and their outbox events in one SQL transaction, in chunks of
+// insertBatchSize, marking the quotes their conversions were priced with as used.
+func createTransactions(ctx context.Context, sqlDB *sql.DB, d dialect, transactions []models.Transaction) error {
+	sqlTx, err := sqlDB.BeginTx(ctx, nil)
 	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+	q := d.bind(sqlTx)
+
+	for start := 0; start < len(transactions); start += insertBatchSize {
+		chunk := transactions[start:min(start+insertBatchSize, len(transactions))]
+		if err := insertTransactions(ctx, q, chunk); err != nil {
+			return err
+		}
+		for _, transaction := range chunk {
+			if transaction.Conversion != nil && transaction.Conversion.QuoteID != "" {
+				if err := useQuote(ctx, q, transaction.Conversion.QuoteID, transaction.ID); err != nil {
+					return err
+				}
+			}
+		}
+		if err := recordCreatedEvents(ctx, q, chunk); err != nil {
+			return err
+		}
+	}
+
+	return sqlTx.Commit()
+}
+
+// insertTransactions inserts transactions with a single multi-row insert, leaving created_at to the column default.
+func insertTransactions(ctx context.Context, q querier, transactions []models.Transaction) error {
+	row := "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	query := "INSERT INTO transactions(id, amount, currency, sender, receiver, status, failure_reason, " +
+		"screening_decision, screening_screener, screening_reason, target_currency, target_amount, fx_rate, quote_id) " +
+		"VALUES " + strings.TrimSuffix(strings.Repeat(row+", ", len(transactions)), ", ")
+
+	args := make([]interface{}, 0, 14*len(transactions))
+	for _, transaction := range transactions {
+		args = append(args, transactionValues(transaction)...)
+	}
+	if _, err := q.ExecContext(ctx, query, args...); err != nil {
 		log.Println(err)
+		if isDuplicateKeyError(err) {
+			return fmt.Errorf("%w: a transaction in the batch already exists", ErrDuplicate)
+		}
 		return err
 	}
 	return nil
 }
 
-// UpdateTransaction updates the status of an existing transaction.
-// Only completed and failed statuses are allowed for updates.
-func (db *DBImpl) UpdateTransaction(id string, status models.Status) error {
-	query := "UPDATE transactions SET status = ? WHERE id = ?"
-	_, err := db.DB.Exec(query, status, id)
+// recordCreatedEvents writes the transaction.created events of newly inserted transactions to the outbox with
+// a single multi-row insert, reading the rows back so that the events carry the stored creation times.
+// Deliveries are queued by selecting the events by transaction, which only finds these events because the
+// transactions are new.
+func recordCreatedEvents(ctx context.Context, q querier, transactions []models.Transaction) error {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(transactions)), ", ")
+	ids := make([]interface{}, len(transactions))
+	for i, transaction := range transactions {
+		ids[i] = transaction.ID
+	}
+
+	rows, err := q.QueryContext(ctx, "SELECT "+transactionColumns+" FROM transactions WHERE id IN ("+placeholders+")", ids...)
+	if err != nil {
+		return err
+	}
+	stored, err := scanTransactions(rows)
+	rows.Close()
 	if err != nil {
 		return err
 	}
+	byID := make(map[string]models.Transaction, len(stored))
+	for _, transaction := range stored {
+		byID[transaction.ID] = transaction
+	}
+
+	// Events are written in batch order, so their IDs follow it
+	args := make([]interface{}, 0, 3*len(transactions))
+	for _, transaction := range transactions {
+		created, ok := byID[transaction.ID]
+		if !ok {
+			return fmt.Errorf("%w: transaction %s", ErrNotFound, transaction.ID)
+		}
+		event, err := models.NewTransactionEvent(models.EventTransactionCreated, models.TransactionEventData{Transaction: created})
+		if err != nil {
+			return err
+		}
+		// The payload is passed as a string: lib/pq would send a []byte as bytea, which JSONB rejects
+		args = append(args, event.Type, event.TransactionID, string(event.Data))
+	}
+	query := "INSERT INTO outbox_events(event_type, transaction_id, payload) VALUES " +
+		strings.TrimSuffix(strings.Repeat("(?, ?, ?), ", len(transactions)), ", ")
+	if _, err := q.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO webhook_deliveries(event_id, endpoint_id, next_attempt_at)
+		SELECT e.id, w.id, e.created_at
+		FROM outbox_events e
+		JOIN webhook_endpoints w ON w.enabled
+		WHERE e.event_type = ? AND e.transaction_id IN (` + placeholders + `)
+		ORDER BY e.id, w.id
+	`
+	_, err = q.ExecContext(ctx, query, append([]interface{}{models.EventTransactionCreated}, ids...)...)
+	return err
+}
+
+// transactionColumns lists the columns of a transaction in the order scanTransaction reads them.
+const transactionColumns = "id, amount, currency, sender, receiver, status, failure_reason, " +
+	"screening_decision, screening_screener, screening_reason, target_currency, target_amount, fx_rate, quote_id, created_at"
+
+// insertTransaction inserts a transaction, leaving created_at to the column default.
+// Screening decides the status a transaction is created in, so the failure reason and screening decision are stored too,
+// along with the conversion of a cross-currency transaction.
+func insertTransaction(ctx context.Context, q querier, transaction models.Transaction) error {
+	query := "INSERT INTO transactions(id, amount, currency, sender, receiver, status, failure_reason, " +
+		"screening_decision, screening_screener, screening_reason, target_currency, target_amount, fx_rate, quote_id) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+
+	_, err := q.ExecContext(ctx, query, transactionValues(transaction)...)
+	if err != nil {
+		log.Println(err)
+		if isDuplicateKeyError(err) {
+			return fmt.Errorf("%w: transaction %s already exists", ErrDuplicate, transaction.ID)
+		}
+		return err
+	}
 	return nil
 }
 
-// GetAllTransactions retrieves a paginated list of all transactions from the database.
-// The results are ordered by transaction ID and limited by the provided limit and offset.
-func (db *DBImpl) GetAllTransactions(limit, offset int) ([]models.Transaction, error) {
-	query := `
-		SELECT id, amount, currency, sender, receiver, status, created_at
-		FROM transactions
-		ORDER BY id
-		LIMIT ? OFFSET ?
-	`
+// transactionValues returns the values of a transaction in the column order of the transactions insert.
+func transactionValues(transaction models.Transaction) []interface{} {
+	var decision, screener, reason sql.NullString
+	if transaction.Screening != nil {
+		decision = nullString(string(transaction.Screening.Decision))
+		screener = nullString(transaction.Screening.Screener)
+		reason = nullString(transaction.Screening.Reason)
+	}
+	var targetCurrency, quoteID sql.NullString
+	var targetAmount sql.Null[models.Amount]
+	var rate sql.Null[models.Rate]
+	if conversion := transaction.Conversion; conversion != nil {
+		targetCurrency = nullString(conversion.TargetCurrency)
+		targetAmount = sql.Null[models.Amount]{V: conversion.TargetAmount, Valid: true}
+		rate = sql.Null[models.Rate]{V: conversion.Rate, Valid: true}
+		quoteID = nullString(conversion.QuoteID)
+	}
+	return []interface{}{transaction.ID, transaction.Amount, transaction.Currency, transaction.Sender, transaction.Receiver,
+		transaction.Status, nullString(transaction.FailureReason), decision, screener, reason, targetCurrency, targetAmount, rate, quoteID}
+}
+
+// UpdateTransaction moves an existing transaction to a new status.
+// The transaction's row is locked while the state machine in models is checked, so concurrent updates
+// cannot both succeed. Illegal transitions return an error wrapping ErrConflict,
+// and ErrNotFound is returned if no transaction has the given ID.
+// Every change is recorded in the transaction's history and written to the outbox as a
+// transaction.status_changed event, and a move to failed stores the change's reason as the failure reason.
+// Completing or reversing a transaction posts its ledger entries. All of this happens in one SQL transaction,
+// so a rejected posting (ErrInsufficientFunds, ErrCurrencyMismatch) leaves no trace.
+func (db *DBImpl) UpdateTransaction(ctx context.Context, id string, change models.StatusChange) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return updateTransaction(ctx, db.DB, mysqlDialect, id, change)
+}
+
+// updateTransaction applies a status change, its history and outbox events and any ledger postings it causes
+// in one SQL transaction.
+func updateTransaction(ctx context.Context, sqlDB *sql.DB, d dialect, id string, change models.StatusChange) error {
+	sqlTx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+	q := d.bind(sqlTx)
+
+	from, err := transitionStatus(ctx, q, id, change)
+	if err != nil {
+		return err
+	}
+
+	// The row is locked by the transition above, so it cannot change before the postings and events are written
+	transaction, err := selectTransaction(ctx, q, id)
+	if err != nil {
+		return err
+	}
+
+	if change.Status.PostsToLedger() {
+		entries := models.LedgerEntries(*transaction, change.Status)
+		if err := openLedgerAccounts(ctx, q, entries, d.ignoreDuplicateAccount); err != nil {
+			return err
+		}
+		if err := postLedgerEntries(ctx, q, entries); err != nil {
+			return err
+		}
+	}
+
+	event := models.TransactionEvent{TransactionID: id, FromStatus: from, ToStatus: change.Status, Reason: change.Reason, Actor: change.Actor}
+	if err := insertTransactionEvent(ctx, q, event); err != nil {
+		return err
+	}
+
+	outboxEvent, err := models.NewTransactionEvent(models.EventTransactionStatusChanged, models.TransactionEventData{
+		Transaction:    *transaction,
+		PreviousStatus: from,
+		Reason:         change.Reason,
+		Actor:          change.Actor,
+	})
+	if err != nil {
+		return err
+	}
+	if err := recordOutboxEvent(ctx, q, d, outboxEvent); err != nil {
+		return err
+	}
+
+	return sqlTx.Commit()
+}
 
-	rows, err := db.DB.Query(query, limit, offset)
+// transitionStatus locks a transaction's row inside an open SQL transaction, checks that the state machine
+// allows the change, and applies it. It returns the status the transaction moved from.
+func transitionStatus(ctx context.Context, q querier, id string, change models.StatusChange) (models.Status, error) {
+	var current models.Status
+	err := q.QueryRowContext(ctx, "SELECT status FROM transactions WHERE id = ? FOR UPDATE", id).Scan(&current)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: transaction %s", ErrNotFound, id)
+		}
+		return "", err
+	}
+	if err := models.ValidateTransition(current, change.Status); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+
+	query := "UPDATE transactions SET status = ? WHERE id = ?"
+	args := []interface{}{change.Status, id}
+	if change.Status == models.StatusFailed {
+		query = "UPDATE transactions SET status = ?, failure_reason = ? WHERE id = ?"
+		args = []interface{}{change.Status, nullString(change.Reason), id}
+	}
+	if _, err := q.ExecContext(ctx, query, args...); err != nil {
+		return "", err
+	}
+	return current, nil
+}
+
+// nullString maps an empty string to SQL NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// GetAllTransactions retrieves a filtered, paginated list of transactions from the database.
+// The results are ordered by the filter's sort column and ID, and limited by the provided limit and offset.
+// Prefer GetTransactionsAfter, whose cost does not grow with the page number.
+func (db *DBImpl) GetAllTransactions(ctx context.Context, filter TransactionFilter, limit, offset int) ([]models.Transaction, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return listTransactions(ctx, db.DB, filter, limit, offset)
+}
+
+// listTransactions selects a page of filtered transactions by LIMIT and OFFSET.
+func listTransactions(ctx context.Context, q querier, filter TransactionFilter, limit, offset int) ([]models.Transaction, error) {
+	var builder queryBuilder
+	filter.apply(&builder)
+
+	order := "ASC"
+	if filter.Descending {
+		order = "DESC"
+	}
+
+	query := "SELECT " + transactionColumns + " FROM transactions" +
+		builder.clause() +
+		" ORDER BY " + filter.sortColumn() + " " + order + ", id " + order +
+		" LIMIT ? OFFSET ?"
+	args := append(builder.args, limit, offset)
+
+	rows, err := q.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanTransactions(rows)
+}
+
+// EachTransaction calls fn with every transaction matching the filter, in the filter's order, scanning rows
+// as fn consumes them instead of loading the result set into memory. It stops at the first error from fn and
+// returns it. The query is bounded by ctx only, not by QueryTimeout, as reading a large result set takes as
+// long as its consumer takes to write it out.
+func (db *DBImpl) EachTransaction(ctx context.Context, filter TransactionFilter, fn func(models.Transaction) error) error {
+	return eachTransaction(ctx, db.DB, filter, fn)
+}
+
+// eachTransaction selects every filtered transaction and calls fn with each row as it is scanned.
+func eachTransaction(ctx context.Context, q querier, filter TransactionFilter, fn func(models.Transaction) error) error {
+	var builder queryBuilder
+	filter.apply(&builder)
+
+	order := "ASC"
+	if filter.Descending {
+		order = "DESC"
+	}
+
+	query := "SELECT " + transactionColumns + " FROM transactions" +
+		builder.clause() +
+		" ORDER BY " + filter.sortColumn() + " " + order + ", id " + order
+
+	rows, err := q.QueryContext(ctx, query, builder.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(*transaction); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetTransactionsAfter retrieves up to limit filtered transactions following the cursor in (sort key, id) order.
+// A nil cursor returns the first page. If cursor.Before is set, the page preceding the cursor is returned instead;
+// either way the results are in the filter's order. The query seeks on the (sort key, id) index.
+func (db *DBImpl) GetTransactionsAfter(ctx context.Context, filter TransactionFilter, cursor *Cursor, limit int) ([]models.Transaction, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return listTransactionsAfter(ctx, db.DB, filter, cursor, limit)
+}
+
+// listTransactionsAfter selects the page of filtered transactions adjacent to a keyset cursor.
+func listTransactionsAfter(ctx context.Context, q querier, filter TransactionFilter, cursor *Cursor, limit int) ([]models.Transaction, error) {
+	var builder queryBuilder
+	filter.apply(&builder)
+
+	// Reading backwards flips the direction of both the seek and the ORDER BY
+	ascending := !filter.Descending
+	if cursor != nil && cursor.Before {
+		ascending = !ascending
+	}
+	comparison, order := ">", "ASC"
+	if !ascending {
+		comparison, order = "<", "DESC"
+	}
+
+	column := filter.sortColumn()
+	if cursor != nil {
+		// Expanded form of (column, id) > (?, ?) so that MySQL can use a range scan on the composite index
+		value := cursor.sortValue(filter)
+		builder.where("("+column+" "+comparison+" ? OR ("+column+" = ? AND id "+comparison+" ?))", value, value, cursor.ID)
+	}
+
+	query := "SELECT " + transactionColumns + " FROM transactions" +
+		builder.clause() +
+		" ORDER BY " + column + " " + order + ", id " + order +
+		" LIMIT ?"
+	args := append(builder.args, limit)
+
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions, err := scanTransactions(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// Backward pages are read in reverse; flip them to match forward pages
+	if cursor != nil && cursor.Before {
+		for i, j := 0, len(transactions)-1; i < j; i, j = i+1, j-1 {
+			transactions[i], transactions[j] = transactions[j], transactions[i]
+		}
+	}
+
+	return transactions, nil
+}
+
+// CountTransactions returns the number of transactions matching the filter.
+// The filter's sort order is irrelevant to the count and is ignored.
+func (db *DBImpl) CountTransactions(ctx context.Context, filter TransactionFilter) (int, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return countTransactions(ctx, db.DB, filter)
+}
+
+// countTransactions counts the transactions matching a filter.
+func countTransactions(ctx context.Context, q querier, filter TransactionFilter) (int, error) {
+	var builder queryBuilder
+	filter.apply(&builder)
+
+	var count int
+	query := "SELECT COUNT(*) FROM transactions" + builder.clause()
+	if err := q.QueryRowContext(ctx, query, builder.args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetTransaction retrieves a single transaction by its ID.
+// Returns ErrNotFound if no transaction is found with the given ID.
+func (db *DBImpl) GetTransaction(ctx context.Context, id string) (*models.Transaction, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectTransaction(ctx, db.DB, id)
+}
+
+// selectTransaction selects a single transaction by its ID, returning ErrNotFound if there is none.
+func selectTransaction(ctx context.Context, q querier, id string) (*models.Transaction, error) {
+	query := "SELECT " + transactionColumns + " FROM transactions WHERE id = ?"
+	row := q.QueryRowContext(ctx, query, id)
+
+	transaction, err := scanTransaction(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: transaction %s", ErrNotFound, id)
+		}
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+// scanTransactions reads every remaining row of a transaction query.
+func scanTransactions(rows *sql.Rows) ([]models.Transaction, error) {
 	var transactions []models.Transaction
 
 	// Iterate through all rows and scan them into Transaction structs
 	for rows.Next() {
-		var transaction models.Transaction
-		err := rows.Scan(
-			&transaction.ID,
-			&transaction.Amount,
-			&transaction.Currency,
-			&transaction.Sender,
-			&transaction.Receiver,
-			&transaction.Status,
-			&transaction.CreatedAt,
-		)
+		transaction, err := scanTransaction(rows)
 		if err != nil {
 			return nil, err
 		}
-		transactions = append(transactions, transaction)
+		transactions = append(transactions, *transaction)
 	}
 
 	// Check for any errors that occurred during iteration
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
 	return transactions, nil
 }
 
-// GetTransaction retrieves a single transaction by its ID.
-// Returns nil if no transaction is found with the given ID.
-func (db *DBImpl) GetTransaction(id string) (*models.Transaction, error) {
-	query := "SELECT id, amount, currency, sender, receiver, status, created_at FROM transactions WHERE id = ?"
-	row := db.DB.QueryRow(query, id)
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
+// scanTransaction reads a transaction row selected in the standard column order.
+// The amounts are normalized to the minor units of their currencies,
+// since the DECIMAL column carries more decimal places than most currencies use.
+func scanTransaction(row rowScanner) (*models.Transaction, error) {
 	var transaction models.Transaction
+	var failureReason, decision, screener, reason, targetCurrency, quoteID sql.NullString
+	var targetAmount sql.Null[models.Amount]
+	var rate sql.Null[models.Rate]
 	err := row.Scan(
 		&transaction.ID,
 		&transaction.Amount,
@@ -94,14 +565,41 @@ func (db *DBImpl) GetTransaction(id string) (*models.Transaction, error) {
 		&transaction.Sender,
 		&transaction.Receiver,
 		&transaction.Status,
+		&failureReason,
+		&decision,
+		&screener,
+		&reason,
+		&targetCurrency,
+		&targetAmount,
+		&rate,
+		&quoteID,
 		&transaction.CreatedAt,
 	)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, nil // No transaction found with that ID
-		}
 		return nil, err
 	}
+	transaction.FailureReason = failureReason.String
+	if decision.Valid {
+		transaction.Screening = &models.Screening{
+			Decision: models.ScreeningDecision(decision.String),
+			Screener: screener.String,
+			Reason:   reason.String,
+		}
+	}
+
+	if transaction.Amount, err = currencyAmount(transaction.Amount, transaction.Currency); err != nil {
+		return nil, fmt.Errorf("transaction %s: %w", transaction.ID, err)
+	}
+	if targetCurrency.Valid {
+		transaction.Conversion = &models.Conversion{
+			TargetCurrency: targetCurrency.String,
+			Rate:           rate.V,
+			QuoteID:        quoteID.String,
+		}
+		if transaction.Conversion.TargetAmount, err = currencyAmount(targetAmount.V, targetCurrency.String); err != nil {
+			return nil, fmt.Errorf("transaction %s: %w", transaction.ID, err)
+		}
+	}
 
 	return &transaction, nil
 }
@@ -1,13 +1,17 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/abadojack/gapstack/internal/models"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -21,19 +25,61 @@ func TestCreateTransaction(t *testing.T) {
 		mockDB := &DBImpl{DB: db}
 		transaction := models.Transaction{
 			ID:       "txn-123",
-			Amount:   100.50,
+			Amount:   models.MustParseAmount("100.50"),
 			Currency: "USD",
 			Sender:   "user-1",
 			Receiver: "user-2",
 			Status:   models.StatusPending,
 		}
 
+		mock.ExpectBegin()
 		mock.ExpectExec("INSERT INTO transactions").
 			WithArgs(transaction.ID, transaction.Amount, transaction.Currency,
-				transaction.Sender, transaction.Receiver, transaction.Status).
+				transaction.Sender, transaction.Receiver, transaction.Status, nil, nil, nil, nil, nil, nil, nil, nil).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery("SELECT (.+) FROM transactions WHERE id = \\?").
+			WithArgs(transaction.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "failure_reason", "screening_decision", "screening_screener", "screening_reason", "target_currency", "target_amount", "fx_rate", "quote_id", "created_at"}).
+				AddRow(transaction.ID, "100.5000", transaction.Currency, transaction.Sender, transaction.Receiver, transaction.Status, nil, nil, nil, nil, nil, nil, nil, nil, time.Now()))
+		expectOutboxEvent(mock, mysqlDialect, models.EventTransactionCreated, transaction.ID)
+		mock.ExpectCommit()
+
+		err = mockDB.CreateTransaction(context.Background(), transaction)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("screened transaction", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
 
-		err = mockDB.CreateTransaction(transaction)
+		mockDB := &DBImpl{DB: db}
+		transaction := models.Transaction{
+			ID:            "txn-123",
+			Amount:        models.MustParseAmount("100.50"),
+			Currency:      "USD",
+			Sender:        "mallory",
+			Receiver:      "user-2",
+			Status:        models.StatusFailed,
+			FailureReason: "sender is on the blocklist",
+			Screening:     &models.Screening{Decision: models.ScreeningReject, Screener: "blocklist", Reason: "sender is on the blocklist"},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO transactions").
+			WithArgs(transaction.ID, transaction.Amount, transaction.Currency, transaction.Sender, transaction.Receiver,
+				transaction.Status, "sender is on the blocklist", "reject", "blocklist", "sender is on the blocklist", nil, nil, nil, nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery("SELECT (.+) FROM transactions WHERE id = \\?").
+			WithArgs(transaction.ID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "failure_reason", "screening_decision", "screening_screener", "screening_reason", "target_currency", "target_amount", "fx_rate", "quote_id", "created_at"}).
+				AddRow(transaction.ID, "100.5000", transaction.Currency, transaction.Sender, transaction.Receiver, transaction.Status,
+					"sender is on the blocklist", "reject", "blocklist", "sender is on the blocklist", nil, nil, nil, nil, time.Now()))
+		expectOutboxEvent(mock, mysqlDialect, models.EventTransactionCreated, transaction.ID)
+		mock.ExpectCommit()
+
+		err = mockDB.CreateTransaction(context.Background(), transaction)
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -46,7 +92,7 @@ func TestCreateTransaction(t *testing.T) {
 		mockDB := &DBImpl{DB: db}
 		transaction := models.Transaction{
 			ID:       "txn-123",
-			Amount:   100.50,
+			Amount:   models.MustParseAmount("100.50"),
 			Currency: "USD",
 			Sender:   "user-1",
 			Receiver: "user-2",
@@ -54,19 +100,134 @@ func TestCreateTransaction(t *testing.T) {
 		}
 
 		expectedErr := errors.New("database error")
+		mock.ExpectBegin()
 		mock.ExpectExec("INSERT INTO transactions").
 			WithArgs(transaction.ID, transaction.Amount, transaction.Currency,
-				transaction.Sender, transaction.Receiver, transaction.Status).
+				transaction.Sender, transaction.Receiver, transaction.Status, nil, nil, nil, nil, nil, nil, nil, nil).
 			WillReturnError(expectedErr)
+		mock.ExpectRollback()
 
-		err = mockDB.CreateTransaction(transaction)
+		err = mockDB.CreateTransaction(context.Background(), transaction)
 		assert.Error(t, err)
 		assert.Equal(t, expectedErr, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
 
+func TestCreateTransaction_Duplicate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mockDB := &DBImpl{DB: db}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO transactions").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'txn-123' for key 'PRIMARY'"})
+	mock.ExpectRollback()
+
+	err = mockDB.CreateTransaction(context.Background(), models.Transaction{ID: "txn-123"})
+	assert.ErrorIs(t, err, ErrDuplicate)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateTransactions(t *testing.T) {
+	transactionRows := func(transactions ...models.Transaction) *sqlmock.Rows {
+		rows := sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "failure_reason", "screening_decision", "screening_screener", "screening_reason", "target_currency", "target_amount", "fx_rate", "quote_id", "created_at"})
+		for _, transaction := range transactions {
+			rows.AddRow(transaction.ID, transaction.Amount.String(), transaction.Currency, transaction.Sender, transaction.Receiver,
+				transaction.Status, nil, nil, nil, nil, nil, nil, nil, nil, time.Now())
+		}
+		return rows
+	}
+	newTransaction := func(id string) models.Transaction {
+		return models.Transaction{ID: id, Amount: models.MustParseAmount("10.00"), Currency: "USD", Sender: "payroll", Receiver: "user-" + id, Status: models.StatusPending}
+	}
+
+	t.Run("multi-row inserts", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		first, second := newTransaction("txn-1"), newTransaction("txn-2")
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?), (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")).
+			WithArgs(first.ID, first.Amount, first.Currency, first.Sender, first.Receiver, first.Status, nil, nil, nil, nil, nil, nil, nil, nil,
+				second.ID, second.Amount, second.Currency, second.Sender, second.Receiver, second.Status, nil, nil, nil, nil, nil, nil, nil, nil).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery(regexp.QuoteMeta("FROM transactions WHERE id IN (?, ?)")).
+			WithArgs(first.ID, second.ID).
+			WillReturnRows(transactionRows(second, first))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO outbox_events(event_type, transaction_id, payload) VALUES (?, ?, ?), (?, ?, ?)")).
+			WithArgs(models.EventTransactionCreated, first.ID, sqlmock.AnyArg(), models.EventTransactionCreated, second.ID, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec("INSERT INTO webhook_deliveries").
+			WithArgs(models.EventTransactionCreated, first.ID, second.ID).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		err = mockDB.CreateTransactions(context.Background(), []models.Transaction{first, second})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("large batches are split", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		transactions := make([]models.Transaction, insertBatchSize+1)
+		for i := range transactions {
+			transactions[i] = newTransaction(fmt.Sprintf("txn-%d", i))
+		}
+
+		mock.ExpectBegin()
+		for _, chunk := range [][]models.Transaction{transactions[:insertBatchSize], transactions[insertBatchSize:]} {
+			mock.ExpectExec("INSERT INTO transactions").WillReturnResult(sqlmock.NewResult(0, int64(len(chunk))))
+			mock.ExpectQuery("FROM transactions WHERE id IN").WillReturnRows(transactionRows(chunk...))
+			mock.ExpectExec("INSERT INTO outbox_events").WillReturnResult(sqlmock.NewResult(0, int64(len(chunk))))
+			mock.ExpectExec("INSERT INTO webhook_deliveries").WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+		mock.ExpectCommit()
+
+		err = mockDB.CreateTransactions(context.Background(), transactions)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("duplicate rolls back the batch", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO transactions").
+			WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'txn-2' for key 'PRIMARY'"})
+		mock.ExpectRollback()
+
+		err = mockDB.CreateTransactions(context.Background(), []models.Transaction{newTransaction("txn-1"), newTransaction("txn-2")})
+		assert.ErrorIs(t, err, ErrDuplicate)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestUpdateTransaction(t *testing.T) {
+	// Failing, unlike completing, posts nothing to the ledger
+	lockQuery := "SELECT status FROM transactions WHERE id = \\? FOR UPDATE"
+	updateQuery := "UPDATE transactions SET status = \\?, failure_reason = \\? WHERE id = \\?"
+	eventQuery := "INSERT INTO transaction_events\\(transaction_id, from_status, to_status, reason, actor\\) VALUES \\(\\?, \\?, \\?, \\?, \\?\\)"
+	selectQuery := "SELECT id, amount, currency, sender, receiver, status, failure_reason, screening_decision, screening_screener, screening_reason, target_currency, target_amount, fx_rate, quote_id, created_at FROM transactions WHERE id = \\?"
+	transactionRow := func(status models.Status, failureReason interface{}) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "failure_reason", "screening_decision", "screening_screener", "screening_reason", "target_currency", "target_amount", "fx_rate", "quote_id", "created_at"}).
+			AddRow("txn-123", "10.0000", "USD", "alice", "bob", status, failureReason, nil, nil, nil, nil, nil, nil, nil, time.Now())
+	}
+	change := models.StatusChange{Status: models.StatusFailed, Reason: "card declined", Actor: "ops"}
+
 	t.Run("successful update", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
@@ -74,13 +235,49 @@ func TestUpdateTransaction(t *testing.T) {
 
 		mockDB := &DBImpl{DB: db}
 		id := "txn-123"
-		status := models.StatusCompleted
 
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.StatusProcessing))
+		mock.ExpectExec(updateQuery).
+			WithArgs(models.StatusFailed, "card declined", id).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(selectQuery).WithArgs(id).WillReturnRows(transactionRow(models.StatusFailed, "card declined"))
+		mock.ExpectExec(eventQuery).
+			WithArgs(id, models.StatusProcessing, models.StatusFailed, "card declined", "ops").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectOutboxEvent(mock, mysqlDialect, models.EventTransactionStatusChanged, id)
+		mock.ExpectCommit()
+
+		err = mockDB.UpdateTransaction(context.Background(), id, change)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("statuses other than failed keep the failure reason", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		id := "txn-123"
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.StatusPending))
 		mock.ExpectExec("UPDATE transactions SET status = \\? WHERE id = \\?").
-			WithArgs(status, id).
+			WithArgs(models.StatusProcessing, id).
 			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(selectQuery).WithArgs(id).WillReturnRows(transactionRow(models.StatusProcessing, nil))
+		mock.ExpectExec(eventQuery).
+			WithArgs(id, models.StatusPending, models.StatusProcessing, nil, "api").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectOutboxEvent(mock, mysqlDialect, models.EventTransactionStatusChanged, id)
+		mock.ExpectCommit()
 
-		err = mockDB.UpdateTransaction(id, status)
+		err = mockDB.UpdateTransaction(context.Background(), id, models.StatusChange{Status: models.StatusProcessing, Actor: "api"})
 		assert.NoError(t, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -92,34 +289,78 @@ func TestUpdateTransaction(t *testing.T) {
 
 		mockDB := &DBImpl{DB: db}
 		id := "txn-123"
-		status := models.StatusCompleted
 
 		expectedErr := errors.New("update error")
-		mock.ExpectExec("UPDATE transactions SET status = \\? WHERE id = \\?").
-			WithArgs(status, id).
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.StatusPending))
+		mock.ExpectExec(updateQuery).
+			WithArgs(models.StatusFailed, "card declined", id).
 			WillReturnError(expectedErr)
+		mock.ExpectRollback()
 
-		err = mockDB.UpdateTransaction(id, status)
+		err = mockDB.UpdateTransaction(context.Background(), id, change)
 		assert.Error(t, err)
 		assert.Equal(t, expectedErr, err)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("update with no rows affected", func(t *testing.T) {
+	t.Run("illegal transition", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		id := "txn-123"
+
+		// Failing is allowed from pending and processing, but the transaction is already completed
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).
+			WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.StatusCompleted))
+		mock.ExpectRollback()
+
+		err = mockDB.UpdateTransaction(context.Background(), id, change)
+		assert.ErrorIs(t, err, ErrConflict)
+		assert.ErrorIs(t, err, models.ErrInvalidTransition)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("transaction not found", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
 		defer db.Close()
 
 		mockDB := &DBImpl{DB: db}
 		id := "non-existent-id"
-		status := models.StatusCompleted
 
-		mock.ExpectExec("UPDATE transactions SET status = \\? WHERE id = \\?").
-			WithArgs(status, id).
-			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).
+			WithArgs(id).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
 
-		err = mockDB.UpdateTransaction(id, status)
-		assert.NoError(t, err) // No error expected even if no rows updated
+		err = mockDB.UpdateTransaction(context.Background(), id, change)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("unreachable status", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(lockQuery).
+			WithArgs("txn-123").
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(models.StatusPending))
+		mock.ExpectRollback()
+
+		err = mockDB.UpdateTransaction(context.Background(), "txn-123", models.StatusChange{Status: models.StatusPending, Actor: "api"})
+		assert.ErrorIs(t, err, ErrConflict)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
@@ -136,7 +377,7 @@ func TestGetAllTransactions(t *testing.T) {
 		expectedTransactions := []models.Transaction{
 			{
 				ID:       "txn-1",
-				Amount:   100.50,
+				Amount:   models.MustParseAmount("100.50"),
 				Currency: "USD",
 				Sender:   "user-1",
 				Receiver: "user-2",
@@ -144,7 +385,7 @@ func TestGetAllTransactions(t *testing.T) {
 			},
 			{
 				ID:       "txn-2",
-				Amount:   200.75,
+				Amount:   models.MustParseAmount("200.75"),
 				Currency: "EUR",
 				Sender:   "user-3",
 				Receiver: "user-4",
@@ -152,17 +393,17 @@ func TestGetAllTransactions(t *testing.T) {
 			},
 		}
 
-		rows := sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "created_at"}).
-			AddRow(expectedTransactions[0].ID, expectedTransactions[0].Amount, expectedTransactions[0].Currency,
-				expectedTransactions[0].Sender, expectedTransactions[0].Receiver, expectedTransactions[0].Status, time.Time{}).
-			AddRow(expectedTransactions[1].ID, expectedTransactions[1].Amount, expectedTransactions[1].Currency,
-				expectedTransactions[1].Sender, expectedTransactions[1].Receiver, expectedTransactions[1].Status, time.Time{})
+		rows := sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "failure_reason", "screening_decision", "screening_screener", "screening_reason", "target_currency", "target_amount", "fx_rate", "quote_id", "created_at"}).
+			AddRow(expectedTransactions[0].ID, expectedTransactions[0].Amount.String(), expectedTransactions[0].Currency,
+				expectedTransactions[0].Sender, expectedTransactions[0].Receiver, expectedTransactions[0].Status, nil, nil, nil, nil, nil, nil, nil, nil, time.Time{}).
+			AddRow(expectedTransactions[1].ID, expectedTransactions[1].Amount.String(), expectedTransactions[1].Currency,
+				expectedTransactions[1].Sender, expectedTransactions[1].Receiver, expectedTransactions[1].Status, nil, nil, nil, nil, nil, nil, nil, nil, time.Time{})
 
-		mock.ExpectQuery("SELECT id, amount, currency, sender, receiver, status, created_at FROM transactions ORDER BY id LIMIT \\? OFFSET \\?").
+		mock.ExpectQuery("SELECT id, amount, currency, sender, receiver, status, failure_reason, screening_decision, screening_screener, screening_reason, target_currency, target_amount, fx_rate, quote_id, created_at FROM transactions ORDER BY created_at ASC, id ASC LIMIT \\? OFFSET \\?").
 			WithArgs(limit, offset).
 			WillReturnRows(rows)
 
-		transactions, err := mockDB.GetAllTransactions(limit, offset)
+		transactions, err := mockDB.GetAllTransactions(context.Background(), TransactionFilter{}, limit, offset)
 		assert.NoError(t, err)
 		assert.Equal(t, expectedTransactions, transactions)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -176,13 +417,13 @@ func TestGetAllTransactions(t *testing.T) {
 		mockDB := &DBImpl{DB: db}
 		limit, offset := 10, 100
 
-		rows := sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "created_at"})
+		rows := sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "failure_reason", "screening_decision", "screening_screener", "screening_reason", "target_currency", "target_amount", "fx_rate", "quote_id", "created_at"})
 
-		mock.ExpectQuery("SELECT id, amount, currency, sender, receiver, status, created_at FROM transactions ORDER BY id LIMIT \\? OFFSET \\?").
+		mock.ExpectQuery("SELECT id, amount, currency, sender, receiver, status, failure_reason, screening_decision, screening_screener, screening_reason, target_currency, target_amount, fx_rate, quote_id, created_at FROM transactions ORDER BY created_at ASC, id ASC LIMIT \\? OFFSET \\?").
 			WithArgs(limit, offset).
 			WillReturnRows(rows)
 
-		transactions, err := mockDB.GetAllTransactions(limit, offset)
+		transactions, err := mockDB.GetAllTransactions(context.Background(), TransactionFilter{}, limit, offset)
 		assert.NoError(t, err)
 		assert.Empty(t, transactions)
 		assert.NoError(t, mock.ExpectationsWereMet())
@@ -197,11 +438,11 @@ func TestGetAllTransactions(t *testing.T) {
 		limit, offset := 10, 0
 
 		expectedErr := errors.New("query error")
-		mock.ExpectQuery("SELECT id, amount, currency, sender, receiver, status, created_at FROM transactions ORDER BY id LIMIT \\? OFFSET \\?").
+		mock.ExpectQuery("SELECT id, amount, currency, sender, receiver, status, failure_reason, screening_decision, screening_screener, screening_reason, target_currency, target_amount, fx_rate, quote_id, created_at FROM transactions ORDER BY created_at ASC, id ASC LIMIT \\? OFFSET \\?").
 			WithArgs(limit, offset).
 			WillReturnError(expectedErr)
 
-		transactions, err := mockDB.GetAllTransactions(limit, offset)
+		transactions, err := mockDB.GetAllTransactions(context.Background(), TransactionFilter{}, limit, offset)
 		assert.Error(t, err)
 		assert.Nil(t, transactions)
 		assert.Equal(t, expectedErr, err)
@@ -217,20 +458,174 @@ func TestGetAllTransactions(t *testing.T) {
 		limit, offset := 10, 0
 
 		// Return rows with wrong data type for amount to cause scan error
-		rows := sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "created_at"}).
-			AddRow("txn-1", "not-a-float", "USD", "user-1", "user-2", models.StatusPending, time.Time{})
+		rows := sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "failure_reason", "screening_decision", "screening_screener", "screening_reason", "target_currency", "target_amount", "fx_rate", "quote_id", "created_at"}).
+			AddRow("txn-1", "not-a-float", "USD", "user-1", "user-2", models.StatusPending, nil, nil, nil, nil, nil, nil, nil, nil, time.Time{})
 
-		mock.ExpectQuery("SELECT id, amount, currency, sender, receiver, status, created_at FROM transactions ORDER BY id LIMIT \\? OFFSET \\?").
+		mock.ExpectQuery("SELECT id, amount, currency, sender, receiver, status, failure_reason, screening_decision, screening_screener, screening_reason, target_currency, target_amount, fx_rate, quote_id, created_at FROM transactions ORDER BY created_at ASC, id ASC LIMIT \\? OFFSET \\?").
 			WithArgs(limit, offset).
 			WillReturnRows(rows)
 
-		transactions, err := mockDB.GetAllTransactions(limit, offset)
+		transactions, err := mockDB.GetAllTransactions(context.Background(), TransactionFilter{}, limit, offset)
 		assert.Error(t, err)
 		assert.Nil(t, transactions)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
 
+func TestEachTransaction(t *testing.T) {
+	columns := []string{"id", "amount", "currency", "sender", "receiver", "status", "failure_reason", "screening_decision", "screening_screener", "screening_reason", "target_currency", "target_amount", "fx_rate", "quote_id", "created_at"}
+
+	t.Run("calls fn with every filtered row without a limit", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		rows := sqlmock.NewRows(columns).
+			AddRow("txn-2", "200.75", "USD", "payroll", "bob", models.StatusCompleted, nil, nil, nil, nil, nil, nil, nil, nil, time.Time{}).
+			AddRow("txn-1", "100.50", "USD", "payroll", "carol", models.StatusCompleted, nil, nil, nil, nil, nil, nil, nil, nil, time.Time{})
+		mock.ExpectQuery("SELECT id, .* FROM transactions WHERE sender = \\? ORDER BY amount DESC, id DESC$").
+			WithArgs("payroll").
+			WillReturnRows(rows)
+
+		var visited []string
+		err = mockDB.EachTransaction(context.Background(), TransactionFilter{Sender: "payroll", SortBy: SortByAmount, Descending: true},
+			func(transaction models.Transaction) error {
+				visited = append(visited, transaction.ID)
+				return nil
+			})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"txn-2", "txn-1"}, visited)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("stops at the first error from fn", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		rows := sqlmock.NewRows(columns).
+			AddRow("txn-1", "100.50", "USD", "payroll", "bob", models.StatusCompleted, nil, nil, nil, nil, nil, nil, nil, nil, time.Time{}).
+			AddRow("txn-2", "200.75", "USD", "payroll", "carol", models.StatusCompleted, nil, nil, nil, nil, nil, nil, nil, nil, time.Time{})
+		mock.ExpectQuery("SELECT id, .* FROM transactions ORDER BY created_at ASC, id ASC$").WillReturnRows(rows)
+
+		stop := errors.New("client went away")
+		calls := 0
+		err = mockDB.EachTransaction(context.Background(), TransactionFilter{}, func(models.Transaction) error {
+			calls++
+			return stop
+		})
+		assert.ErrorIs(t, err, stop)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		mock.ExpectQuery("SELECT id, .* FROM transactions").WillReturnError(errors.New("database error"))
+
+		err = mockDB.EachTransaction(context.Background(), TransactionFilter{}, func(models.Transaction) error {
+			t.Fatal("fn must not be called")
+			return nil
+		})
+		assert.EqualError(t, err, "database error")
+	})
+}
+
+func TestGetTransactionsAfter(t *testing.T) {
+	columns := []string{"id", "amount", "currency", "sender", "receiver", "status", "failure_reason", "screening_decision", "screening_screener", "screening_reason", "target_currency", "target_amount", "fx_rate", "quote_id", "created_at"}
+	createdAt := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("first page", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		rows := sqlmock.NewRows(columns).
+			AddRow("txn-1", "100.50", "USD", "user-1", "user-2", models.StatusPending, nil, nil, nil, nil, nil, nil, nil, nil, createdAt)
+
+		mock.ExpectQuery("SELECT id, amount, currency, sender, receiver, status, failure_reason, screening_decision, screening_screener, screening_reason, target_currency, target_amount, fx_rate, quote_id, created_at FROM transactions ORDER BY created_at ASC, id ASC LIMIT \\?").
+			WithArgs(11).
+			WillReturnRows(rows)
+
+		transactions, err := mockDB.GetTransactionsAfter(context.Background(), TransactionFilter{}, nil, 11)
+		assert.NoError(t, err)
+		require.Len(t, transactions, 1)
+		assert.Equal(t, "txn-1", transactions[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("page after cursor", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		cursor := &Cursor{CreatedAt: createdAt, ID: "txn-1"}
+
+		rows := sqlmock.NewRows(columns).
+			AddRow("txn-2", "5.00", "EUR", "user-3", "user-4", models.StatusPending, nil, nil, nil, nil, nil, nil, nil, nil, createdAt).
+			AddRow("txn-3", "7.00", "EUR", "user-3", "user-4", models.StatusPending, nil, nil, nil, nil, nil, nil, nil, nil, createdAt.Add(time.Second))
+
+		mock.ExpectQuery("FROM transactions WHERE \\(created_at > \\? OR \\(created_at = \\? AND id > \\?\\)\\) ORDER BY created_at ASC, id ASC LIMIT \\?").
+			WithArgs(createdAt, createdAt, "txn-1", 5).
+			WillReturnRows(rows)
+
+		transactions, err := mockDB.GetTransactionsAfter(context.Background(), TransactionFilter{}, cursor, 5)
+		assert.NoError(t, err)
+		require.Len(t, transactions, 2)
+		assert.Equal(t, "txn-2", transactions[0].ID)
+		assert.Equal(t, "txn-3", transactions[1].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("page before cursor is returned in ascending order", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		cursor := &Cursor{CreatedAt: createdAt, ID: "txn-9", Before: true}
+
+		rows := sqlmock.NewRows(columns).
+			AddRow("txn-8", "5.00", "EUR", "user-3", "user-4", models.StatusPending, nil, nil, nil, nil, nil, nil, nil, nil, createdAt).
+			AddRow("txn-7", "7.00", "EUR", "user-3", "user-4", models.StatusPending, nil, nil, nil, nil, nil, nil, nil, nil, createdAt.Add(-time.Second))
+
+		mock.ExpectQuery("FROM transactions WHERE \\(created_at < \\? OR \\(created_at = \\? AND id < \\?\\)\\) ORDER BY created_at DESC, id DESC LIMIT \\?").
+			WithArgs(createdAt, createdAt, "txn-9", 5).
+			WillReturnRows(rows)
+
+		transactions, err := mockDB.GetTransactionsAfter(context.Background(), TransactionFilter{}, cursor, 5)
+		assert.NoError(t, err)
+		require.Len(t, transactions, 2)
+		assert.Equal(t, "txn-7", transactions[0].ID)
+		assert.Equal(t, "txn-8", transactions[1].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("query fails", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		expectedErr := errors.New("query error")
+		mock.ExpectQuery("SELECT (.+) FROM transactions").WillReturnError(expectedErr)
+
+		transactions, err := mockDB.GetTransactionsAfter(context.Background(), TransactionFilter{}, nil, 10)
+		assert.Equal(t, expectedErr, err)
+		assert.Nil(t, transactions)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestGetTransaction(t *testing.T) {
 	t.Run("successful get transaction", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
@@ -242,27 +637,46 @@ func TestGetTransaction(t *testing.T) {
 
 		expectedTransaction := &models.Transaction{
 			ID:       id,
-			Amount:   100.50,
+			Amount:   models.MustParseAmount("100.50"),
 			Currency: "USD",
 			Sender:   "user-1",
 			Receiver: "user-2",
 			Status:   models.StatusCompleted,
 		}
 
-		row := sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "created_at"}).
-			AddRow(expectedTransaction.ID, expectedTransaction.Amount, expectedTransaction.Currency,
-				expectedTransaction.Sender, expectedTransaction.Receiver, expectedTransaction.Status, time.Time{})
+		row := sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "failure_reason", "screening_decision", "screening_screener", "screening_reason", "target_currency", "target_amount", "fx_rate", "quote_id", "created_at"}).
+			AddRow(expectedTransaction.ID, expectedTransaction.Amount.String(), expectedTransaction.Currency,
+				expectedTransaction.Sender, expectedTransaction.Receiver, expectedTransaction.Status, nil, nil, nil, nil, nil, nil, nil, nil, time.Time{})
 
-		mock.ExpectQuery("SELECT id, amount, currency, sender, receiver, status, created_at FROM transactions WHERE id = \\?").
+		mock.ExpectQuery("SELECT id, amount, currency, sender, receiver, status, failure_reason, screening_decision, screening_screener, screening_reason, target_currency, target_amount, fx_rate, quote_id, created_at FROM transactions WHERE id = \\?").
 			WithArgs(id).
 			WillReturnRows(row)
 
-		transaction, err := mockDB.GetTransaction(id)
+		transaction, err := mockDB.GetTransaction(context.Background(), id)
 		assert.NoError(t, err)
 		assert.Equal(t, expectedTransaction, transaction)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
+	t.Run("held transaction with screening decision", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		row := sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "failure_reason", "screening_decision", "screening_screener", "screening_reason", "target_currency", "target_amount", "fx_rate", "quote_id", "created_at"}).
+			AddRow("txn-123", "20000.0000", "USD", "user-1", "user-2", models.StatusHeld, nil, "review", "amount_threshold", "large amount", nil, nil, nil, nil, time.Time{})
+		mock.ExpectQuery("SELECT (.+) FROM transactions WHERE id = \\?").
+			WithArgs("txn-123").
+			WillReturnRows(row)
+
+		transaction, err := mockDB.GetTransaction(context.Background(), "txn-123")
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusHeld, transaction.Status)
+		assert.Equal(t, &models.Screening{Decision: models.ScreeningReview, Screener: "amount_threshold", Reason: "large amount"}, transaction.Screening)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("transaction not found", func(t *testing.T) {
 		db, mock, err := sqlmock.New()
 		require.NoError(t, err)
@@ -271,12 +685,12 @@ func TestGetTransaction(t *testing.T) {
 		mockDB := &DBImpl{DB: db}
 		id := "non-existent-id"
 
-		mock.ExpectQuery("SELECT id, amount, currency, sender, receiver, status, created_at FROM transactions WHERE id = \\?").
+		mock.ExpectQuery("SELECT id, amount, currency, sender, receiver, status, failure_reason, screening_decision, screening_screener, screening_reason, target_currency, target_amount, fx_rate, quote_id, created_at FROM transactions WHERE id = \\?").
 			WithArgs(id).
 			WillReturnError(sql.ErrNoRows)
 
-		transaction, err := mockDB.GetTransaction(id)
-		assert.NoError(t, err)
+		transaction, err := mockDB.GetTransaction(context.Background(), id)
+		assert.ErrorIs(t, err, ErrNotFound)
 		assert.Nil(t, transaction)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -290,11 +704,11 @@ func TestGetTransaction(t *testing.T) {
 		id := "txn-123"
 
 		expectedErr := errors.New("database error")
-		mock.ExpectQuery("SELECT id, amount, currency, sender, receiver, status, created_at FROM transactions WHERE id = \\?").
+		mock.ExpectQuery("SELECT id, amount, currency, sender, receiver, status, failure_reason, screening_decision, screening_screener, screening_reason, target_currency, target_amount, fx_rate, quote_id, created_at FROM transactions WHERE id = \\?").
 			WithArgs(id).
 			WillReturnError(expectedErr)
 
-		transaction, err := mockDB.GetTransaction(id)
+		transaction, err := mockDB.GetTransaction(context.Background(), id)
 		assert.Error(t, err)
 		assert.Nil(t, transaction)
 		assert.Equal(t, expectedErr, err)
@@ -310,16 +724,54 @@ func TestGetTransaction(t *testing.T) {
 		id := "txn-123"
 
 		// Return row with wrong data type for amount to cause scan error
-		row := sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "created_at"}).
-			AddRow(id, "not-a-float", "USD", "user-1", "user-2", models.StatusPending, time.Time{})
+		row := sqlmock.NewRows([]string{"id", "amount", "currency", "sender", "receiver", "status", "failure_reason", "screening_decision", "screening_screener", "screening_reason", "target_currency", "target_amount", "fx_rate", "quote_id", "created_at"}).
+			AddRow(id, "not-a-float", "USD", "user-1", "user-2", models.StatusPending, nil, nil, nil, nil, nil, nil, nil, nil, time.Time{})
 
-		mock.ExpectQuery("SELECT id, amount, currency, sender, receiver, status, created_at FROM transactions WHERE id = \\?").
+		mock.ExpectQuery("SELECT id, amount, currency, sender, receiver, status, failure_reason, screening_decision, screening_screener, screening_reason, target_currency, target_amount, fx_rate, quote_id, created_at FROM transactions WHERE id = \\?").
 			WithArgs(id).
 			WillReturnRows(row)
 
-		transaction, err := mockDB.GetTransaction(id)
+		transaction, err := mockDB.GetTransaction(context.Background(), id)
 		assert.Error(t, err)
 		assert.Nil(t, transaction)
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
+
+func TestQueryTimeout(t *testing.T) {
+	t.Run("query exceeding timeout is cancelled", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db, QueryTimeout: 10 * time.Millisecond}
+
+		mock.ExpectQuery("SELECT (.+) FROM transactions WHERE id = \\?").
+			WithArgs("txn-123").
+			WillDelayFor(time.Second).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		// sqlmock reports its own cancellation error rather than the context's
+		transaction, err := mockDB.GetTransaction(context.Background(), "txn-123")
+		assert.Error(t, err)
+		assert.Nil(t, transaction)
+	})
+
+	t.Run("cancelled caller context", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectExec("INSERT INTO transactions").
+			WillDelayFor(time.Second).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err = mockDB.CreateTransaction(ctx, models.Transaction{ID: "txn-123"})
+		assert.Error(t, err)
+	})
+}
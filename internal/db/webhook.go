@@ -0,0 +1,482 @@
+// Package db implements the database operations for the transaction service.
+// This file contains the transactional outbox and the webhook endpoints and deliveries that publish it.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// recordOutboxEvent writes an event to the outbox and queues its delivery to every enabled webhook endpoint.
+// It must run inside the SQL transaction that makes the change the event describes.
+func recordOutboxEvent(ctx context.Context, q querier, d dialect, event models.OutboxEvent) error {
+	query := "INSERT INTO outbox_events(event_type, transaction_id, payload) VALUES (?, ?, ?)"
+	// The payload is passed as a string: lib/pq would send a []byte as bytea, which JSONB rejects
+	if _, err := q.ExecContext(ctx, query, event.Type, event.TransactionID, string(event.Data)); err != nil {
+		return err
+	}
+
+	var eventID int64
+	if err := q.QueryRowContext(ctx, d.lastInsertID).Scan(&eventID); err != nil {
+		return err
+	}
+
+	// The event ID is only compared, never selected, so that PostgreSQL can infer the placeholder's type
+	query = `
+		INSERT INTO webhook_deliveries(event_id, endpoint_id, next_attempt_at)
+		SELECT e.id, w.id, e.created_at
+		FROM outbox_events e
+		JOIN webhook_endpoints w ON w.enabled
+		WHERE e.id = ?
+	`
+	_, err := q.ExecContext(ctx, query, eventID)
+	return err
+}
+
+// ListOutboxEvents retrieves up to limit outbox events with an ID greater than afterID, oldest first.
+// Event IDs are the persisted sequence that streaming clients resume from.
+func (db *DBImpl) ListOutboxEvents(ctx context.Context, afterID int64, limit int) ([]models.OutboxEvent, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectOutboxEvents(ctx, db.DB, afterID, limit)
+}
+
+// selectOutboxEvents selects a page of outbox events ordered by ID.
+func selectOutboxEvents(ctx context.Context, q querier, afterID int64, limit int) ([]models.OutboxEvent, error) {
+	query := "SELECT id, event_type, transaction_id, payload, created_at FROM outbox_events WHERE id > ? ORDER BY id ASC LIMIT ?"
+
+	rows, err := q.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []models.OutboxEvent
+	for rows.Next() {
+		var event models.OutboxEvent
+		var payload []byte
+		if err := rows.Scan(&event.ID, &event.Type, &event.TransactionID, &payload, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		event.Data = payload
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// LastOutboxEventID returns the ID of the latest outbox event, or 0 if the outbox is empty.
+func (db *DBImpl) LastOutboxEventID(ctx context.Context) (int64, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectLastOutboxEventID(ctx, db.DB)
+}
+
+// selectLastOutboxEventID selects the highest outbox event ID.
+func selectLastOutboxEventID(ctx context.Context, q querier) (int64, error) {
+	var id int64
+	err := q.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM outbox_events").Scan(&id)
+	return id, err
+}
+
+// CreateWebhookEndpoint registers an endpoint for every event written from now on.
+// Returns ErrDuplicate if an endpoint with the same ID already exists.
+func (db *DBImpl) CreateWebhookEndpoint(ctx context.Context, endpoint models.WebhookEndpoint) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return insertWebhookEndpoint(ctx, db.DB, endpoint)
+}
+
+// insertWebhookEndpoint inserts an endpoint, leaving created_at to the column default.
+func insertWebhookEndpoint(ctx context.Context, q querier, endpoint models.WebhookEndpoint) error {
+	query := "INSERT INTO webhook_endpoints(id, url, secret, enabled) VALUES (?, ?, ?, ?)"
+
+	_, err := q.ExecContext(ctx, query, endpoint.ID, endpoint.URL, endpoint.Secret, endpoint.Enabled)
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return fmt.Errorf("%w: webhook endpoint %s already exists", ErrDuplicate, endpoint.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+// GetWebhookEndpoint retrieves a single endpoint, including its secret, by its ID.
+// Returns ErrNotFound if no endpoint has the given ID.
+func (db *DBImpl) GetWebhookEndpoint(ctx context.Context, id string) (*models.WebhookEndpoint, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectWebhookEndpoint(ctx, db.DB, id)
+}
+
+// selectWebhookEndpoint selects a single endpoint by its ID, returning ErrNotFound if there is none.
+func selectWebhookEndpoint(ctx context.Context, q querier, id string) (*models.WebhookEndpoint, error) {
+	query := "SELECT id, url, secret, enabled, created_at FROM webhook_endpoints WHERE id = ?"
+
+	endpoint, err := scanWebhookEndpoint(q.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: webhook endpoint %s", ErrNotFound, id)
+		}
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+// ListWebhookEndpoints retrieves every endpoint, including its secret, in registration order.
+func (db *DBImpl) ListWebhookEndpoints(ctx context.Context) ([]models.WebhookEndpoint, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectWebhookEndpoints(ctx, db.DB)
+}
+
+// selectWebhookEndpoints selects every endpoint ordered by creation time and ID.
+func selectWebhookEndpoints(ctx context.Context, q querier) ([]models.WebhookEndpoint, error) {
+	query := "SELECT id, url, secret, enabled, created_at FROM webhook_endpoints ORDER BY created_at ASC, id ASC"
+
+	rows, err := q.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var endpoints []models.WebhookEndpoint
+	for rows.Next() {
+		endpoint, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, *endpoint)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return endpoints, nil
+}
+
+// UpdateWebhookEndpoint replaces an endpoint's URL and enabled flag; its secret and creation time are kept.
+// Returns ErrNotFound if no endpoint has the given ID.
+func (db *DBImpl) UpdateWebhookEndpoint(ctx context.Context, endpoint models.WebhookEndpoint) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return updateWebhookEndpoint(ctx, db.DB, endpoint)
+}
+
+// updateWebhookEndpoint updates an endpoint's URL and enabled flag.
+func updateWebhookEndpoint(ctx context.Context, q querier, endpoint models.WebhookEndpoint) error {
+	// MySQL reports zero affected rows for an update that changes nothing, so existence is checked separately
+	if _, err := selectWebhookEndpoint(ctx, q, endpoint.ID); err != nil {
+		return err
+	}
+
+	query := "UPDATE webhook_endpoints SET url = ?, enabled = ? WHERE id = ?"
+	_, err := q.ExecContext(ctx, query, endpoint.URL, endpoint.Enabled, endpoint.ID)
+	return err
+}
+
+// DeleteWebhookEndpoint removes an endpoint together with its deliveries.
+// Returns ErrNotFound if no endpoint has the given ID.
+func (db *DBImpl) DeleteWebhookEndpoint(ctx context.Context, id string) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return deleteWebhookEndpoint(ctx, db.DB, id)
+}
+
+// deleteWebhookEndpoint deletes an endpoint; its deliveries go with it through the foreign key's ON DELETE CASCADE.
+func deleteWebhookEndpoint(ctx context.Context, q querier, id string) error {
+	result, err := q.ExecContext(ctx, "DELETE FROM webhook_endpoints WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: webhook endpoint %s", ErrNotFound, id)
+	}
+	return nil
+}
+
+// ListWebhookDeliveries retrieves up to limit of an endpoint's deliveries with IDs greater than afterID, oldest first.
+// An empty status matches deliveries in every state.
+// Returns ErrNotFound if no endpoint has the given ID.
+func (db *DBImpl) ListWebhookDeliveries(ctx context.Context, endpointID string, status models.DeliveryStatus, afterID int64, limit int) ([]models.WebhookDelivery, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return selectWebhookDeliveries(ctx, db.DB, endpointID, status, afterID, limit)
+}
+
+// selectWebhookDeliveries selects a page of an endpoint's deliveries, returning ErrNotFound if there is no such endpoint.
+func selectWebhookDeliveries(ctx context.Context, q querier, endpointID string, status models.DeliveryStatus, afterID int64, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := selectWebhookEndpoint(ctx, q, endpointID); err != nil {
+		return nil, err
+	}
+
+	var builder queryBuilder
+	builder.where("endpoint_id = ?", endpointID)
+	builder.where("id > ?", afterID)
+	if status != "" {
+		builder.where("status = ?", status)
+	}
+
+	query := "SELECT " + deliveryColumns + " FROM webhook_deliveries" + builder.clause() + " ORDER BY id ASC LIMIT ?"
+	rows, err := q.QueryContext(ctx, query, append(builder.args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// RedeliverWebhookDelivery queues a delivery to be attempted again straight away with a fresh set of attempts,
+// whatever its current state. Returns ErrNotFound if the endpoint has no delivery with the given ID.
+func (db *DBImpl) RedeliverWebhookDelivery(ctx context.Context, endpointID string, deliveryID int64) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return redeliverWebhookDelivery(ctx, db.DB, endpointID, deliveryID)
+}
+
+// redeliverWebhookDelivery resets a delivery to pending, due now.
+func redeliverWebhookDelivery(ctx context.Context, q querier, endpointID string, deliveryID int64) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = ?
+		WHERE id = ? AND endpoint_id = ?
+	`
+	result, err := q.ExecContext(ctx, query, time.Now().UTC(), deliveryID, endpointID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: webhook delivery %d", ErrNotFound, deliveryID)
+	}
+	return nil
+}
+
+// ClaimWebhookDeliveries claims up to limit pending deliveries to enabled endpoints that are due at now,
+// earliest first. Each claimed delivery is hidden from other callers until now+lease by moving its next
+// attempt time, so a dispatcher that dies mid-attempt only delays it. Rows locked by a concurrent
+// claim are skipped rather than waited for.
+func (db *DBImpl) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.PendingDelivery, error) {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return claimWebhookDeliveries(ctx, db.DB, mysqlDialect, now, lease, limit)
+}
+
+// claimWebhookDeliveries locks, leases and reads a batch of due deliveries in one SQL transaction.
+func claimWebhookDeliveries(ctx context.Context, sqlDB *sql.DB, d dialect, now time.Time, lease time.Duration, limit int) ([]models.PendingDelivery, error) {
+	sqlTx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer sqlTx.Rollback()
+	q := d.bind(sqlTx)
+
+	query := `
+		SELECT d.id
+		FROM webhook_deliveries d
+		JOIN webhook_endpoints w ON w.id = d.endpoint_id
+		WHERE d.status = 'pending' AND d.next_attempt_at <= ? AND w.enabled
+		ORDER BY d.next_attempt_at ASC, d.id ASC
+		LIMIT ?
+		FOR UPDATE OF d SKIP LOCKED
+	`
+	rows, err := q.QueryContext(ctx, query, now.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	var ids []interface{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	query = "UPDATE webhook_deliveries SET next_attempt_at = ? WHERE id IN (" + placeholders + ")"
+	if _, err := q.ExecContext(ctx, query, append([]interface{}{now.Add(lease).UTC()}, ids...)...); err != nil {
+		return nil, err
+	}
+
+	query = "SELECT " + qualifiedDeliveryColumns + `, e.event_type, e.transaction_id, e.payload, e.created_at, w.url, w.secret
+		FROM webhook_deliveries d
+		JOIN outbox_events e ON e.id = d.event_id
+		JOIN webhook_endpoints w ON w.id = d.endpoint_id
+		WHERE d.id IN (` + placeholders + `)
+		ORDER BY d.id ASC`
+	rows, err = q.QueryContext(ctx, query, ids...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claimed []models.PendingDelivery
+	for rows.Next() {
+		var pending models.PendingDelivery
+		var payload []byte
+		pending.Delivery, err = scanWebhookDelivery(rows,
+			&pending.Event.Type, &pending.Event.TransactionID, &payload, &pending.Event.CreatedAt, &pending.URL, &pending.Secret)
+		if err != nil {
+			return nil, err
+		}
+		pending.Event.ID = pending.Delivery.EventID
+		pending.Event.Data = payload
+		claimed = append(claimed, pending)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return nil, err
+	}
+	return claimed, nil
+}
+
+// RecordDeliveryAttempt stores the outcome of an attempt to deliver a claimed webhook and counts the attempt.
+// Returns ErrNotFound if the delivery no longer exists, as when its endpoint was deleted mid-attempt.
+func (db *DBImpl) RecordDeliveryAttempt(ctx context.Context, deliveryID int64, attempt models.DeliveryAttempt) error {
+	ctx, cancel := db.withTimeout(ctx)
+	defer cancel()
+
+	return recordDeliveryAttempt(ctx, db.DB, deliveryID, attempt)
+}
+
+// recordDeliveryAttempt updates a delivery with the outcome of an attempt.
+func recordDeliveryAttempt(ctx context.Context, q querier, deliveryID int64, attempt models.DeliveryAttempt) error {
+	var deliveredAt sql.NullTime
+	if attempt.Status == models.DeliveryDelivered {
+		deliveredAt = sql.NullTime{Time: attempt.AttemptedAt.UTC(), Valid: true}
+	}
+	nextAttemptAt := attempt.NextAttemptAt
+	if nextAttemptAt.IsZero() {
+		nextAttemptAt = attempt.AttemptedAt
+	}
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = attempts + 1, next_attempt_at = ?, last_response_code = ?, last_error = ?, delivered_at = ?
+		WHERE id = ?
+	`
+	result, err := q.ExecContext(ctx, query,
+		attempt.Status,
+		nextAttemptAt.UTC(),
+		sql.NullInt64{Int64: int64(attempt.ResponseCode), Valid: attempt.ResponseCode != 0},
+		nullString(truncate(attempt.Error, models.MaxDeliveryErrorLength)),
+		deliveredAt,
+		deliveryID,
+	)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w: webhook delivery %d", ErrNotFound, deliveryID)
+	}
+	return nil
+}
+
+// truncate shortens s to at most n runes.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// deliveryColumns are the webhook_deliveries columns read by scanWebhookDelivery, in order.
+const deliveryColumns = "id, event_id, endpoint_id, status, attempts, next_attempt_at, last_response_code, last_error, delivered_at, created_at"
+
+// qualifiedDeliveryColumns are deliveryColumns for a query that aliases webhook_deliveries as d.
+const qualifiedDeliveryColumns = "d.id, d.event_id, d.endpoint_id, d.status, d.attempts, d.next_attempt_at, d.last_response_code, d.last_error, d.delivered_at, d.created_at"
+
+// scanWebhookEndpoint reads an endpoint row selected as id, url, secret, enabled, created_at.
+func scanWebhookEndpoint(row rowScanner) (*models.WebhookEndpoint, error) {
+	var endpoint models.WebhookEndpoint
+	if err := row.Scan(&endpoint.ID, &endpoint.URL, &endpoint.Secret, &endpoint.Enabled, &endpoint.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+// scanWebhookDelivery reads a delivery row selected as deliveryColumns, followed by any extra columns into extra.
+func scanWebhookDelivery(row rowScanner, extra ...interface{}) (models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var responseCode sql.NullInt64
+	var lastError sql.NullString
+	var deliveredAt sql.NullTime
+	dest := append([]interface{}{
+		&delivery.ID,
+		&delivery.EventID,
+		&delivery.EndpointID,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&responseCode,
+		&lastError,
+		&deliveredAt,
+		&delivery.CreatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	delivery.LastResponseCode = int(responseCode.Int64)
+	delivery.LastError = lastError.String
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return delivery, nil
+}
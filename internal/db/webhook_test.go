@@ -0,0 +1,346 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// expectOutboxEvent expects an outbox event about a transaction to be written and fanned out to the endpoints.
+func expectOutboxEvent(mock sqlmock.Sqlmock, d dialect, eventType, transactionID string) {
+	mock.ExpectExec("INSERT INTO outbox_events").
+		WithArgs(eventType, transactionID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(d.lastInsertID)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(7)))
+	mock.ExpectExec("INSERT INTO webhook_deliveries").
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+}
+
+func TestRecordOutboxEvent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	event, err := models.NewTransactionEvent(models.EventTransactionCreated, models.TransactionEventData{
+		Transaction: models.Transaction{ID: "txn-1", Amount: models.MustParseAmount("1.00"), Currency: "USD", Status: models.StatusPending},
+	})
+	require.NoError(t, err)
+
+	mock.ExpectExec(`INSERT INTO outbox_events\(event_type, transaction_id, payload\) VALUES \(\?, \?, \?\)`).
+		WithArgs(models.EventTransactionCreated, "txn-1", string(event.Data)).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectQuery(`SELECT LAST_INSERT_ID\(\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(7)))
+	mock.ExpectExec(`INSERT INTO webhook_deliveries\(event_id, endpoint_id, next_attempt_at\)\s+SELECT e.id, w.id, e.created_at\s+FROM outbox_events e\s+JOIN webhook_endpoints w ON w.enabled\s+WHERE e.id = \?`).
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	assert.NoError(t, recordOutboxEvent(context.Background(), db, mysqlDialect, event))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.JSONEq(t, `{"transaction":{"id":"txn-1","amount":"1.00","currency":"USD","sender":"","receiver":"","status":"pending","created_at":"0001-01-01T00:00:00Z"}}`, string(event.Data))
+}
+
+func TestListOutboxEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	database := &DBImpl{DB: db}
+
+	createdAt := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT id, event_type, transaction_id, payload, created_at FROM outbox_events WHERE id > \? ORDER BY id ASC LIMIT \?`).
+		WithArgs(int64(3), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "transaction_id", "payload", "created_at"}).
+			AddRow(int64(4), models.EventTransactionCreated, "txn-1", []byte(`{"transaction":{}}`), createdAt).
+			AddRow(int64(6), models.EventTransactionStatusChanged, "txn-1", []byte(`{"previous_status":"pending"}`), createdAt))
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(id\), 0\) FROM outbox_events`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(6)))
+
+	events, err := database.ListOutboxEvents(context.Background(), 3, 2)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, int64(4), events[0].ID)
+	assert.Equal(t, models.EventTransactionCreated, events[0].Type)
+	assert.Equal(t, "txn-1", events[0].TransactionID)
+	assert.JSONEq(t, `{"transaction":{}}`, string(events[0].Data))
+	assert.Equal(t, createdAt, events[0].CreatedAt)
+	assert.Equal(t, int64(6), events[1].ID)
+
+	last, err := database.LastOutboxEventID(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), last)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookEndpoints(t *testing.T) {
+	columns := []string{"id", "url", "secret", "enabled", "created_at"}
+
+	t.Run("create", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		endpoint := models.WebhookEndpoint{ID: "wh-1", URL: "https://example.com/hook", Secret: "whsec_1", Enabled: true}
+
+		mock.ExpectExec(`INSERT INTO webhook_endpoints\(id, url, secret, enabled\) VALUES \(\?, \?, \?, \?\)`).
+			WithArgs("wh-1", "https://example.com/hook", "whsec_1", true).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO webhook_endpoints").
+			WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+
+		assert.NoError(t, mockDB.CreateWebhookEndpoint(context.Background(), endpoint))
+		assert.ErrorIs(t, mockDB.CreateWebhookEndpoint(context.Background(), endpoint), ErrDuplicate)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("get", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		createdAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		mock.ExpectQuery(`SELECT id, url, secret, enabled, created_at FROM webhook_endpoints WHERE id = \?`).
+			WithArgs("wh-1").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("wh-1", "https://example.com/hook", "whsec_1", true, createdAt))
+		mock.ExpectQuery("FROM webhook_endpoints WHERE id = ").
+			WithArgs("missing").
+			WillReturnError(sql.ErrNoRows)
+
+		endpoint, err := mockDB.GetWebhookEndpoint(context.Background(), "wh-1")
+		require.NoError(t, err)
+		assert.Equal(t, models.WebhookEndpoint{ID: "wh-1", URL: "https://example.com/hook", Secret: "whsec_1", Enabled: true, CreatedAt: createdAt}, *endpoint)
+
+		_, err = mockDB.GetWebhookEndpoint(context.Background(), "missing")
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("update checks the endpoint exists", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectQuery("FROM webhook_endpoints WHERE id = ").
+			WithArgs("wh-1").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("wh-1", "https://example.com/old", "whsec_1", true, time.Now()))
+		mock.ExpectExec(`UPDATE webhook_endpoints SET url = \?, enabled = \? WHERE id = \?`).
+			WithArgs("https://example.com/new", false, "wh-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("FROM webhook_endpoints WHERE id = ").
+			WithArgs("missing").
+			WillReturnError(sql.ErrNoRows)
+
+		assert.NoError(t, mockDB.UpdateWebhookEndpoint(context.Background(), models.WebhookEndpoint{ID: "wh-1", URL: "https://example.com/new"}))
+		assert.ErrorIs(t, mockDB.UpdateWebhookEndpoint(context.Background(), models.WebhookEndpoint{ID: "missing", URL: "https://example.com"}), ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectExec(`DELETE FROM webhook_endpoints WHERE id = \?`).WithArgs("wh-1").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM webhook_endpoints WHERE id = \?`).WithArgs("missing").WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.NoError(t, mockDB.DeleteWebhookEndpoint(context.Background(), "wh-1"))
+		assert.ErrorIs(t, mockDB.DeleteWebhookEndpoint(context.Background(), "missing"), ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// deliveryRow returns a delivery row in deliveryColumns order, followed by any extra values.
+func deliveryRow(rows *sqlmock.Rows, id int64, status models.DeliveryStatus, extra ...interface{}) *sqlmock.Rows {
+	values := []driver.Value{id, int64(7), "wh-1", status, 2, time.Now(), 500, "endpoint responded with 500", nil, time.Now()}
+	for _, value := range extra {
+		values = append(values, value)
+	}
+	return rows.AddRow(values...)
+}
+
+func TestListWebhookDeliveries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mockDB := &DBImpl{DB: db}
+
+	mock.ExpectQuery("FROM webhook_endpoints WHERE id = ").
+		WithArgs("wh-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "secret", "enabled", "created_at"}).AddRow("wh-1", "https://example.com", "s", true, time.Now()))
+	mock.ExpectQuery(`SELECT id, event_id, endpoint_id, status, attempts, next_attempt_at, last_response_code, last_error, delivered_at, created_at `+
+		`FROM webhook_deliveries WHERE endpoint_id = \? AND id > \? AND status = \? ORDER BY id ASC LIMIT \?`).
+		WithArgs("wh-1", int64(3), models.DeliveryDead, 11).
+		WillReturnRows(deliveryRow(sqlmock.NewRows(strings.Split(deliveryColumns, ", ")), 4, models.DeliveryDead))
+
+	deliveries, err := mockDB.ListWebhookDeliveries(context.Background(), "wh-1", models.DeliveryDead, 3, 11)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, int64(4), deliveries[0].ID)
+	assert.Equal(t, models.DeliveryDead, deliveries[0].Status)
+	assert.Equal(t, 500, deliveries[0].LastResponseCode)
+	assert.Equal(t, "endpoint responded with 500", deliveries[0].LastError)
+	assert.Nil(t, deliveries[0].DeliveredAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClaimWebhookDeliveries(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	lease := time.Minute
+
+	t.Run("locks, leases and reads due deliveries", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		eventAt := now.Add(-time.Second)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT d.id\s+FROM webhook_deliveries d\s+JOIN webhook_endpoints w ON w.id = d.endpoint_id\s+`+
+			`WHERE d.status = 'pending' AND d.next_attempt_at <= \? AND w.enabled\s+`+
+			`ORDER BY d.next_attempt_at ASC, d.id ASC\s+LIMIT \?\s+FOR UPDATE OF d SKIP LOCKED`).
+			WithArgs(now, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(4)).AddRow(int64(5)))
+		mock.ExpectExec(`UPDATE webhook_deliveries SET next_attempt_at = \? WHERE id IN \(\?, \?\)`).
+			WithArgs(now.Add(lease), int64(4), int64(5)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		columns := append(strings.Split(deliveryColumns, ", "), "event_type", "transaction_id", "payload", "event_created_at", "url", "secret")
+		rows := sqlmock.NewRows(columns)
+		for _, id := range []int64{4, 5} {
+			deliveryRow(rows, id, models.DeliveryPending,
+				models.EventTransactionCreated, "txn-1", []byte(`{"transaction":{}}`), eventAt, "https://example.com/hook", "whsec_1")
+		}
+		mock.ExpectQuery(`FROM webhook_deliveries d\s+JOIN outbox_events e ON e.id = d.event_id\s+JOIN webhook_endpoints w ON w.id = d.endpoint_id\s+WHERE d.id IN \(\?, \?\)`).
+			WithArgs(int64(4), int64(5)).
+			WillReturnRows(rows)
+		mock.ExpectCommit()
+
+		claimed, err := mockDB.ClaimWebhookDeliveries(context.Background(), now, lease, 10)
+		require.NoError(t, err)
+		require.Len(t, claimed, 2)
+		assert.Equal(t, int64(4), claimed[0].Delivery.ID)
+		assert.Equal(t, models.OutboxEvent{
+			ID:            7,
+			Type:          models.EventTransactionCreated,
+			TransactionID: "txn-1",
+			Data:          []byte(`{"transaction":{}}`),
+			CreatedAt:     eventAt,
+		}, claimed[0].Event)
+		assert.Equal(t, "https://example.com/hook", claimed[0].URL)
+		assert.Equal(t, "whsec_1", claimed[0].Secret)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("nothing due", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("FOR UPDATE OF d SKIP LOCKED").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectRollback()
+
+		claimed, err := mockDB.ClaimWebhookDeliveries(context.Background(), now, lease, 10)
+		require.NoError(t, err)
+		assert.Empty(t, claimed)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRecordDeliveryAttempt(t *testing.T) {
+	attemptedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	query := `UPDATE webhook_deliveries\s+SET status = \?, attempts = attempts \+ 1, next_attempt_at = \?, last_response_code = \?, last_error = \?, delivered_at = \?\s+WHERE id = \?`
+
+	t.Run("delivered", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectExec(query).
+			WithArgs(models.DeliveryDelivered, attemptedAt, int64(204), nil, attemptedAt, int64(4)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err = mockDB.RecordDeliveryAttempt(context.Background(), 4, models.DeliveryAttempt{
+			Status:       models.DeliveryDelivered,
+			ResponseCode: 204,
+			AttemptedAt:  attemptedAt,
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("retry truncates the error", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+		next := attemptedAt.Add(time.Minute)
+
+		mock.ExpectExec(query).
+			WithArgs(models.DeliveryPending, next, nil, strings.Repeat("x", models.MaxDeliveryErrorLength), nil, int64(4)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err = mockDB.RecordDeliveryAttempt(context.Background(), 4, models.DeliveryAttempt{
+			Status:        models.DeliveryPending,
+			Error:         strings.Repeat("x", 2000),
+			NextAttemptAt: next,
+			AttemptedAt:   attemptedAt,
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("deleted delivery", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mockDB := &DBImpl{DB: db}
+
+		mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err = mockDB.RecordDeliveryAttempt(context.Background(), 4, models.DeliveryAttempt{Status: models.DeliveryDead, AttemptedAt: attemptedAt})
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRedeliverWebhookDelivery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mockDB := &DBImpl{DB: db}
+	query := `UPDATE webhook_deliveries\s+SET status = 'pending', attempts = 0, next_attempt_at = \?\s+WHERE id = \? AND endpoint_id = \?`
+
+	mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), int64(4), "wh-1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(query).WithArgs(sqlmock.AnyArg(), int64(4), "wh-2").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, mockDB.RedeliverWebhookDelivery(context.Background(), "wh-1", 4))
+	assert.ErrorIs(t, mockDB.RedeliverWebhookDelivery(context.Background(), "wh-2", 4), ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,89 @@
+// Package fx converts amounts between currencies for cross-currency transfers.
+// A Provider supplies exchange rates; a Converter applies them and issues quotes that lock a rate until they expire.
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/currency"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrRateUnavailable is returned when a provider has no rate for a currency pair.
+var ErrRateUnavailable = errors.New("exchange rate unavailable")
+
+// Provider supplies exchange rates.
+type Provider interface {
+	// Name identifies the provider in issued quotes
+	Name() string
+	// Rate returns the number of units of target currency one unit of source currency buys.
+	// Currency codes are upper case.
+	Rate(ctx context.Context, source, target string) (models.Rate, error)
+}
+
+// Converter converts amounts at the rates of its provider and issues quotes that lock a rate for QuoteTTL.
+type Converter struct {
+	Provider Provider
+	QuoteTTL time.Duration
+	// now returns the current time; it is replaced in tests
+	now func() time.Time
+}
+
+// NewConverter creates a Converter whose quotes are valid for quoteTTL.
+func NewConverter(provider Provider, quoteTTL time.Duration) *Converter {
+	return &Converter{Provider: provider, QuoteTTL: quoteTTL, now: time.Now}
+}
+
+// Convert converts an amount in the source currency at the provider's current rate. The result is rounded
+// half away from zero to the minor units of the target currency.
+// It returns an error wrapping ErrRateUnavailable if the provider has no rate for the pair.
+func (c *Converter) Convert(ctx context.Context, amount models.Amount, source, target string) (*models.Conversion, error) {
+	source, target = strings.ToUpper(source), strings.ToUpper(target)
+	exponent, ok := currency.Exponent(target)
+	if !ok {
+		return nil, fmt.Errorf("unknown currency %s", target)
+	}
+
+	rate, err := c.Provider.Rate(ctx, source, target)
+	if err != nil {
+		return nil, fmt.Errorf("%s rate for %s/%s: %w", c.Provider.Name(), source, target, err)
+	}
+	targetAmount, err := rate.Convert(amount, exponent)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Conversion{TargetCurrency: target, TargetAmount: targetAmount, Rate: rate}, nil
+}
+
+// Quote converts an amount like Convert and locks the result in a quote that expires after QuoteTTL.
+// The quote is not stored; that is up to the caller.
+func (c *Converter) Quote(ctx context.Context, amount models.Amount, source, target string) (models.Quote, error) {
+	conversion, err := c.Convert(ctx, amount, source, target)
+	if err != nil {
+		return models.Quote{}, err
+	}
+
+	// Stores keep microseconds, so the expiry the client is told is the one that is enforced
+	createdAt := c.now().UTC().Truncate(time.Microsecond)
+	return models.Quote{
+		ID:             uuid.New().String(),
+		SourceCurrency: strings.ToUpper(source),
+		SourceAmount:   amount,
+		TargetCurrency: conversion.TargetCurrency,
+		TargetAmount:   conversion.TargetAmount,
+		Rate:           conversion.Rate,
+		Provider:       c.Provider.Name(),
+		CreatedAt:      createdAt,
+		ExpiresAt:      createdAt.Add(c.QuoteTTL),
+	}, nil
+}
+
+// Expired reports whether a quote can no longer be used.
+func (c *Converter) Expired(quote models.Quote) bool {
+	return !c.now().Before(quote.ExpiresAt)
+}
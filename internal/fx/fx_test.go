@@ -0,0 +1,84 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingProvider is a Provider that always fails.
+type failingProvider struct{}
+
+func (failingProvider) Name() string { return "failing" }
+
+func (failingProvider) Rate(ctx context.Context, source, target string) (models.Rate, error) {
+	return models.Rate{}, errors.New("service unavailable")
+}
+
+func TestConverter_Convert(t *testing.T) {
+	converter := NewConverter(NewStaticRates(map[string]models.Rate{
+		"USD/KES": models.MustParseRate("129.155"),
+		"EUR/JPY": models.MustParseRate("162.4567"),
+	}), time.Minute)
+
+	tests := []struct {
+		name     string
+		amount   string
+		source   string
+		target   string
+		want     string
+		wantRate string
+	}{
+		{"rounds to the target's minor units", "10.01", "USD", "KES", "1292.84", "129.155"},
+		{"rounds half away from zero", "1.00", "usd", "kes", "129.16", "129.155"},
+		{"currencies without minor units", "10.00", "EUR", "JPY", "1625", "162.4567"},
+		{"inverse pairs", "1000", "JPY", "EUR", "6.16", "0.0061554864"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conversion, err := converter.Convert(context.Background(), models.MustParseAmount(tt.amount), tt.source, tt.target)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, conversion.TargetAmount.String())
+			assert.Equal(t, tt.wantRate, conversion.Rate.String())
+			assert.Equal(t, models.MustParseAmount(tt.want), conversion.TargetAmount)
+		})
+	}
+
+	t.Run("unknown pair", func(t *testing.T) {
+		_, err := converter.Convert(context.Background(), models.MustParseAmount("1.00"), "USD", "GBP")
+		assert.ErrorIs(t, err, ErrRateUnavailable)
+	})
+
+	t.Run("provider failure", func(t *testing.T) {
+		_, err := NewConverter(failingProvider{}, time.Minute).Convert(context.Background(), models.MustParseAmount("1.00"), "USD", "KES")
+		assert.ErrorContains(t, err, "service unavailable")
+	})
+}
+
+func TestConverter_Quote(t *testing.T) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 123456789, time.UTC)
+	converter := NewConverter(NewStaticRates(map[string]models.Rate{"USD/KES": models.MustParseRate("129.15")}), 30*time.Second)
+	converter.now = func() time.Time { return now }
+
+	quote, err := converter.Quote(context.Background(), models.MustParseAmount("10.00"), "usd", "kes")
+	require.NoError(t, err)
+	assert.NotEmpty(t, quote.ID)
+	assert.Equal(t, "USD", quote.SourceCurrency)
+	assert.Equal(t, "10.00", quote.SourceAmount.String())
+	assert.Equal(t, "KES", quote.TargetCurrency)
+	assert.Equal(t, "1291.50", quote.TargetAmount.String())
+	assert.Equal(t, "129.15", quote.Rate.String())
+	assert.Equal(t, "static", quote.Provider)
+	assert.Equal(t, now.Truncate(time.Microsecond), quote.CreatedAt)
+	assert.Equal(t, quote.CreatedAt.Add(30*time.Second), quote.ExpiresAt)
+
+	assert.False(t, converter.Expired(quote))
+	now = quote.ExpiresAt
+	assert.True(t, converter.Expired(quote))
+}
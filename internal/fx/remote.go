@@ -0,0 +1,82 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// maxRemoteResponse is the largest response read from a remote rate source.
+const maxRemoteResponse = 64 << 10
+
+// remoteResponse is the body a remote rate source answers with.
+type remoteResponse struct {
+	Rate models.Rate `json:"rate"`
+}
+
+// Remote fetches exchange rates from an external HTTP service.
+// The service is asked with GET URL?source=USD&target=KES and answers 200 with a body such as
+// {"rate": "129.15"}, or 404 if it has no rate for the pair. Calls are bounded by the client's timeout.
+type Remote struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewRemote creates a Remote rate source with a 2 second timeout.
+func NewRemote(url string) *Remote {
+	return &Remote{URL: url, Client: &http.Client{Timeout: 2 * time.Second}}
+}
+
+// Name identifies the remote source in issued quotes.
+func (r *Remote) Name() string {
+	return "remote"
+}
+
+// Rate asks the service for the rate of the pair.
+func (r *Remote) Rate(ctx context.Context, source, target string) (models.Rate, error) {
+	endpoint, err := url.Parse(r.URL)
+	if err != nil {
+		return models.Rate{}, err
+	}
+	query := endpoint.Query()
+	query.Set("source", source)
+	query.Set("target", target)
+	endpoint.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return models.Rate{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return models.Rate{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxRemoteResponse))
+		return models.Rate{}, ErrRateUnavailable
+	default:
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxRemoteResponse))
+		return models.Rate{}, fmt.Errorf("rate service responded with %s", resp.Status)
+	}
+
+	var response remoteResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxRemoteResponse)).Decode(&response); err != nil {
+		return models.Rate{}, fmt.Errorf("invalid rate response: %w", err)
+	}
+	if response.Rate.IsZero() {
+		return models.Rate{}, fmt.Errorf("invalid rate response: %w", models.ErrInvalidRate)
+	}
+	return response.Rate, nil
+}
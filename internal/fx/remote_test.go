@@ -0,0 +1,64 @@
+package fx
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemote_Rate(t *testing.T) {
+	t.Run("returns the service's rate", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodGet, r.Method)
+			assert.Equal(t, "live", r.URL.Query().Get("feed"))
+			assert.Equal(t, "USD", r.URL.Query().Get("source"))
+			assert.Equal(t, "KES", r.URL.Query().Get("target"))
+			w.Write([]byte(`{"rate":"129.1500"}`))
+		}))
+		defer server.Close()
+
+		rate, err := NewRemote(server.URL+"/rates?feed=live").Rate(context.Background(), "USD", "KES")
+		require.NoError(t, err)
+		assert.Equal(t, "129.1500", rate.String())
+	})
+
+	t.Run("unknown pair", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		_, err := NewRemote(server.URL).Rate(context.Background(), "USD", "KES")
+		assert.ErrorIs(t, err, ErrRateUnavailable)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			handler http.HandlerFunc
+		}{
+			{"server error", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadGateway) }},
+			{"malformed body", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`not json`)) }},
+			{"missing rate", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{}`)) }},
+			{"negative rate", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{"rate":"-1.5"}`)) }},
+			{"timeout", func(w http.ResponseWriter, r *http.Request) { time.Sleep(200 * time.Millisecond) }},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				server := httptest.NewServer(tt.handler)
+				defer server.Close()
+
+				remote := NewRemote(server.URL)
+				remote.Client.Timeout = 50 * time.Millisecond
+				_, err := remote.Rate(context.Background(), "USD", "KES")
+				assert.Error(t, err)
+			})
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package fx
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/abadojack/gapstack/internal/currency"
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// StaticRates serves fixed exchange rates, such as those loaded from a rates file.
+// A pair without a rate of its own is served the inverse of the opposite pair.
+type StaticRates struct {
+	rates map[string]models.Rate
+}
+
+// NewStaticRates creates StaticRates from rates keyed by pair, such as "USD/KES".
+func NewStaticRates(rates map[string]models.Rate) *StaticRates {
+	s := &StaticRates{rates: make(map[string]models.Rate, len(rates))}
+	for pair, rate := range rates {
+		s.rates[strings.ToUpper(pair)] = rate
+	}
+	return s
+}
+
+// LoadStaticRates reads StaticRates from a file with one rate per line, such as "USD/KES = 129.15".
+// Blank lines and lines starting with # are ignored.
+func LoadStaticRates(path string) (*StaticRates, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rates file: %w", err)
+	}
+	defer file.Close()
+
+	rates := make(map[string]models.Rate)
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		pair, value, ok := strings.Cut(line, "=")
+		pair, value = strings.TrimSpace(pair), strings.TrimSpace(value)
+		source, target, isPair := strings.Cut(pair, "/")
+		if !ok || !isPair || !isCurrencyCode(source) || !isCurrencyCode(target) {
+			return nil, fmt.Errorf("rates file %s line %d: expected a line such as USD/KES = 129.15", path, number)
+		}
+		rate, err := models.ParseRate(value)
+		if err != nil {
+			return nil, fmt.Errorf("rates file %s line %d: %w", path, number, err)
+		}
+		rates[strings.ToUpper(pair)] = rate
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rates file %s: %w", path, err)
+	}
+
+	return NewStaticRates(rates), nil
+}
+
+// Len returns the number of pairs with a rate of their own.
+func (s *StaticRates) Len() int {
+	return len(s.rates)
+}
+
+// Name identifies static rates in issued quotes.
+func (s *StaticRates) Name() string {
+	return "static"
+}
+
+// Rate returns the rate of the pair, or the inverse of the opposite pair's rate.
+func (s *StaticRates) Rate(ctx context.Context, source, target string) (models.Rate, error) {
+	if rate, ok := s.rates[source+"/"+target]; ok {
+		return rate, nil
+	}
+	if rate, ok := s.rates[target+"/"+source]; ok {
+		return rate.Inverse()
+	}
+	return models.Rate{}, ErrRateUnavailable
+}
+
+// isCurrencyCode reports whether s is the code of a usable ISO 4217 currency, in either case.
+func isCurrencyCode(s string) bool {
+	c, ok := currency.Lookup(strings.ToUpper(s))
+	return ok && c.Usable()
+}
@@ -0,0 +1,61 @@
+package fx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadStaticRates(t *testing.T) {
+	t.Run("valid file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "rates.txt")
+		require.NoError(t, os.WriteFile(path, []byte("# Rates for 2025-10-01\n\nUSD/KES = 129.15\n eur/usd=1.0845 \n"), 0o600))
+
+		rates, err := LoadStaticRates(path)
+		require.NoError(t, err)
+		assert.Equal(t, 2, rates.Len())
+
+		rate, err := rates.Rate(context.Background(), "EUR", "USD")
+		require.NoError(t, err)
+		assert.Equal(t, "1.0845", rate.String())
+	})
+
+	t.Run("invalid lines", func(t *testing.T) {
+		for _, content := range []string{"USD KES 129.15", "USD/XYZ = 1", "USD/KES = -1", "USD/KES = 1e3"} {
+			path := filepath.Join(t.TempDir(), "rates.txt")
+			require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+			_, err := LoadStaticRates(path)
+			assert.ErrorContains(t, err, "line 1", content)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := LoadStaticRates(filepath.Join(t.TempDir(), "missing.txt"))
+		assert.Error(t, err)
+	})
+}
+
+func TestStaticRates_Rate(t *testing.T) {
+	rates := NewStaticRates(map[string]models.Rate{
+		"USD/KES": models.MustParseRate("129"),
+		"KES/USD": models.MustParseRate("0.0077"),
+		"GBP/USD": models.MustParseRate("1.25"),
+	})
+
+	rate, err := rates.Rate(context.Background(), "KES", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "0.0077", rate.String(), "a pair's own rate wins over the inverse")
+
+	rate, err = rates.Rate(context.Background(), "USD", "GBP")
+	require.NoError(t, err)
+	assert.Equal(t, "0.8", rate.String())
+
+	_, err = rates.Rate(context.Background(), "USD", "EUR")
+	assert.ErrorIs(t, err, ErrRateUnavailable)
+}
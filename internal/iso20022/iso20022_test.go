@@ -0,0 +1,53 @@
+package iso20022
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertValid validates a message against a schema in testdata with xmllint. The test is skipped if xmllint
+// is not installed, unless ISO20022_TEST_REQUIRE_XMLLINT is set, as it should be in CI.
+func assertValid(t *testing.T, schema string, message []byte) {
+	t.Helper()
+	xmllint, err := exec.LookPath("xmllint")
+	if err != nil {
+		if os.Getenv("ISO20022_TEST_REQUIRE_XMLLINT") != "" {
+			t.Fatal("xmllint is needed to validate messages against their schema")
+		}
+		t.Skip("xmllint is needed to validate messages against their schema")
+	}
+
+	path := filepath.Join(t.TempDir(), "message.xml")
+	require.NoError(t, os.WriteFile(path, message, 0o600))
+	output, err := exec.Command(xmllint, "--noout", "--schema", filepath.Join("testdata", schema), path).CombinedOutput()
+	assert.NoError(t, err, "%s\n%s", output, message)
+}
+
+func TestIsBIC(t *testing.T) {
+	for _, bic := range []string{"DEUTDEFF", "DEUTDEFF500", "COBADEFFXXX"} {
+		assert.True(t, IsBIC(bic), bic)
+	}
+	for _, bic := range []string{"", "DEUTDEF", "DEUTDEFF5", "deutdeff", "DEUT1EFF"} {
+		assert.False(t, IsBIC(bic), bic)
+	}
+}
+
+func TestIsIBAN(t *testing.T) {
+	for _, iban := range []string{"DE89370400440532013000", "GB82WEST12345698765432"} {
+		assert.True(t, isIBAN(iban), iban)
+	}
+	for _, iban := range []string{"DE88370400440532013000", "GB82 WEST 1234 5698 7654 32", "payroll", "AB12"} {
+		assert.False(t, isIBAN(iban), iban)
+	}
+}
+
+func TestSampleMessagesAreValid(t *testing.T) {
+	message, err := os.ReadFile(filepath.Join("testdata", "pain.001.xml"))
+	require.NoError(t, err)
+	assertValid(t, "pain.001.001.09.xsd", message)
+}
@@ -0,0 +1,126 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// ErrNotCompleted is returned when a transaction that has not completed is written as a pacs.008.
+var ErrNotCompleted = errors.New("transaction is not completed")
+
+const (
+	// settlementMethod settles transfers on the books of the instructed agent, which is the service itself
+	settlementMethod = "INDA"
+	// chargeBearer shares charges between debtor and creditor; the service charges neither
+	chargeBearer = "SHAR"
+	// maxRateDigits is the largest number of digits ISO 20022 allows in an exchange rate
+	maxRateDigits = 11
+)
+
+// pacs008Document is a pacs.008 FI to FI customer credit transfer, in version 8.
+type pacs008Document struct {
+	XMLName  xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:pacs.008.001.08 Document"`
+	Transfer struct {
+		GroupHeader struct {
+			MessageID            string `xml:"MsgId"`
+			CreatedAt            string `xml:"CreDtTm"`
+			NumberOfTransactions int    `xml:"NbOfTxs"`
+			Settlement           struct {
+				Method string `xml:"SttlmMtd"`
+			} `xml:"SttlmInf"`
+		} `xml:"GrpHdr"`
+		Transactions []pacs008Transaction `xml:"CdtTrfTxInf"`
+	} `xml:"FIToFICstmrCdtTrf"`
+}
+
+// pacs008Transaction is a credit transfer of a pacs.008, with its elements in schema order.
+type pacs008Transaction struct {
+	PaymentID struct {
+		EndToEndID    string `xml:"EndToEndId"`
+		TransactionID string `xml:"TxId,omitempty"`
+		UETR          string `xml:"UETR,omitempty"`
+	} `xml:"PmtId"`
+	SettlementAmount amount   `xml:"IntrBkSttlmAmt"`
+	InstructedAmount *amount  `xml:"InstdAmt,omitempty"`
+	ExchangeRate     string   `xml:"XchgRate,omitempty"`
+	ChargeBearer     string   `xml:"ChrgBr"`
+	Debtor           party    `xml:"Dbtr"`
+	DebtorAccount    *account `xml:"DbtrAcct,omitempty"`
+	DebtorAgent      agent    `xml:"DbtrAgt"`
+	CreditorAgent    agent    `xml:"CdtrAgt"`
+	Creditor         party    `xml:"Cdtr"`
+	CreditorAccount  *account `xml:"CdtrAcct,omitempty"`
+}
+
+// WritePacs008 writes a completed transaction as a pacs.008 FI to FI customer credit transfer, with the
+// service as both debtor and creditor agent. A converted transaction settles the amount credited to the
+// receiver, and carries the amount the sender paid as the instructed amount together with the rate.
+// The transaction ID is written as the UETR, and the end-to-end identifier, which is not kept, as NOTPROVIDED.
+// It returns ErrNotCompleted for a transaction in any other status.
+func WritePacs008(w io.Writer, agent Agent, transaction models.Transaction, now time.Time) error {
+	if transaction.Status != models.StatusCompleted {
+		return fmt.Errorf("%w: transaction %s is %s", ErrNotCompleted, transaction.ID, transaction.Status)
+	}
+
+	var document pacs008Document
+	header := &document.Transfer.GroupHeader
+	header.MessageID = newMessageID()
+	header.CreatedAt = now.UTC().Format(time.RFC3339)
+	header.NumberOfTransactions = 1
+	header.Settlement.Method = settlementMethod
+
+	element := pacs008Transaction{
+		SettlementAmount: amount{Currency: transaction.Currency, Value: transaction.Amount.String()},
+		ChargeBearer:     chargeBearer,
+		Debtor:           partyFor(transaction.Sender),
+		DebtorAccount:    accountFor(transaction.Sender),
+		DebtorAgent:      agent.element(),
+		CreditorAgent:    agent.element(),
+		Creditor:         partyFor(transaction.Receiver),
+		CreditorAccount:  accountFor(transaction.Receiver),
+	}
+	element.PaymentID.EndToEndID = notProvided
+	if uetrPattern.MatchString(transaction.ID) {
+		element.PaymentID.UETR = transaction.ID
+	} else if len(transaction.ID) <= maxIDLength {
+		element.PaymentID.TransactionID = transaction.ID
+	}
+	if conversion := transaction.Conversion; conversion != nil {
+		instructed := element.SettlementAmount
+		element.InstructedAmount = &instructed
+		element.SettlementAmount = amount{Currency: conversion.TargetCurrency, Value: conversion.TargetAmount.String()}
+		// A rate too precise to be written is left out; the two amounts still state the conversion exactly
+		if rate := conversion.Rate.String(); countDigits(rate) <= maxRateDigits {
+			element.ExchangeRate = rate
+		}
+	}
+	document.Transfer.Transactions = []pacs008Transaction{element}
+
+	return writeDocument(w, document)
+}
+
+// countDigits counts the significant digits of a plain decimal string, as the XML Schema totalDigits facet does.
+func countDigits(s string) int {
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	return len(strings.TrimLeft(intPart, "0")) + len(strings.TrimRight(fracPart, "0"))
+}
+
+// writeDocument writes a message as an indented XML document.
+func writeDocument(w io.Writer, document interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
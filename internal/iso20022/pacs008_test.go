@@ -0,0 +1,89 @@
+package iso20022
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePacs008(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	completed := models.Transaction{
+		ID:       "8a562c67-ca16-48ba-b074-65581be6f011",
+		Amount:   models.MustParseAmount("1000.50"),
+		Currency: "EUR",
+		Sender:   "DE89370400440532013000",
+		Receiver: "bob",
+		Status:   models.StatusCompleted,
+	}
+
+	t.Run("completed transaction", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WritePacs008(&buf, Agent{BIC: "GAPSKENA"}, completed, now))
+		assertValid(t, "pacs.008.001.08.xsd", buf.Bytes())
+
+		var document pacs008Document
+		require.NoError(t, xml.Unmarshal(buf.Bytes(), &document))
+		assert.Equal(t, "2026-10-16T09:00:00Z", document.Transfer.GroupHeader.CreatedAt)
+		assert.Equal(t, 1, document.Transfer.GroupHeader.NumberOfTransactions)
+		require.Len(t, document.Transfer.Transactions, 1)
+		element := document.Transfer.Transactions[0]
+		assert.Equal(t, "NOTPROVIDED", element.PaymentID.EndToEndID)
+		assert.Equal(t, completed.ID, element.PaymentID.UETR)
+		assert.Equal(t, amount{Currency: "EUR", Value: "1000.50"}, element.SettlementAmount)
+		assert.Nil(t, element.InstructedAmount)
+		assert.Equal(t, "GAPSKENA", element.DebtorAgent.Institution.BIC)
+		assert.Equal(t, "DE89370400440532013000", element.DebtorAccount.ID.IBAN)
+		assert.Equal(t, "bob", element.CreditorAccount.ID.Other.ID)
+		assert.Equal(t, "bob", element.Creditor.Name)
+	})
+
+	t.Run("converted transaction", func(t *testing.T) {
+		converted := completed
+		converted.ID = "legacy-42"
+		converted.Receiver = strings.Repeat("r", 35)
+		converted.Conversion = &models.Conversion{
+			TargetCurrency: "KES",
+			TargetAmount:   models.MustParseAmount("151575.75"),
+			Rate:           models.MustParseRate("151.5"),
+		}
+
+		var buf bytes.Buffer
+		require.NoError(t, WritePacs008(&buf, Agent{}, converted, now))
+		assertValid(t, "pacs.008.001.08.xsd", buf.Bytes())
+
+		var document pacs008Document
+		require.NoError(t, xml.Unmarshal(buf.Bytes(), &document))
+		element := document.Transfer.Transactions[0]
+		assert.Equal(t, "legacy-42", element.PaymentID.TransactionID)
+		assert.Empty(t, element.PaymentID.UETR)
+		assert.Equal(t, amount{Currency: "KES", Value: "151575.75"}, element.SettlementAmount)
+		assert.Equal(t, &amount{Currency: "EUR", Value: "1000.50"}, element.InstructedAmount)
+		assert.Equal(t, "151.5", element.ExchangeRate)
+		assert.Equal(t, "NOTPROVIDED", element.CreditorAgent.Institution.Other.ID)
+		assert.Nil(t, element.CreditorAccount, "an identifier too long for Othr is left out")
+	})
+
+	t.Run("transaction not completed", func(t *testing.T) {
+		pending := completed
+		pending.Status = models.StatusPending
+
+		var buf bytes.Buffer
+		err := WritePacs008(&buf, Agent{}, pending, now)
+		assert.True(t, errors.Is(err, ErrNotCompleted))
+		assert.Zero(t, buf.Len())
+	})
+}
+
+func TestCountDigits(t *testing.T) {
+	assert.Equal(t, 3, countDigits("129"))
+	assert.Equal(t, 4, countDigits("0.0077"))
+	assert.Equal(t, 4, countDigits("151.5000"))
+}
@@ -0,0 +1,226 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// pain001Namespace is the namespace of every version of pain.001, followed by the two-digit version number.
+const pain001Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001."
+
+// The versions of pain.001 accepted. The elements read here are the same in all of them, except that UETR was
+// added in version 9; versions 1 and 2 predate the 2009 restructuring of the message, and later versions are
+// refused until they have been checked against their schemas.
+const (
+	pain001MinVersion = 3
+	pain001MaxVersion = 12
+)
+
+// Initiation is a pain.001 customer credit transfer initiation.
+type Initiation struct {
+	// MessageName is the message and its version, such as pain.001.001.09
+	MessageName string
+	// MessageID is the initiator's identifier of the message
+	MessageID string
+	// CreatedAt is the creation time of the message as written in it
+	CreatedAt string
+	// Payments are the payment instructions of the message, each from one debtor account
+	Payments []Payment
+}
+
+// Payment is a payment instruction: credit transfers from one debtor account.
+type Payment struct {
+	// ID is the initiator's identifier of the payment instruction
+	ID string
+	// Transfers are the credit transfers of the instruction
+	Transfers []Transfer
+}
+
+// Transfer is a credit transfer of a payment instruction.
+type Transfer struct {
+	// InstructionID is the identifier of the transfer between the initiator and the service, if any
+	InstructionID string
+	// EndToEndID is the initiator's identifier of the transfer for the whole of its journey
+	EndToEndID string
+	// UETR is the unique end-to-end transaction reference of the transfer, if any
+	UETR string
+	// Transaction holds the amount, currency, sender and receiver of the transfer. It has not been validated.
+	Transaction models.Transaction
+	// TargetCurrency is the currency of the creditor's account, if the message gives one
+	TargetCurrency string
+}
+
+// Len returns the number of transfers in the message.
+func (i *Initiation) Len() int {
+	n := 0
+	for _, payment := range i.Payments {
+		n += len(payment.Transfers)
+	}
+	return n
+}
+
+// pain001Document is the subset of a pain.001 message that is read.
+type pain001Document struct {
+	XMLName    xml.Name
+	Initiation struct {
+		GroupHeader struct {
+			MessageID            string `xml:"MsgId"`
+			CreatedAt            string `xml:"CreDtTm"`
+			NumberOfTransactions string `xml:"NbOfTxs"`
+			ControlSum           string `xml:"CtrlSum"`
+		} `xml:"GrpHdr"`
+		Payments []struct {
+			ID            string  `xml:"PmtInfId"`
+			Method        string  `xml:"PmtMtd"`
+			DebtorAccount account `xml:"DbtrAcct"`
+			Transfers     []struct {
+				PaymentID struct {
+					InstructionID string `xml:"InstrId"`
+					EndToEndID    string `xml:"EndToEndId"`
+					UETR          string `xml:"UETR"`
+				} `xml:"PmtId"`
+				Amount struct {
+					Instructed *amount `xml:"InstdAmt"`
+				} `xml:"Amt"`
+				CreditorAccount *account `xml:"CdtrAcct"`
+			} `xml:"CdtTrfTxInf"`
+		} `xml:"PmtInf"`
+	} `xml:"CstmrCdtTrfInitn"`
+}
+
+// ParsePain001 reads a pain.001 customer credit transfer initiation. The sender of each transfer is its
+// payment instruction's debtor account and the receiver its creditor account, by IBAN or other identifier.
+// It returns an error wrapping ErrInvalidMessage if the message is not a pain.001 of version 03 to 12, is not
+// a credit transfer, has a number of transactions or control sum that does not add up, repeats a UETR, or has
+// an amount that is not a decimal number in a currency. The transfers are otherwise left for the caller to
+// validate.
+func ParsePain001(r io.Reader) (*Initiation, error) {
+	var document pain001Document
+	if err := xml.NewDecoder(r).Decode(&document); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+	}
+	version, ok := strings.CutPrefix(document.XMLName.Space, pain001Namespace)
+	if document.XMLName.Local != "Document" || !ok {
+		return nil, fmt.Errorf("%w: not a pain.001 message", ErrInvalidMessage)
+	}
+	if n, err := strconv.Atoi(version); err != nil || fmt.Sprintf("%02d", n) != version {
+		return nil, fmt.Errorf("%w: pain.001 version %q is not a two-digit number", ErrInvalidMessage, version)
+	} else if n < pain001MinVersion || n > pain001MaxVersion {
+		return nil, fmt.Errorf("%w: pain.001.001.%s is not supported; versions %02d to %02d are", ErrInvalidMessage, version, pain001MinVersion, pain001MaxVersion)
+	}
+
+	header := document.Initiation.GroupHeader
+	initiation := &Initiation{
+		MessageName: "pain.001.001." + version,
+		MessageID:   strings.TrimSpace(header.MessageID),
+		CreatedAt:   strings.TrimSpace(header.CreatedAt),
+	}
+	if err := checkID("MsgId", initiation.MessageID, true); err != nil {
+		return nil, err
+	}
+	if !isDateTime(initiation.CreatedAt) {
+		return nil, fmt.Errorf("%w: CreDtTm %q is not a date and time", ErrInvalidMessage, initiation.CreatedAt)
+	}
+
+	sum := models.NewAmount(0, 0)
+	// uetrs holds the UETRs seen so far, as each identifies a single transfer
+	uetrs := make(map[string]bool)
+	for _, payment := range document.Initiation.Payments {
+		if method := strings.TrimSpace(payment.Method); method != "TRF" {
+			return nil, fmt.Errorf("%w: payment %s: only credit transfers (TRF) are supported, not %q", ErrInvalidMessage, payment.ID, method)
+		}
+
+		parsed := Payment{ID: strings.TrimSpace(payment.ID)}
+		if err := checkID("PmtInfId", parsed.ID, true); err != nil {
+			return nil, err
+		}
+		for _, transfer := range payment.Transfers {
+			endToEndID := strings.TrimSpace(transfer.PaymentID.EndToEndID)
+			instructionID := strings.TrimSpace(transfer.PaymentID.InstructionID)
+			if err := checkID("EndToEndId", endToEndID, true); err != nil {
+				return nil, err
+			}
+			if err := checkID("InstrId", instructionID, false); err != nil {
+				return nil, err
+			}
+			uetr := strings.TrimSpace(transfer.PaymentID.UETR)
+			if uetr != "" && !uetrPattern.MatchString(uetr) {
+				return nil, fmt.Errorf("%w: transfer %s: UETR %q is not a version 4 UUID", ErrInvalidMessage, endToEndID, uetr)
+			}
+			if uetr != "" && uetrs[uetr] {
+				return nil, fmt.Errorf("%w: transfer %s: UETR %s appears more than once", ErrInvalidMessage, endToEndID, uetr)
+			}
+			uetrs[uetr] = true
+			instructed := transfer.Amount.Instructed
+			if instructed == nil {
+				return nil, fmt.Errorf("%w: transfer %s: only instructed amounts (InstdAmt) are supported", ErrInvalidMessage, endToEndID)
+			}
+			value, err := models.ParseAmount(strings.TrimSpace(instructed.Value))
+			if err != nil || value.Sign() < 0 {
+				return nil, fmt.Errorf("%w: transfer %s: InstdAmt %q is not an amount", ErrInvalidMessage, endToEndID, instructed.Value)
+			}
+			if sum, err = sum.Add(value); err != nil {
+				return nil, fmt.Errorf("%w: the amounts add up to more than can be represented", ErrInvalidMessage)
+			}
+
+			var targetCurrency string
+			if transfer.CreditorAccount != nil {
+				targetCurrency = strings.TrimSpace(transfer.CreditorAccount.Currency)
+			}
+			parsed.Transfers = append(parsed.Transfers, Transfer{
+				InstructionID: instructionID,
+				EndToEndID:    endToEndID,
+				UETR:          uetr,
+				Transaction: models.Transaction{
+					Amount:   value,
+					Currency: strings.TrimSpace(instructed.Currency),
+					Sender:   payment.DebtorAccount.identifier(),
+					Receiver: transfer.CreditorAccount.identifier(),
+				},
+				TargetCurrency: targetCurrency,
+			})
+		}
+		initiation.Payments = append(initiation.Payments, parsed)
+	}
+
+	// The header totals guard against a message that was cut short or altered on the way
+	if count, err := strconv.Atoi(strings.TrimSpace(header.NumberOfTransactions)); err != nil || count != initiation.Len() {
+		return nil, fmt.Errorf("%w: NbOfTxs is %q but the message has %d transactions", ErrInvalidMessage, header.NumberOfTransactions, initiation.Len())
+	}
+	if value := strings.TrimSpace(header.ControlSum); value != "" {
+		controlSum, err := models.ParseAmount(value)
+		if err != nil || controlSum.Cmp(sum) != 0 {
+			return nil, fmt.Errorf("%w: CtrlSum is %q but the amounts add up to %s", ErrInvalidMessage, value, sum)
+		}
+	}
+	return initiation, nil
+}
+
+// checkID checks that an identifier fits in maxIDLength characters, as they are echoed in status reports,
+// and that it is given if required.
+func checkID(name, id string, required bool) error {
+	if required && id == "" {
+		return fmt.Errorf("%w: %s is required", ErrInvalidMessage, name)
+	}
+	if utf8.RuneCountInString(id) > maxIDLength {
+		return fmt.Errorf("%w: %s %q is longer than %d characters", ErrInvalidMessage, name, id, maxIDLength)
+	}
+	return nil
+}
+
+// isDateTime reports whether s is an XML Schema date and time, with or without a time zone.
+func isDateTime(s string) bool {
+	for _, layout := range []string{"2006-01-02T15:04:05.999999999Z07:00", "2006-01-02T15:04:05.999999999"} {
+		if _, err := time.Parse(layout, s); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package iso20022
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePain001(t *testing.T) {
+	t.Run("sample message", func(t *testing.T) {
+		file, err := os.Open(filepath.Join("testdata", "pain.001.xml"))
+		require.NoError(t, err)
+		defer file.Close()
+
+		initiation, err := ParsePain001(file)
+		require.NoError(t, err)
+
+		assert.Equal(t, "pain.001.001.09", initiation.MessageName)
+		assert.Equal(t, "PAYROLL-2026-10-16", initiation.MessageID)
+		assert.Equal(t, "2026-10-16T08:30:00+03:00", initiation.CreatedAt)
+		assert.Equal(t, 3, initiation.Len())
+		require.Len(t, initiation.Payments, 2)
+
+		salaries := initiation.Payments[0]
+		assert.Equal(t, "SALARIES", salaries.ID)
+		require.Len(t, salaries.Transfers, 2)
+		assert.Equal(t, Transfer{
+			InstructionID: "SAL-1",
+			EndToEndID:    "E2E-SAL-1",
+			UETR:          "8a562c67-ca16-48ba-b074-65581be6f011",
+			Transaction: models.Transaction{
+				Amount:   models.MustParseAmount("1000.50"),
+				Currency: "EUR",
+				Sender:   "DE89370400440532013000",
+				Receiver: "GB82WEST12345698765432",
+			},
+		}, salaries.Transfers[0])
+		assert.Equal(t, "carol", salaries.Transfers[1].Transaction.Receiver)
+		assert.Equal(t, "KES", salaries.Transfers[1].TargetCurrency, "the creditor account currency asks for a conversion")
+
+		expenses := initiation.Payments[1]
+		require.Len(t, expenses.Transfers, 1)
+		assert.Equal(t, "expenses", expenses.Transfers[0].Transaction.Sender)
+		assert.Equal(t, "dave", expenses.Transfers[0].Transaction.Receiver)
+	})
+
+	t.Run("supported versions", func(t *testing.T) {
+		sample, err := os.ReadFile(filepath.Join("testdata", "pain.001.xml"))
+		require.NoError(t, err)
+
+		for version := pain001MinVersion; version <= pain001MaxVersion; version++ {
+			name := fmt.Sprintf("pain.001.001.%02d", version)
+			initiation, err := ParsePain001(strings.NewReader(strings.Replace(string(sample), "pain.001.001.09", name, 1)))
+			require.NoError(t, err, name)
+			assert.Equal(t, name, initiation.MessageName)
+			assert.Equal(t, 3, initiation.Len(), name)
+		}
+	})
+
+	t.Run("invalid messages", func(t *testing.T) {
+		sample, err := os.ReadFile(filepath.Join("testdata", "pain.001.xml"))
+		require.NoError(t, err)
+
+		tests := []struct {
+			name    string
+			message string
+			detail  string
+		}{
+			{"not XML", "payroll", "EOF"},
+			{"other message", strings.Replace(string(sample), "pain.001.001.09", "pain.008.001.08", 1), "not a pain.001 message"},
+			{"version before 03", strings.Replace(string(sample), "pain.001.001.09", "pain.001.001.02", 1), "pain.001.001.02 is not supported"},
+			{"version after 12", strings.Replace(string(sample), "pain.001.001.09", "pain.001.001.13", 1), "pain.001.001.13 is not supported"},
+			{"version not a number", strings.Replace(string(sample), "pain.001.001.09", "pain.001.001.09x", 1), `version "09x" is not a two-digit number`},
+			{"version not two digits", strings.Replace(string(sample), "pain.001.001.09", "pain.001.001.9", 1), `version "9" is not a two-digit number`},
+			{"version signed", strings.Replace(string(sample), "pain.001.001.09", "pain.001.001.+9", 1), `version "+9" is not a two-digit number`},
+			{"version missing", strings.Replace(string(sample), "pain.001.001.09", "pain.001.001.", 1), `version "" is not a two-digit number`},
+			{"missing message ID", strings.Replace(string(sample), "<MsgId>PAYROLL-2026-10-16</MsgId>", "", 1), "MsgId is required"},
+			{"bad creation time", strings.Replace(string(sample), "2026-10-16T08:30:00+03:00", "yesterday", 1), `CreDtTm "yesterday"`},
+			{"cheques", strings.Replace(string(sample), "<PmtMtd>TRF</PmtMtd>", "<PmtMtd>CHK</PmtMtd>", 1), "only credit transfers (TRF)"},
+			{"long end-to-end ID", strings.Replace(string(sample), "E2E-SAL-2", strings.Repeat("E", 36), 1), "EndToEndId"},
+			{"bad UETR", strings.Replace(string(sample), "8a562c67", "ZZ562c67", 1), "not a version 4 UUID"},
+			{"repeated UETR", strings.Replace(string(sample), "<EndToEndId>E2E-SAL-2</EndToEndId>", "<EndToEndId>E2E-SAL-2</EndToEndId><UETR>8a562c67-ca16-48ba-b074-65581be6f011</UETR>", 1), "appears more than once"},
+			{"bad amount", strings.Replace(string(sample), ">500<", ">5e2<", 1), `InstdAmt "5e2" is not an amount`},
+			{"wrong number of transactions", strings.Replace(string(sample), "<NbOfTxs>3</NbOfTxs>", "<NbOfTxs>4</NbOfTxs>", 1), "NbOfTxs is \"4\" but the message has 3"},
+			{"wrong control sum", strings.Replace(string(sample), "<CtrlSum>1750.50</CtrlSum>", "<CtrlSum>1750</CtrlSum>", 1), "amounts add up to 1750.50"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := ParsePain001(strings.NewReader(tt.message))
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, ErrInvalidMessage))
+				assert.Contains(t, err.Error(), tt.detail)
+			})
+		}
+	})
+}
@@ -0,0 +1,192 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// Status codes of a payment status report, from the ISO 20022 external code sets.
+const (
+	// StatusAccepted reports a message or transfer that passed every check before execution
+	StatusAccepted = "ACCP"
+	// StatusAcceptedSettlementInProcess reports a transfer accepted for execution
+	StatusAcceptedSettlementInProcess = "ACSP"
+	// StatusAcceptedSettlementCompleted reports a transfer that has been settled
+	StatusAcceptedSettlementCompleted = "ACSC"
+	// StatusPending reports a transfer on hold, such as for review
+	StatusPending = "PDNG"
+	// StatusPartiallyAccepted reports a message some of whose transfers were rejected
+	StatusPartiallyAccepted = "PART"
+	// StatusRejected reports a rejected message or transfer
+	StatusRejected = "RJCT"
+)
+
+// Reason codes of a rejection, from the ISO 20022 external code sets.
+const (
+	// ReasonIncorrectAccount rejects a transfer whose debtor or creditor account is missing or invalid
+	ReasonIncorrectAccount = "AC01"
+	// ReasonNotAllowedAmount rejects a transfer over an amount limit
+	ReasonNotAllowedAmount = "AM02"
+	// ReasonNotAllowedCurrency rejects a transfer in a currency that is not accepted
+	ReasonNotAllowedCurrency = "AM03"
+	// ReasonDuplicate rejects a transfer that has already been received
+	ReasonDuplicate = "AM05"
+	// ReasonInvalidAmount rejects a transfer whose amount is invalid
+	ReasonInvalidAmount = "AM12"
+	// ReasonRegulatory rejects a transfer that regulatory checks stopped
+	ReasonRegulatory = "RR04"
+	// ReasonNotSpecified rejects a transfer for a reason of the service's own, such as an internal failure
+	ReasonNotSpecified = "MS03"
+	// ReasonNarrative rejects a transfer for the reason given in the additional information
+	ReasonNarrative = "NARR"
+)
+
+// maxInfoLength is the longest additional information ISO 20022 allows in one element.
+const maxInfoLength = 105
+
+// TransactionStatus returns the status code that reports a transaction in the given status.
+func TransactionStatus(status models.Status) string {
+	switch status {
+	case models.StatusCompleted:
+		return StatusAcceptedSettlementCompleted
+	case models.StatusHeld:
+		return StatusPending
+	case models.StatusFailed, models.StatusReversed:
+		return StatusRejected
+	default:
+		return StatusAcceptedSettlementInProcess
+	}
+}
+
+// StatusReport is a pain.002 customer payment status report on a pain.001 initiation.
+type StatusReport struct {
+	// Initiation is the message reported on
+	Initiation *Initiation
+	// GroupStatus is the status of the message as a whole
+	GroupStatus string
+	// Transfers are the statuses of the transfers reported on, in the order of the initiation
+	Transfers []TransferStatus
+}
+
+// TransferStatus is the status of a transfer of an initiation.
+type TransferStatus struct {
+	// PaymentID is the payment instruction the transfer belongs to
+	PaymentID string
+	// Transfer is the transfer reported on
+	Transfer Transfer
+	// UETR is reported as the original UETR: the transfer's own, or the one the service gave it
+	UETR string
+	// Status is the status code of the transfer
+	Status string
+	// Reason explains a rejection, if any
+	Reason *Reason
+}
+
+// Reason explains the status of a transfer.
+type Reason struct {
+	// Code is one of the reason codes, such as AM12
+	Code string
+	// Info is a description of the reason
+	Info string
+}
+
+// pain002Document is a pain.002 customer payment status report, in version 10.
+type pain002Document struct {
+	XMLName xml.Name `xml:"urn:iso:std:iso:20022:tech:xsd:pain.002.001.10 Document"`
+	Report  struct {
+		GroupHeader struct {
+			MessageID string `xml:"MsgId"`
+			CreatedAt string `xml:"CreDtTm"`
+		} `xml:"GrpHdr"`
+		OriginalGroup struct {
+			MessageID            string `xml:"OrgnlMsgId"`
+			MessageName          string `xml:"OrgnlMsgNmId"`
+			CreatedAt            string `xml:"OrgnlCreDtTm,omitempty"`
+			NumberOfTransactions int    `xml:"OrgnlNbOfTxs"`
+			Status               string `xml:"GrpSts"`
+		} `xml:"OrgnlGrpInfAndSts"`
+		Payments []pain002Payment `xml:"OrgnlPmtInfAndSts"`
+	} `xml:"CstmrPmtStsRpt"`
+}
+
+// pain002Payment is the status of the transfers of a payment instruction.
+type pain002Payment struct {
+	ID        string            `xml:"OrgnlPmtInfId"`
+	Transfers []pain002Transfer `xml:"TxInfAndSts"`
+}
+
+// pain002Transfer is the status of a transfer, with its elements in schema order.
+type pain002Transfer struct {
+	InstructionID string         `xml:"OrgnlInstrId,omitempty"`
+	EndToEndID    string         `xml:"OrgnlEndToEndId,omitempty"`
+	UETR          string         `xml:"OrgnlUETR,omitempty"`
+	Status        string         `xml:"TxSts"`
+	Reason        *pain002Reason `xml:"StsRsnInf,omitempty"`
+}
+
+// pain002Reason explains the status of a transfer.
+type pain002Reason struct {
+	Code string   `xml:"Rsn>Cd"`
+	Info []string `xml:"AddtlInf,omitempty"`
+}
+
+// WritePain002 writes a status report as a pain.002 customer payment status report. Transfers are grouped
+// under their payment instruction; a long reason is split over as many AddtlInf elements as it needs.
+func WritePain002(w io.Writer, report StatusReport, now time.Time) error {
+	var document pain002Document
+	document.Report.GroupHeader.MessageID = newMessageID()
+	document.Report.GroupHeader.CreatedAt = now.UTC().Format(time.RFC3339)
+
+	original := &document.Report.OriginalGroup
+	original.MessageID = report.Initiation.MessageID
+	original.MessageName = report.Initiation.MessageName
+	original.CreatedAt = report.Initiation.CreatedAt
+	original.NumberOfTransactions = report.Initiation.Len()
+	original.Status = report.GroupStatus
+
+	// payments maps a payment instruction ID to its position in the report
+	payments := make(map[string]int)
+	for _, status := range report.Transfers {
+		i, ok := payments[status.PaymentID]
+		if !ok {
+			i = len(document.Report.Payments)
+			payments[status.PaymentID] = i
+			document.Report.Payments = append(document.Report.Payments, pain002Payment{ID: status.PaymentID})
+		}
+
+		transfer := pain002Transfer{
+			InstructionID: status.Transfer.InstructionID,
+			EndToEndID:    status.Transfer.EndToEndID,
+			UETR:          status.UETR,
+			Status:        status.Status,
+		}
+		if status.Reason != nil {
+			transfer.Reason = &pain002Reason{Code: status.Reason.Code, Info: splitInfo(status.Reason.Info)}
+		}
+		document.Report.Payments[i].Transfers = append(document.Report.Payments[i].Transfers, transfer)
+	}
+
+	return writeDocument(w, document)
+}
+
+// splitInfo splits a description into pieces of at most maxInfoLength characters.
+func splitInfo(info string) []string {
+	var pieces []string
+	for info != "" {
+		end := len(info)
+		if utf8.RuneCountInString(info) > maxInfoLength {
+			end = 0
+			for range maxInfoLength {
+				_, size := utf8.DecodeRuneInString(info[end:])
+				end += size
+			}
+		}
+		pieces = append(pieces, info[:end])
+		info = info[end:]
+	}
+	return pieces
+}
@@ -0,0 +1,67 @@
+package iso20022
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWritePain002(t *testing.T) {
+	file, err := os.Open(filepath.Join("testdata", "pain.001.xml"))
+	require.NoError(t, err)
+	defer file.Close()
+	initiation, err := ParsePain001(file)
+	require.NoError(t, err)
+
+	salaries, expenses := initiation.Payments[0], initiation.Payments[1]
+	longReason := strings.Repeat("é", 150)
+	report := StatusReport{
+		Initiation:  initiation,
+		GroupStatus: StatusPartiallyAccepted,
+		Transfers: []TransferStatus{
+			{PaymentID: salaries.ID, Transfer: salaries.Transfers[0], UETR: salaries.Transfers[0].UETR, Status: StatusAcceptedSettlementCompleted},
+			{PaymentID: expenses.ID, Transfer: expenses.Transfers[0], Status: StatusRejected, Reason: &Reason{Code: ReasonNotAllowedCurrency, Info: "currency is not supported"}},
+			{PaymentID: salaries.ID, Transfer: salaries.Transfers[1], Status: StatusRejected, Reason: &Reason{Code: ReasonNarrative, Info: longReason}},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WritePain002(&buf, report, time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)))
+	assertValid(t, "pain.002.001.10.xsd", buf.Bytes())
+
+	var document pain002Document
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &document))
+	original := document.Report.OriginalGroup
+	assert.Equal(t, "PAYROLL-2026-10-16", original.MessageID)
+	assert.Equal(t, "pain.001.001.09", original.MessageName)
+	assert.Equal(t, 3, original.NumberOfTransactions)
+	assert.Equal(t, "PART", original.Status)
+
+	require.Len(t, document.Report.Payments, 2)
+	assert.Equal(t, "SALARIES", document.Report.Payments[0].ID)
+	assert.Equal(t, []pain002Transfer{
+		{InstructionID: "SAL-1", EndToEndID: "E2E-SAL-1", UETR: "8a562c67-ca16-48ba-b074-65581be6f011", Status: "ACSC"},
+		{EndToEndID: "E2E-SAL-2", Status: "RJCT", Reason: &pain002Reason{Code: "NARR", Info: []string{longReason[:2*maxInfoLength], longReason[2*maxInfoLength:]}}},
+	}, document.Report.Payments[0].Transfers)
+	assert.Equal(t, "EXPENSES", document.Report.Payments[1].ID)
+	assert.Equal(t, []pain002Transfer{
+		{InstructionID: "EXP-1", EndToEndID: "E2E-EXP-1", Status: "RJCT", Reason: &pain002Reason{Code: "AM03", Info: []string{"currency is not supported"}}},
+	}, document.Report.Payments[1].Transfers)
+}
+
+func TestTransactionStatus(t *testing.T) {
+	assert.Equal(t, "ACSP", TransactionStatus(models.StatusPending))
+	assert.Equal(t, "ACSP", TransactionStatus(models.StatusProcessing))
+	assert.Equal(t, "ACSC", TransactionStatus(models.StatusCompleted))
+	assert.Equal(t, "PDNG", TransactionStatus(models.StatusHeld))
+	assert.Equal(t, "RJCT", TransactionStatus(models.StatusFailed))
+	assert.Equal(t, "RJCT", TransactionStatus(models.StatusReversed))
+}
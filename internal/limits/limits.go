@@ -0,0 +1,160 @@
+// Package limits enforces velocity and spending limits on senders before their transactions are accepted.
+// Default limits apply to every sender, and per-sender overrides stored in the database replace or lift them.
+package limits
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+const (
+	// ExceededCount marks a breach of a limit's maximum number of transactions
+	ExceededCount = "max_count"
+	// ExceededAmount marks a breach of a limit's maximum total amount
+	ExceededAmount = "max_amount"
+)
+
+// Store is the part of the database that limits are checked against.
+type Store interface {
+	// GetSpendingTotal counts and sums a sender's transactions in a currency created since a point in time
+	GetSpendingTotal(ctx context.Context, sender, currency string, since time.Time) (*models.SpendingTotal, error)
+	// GetSenderLimits retrieves a sender's overrides of the default limits
+	GetSenderLimits(ctx context.Context, sender string) ([]models.SpendingLimit, error)
+}
+
+// Breach describes a limit that a transaction would take its sender over.
+type Breach struct {
+	// Limit is the limit that would be breached
+	Limit models.SpendingLimit `json:"limit"`
+	// Exceeded is the part of the limit that would be breached, ExceededCount or ExceededAmount
+	Exceeded string `json:"exceeded"`
+	// Spent is what the sender had already sent in the limit's window
+	Spent models.SpendingTotal `json:"spent"`
+}
+
+// Error describes the breach for API clients.
+func (b *Breach) Error() string {
+	if b.Exceeded == ExceededCount {
+		return fmt.Sprintf("sender has reached the limit of %d %s transactions per %s",
+			b.Limit.MaxCount, b.Limit.Currency, b.Limit.Window)
+	}
+	return fmt.Sprintf("transaction would take the sender over the limit of %s %s per %s (%s already sent)",
+		b.Limit.MaxAmount, b.Limit.Currency, b.Limit.Window, b.Spent.Amount)
+}
+
+// Limiter checks new transactions against the limits in force for their sender.
+// Checks read the sender's totals before the transaction is stored, so concurrent requests from one sender
+// may together go slightly over a limit; limits bound abuse rather than guarantee an exact ceiling.
+type Limiter struct {
+	Store Store
+	// Defaults are the limits that apply to every sender without an override
+	Defaults []models.SpendingLimit
+	// now returns the current time; tests replace it
+	now func() time.Time
+}
+
+// NewLimiter creates a Limiter with the given default limits.
+func NewLimiter(store Store, defaults []models.SpendingLimit) *Limiter {
+	return &Limiter{Store: store, Defaults: defaults, now: time.Now}
+}
+
+// Limits returns the limits in force for a sender: the defaults merged with the sender's overrides.
+func (l *Limiter) Limits(ctx context.Context, sender string) ([]models.SpendingLimit, error) {
+	overrides, err := l.Store.GetSenderLimits(ctx, sender)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get limits of sender %s: %w", sender, err)
+	}
+	return models.EffectiveLimits(l.Defaults, overrides), nil
+}
+
+// Check returns the first limit, shortest window first, that the transaction would take its sender over,
+// or nil if it is within every limit for its currency.
+func (l *Limiter) Check(ctx context.Context, transaction models.Transaction) (*Breach, error) {
+	breaches, err := l.CheckBatch(ctx, []models.Transaction{transaction})
+	if err != nil {
+		return nil, err
+	}
+	return breaches[0], nil
+}
+
+// CheckBatch checks transactions as if they were created one after another: each is held to its sender's
+// limits counting the transactions before it in the batch that are within them. It returns the breach of
+// each transaction, nil for those within every limit, and reads each sender's limits and spending once.
+func (l *Limiter) CheckBatch(ctx context.Context, transactions []models.Transaction) ([]*Breach, error) {
+	breaches := make([]*Breach, len(transactions))
+	limitsBySender := make(map[string][]models.SpendingLimit)
+	// spending holds each sender's spending per limit, keyed by sender and limit name
+	spending := make(map[[2]string]*models.SpendingTotal)
+
+	now := l.clock()
+	for i, transaction := range transactions {
+		limits, ok := limitsBySender[transaction.Sender]
+		if !ok {
+			var err error
+			if limits, err = l.Limits(ctx, transaction.Sender); err != nil {
+				return nil, err
+			}
+			limitsBySender[transaction.Sender] = limits
+		}
+
+		var within []*models.SpendingTotal
+		for _, limit := range limits {
+			if !strings.EqualFold(limit.Currency, transaction.Currency) {
+				continue
+			}
+
+			key := [2]string{transaction.Sender, limit.Name()}
+			spent, ok := spending[key]
+			if !ok {
+				var err error
+				spent, err = l.Store.GetSpendingTotal(ctx, transaction.Sender, limit.Currency, now.Add(-limit.Window.Duration()))
+				if err != nil {
+					return nil, fmt.Errorf("failed to get spending of sender %s: %w", transaction.Sender, err)
+				}
+				spending[key] = spent
+			}
+			if breaches[i] = exceeds(limit, *spent, transaction.Amount); breaches[i] != nil {
+				break
+			}
+			within = append(within, spent)
+		}
+
+		// A transaction that breaches a limit is not created, so it does not count towards the rest
+		if breaches[i] != nil {
+			continue
+		}
+		for _, spent := range within {
+			spent.Count++
+			if total, err := spent.Amount.Add(transaction.Amount); err == nil {
+				spent.Amount = total
+			}
+		}
+	}
+	return breaches, nil
+}
+
+// exceeds returns the breach if sending amount would take spending over a limit, or nil if it is within it.
+func exceeds(limit models.SpendingLimit, spent models.SpendingTotal, amount models.Amount) *Breach {
+	if limit.MaxCount > 0 && spent.Count >= limit.MaxCount {
+		return &Breach{Limit: limit, Exceeded: ExceededCount, Spent: spent}
+	}
+	if limit.MaxAmount != nil {
+		total, err := spent.Amount.Add(amount)
+		if err != nil || total.Cmp(*limit.MaxAmount) > 0 {
+			return &Breach{Limit: limit, Exceeded: ExceededAmount, Spent: spent}
+		}
+	}
+	return nil
+}
+
+// clock returns the current time from the limiter's clock.
+func (l *Limiter) clock() time.Time {
+	if l.now == nil {
+		return time.Now()
+	}
+	return l.now()
+}
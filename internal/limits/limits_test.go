@@ -0,0 +1,205 @@
+package limits
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/db"
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParseLimits(t *testing.T, spec string) []models.SpendingLimit {
+	t.Helper()
+	limits, err := models.ParseSpendingLimits(spec)
+	require.NoError(t, err)
+	return limits
+}
+
+// seedTransactions stores transactions from alice of the given amounts.
+func seedTransactions(t *testing.T, store *db.MemoryDB, currency string, amounts ...string) {
+	t.Helper()
+	for i, amount := range amounts {
+		require.NoError(t, store.CreateTransaction(context.Background(), models.Transaction{
+			ID:       t.Name() + currency + amount + string(rune('a'+i)),
+			Amount:   models.MustParseAmount(amount),
+			Currency: currency,
+			Sender:   "alice",
+			Receiver: "bob",
+			Status:   models.StatusPending,
+		}))
+	}
+}
+
+func transaction(amount, currency string) models.Transaction {
+	return models.Transaction{Amount: models.MustParseAmount(amount), Currency: currency, Sender: "alice", Receiver: "bob"}
+}
+
+func TestLimiter_Check(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("within every limit", func(t *testing.T) {
+		store := db.NewMemoryDB()
+		seedTransactions(t, store, "USD", "100.00", "200.00")
+		limiter := NewLimiter(store, mustParseLimits(t, "USD/1h=3:1000,EUR/1h=1"))
+
+		breach, err := limiter.Check(ctx, transaction("700.00", "USD"))
+		require.NoError(t, err)
+		assert.Nil(t, breach)
+	})
+
+	t.Run("count limit", func(t *testing.T) {
+		store := db.NewMemoryDB()
+		seedTransactions(t, store, "USD", "1.00", "1.00")
+		limiter := NewLimiter(store, mustParseLimits(t, "USD/1h=2"))
+
+		breach, err := limiter.Check(ctx, transaction("1.00", "usd"))
+		require.NoError(t, err)
+		require.NotNil(t, breach)
+		assert.Equal(t, ExceededCount, breach.Exceeded)
+		assert.Equal(t, "USD/1h", breach.Limit.Name())
+		assert.Equal(t, 2, breach.Spent.Count)
+		assert.Equal(t, "sender has reached the limit of 2 USD transactions per 1h", breach.Error())
+	})
+
+	t.Run("amount limit", func(t *testing.T) {
+		store := db.NewMemoryDB()
+		seedTransactions(t, store, "USD", "600.00")
+		limiter := NewLimiter(store, mustParseLimits(t, "USD/24h=:1000"))
+
+		breach, err := limiter.Check(ctx, transaction("400.00", "USD"))
+		require.NoError(t, err)
+		assert.Nil(t, breach, "reaching the limit exactly is allowed")
+
+		breach, err = limiter.Check(ctx, transaction("400.01", "USD"))
+		require.NoError(t, err)
+		require.NotNil(t, breach)
+		assert.Equal(t, ExceededAmount, breach.Exceeded)
+		assert.Equal(t, "600.00", breach.Spent.Amount.String())
+		assert.Equal(t, "transaction would take the sender over the limit of 1000 USD per 24h (600.00 already sent)", breach.Error())
+	})
+
+	t.Run("a single transaction over the amount limit", func(t *testing.T) {
+		limiter := NewLimiter(db.NewMemoryDB(), mustParseLimits(t, "USD/24h=:1000"))
+
+		breach, err := limiter.Check(ctx, transaction("1000.01", "USD"))
+		require.NoError(t, err)
+		require.NotNil(t, breach)
+		assert.Equal(t, 0, breach.Spent.Count)
+	})
+
+	t.Run("shortest window first", func(t *testing.T) {
+		store := db.NewMemoryDB()
+		seedTransactions(t, store, "USD", "1.00")
+		limiter := NewLimiter(store, mustParseLimits(t, "USD/30d=1,USD/1h=1"))
+
+		breach, err := limiter.Check(ctx, transaction("1.00", "USD"))
+		require.NoError(t, err)
+		require.NotNil(t, breach)
+		assert.Equal(t, "USD/1h", breach.Limit.Name())
+	})
+
+	t.Run("transactions outside the window and failed transactions do not count", func(t *testing.T) {
+		store := db.NewMemoryDB()
+		seedTransactions(t, store, "USD", "1.00", "2.00")
+		limiter := NewLimiter(store, mustParseLimits(t, "USD/1h=2"))
+
+		limiter.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+		breach, err := limiter.Check(ctx, transaction("1.00", "USD"))
+		require.NoError(t, err)
+		assert.Nil(t, breach)
+
+		limiter.now = nil
+		all, err := store.GetAllTransactions(ctx, db.TransactionFilter{}, 10, 0)
+		require.NoError(t, err)
+		require.NoError(t, store.UpdateTransaction(ctx, all[0].ID, models.StatusChange{Status: models.StatusFailed, Actor: "test"}))
+		breach, err = limiter.Check(ctx, transaction("1.00", "USD"))
+		require.NoError(t, err)
+		assert.Nil(t, breach)
+	})
+
+	t.Run("overrides replace and lift defaults", func(t *testing.T) {
+		store := db.NewMemoryDB()
+		seedTransactions(t, store, "USD", "1.00", "1.00")
+		seedTransactions(t, store, "EUR", "1.00")
+		limiter := NewLimiter(store, mustParseLimits(t, "USD/1h=2,EUR/1h=1"))
+		require.NoError(t, store.SetSenderLimits(ctx, "alice", []models.SpendingLimit{
+			{Currency: "USD", Window: models.LimitWindow(time.Hour), MaxCount: 5},
+			{Currency: "EUR", Window: models.LimitWindow(time.Hour)},
+		}))
+
+		breach, err := limiter.Check(ctx, transaction("1.00", "USD"))
+		require.NoError(t, err)
+		assert.Nil(t, breach)
+		breach, err = limiter.Check(ctx, transaction("1.00", "EUR"))
+		require.NoError(t, err)
+		assert.Nil(t, breach)
+
+		limits, err := limiter.Limits(ctx, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, []models.SpendingLimit{{Currency: "USD", Window: models.LimitWindow(time.Hour), MaxCount: 5}}, limits)
+	})
+
+	t.Run("store errors", func(t *testing.T) {
+		limiter := NewLimiter(failingStore{}, mustParseLimits(t, "USD/1h=2"))
+
+		_, err := limiter.Check(ctx, transaction("1.00", "USD"))
+		assert.ErrorContains(t, err, "database error")
+	})
+}
+
+func TestLimiter_CheckBatch(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("earlier transactions in the batch count", func(t *testing.T) {
+		store := db.NewMemoryDB()
+		seedTransactions(t, store, "USD", "100.00")
+		limiter := NewLimiter(store, mustParseLimits(t, "USD/1h=3:500"))
+
+		bob := transaction("1.00", "USD")
+		bob.Sender = "bob"
+		breaches, err := limiter.CheckBatch(ctx, []models.Transaction{
+			transaction("300.00", "USD"),
+			transaction("200.00", "USD"),
+			transaction("50.00", "EUR"),
+			transaction("100.00", "USD"),
+			bob,
+			transaction("1.00", "USD"),
+			transaction("1.00", "USD"),
+		})
+		require.NoError(t, err)
+		require.Len(t, breaches, 7)
+		assert.Nil(t, breaches[0])
+		require.NotNil(t, breaches[1])
+		assert.Equal(t, ExceededAmount, breaches[1].Exceeded)
+		assert.Equal(t, "400.00", breaches[1].Spent.Amount.String(), "spending includes the earlier transaction")
+		assert.Nil(t, breaches[2], "other currencies are not limited")
+		assert.Nil(t, breaches[3], "a breaching transaction does not count")
+		assert.Nil(t, breaches[4], "other senders have their own spending")
+		require.NotNil(t, breaches[5])
+		assert.Equal(t, ExceededCount, breaches[5].Exceeded)
+		assert.Equal(t, 3, breaches[5].Spent.Count)
+		assert.NotNil(t, breaches[6])
+	})
+
+	t.Run("store errors", func(t *testing.T) {
+		limiter := NewLimiter(failingStore{}, mustParseLimits(t, "USD/1h=2"))
+
+		_, err := limiter.CheckBatch(ctx, []models.Transaction{transaction("1.00", "USD")})
+		assert.ErrorContains(t, err, "database error")
+	})
+}
+
+// failingStore fails every query.
+type failingStore struct{}
+
+func (failingStore) GetSpendingTotal(ctx context.Context, sender, currency string, since time.Time) (*models.SpendingTotal, error) {
+	return nil, errors.New("database error")
+}
+
+func (failingStore) GetSenderLimits(ctx context.Context, sender string) ([]models.SpendingLimit, error) {
+	return nil, errors.New("database error")
+}
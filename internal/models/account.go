@@ -0,0 +1,100 @@
+package models
+
+import "time"
+
+// Direction is the side of the ledger an entry is posted to.
+type Direction string
+
+const (
+	// DirectionDebit removes funds from an account
+	DirectionDebit Direction = "debit"
+	// DirectionCredit adds funds to an account
+	DirectionCredit Direction = "credit"
+)
+
+// Account is a holder of funds in a single currency.
+// Its balance is never stored; it is derived from the ledger entries posted against it.
+type Account struct {
+	// ID is the unique identifier of the account; transactions name it as sender or receiver
+	ID string `json:"id"`
+	// Currency is the 3-letter ISO currency code the account is denominated in
+	Currency string `json:"currency"`
+	// AllowOverdraft permits the balance to go negative, as for settlement or funding accounts
+	AllowOverdraft bool `json:"allow_overdraft"`
+	// CreatedAt is the timestamp when the account was opened
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LedgerEntry is one side of a double-entry posting.
+// Every posting consists of a debit and a credit of the same amount, so the ledger always balances.
+type LedgerEntry struct {
+	// ID is the sequential identifier of the entry; later entries have larger IDs
+	ID int64 `json:"id"`
+	// AccountID is the account the entry is posted to
+	AccountID string `json:"account_id"`
+	// TransactionID is the transaction whose status change produced the entry
+	TransactionID string `json:"transaction_id"`
+	// Direction is whether the entry debits or credits the account
+	Direction Direction `json:"direction"`
+	// Amount is the positive value of the entry
+	Amount Amount `json:"amount"`
+	// Currency is the 3-letter ISO currency code of the amount
+	Currency string `json:"currency"`
+	// CreatedAt is the timestamp when the entry was posted
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Balance is the funds held by an account, computed as its credits minus its debits.
+type Balance struct {
+	// AccountID is the account the balance belongs to
+	AccountID string `json:"account_id"`
+	// Currency is the 3-letter ISO currency code of the amount
+	Currency string `json:"currency"`
+	// Amount is the net of all entries posted to the account; it may be negative for overdraft accounts
+	Amount Amount `json:"amount"`
+}
+
+// LedgerEntries returns the balanced entries a transaction posts when it moves to status.
+// Completing a transaction moves funds from sender to receiver and reversing it moves them back;
+// every other status posts nothing and yields nil.
+// A cross-currency transaction posts two balanced pairs, one in each currency, through the exchange accounts:
+// the sender pays the source exchange account and the target exchange account pays the receiver.
+func LedgerEntries(transaction Transaction, status Status) []LedgerEntry {
+	var from, to string
+	switch status {
+	case StatusCompleted:
+		from, to = transaction.Sender, transaction.Receiver
+	case StatusReversed:
+		from, to = transaction.Receiver, transaction.Sender
+	default:
+		return nil
+	}
+
+	conversion := transaction.Conversion
+	if conversion == nil {
+		return transfer(transaction.ID, from, to, transaction.Amount, transaction.Currency)
+	}
+
+	sourceAccount, targetAccount := ExchangeAccountID(transaction.Currency), ExchangeAccountID(conversion.TargetCurrency)
+	if status == StatusCompleted {
+		return append(
+			transfer(transaction.ID, from, sourceAccount, transaction.Amount, transaction.Currency),
+			transfer(transaction.ID, targetAccount, to, conversion.TargetAmount, conversion.TargetCurrency)...)
+	}
+	return append(
+		transfer(transaction.ID, from, targetAccount, conversion.TargetAmount, conversion.TargetCurrency),
+		transfer(transaction.ID, sourceAccount, to, transaction.Amount, transaction.Currency)...)
+}
+
+// transfer returns the debit and credit that move an amount from one account to another.
+func transfer(transactionID, from, to string, amount Amount, currency string) []LedgerEntry {
+	entry := LedgerEntry{
+		TransactionID: transactionID,
+		Amount:        amount,
+		Currency:      currency,
+	}
+	debit, credit := entry, entry
+	debit.AccountID, debit.Direction = from, DirectionDebit
+	credit.AccountID, credit.Direction = to, DirectionCredit
+	return []LedgerEntry{debit, credit}
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedgerEntries(t *testing.T) {
+	transaction := Transaction{
+		ID:       "txn-1",
+		Amount:   MustParseAmount("25.00"),
+		Currency: "USD",
+		Sender:   "alice",
+		Receiver: "bob",
+	}
+
+	t.Run("completion moves funds from sender to receiver", func(t *testing.T) {
+		entries := LedgerEntries(transaction, StatusCompleted)
+		require.Len(t, entries, 2)
+
+		assert.Equal(t, "alice", entries[0].AccountID)
+		assert.Equal(t, DirectionDebit, entries[0].Direction)
+		assert.Equal(t, "bob", entries[1].AccountID)
+		assert.Equal(t, DirectionCredit, entries[1].Direction)
+		for _, entry := range entries {
+			assert.Equal(t, "txn-1", entry.TransactionID)
+			assert.Equal(t, "25.00", entry.Amount.String())
+			assert.Equal(t, "USD", entry.Currency)
+		}
+	})
+
+	t.Run("reversal moves funds back", func(t *testing.T) {
+		entries := LedgerEntries(transaction, StatusReversed)
+		require.Len(t, entries, 2)
+
+		assert.Equal(t, "bob", entries[0].AccountID)
+		assert.Equal(t, DirectionDebit, entries[0].Direction)
+		assert.Equal(t, "alice", entries[1].AccountID)
+		assert.Equal(t, DirectionCredit, entries[1].Direction)
+	})
+
+	t.Run("cross-currency transactions pass through the exchange accounts", func(t *testing.T) {
+		converted := transaction
+		converted.Conversion = &Conversion{TargetCurrency: "KES", TargetAmount: MustParseAmount("3228.75"), Rate: MustParseRate("129.15")}
+
+		type posting struct {
+			account   string
+			direction Direction
+			amount    string
+			currency  string
+		}
+		postings := func(entries []LedgerEntry) []posting {
+			result := make([]posting, len(entries))
+			for i, entry := range entries {
+				result[i] = posting{entry.AccountID, entry.Direction, entry.Amount.String(), entry.Currency}
+			}
+			return result
+		}
+
+		assert.Equal(t, []posting{
+			{"alice", DirectionDebit, "25.00", "USD"},
+			{"fx:USD", DirectionCredit, "25.00", "USD"},
+			{"fx:KES", DirectionDebit, "3228.75", "KES"},
+			{"bob", DirectionCredit, "3228.75", "KES"},
+		}, postings(LedgerEntries(converted, StatusCompleted)))
+
+		assert.Equal(t, []posting{
+			{"bob", DirectionDebit, "3228.75", "KES"},
+			{"fx:KES", DirectionCredit, "3228.75", "KES"},
+			{"fx:USD", DirectionDebit, "25.00", "USD"},
+			{"alice", DirectionCredit, "25.00", "USD"},
+		}, postings(LedgerEntries(converted, StatusReversed)))
+	})
+
+	t.Run("other statuses post nothing", func(t *testing.T) {
+		for _, status := range []Status{StatusPending, StatusProcessing, StatusFailed} {
+			assert.Nil(t, LedgerEntries(transaction, status), status)
+			assert.False(t, status.PostsToLedger(), status)
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// MaxFailureReasonLength is the longest failure reason that can be stored with a transaction.
+const MaxFailureReasonLength = 512
+
+// MaxActorLength is the longest actor name that can be recorded with a status change.
+const MaxActorLength = 255
+
+// StatusChange is a request to move a transaction to a new status.
+type StatusChange struct {
+	// Status is the status to move to
+	Status Status
+	// Reason explains the change; for StatusFailed it is also stored as the transaction's failure reason
+	Reason string
+	// Actor identifies who requested the change
+	Actor string
+}
+
+// TransactionEvent records a single status change in a transaction's audit trail.
+// Events are written atomically with the change they describe and are never modified.
+type TransactionEvent struct {
+	// ID orders the events of a transaction; it increases with every recorded change
+	ID int64 `json:"id"`
+	// TransactionID is the transaction whose status changed
+	TransactionID string `json:"transaction_id"`
+	// FromStatus is the status before the change
+	FromStatus Status `json:"from_status"`
+	// ToStatus is the status after the change
+	ToStatus Status `json:"to_status"`
+	// Reason explains the change, if one was given
+	Reason string `json:"reason,omitempty"`
+	// Actor identifies who requested the change
+	Actor string `json:"actor"`
+	// CreatedAt is when the change happened
+	CreatedAt time.Time `json:"created_at"`
+}
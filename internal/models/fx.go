@@ -0,0 +1,242 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxRateExponent is the largest number of decimal places an exchange rate may carry
+	MaxRateExponent = 10
+	// maxRateDigits is the largest number of digits before the decimal point of an exchange rate,
+	// so that rates fit a DECIMAL(19,10) column
+	maxRateDigits = 9
+	// exchangeAccountPrefix starts the ID of every currency exchange account
+	exchangeAccountPrefix = "fx:"
+)
+
+// ErrInvalidRate is returned when a value cannot be parsed as an exchange rate.
+var ErrInvalidRate = errors.New("invalid exchange rate")
+
+// Rate is an exact, positive exchange rate: the number of units of a target currency one unit of a source
+// currency buys. Like Amount it is stored as an integer scaled by a power of ten, with up to ten decimal places.
+type Rate struct {
+	units    int64
+	exponent int
+}
+
+// ParseRate parses a positive plain decimal string such as "0.0077519" into a Rate.
+// Rates have at most nine digits before the decimal point and ten after it.
+func ParseRate(s string) (Rate, error) {
+	intPart, fracPart, hasPoint := strings.Cut(s, ".")
+	if intPart == "" || (hasPoint && fracPart == "") || !isDigits(intPart) || !isDigits(fracPart) {
+		return Rate{}, fmt.Errorf("%w: %q", ErrInvalidRate, s)
+	}
+	if len(fracPart) > MaxRateExponent {
+		return Rate{}, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidRate, s, MaxRateExponent)
+	}
+	if len(strings.TrimLeft(intPart, "0")) > maxRateDigits {
+		return Rate{}, fmt.Errorf("%w: %q is too large", ErrInvalidRate, s)
+	}
+
+	units, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil || units <= 0 {
+		return Rate{}, fmt.Errorf("%w: %q", ErrInvalidRate, s)
+	}
+	return Rate{units: units, exponent: len(fracPart)}, nil
+}
+
+// MustParseRate is like ParseRate but panics if the value cannot be parsed.
+// It is intended for constants and tests.
+func MustParseRate(s string) Rate {
+	rate, err := ParseRate(s)
+	if err != nil {
+		panic(err)
+	}
+	return rate
+}
+
+// IsZero reports whether the rate is unset.
+func (r Rate) IsZero() bool {
+	return r.units == 0
+}
+
+// Inverse returns the rate in the opposite direction, rounded half away from zero to MaxRateExponent places.
+func (r Rate) Inverse() (Rate, error) {
+	if r.units <= 0 {
+		return Rate{}, fmt.Errorf("%w: %s has no inverse", ErrInvalidRate, r)
+	}
+	units, err := roundRat(new(big.Rat).Inv(r.rat()), MaxRateExponent)
+	if err != nil || units == 0 {
+		return Rate{}, fmt.Errorf("%w: the inverse of %s cannot be represented", ErrInvalidRate, r)
+	}
+	return trimRate(Rate{units: units, exponent: MaxRateExponent}), nil
+}
+
+// Convert applies the rate to an amount and rounds the result half away from zero to the given number of
+// decimal places, which is the exponent of the target currency.
+// It returns an error wrapping ErrInvalidAmount if the result does not fit in an Amount.
+func (r Rate) Convert(amount Amount, exponent int) (Amount, error) {
+	if exponent < 0 || exponent > maxExponent {
+		return Amount{}, fmt.Errorf("%w: unsupported exponent %d", ErrInvalidAmount, exponent)
+	}
+	units, err := roundRat(new(big.Rat).Mul(amount.rat(), r.rat()), exponent)
+	if err != nil {
+		return Amount{}, fmt.Errorf("%w: %s at %s overflows", ErrInvalidAmount, amount, r)
+	}
+	return Amount{units: units, exponent: exponent}, nil
+}
+
+// Cmp compares two rates numerically regardless of their exponents.
+func (r Rate) Cmp(other Rate) int {
+	return r.rat().Cmp(other.rat())
+}
+
+// String formats the rate as a plain decimal string with exactly as many decimal places as it carries.
+func (r Rate) String() string {
+	return Amount{units: r.units, exponent: r.exponent}.String()
+}
+
+// MarshalJSON encodes the rate as a JSON string so that no precision is lost in transit.
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON decodes a rate from either a JSON string or a JSON number.
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+
+	rate, err := ParseRate(text)
+	if err != nil {
+		return err
+	}
+	*r = rate
+	return nil
+}
+
+// Value implements driver.Valuer so the rate is stored as an exact decimal string.
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// Scan implements sql.Scanner for DECIMAL columns.
+func (r *Rate) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("%w: cannot scan %T into Rate", ErrInvalidRate, src)
+	}
+
+	rate, err := ParseRate(trimTrailingZeros(text))
+	if err != nil {
+		return err
+	}
+	*r = rate
+	return nil
+}
+
+// rat returns the rate as an exact rational number.
+func (r Rate) rat() *big.Rat {
+	return Amount{units: r.units, exponent: r.exponent}.rat()
+}
+
+// trimRate removes insignificant trailing zeros from a rate.
+func trimRate(r Rate) Rate {
+	for r.exponent > 0 && r.units%10 == 0 {
+		r.units /= 10
+		r.exponent--
+	}
+	return r
+}
+
+// roundRat scales x by 10^exponent and rounds it half away from zero to an int64.
+func roundRat(x *big.Rat, exponent int) (int64, error) {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exponent)), nil)
+	scaled := new(big.Rat).Mul(x, new(big.Rat).SetInt(scale))
+
+	quotient, remainder := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	// Round away from zero when the remainder is at least half the denominator
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(scaled.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(scaled.Sign())))
+	}
+	if !quotient.IsInt64() {
+		return 0, errors.New("value out of range")
+	}
+	return quotient.Int64(), nil
+}
+
+// Conversion records the currency exchange of a cross-currency transaction. The transaction's own amount and
+// currency are what the sender pays; the receiver is credited the target amount in the target currency.
+type Conversion struct {
+	// TargetCurrency is the 3-letter ISO currency code the receiver is credited in
+	TargetCurrency string `json:"target_currency"`
+	// TargetAmount is the amount the receiver is credited, rounded to the minor units of the target currency
+	TargetAmount Amount `json:"target_amount"`
+	// Rate is the number of target currency units applied to each unit of the transaction's currency
+	Rate Rate `json:"rate"`
+	// QuoteID is the quote the rate was locked with, if any
+	QuoteID string `json:"quote_id,omitempty"`
+}
+
+// Quote is an exchange rate locked for converting a given amount until it expires.
+// A quote can be used by a single transaction.
+type Quote struct {
+	// ID is the unique identifier of the quote
+	ID string `json:"id"`
+	// SourceCurrency is the 3-letter ISO currency code the sender pays in
+	SourceCurrency string `json:"source_currency"`
+	// SourceAmount is the amount the sender pays
+	SourceAmount Amount `json:"source_amount"`
+	// TargetCurrency is the 3-letter ISO currency code the receiver is credited in
+	TargetCurrency string `json:"target_currency"`
+	// TargetAmount is the amount the receiver is credited
+	TargetAmount Amount `json:"target_amount"`
+	// Rate is the locked exchange rate
+	Rate Rate `json:"rate"`
+	// Provider names the source of the rate
+	Provider string `json:"provider"`
+	// TransactionID is the transaction that used the quote, if any
+	TransactionID string `json:"transaction_id,omitempty"`
+	// CreatedAt is the timestamp when the quote was issued
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt is the timestamp after which the quote can no longer be used
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Conversion returns the conversion a transaction using the quote records.
+func (q Quote) Conversion() *Conversion {
+	return &Conversion{TargetCurrency: q.TargetCurrency, TargetAmount: q.TargetAmount, Rate: q.Rate, QuoteID: q.ID}
+}
+
+// ExchangeAccountID names the account that holds the service's position in a currency. Cross-currency
+// transactions pay into the exchange account of their source currency and out of that of their target currency.
+func ExchangeAccountID(currency string) string {
+	return exchangeAccountPrefix + strings.ToUpper(currency)
+}
+
+// IsExchangeAccount reports whether an account ID is reserved for a currency exchange account.
+func IsExchangeAccount(id string) bool {
+	return strings.HasPrefix(strings.ToLower(id), exchangeAccountPrefix)
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRate(t *testing.T) {
+	for _, input := range []string{"1", "129.15", "0.0077519380", "3700.5"} {
+		rate, err := ParseRate(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, input, rate.String())
+	}
+
+	for _, input := range []string{"", "0", "0.000", "-1.5", "1e3", ".5", "1.", "0.00000000001", "1000000000", "abc"} {
+		_, err := ParseRate(input)
+		assert.ErrorIs(t, err, ErrInvalidRate, input)
+	}
+}
+
+func TestRate_Convert(t *testing.T) {
+	tests := []struct {
+		amount   string
+		rate     string
+		exponent int
+		want     string
+	}{
+		{"25.00", "129.15", 2, "3228.75"},
+		{"1000.00", "0.0077519380", 2, "7.75"},
+		// Halves are rounded away from zero
+		{"0.01", "0.5", 2, "0.01"},
+		{"0.03", "0.5", 2, "0.02"},
+		{"0.01", "0.4999", 2, "0.00"},
+		{"100", "0.0068", 2, "0.68"},
+		{"10.00", "0.3769", 3, "3.769"},
+		{"12.34", "160.1234", 0, "1976"},
+	}
+	for _, tt := range tests {
+		converted, err := MustParseRate(tt.rate).Convert(MustParseAmount(tt.amount), tt.exponent)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, converted.String(), "%s at %s", tt.amount, tt.rate)
+	}
+
+	_, err := MustParseRate("900000000").Convert(MustParseAmount("99999999999.00"), 2)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+}
+
+func TestRate_Inverse(t *testing.T) {
+	inverse, err := MustParseRate("129").Inverse()
+	require.NoError(t, err)
+	assert.Equal(t, "0.007751938", inverse.String(), "rounded to ten places")
+
+	inverse, err = MustParseRate("0.5").Inverse()
+	require.NoError(t, err)
+	assert.Equal(t, "2", inverse.String(), "insignificant zeros are dropped")
+}
+
+func TestRate_JSONAndScan(t *testing.T) {
+	var rate Rate
+	require.NoError(t, json.Unmarshal([]byte(`"129.15"`), &rate))
+	assert.Equal(t, "129.15", rate.String())
+	require.NoError(t, json.Unmarshal([]byte(`0.25`), &rate))
+	assert.Equal(t, "0.25", rate.String())
+
+	encoded, err := json.Marshal(MustParseRate("0.0077"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `"0.0077"`, string(encoded))
+
+	require.NoError(t, rate.Scan([]byte("129.1500000000")))
+	assert.Equal(t, "129.15", rate.String(), "DECIMAL padding is trimmed")
+	assert.Error(t, rate.Scan(true))
+}
+
+func TestExchangeAccountID(t *testing.T) {
+	assert.Equal(t, "fx:USD", ExchangeAccountID("usd"))
+	assert.True(t, IsExchangeAccount("fx:USD"))
+	assert.True(t, IsExchangeAccount("FX:anything"))
+	assert.False(t, IsExchangeAccount("alice"))
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// IdempotencyStatus is the state of an idempotency key.
+type IdempotencyStatus string
+
+const (
+	// IdempotencyPending keys are reserved by a request that has not finished yet
+	IdempotencyPending IdempotencyStatus = "pending"
+	// IdempotencyCompleted keys hold the response of a request that has finished
+	IdempotencyCompleted IdempotencyStatus = "completed"
+)
+
+// IdempotencyRecord stores the outcome of a request made with an Idempotency-Key header.
+// It allows a retried request to be answered with the original response instead of being executed twice.
+type IdempotencyRecord struct {
+	// Key is the client-supplied Idempotency-Key header value
+	Key string `json:"key"`
+	// RequestHash is the SHA-256 fingerprint of the original request body
+	RequestHash string `json:"request_hash"`
+	// Status is pending while the original request is in flight and completed once its response is stored
+	Status IdempotencyStatus `json:"status"`
+	// ResponseCode is the HTTP status code returned for the original request
+	ResponseCode int `json:"response_code"`
+	// ResponseBody is the exact response body returned for the original request
+	ResponseBody []byte `json:"response_body"`
+	// CreatedAt is the timestamp when the key was first used
+	CreatedAt time.Time `json:"created_at"`
+	// ExpiresAt is the timestamp after which the key may be reused for a different request
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,209 @@
+package models
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/currency"
+)
+
+const (
+	// MinLimitWindow is the shortest window a spending limit may cover
+	MinLimitWindow = LimitWindow(time.Minute)
+	// MaxLimitWindow is the longest window a spending limit may cover
+	MaxLimitWindow = LimitWindow(366 * 24 * time.Hour)
+)
+
+// ErrInvalidLimitWindow is returned when a value cannot be parsed as a limit window.
+var ErrInvalidLimitWindow = errors.New("invalid limit window")
+
+// LimitWindow is the rolling period a spending limit covers, ending at the time a transaction is created.
+// It is written as a whole number of minutes, hours or days, such as "15m", "1h", "24h" or "30d".
+type LimitWindow time.Duration
+
+// ParseLimitWindow parses a window written as a positive whole number followed by m, h or d.
+func ParseLimitWindow(s string) (LimitWindow, error) {
+	if len(s) < 2 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidLimitWindow, s)
+	}
+
+	var unit time.Duration
+	switch s[len(s)-1] {
+	case 'm':
+		unit = time.Minute
+	case 'h':
+		unit = time.Hour
+	case 'd':
+		unit = 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("%w: %q must end in m, h or d", ErrInvalidLimitWindow, s)
+	}
+	n, err := strconv.ParseInt(s[:len(s)-1], 10, 64)
+	if err != nil || n <= 0 || n > int64(MaxLimitWindow)/int64(unit) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidLimitWindow, s)
+	}
+	return LimitWindow(time.Duration(n) * unit), nil
+}
+
+// Duration returns the window as a time.Duration.
+func (w LimitWindow) Duration() time.Duration {
+	return time.Duration(w)
+}
+
+// IsValid reports whether the window is a whole number of minutes within the supported range.
+func (w LimitWindow) IsValid() bool {
+	return w >= MinLimitWindow && w <= MaxLimitWindow && w.Duration()%time.Minute == 0
+}
+
+// String formats the window in the largest unit that divides it, e.g. "30d" rather than "720h",
+// except that a single day is written "24h".
+func (w LimitWindow) String() string {
+	d := w.Duration()
+	switch {
+	case d%(24*time.Hour) == 0 && d > 24*time.Hour:
+		return strconv.FormatInt(int64(d/(24*time.Hour)), 10) + "d"
+	case d%time.Hour == 0:
+		return strconv.FormatInt(int64(d/time.Hour), 10) + "h"
+	default:
+		return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+	}
+}
+
+// MarshalJSON encodes the window as a string such as "24h".
+func (w LimitWindow) MarshalJSON() ([]byte, error) {
+	return json.Marshal(w.String())
+}
+
+// UnmarshalJSON decodes a window written as a string such as "24h".
+func (w *LimitWindow) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%w: must be a string such as \"24h\"", ErrInvalidLimitWindow)
+	}
+	window, err := ParseLimitWindow(s)
+	if err != nil {
+		return err
+	}
+	*w = window
+	return nil
+}
+
+// SpendingLimit caps how many transactions, and how much in total, a sender may send in one currency
+// over a rolling window. A limit with neither cap lifts a default limit for the sender it is set for.
+type SpendingLimit struct {
+	// Currency is the 3-letter ISO currency code the limit applies to
+	Currency string `json:"currency"`
+	// Window is the rolling period the limit covers
+	Window LimitWindow `json:"window"`
+	// MaxCount is the largest number of transactions allowed in the window; zero means no count limit
+	MaxCount int `json:"max_count,omitempty"`
+	// MaxAmount is the largest total amount allowed in the window; nil means no amount limit
+	MaxAmount *Amount `json:"max_amount,omitempty"`
+}
+
+// Name identifies the limit by its currency and window, e.g. "USD/24h".
+func (l SpendingLimit) Name() string {
+	return l.Currency + "/" + l.Window.String()
+}
+
+// IsUnlimited reports whether the limit sets neither a count nor an amount cap.
+func (l SpendingLimit) IsUnlimited() bool {
+	return l.MaxCount == 0 && l.MaxAmount == nil
+}
+
+// SpendingTotal is the number and total amount of a sender's transactions in one currency over a window.
+type SpendingTotal struct {
+	// Count is the number of transactions
+	Count int `json:"count"`
+	// Amount is the sum of their amounts
+	Amount Amount `json:"amount"`
+}
+
+// EffectiveLimits merges a sender's overrides into the default limits. An override replaces the default
+// with the same currency and window and adds a limit otherwise; overrides without a cap only remove the default.
+// The result is ordered by currency, then window.
+func EffectiveLimits(defaults, overrides []SpendingLimit) []SpendingLimit {
+	byName := make(map[string]SpendingLimit, len(defaults)+len(overrides))
+	for _, limit := range defaults {
+		byName[limit.Name()] = limit
+	}
+	for _, limit := range overrides {
+		if limit.IsUnlimited() {
+			delete(byName, limit.Name())
+			continue
+		}
+		byName[limit.Name()] = limit
+	}
+
+	limits := make([]SpendingLimit, 0, len(byName))
+	for _, limit := range byName {
+		limits = append(limits, limit)
+	}
+	SortLimits(limits)
+	return limits
+}
+
+// SortLimits orders limits by currency, then window.
+func SortLimits(limits []SpendingLimit) {
+	slices.SortFunc(limits, func(a, b SpendingLimit) int {
+		return cmp.Or(strings.Compare(a.Currency, b.Currency), cmp.Compare(a.Window, b.Window))
+	})
+}
+
+// ParseSpendingLimits parses limits written as comma-separated CURRENCY/WINDOW=COUNT:AMOUNT entries, for example
+// "USD/1h=20:5000,USD/24h=100:20000". Either cap may be left out, as in "KES/30d=:1000000" or "EUR/1h=10".
+func ParseSpendingLimits(spec string) ([]SpendingLimit, error) {
+	var limits []SpendingLimit
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, caps, ok := strings.Cut(entry, "=")
+		code, window, hasWindow := strings.Cut(strings.TrimSpace(key), "/")
+		if !ok || !hasWindow {
+			return nil, fmt.Errorf("invalid spending limit %q: expected CURRENCY/WINDOW=COUNT:AMOUNT", entry)
+		}
+
+		limit := SpendingLimit{Currency: strings.ToUpper(strings.TrimSpace(code))}
+		if _, ok := currency.Exponent(limit.Currency); !ok {
+			return nil, fmt.Errorf("invalid spending limit %q: unknown currency %q", entry, code)
+		}
+		var err error
+		if limit.Window, err = ParseLimitWindow(strings.TrimSpace(window)); err != nil {
+			return nil, fmt.Errorf("invalid spending limit %q: %w", entry, err)
+		}
+		if seen[limit.Name()] {
+			return nil, fmt.Errorf("duplicate spending limit for %s", limit.Name())
+		}
+		seen[limit.Name()] = true
+
+		count, amount, _ := strings.Cut(caps, ":")
+		if count = strings.TrimSpace(count); count != "" {
+			if limit.MaxCount, err = strconv.Atoi(count); err != nil || limit.MaxCount <= 0 {
+				return nil, fmt.Errorf("invalid spending limit %q: count must be a positive integer", entry)
+			}
+		}
+		if amount = strings.TrimSpace(amount); amount != "" {
+			parsed, err := ParseAmount(amount)
+			if err != nil || parsed.Sign() <= 0 {
+				return nil, fmt.Errorf("invalid spending limit %q: amount must be positive", entry)
+			}
+			limit.MaxAmount = &parsed
+		}
+		if limit.IsUnlimited() {
+			return nil, fmt.Errorf("spending limit for %s sets no limit", limit.Name())
+		}
+		limits = append(limits, limit)
+	}
+	SortLimits(limits)
+	return limits, nil
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimitWindow(t *testing.T) {
+	tests := []struct {
+		input  string
+		want   time.Duration
+		format string
+	}{
+		{"15m", 15 * time.Minute, "15m"},
+		{"90m", 90 * time.Minute, "90m"},
+		{"60m", time.Hour, "1h"},
+		{"1h", time.Hour, "1h"},
+		{"24h", 24 * time.Hour, "24h"},
+		{"1d", 24 * time.Hour, "24h"},
+		{"48h", 48 * time.Hour, "2d"},
+		{"36h", 36 * time.Hour, "36h"},
+		{"30d", 30 * 24 * time.Hour, "30d"},
+		{"366d", 366 * 24 * time.Hour, "366d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			window, err := ParseLimitWindow(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, window.Duration())
+			assert.True(t, window.IsValid())
+			assert.Equal(t, tt.format, window.String())
+		})
+	}
+
+	for _, input := range []string{"", "h", "1", "0h", "-1h", "1.5h", "1w", "367d", "1 h"} {
+		_, err := ParseLimitWindow(input)
+		assert.ErrorIs(t, err, ErrInvalidLimitWindow, input)
+	}
+}
+
+func TestLimitWindow_JSON(t *testing.T) {
+	data, err := json.Marshal(SpendingLimit{Currency: "USD", Window: LimitWindow(time.Hour), MaxCount: 5})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"currency":"USD","window":"1h","max_count":5}`, string(data))
+
+	var limit SpendingLimit
+	require.NoError(t, json.Unmarshal([]byte(`{"currency":"EUR","window":"30d","max_amount":"500.00"}`), &limit))
+	assert.Equal(t, LimitWindow(30*24*time.Hour), limit.Window)
+	assert.Equal(t, "500.00", limit.MaxAmount.String())
+	assert.Equal(t, "EUR/30d", limit.Name())
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"window":3600}`), &limit), ErrInvalidLimitWindow)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"window":"1y"}`), &limit), ErrInvalidLimitWindow)
+}
+
+func TestParseSpendingLimits(t *testing.T) {
+	limits, err := ParseSpendingLimits(" usd/24h=100:20000, KES/30d=:1000000,USD/1h=20 ,")
+	require.NoError(t, err)
+	require.Len(t, limits, 3)
+
+	assert.Equal(t, "KES/30d", limits[0].Name())
+	assert.Zero(t, limits[0].MaxCount)
+	assert.Equal(t, "1000000", limits[0].MaxAmount.String())
+	assert.Equal(t, "USD/1h", limits[1].Name(), "ordered by currency, then window")
+	assert.Equal(t, 20, limits[1].MaxCount)
+	assert.Nil(t, limits[1].MaxAmount)
+	assert.Equal(t, "USD/24h", limits[2].Name())
+	assert.Equal(t, 100, limits[2].MaxCount)
+	assert.Equal(t, "20000", limits[2].MaxAmount.String())
+
+	empty, err := ParseSpendingLimits("")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	for _, spec := range []string{
+		"USD=10", "USD/1h", "XXX/1h=10", "USD/1y=10", "USD/1h=0", "USD/1h=abc",
+		"USD/1h=:-5", "USD/1h=:", "USD/1h=1,usd/60m=2",
+	} {
+		_, err := ParseSpendingLimits(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestEffectiveLimits(t *testing.T) {
+	hour := LimitWindow(time.Hour)
+	day := LimitWindow(24 * time.Hour)
+	amount := MustParseAmount("500")
+	defaults := []SpendingLimit{
+		{Currency: "USD", Window: hour, MaxCount: 10},
+		{Currency: "USD", Window: day, MaxCount: 50},
+		{Currency: "EUR", Window: day, MaxAmount: &amount},
+	}
+
+	assert.Equal(t, []SpendingLimit{defaults[2], defaults[0], defaults[1]}, EffectiveLimits(defaults, nil))
+
+	overrides := []SpendingLimit{
+		{Currency: "USD", Window: hour, MaxCount: 100},
+		{Currency: "EUR", Window: day},
+		{Currency: "KES", Window: hour, MaxCount: 3},
+	}
+	assert.Equal(t, []SpendingLimit{
+		{Currency: "KES", Window: hour, MaxCount: 3},
+		{Currency: "USD", Window: hour, MaxCount: 100},
+		{Currency: "USD", Window: day, MaxCount: 50},
+	}, EffectiveLimits(defaults, overrides))
+}
@@ -0,0 +1,262 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxExponent is the largest number of decimal places an Amount may carry.
+	// ISO 4217 does not define any currency with more than four minor-unit digits.
+	maxExponent = 4
+	// maxUnits is the largest magnitude of minor units that fits in an int64
+	maxUnits = int64(1<<63 - 1)
+)
+
+var (
+	// ErrInvalidAmount is returned when a value cannot be parsed as a decimal amount
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrAmountPrecision is returned when an amount has more decimal places than allowed
+	ErrAmountPrecision = errors.New("amount has too many decimal places")
+)
+
+// Amount is an exact monetary value stored as an integer number of minor units.
+// The exponent records how many of those digits are decimal places, so an Amount
+// of 10050 units with exponent 2 represents 100.50.
+type Amount struct {
+	units    int64
+	exponent int
+}
+
+// NewAmount creates an Amount from a number of minor units and a currency exponent.
+func NewAmount(units int64, exponent int) Amount {
+	return Amount{units: units, exponent: exponent}
+}
+
+// ParseAmount parses a plain decimal string such as "100.50" into an Amount.
+// The exponent of the result is the number of digits after the decimal point.
+// Exponents, signs other than a leading minus, and more than four decimal places are rejected.
+func ParseAmount(s string) (Amount, error) {
+	if s == "" {
+		return Amount{}, fmt.Errorf("%w: empty value", ErrInvalidAmount)
+	}
+
+	digits := s
+	negative := false
+	if strings.HasPrefix(digits, "-") {
+		negative = true
+		digits = digits[1:]
+	}
+
+	intPart, fracPart, hasPoint := strings.Cut(digits, ".")
+	if intPart == "" || (hasPoint && fracPart == "") || !isDigits(intPart) || !isDigits(fracPart) {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if len(fracPart) > maxExponent {
+		return Amount{}, fmt.Errorf("%w: %q", ErrAmountPrecision, s)
+	}
+
+	units, err := strconv.ParseInt(intPart+fracPart, 10, 64)
+	if err != nil {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if negative {
+		units = -units
+	}
+
+	return Amount{units: units, exponent: len(fracPart)}, nil
+}
+
+// MustParseAmount is like ParseAmount but panics if the value cannot be parsed.
+// It is intended for constants and tests.
+func MustParseAmount(s string) Amount {
+	amount, err := ParseAmount(s)
+	if err != nil {
+		panic(err)
+	}
+	return amount
+}
+
+// Units returns the amount in minor units.
+func (a Amount) Units() int64 {
+	return a.units
+}
+
+// Exponent returns the number of decimal places the amount carries.
+func (a Amount) Exponent() int {
+	return a.exponent
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of the amount.
+func (a Amount) Sign() int {
+	switch {
+	case a.units < 0:
+		return -1
+	case a.units > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Cmp compares two amounts numerically regardless of their exponents.
+// It returns -1 if a < b, 0 if a == b and +1 if a > b.
+func (a Amount) Cmp(b Amount) int {
+	return a.rat().Cmp(b.rat())
+}
+
+// Add returns the sum of two amounts at the larger of their exponents.
+// It returns an error wrapping ErrInvalidAmount if the sum does not fit in an int64.
+func (a Amount) Add(b Amount) (Amount, error) {
+	exponent := max(a.exponent, b.exponent)
+	a, err := a.Rescale(exponent)
+	if err != nil {
+		return Amount{}, err
+	}
+	b, err = b.Rescale(exponent)
+	if err != nil {
+		return Amount{}, err
+	}
+
+	sum := a.units + b.units
+	if (b.units > 0 && sum < a.units) || (b.units < 0 && sum > a.units) {
+		return Amount{}, fmt.Errorf("%w: %s + %s overflows", ErrInvalidAmount, a, b)
+	}
+	return Amount{units: sum, exponent: exponent}, nil
+}
+
+// Neg returns the amount with its sign flipped.
+func (a Amount) Neg() Amount {
+	return Amount{units: -a.units, exponent: a.exponent}
+}
+
+// Rescale converts the amount to the given exponent.
+// It returns ErrAmountPrecision if the conversion would drop non-zero digits.
+func (a Amount) Rescale(exponent int) (Amount, error) {
+	if exponent < 0 || exponent > maxExponent {
+		return Amount{}, fmt.Errorf("%w: unsupported exponent %d", ErrInvalidAmount, exponent)
+	}
+
+	units := a.units
+	for e := a.exponent; e < exponent; e++ {
+		if units > maxUnits/10 || units < -maxUnits/10 {
+			return Amount{}, fmt.Errorf("%w: %s overflows", ErrInvalidAmount, a)
+		}
+		units *= 10
+	}
+	for e := a.exponent; e > exponent; e-- {
+		if units%10 != 0 {
+			return Amount{}, fmt.Errorf("%w: %s allows at most %d", ErrAmountPrecision, a, exponent)
+		}
+		units /= 10
+	}
+
+	return Amount{units: units, exponent: exponent}, nil
+}
+
+// String formats the amount as a plain decimal string with exactly Exponent decimal places.
+func (a Amount) String() string {
+	units := a.units
+	sign := ""
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+
+	digits := strconv.FormatInt(units, 10)
+	if a.exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= a.exponent {
+		digits = strings.Repeat("0", a.exponent-len(digits)+1) + digits
+	}
+
+	point := len(digits) - a.exponent
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// MarshalJSON encodes the amount as a JSON string so that no precision is lost in transit.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.String())
+}
+
+// UnmarshalJSON decodes an amount from either a JSON string or a JSON number.
+// Numbers are parsed from their literal text rather than through float64.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+
+	amount, err := ParseAmount(text)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// Value implements driver.Valuer so the amount is stored as an exact decimal string.
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Scan implements sql.Scanner for DECIMAL columns.
+func (a *Amount) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("%w: cannot scan %T into Amount", ErrInvalidAmount, src)
+	}
+
+	amount, err := ParseAmount(trimTrailingZeros(text))
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
+
+// rat returns the amount as an exact rational number.
+func (a Amount) rat() *big.Rat {
+	denominator := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(a.exponent)), nil)
+	return new(big.Rat).SetFrac(big.NewInt(a.units), denominator)
+}
+
+// trimTrailingZeros removes insignificant zeros after the decimal point,
+// so that a DECIMAL(19,4) column value like "100.5000" parses as "100.5".
+func trimTrailingZeros(s string) string {
+	if !strings.Contains(s, ".") {
+		return s
+	}
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// isDigits reports whether s consists solely of ASCII digits.
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
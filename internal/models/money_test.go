@@ -0,0 +1,123 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		input    string
+		units    int64
+		exponent int
+		wantErr  error
+	}{
+		{input: "100.50", units: 10050, exponent: 2},
+		{input: "100", units: 100, exponent: 0},
+		{input: "0.001", units: 1, exponent: 3},
+		{input: "-12.5", units: -125, exponent: 1},
+		{input: "", wantErr: ErrInvalidAmount},
+		{input: "1e3", wantErr: ErrInvalidAmount},
+		{input: "12.", wantErr: ErrInvalidAmount},
+		{input: ".5", wantErr: ErrInvalidAmount},
+		{input: "+5", wantErr: ErrInvalidAmount},
+		{input: "1.23456", wantErr: ErrAmountPrecision},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			amount, err := ParseAmount(tt.input)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.units, amount.Units())
+			assert.Equal(t, tt.exponent, amount.Exponent())
+		})
+	}
+}
+
+func TestAmount_Rescale(t *testing.T) {
+	t.Run("adds decimal places", func(t *testing.T) {
+		amount, err := MustParseAmount("12.5").Rescale(2)
+		require.NoError(t, err)
+		assert.Equal(t, NewAmount(1250, 2), amount)
+	})
+
+	t.Run("drops trailing zeros", func(t *testing.T) {
+		amount, err := MustParseAmount("1500.00").Rescale(0)
+		require.NoError(t, err)
+		assert.Equal(t, NewAmount(1500, 0), amount)
+	})
+
+	t.Run("refuses to truncate", func(t *testing.T) {
+		_, err := MustParseAmount("12.345").Rescale(2)
+		assert.ErrorIs(t, err, ErrAmountPrecision)
+	})
+}
+
+func TestAmount_Add(t *testing.T) {
+	t.Run("aligns exponents", func(t *testing.T) {
+		sum, err := MustParseAmount("12.5").Add(MustParseAmount("0.25"))
+		require.NoError(t, err)
+		assert.Equal(t, NewAmount(1275, 2), sum)
+	})
+
+	t.Run("negative operands", func(t *testing.T) {
+		sum, err := MustParseAmount("10.00").Add(MustParseAmount("25.50").Neg())
+		require.NoError(t, err)
+		assert.Equal(t, "-15.50", sum.String())
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		_, err := NewAmount(maxUnits, 0).Add(NewAmount(1, 0))
+		assert.ErrorIs(t, err, ErrInvalidAmount)
+	})
+}
+
+func TestAmount_String(t *testing.T) {
+	assert.Equal(t, "100.50", NewAmount(10050, 2).String())
+	assert.Equal(t, "0.05", NewAmount(5, 2).String())
+	assert.Equal(t, "-0.005", NewAmount(-5, 3).String())
+	assert.Equal(t, "1500", NewAmount(1500, 0).String())
+}
+
+func TestAmount_Cmp(t *testing.T) {
+	assert.Equal(t, 0, MustParseAmount("0.3").Cmp(MustParseAmount("0.30")))
+	assert.Equal(t, -1, MustParseAmount("99999999.99").Cmp(MustParseAmount("100000000")))
+	assert.Equal(t, 1, MustParseAmount("0.001").Cmp(NewAmount(0, 2)))
+}
+
+func TestAmount_JSON(t *testing.T) {
+	t.Run("encodes as string", func(t *testing.T) {
+		data, err := json.Marshal(NewAmount(10050, 2))
+		require.NoError(t, err)
+		assert.Equal(t, `"100.50"`, string(data))
+	})
+
+	t.Run("decodes strings and numbers exactly", func(t *testing.T) {
+		var amounts []Amount
+		require.NoError(t, json.Unmarshal([]byte(`["0.1", 0.2, 12.345]`), &amounts))
+		assert.Equal(t, []Amount{NewAmount(1, 1), NewAmount(2, 1), NewAmount(12345, 3)}, amounts)
+	})
+
+	t.Run("rejects malformed values", func(t *testing.T) {
+		var amount Amount
+		assert.Error(t, json.Unmarshal([]byte(`"ten"`), &amount))
+	})
+}
+
+func TestAmount_Scan(t *testing.T) {
+	var amount Amount
+	require.NoError(t, amount.Scan([]byte("100.5000")))
+	assert.Equal(t, NewAmount(1005, 1), amount)
+
+	require.NoError(t, amount.Scan(int64(42)))
+	assert.Equal(t, NewAmount(42, 0), amount)
+
+	assert.Error(t, amount.Scan("not-a-number"))
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Types of the events written to the outbox.
+const (
+	// EventTransactionCreated is written when a transaction is created
+	EventTransactionCreated = "transaction.created"
+	// EventTransactionStatusChanged is written whenever a transaction moves to a new status
+	EventTransactionStatusChanged = "transaction.status_changed"
+)
+
+// OutboxEvent is a change to a transaction, written to the outbox in the same database transaction
+// as the change itself so that it is published if and only if the change commits.
+// Its JSON form is the body of the webhooks that deliver it.
+type OutboxEvent struct {
+	// ID orders the events; it increases with every event written
+	ID int64 `json:"id"`
+	// Type is one of the Event* constants
+	Type string `json:"type"`
+	// TransactionID is the transaction the event is about
+	TransactionID string `json:"transaction_id"`
+	// Data is the JSON-encoded TransactionEventData describing the change
+	Data json.RawMessage `json:"data"`
+	// CreatedAt is when the event was written
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TransactionEventData is the data of a transaction event: the transaction as it was after the change,
+// and for status changes the details of the change.
+type TransactionEventData struct {
+	// Transaction is the transaction after the change
+	Transaction Transaction `json:"transaction"`
+	// PreviousStatus is the status before a status change
+	PreviousStatus Status `json:"previous_status,omitempty"`
+	// Reason explains a status change, if one was given
+	Reason string `json:"reason,omitempty"`
+	// Actor identifies who requested a status change
+	Actor string `json:"actor,omitempty"`
+}
+
+// NewTransactionEvent builds an outbox event of the given type about a transaction.
+// The ID and creation time are assigned when the event is written.
+func NewTransactionEvent(eventType string, data TransactionEventData) (OutboxEvent, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return OutboxEvent{}, err
+	}
+	return OutboxEvent{Type: eventType, TransactionID: data.Transaction.ID, Data: encoded}, nil
+}
@@ -0,0 +1,22 @@
+package models
+
+// SenderTotal is the number and total amount of one sender's transactions in one currency.
+// Amounts in different currencies are never added together, so a sender has one total per currency.
+type SenderTotal struct {
+	// Sender is the account the transactions were sent from
+	Sender string `json:"sender"`
+	// Currency is the upper-case 3-letter ISO currency code of the transactions
+	Currency string `json:"currency"`
+	// Count is the number of transactions
+	Count int `json:"count"`
+	// Total is the sum of their amounts
+	Total Amount `json:"total"`
+}
+
+// SenderFailures is the number of failed transactions of one sender.
+type SenderFailures struct {
+	// Sender is the account the transactions were sent from
+	Sender string `json:"sender"`
+	// FailedCount is the number of the sender's transactions that failed
+	FailedCount int `json:"failed_count"`
+}
@@ -0,0 +1,47 @@
+package models
+
+// MaxScreenerNameLength is the longest screener name that can be stored with a screening decision.
+const MaxScreenerNameLength = 64
+
+// ScreeningDecision is the outcome of screening a transaction before it is accepted.
+type ScreeningDecision string
+
+const (
+	// ScreeningApprove accepts the transaction as pending
+	ScreeningApprove ScreeningDecision = "approve"
+	// ScreeningReview holds the transaction until a reviewer releases or rejects it
+	ScreeningReview ScreeningDecision = "review"
+	// ScreeningReject records the transaction as failed without processing it
+	ScreeningReject ScreeningDecision = "reject"
+)
+
+// IsValid reports whether the decision is one of the known screening decisions.
+func (d ScreeningDecision) IsValid() bool {
+	switch d {
+	case ScreeningApprove, ScreeningReview, ScreeningReject:
+		return true
+	}
+	return false
+}
+
+// Status returns the status a newly created transaction starts in after the decision.
+func (d ScreeningDecision) Status() Status {
+	switch d {
+	case ScreeningReview:
+		return StatusHeld
+	case ScreeningReject:
+		return StatusFailed
+	}
+	return StatusPending
+}
+
+// Screening records the decision made about a transaction before it was accepted.
+// It is stored with the transaction and never changes once the transaction exists.
+type Screening struct {
+	// Decision is the outcome of screening
+	Decision ScreeningDecision `json:"decision"`
+	// Screener names the check that held or rejected the transaction; it is empty when every check approved
+	Screener string `json:"screener,omitempty"`
+	// Reason explains a hold or a rejection
+	Reason string `json:"reason,omitempty"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidTransition is returned when a transaction cannot move from its current status to the requested one.
+var ErrInvalidTransition = errors.New("invalid status transition")
+
+// transitions is the transaction state machine.
+// Each status maps to the statuses it may move to; statuses with no entries are terminal.
+var transitions = map[Status][]Status{
+	StatusPending:    {StatusProcessing, StatusCompleted, StatusFailed},
+	StatusProcessing: {StatusCompleted, StatusFailed},
+	StatusCompleted:  {StatusReversed},
+	StatusFailed:     {},
+	StatusReversed:   {},
+	// A held transaction is released by its reviewer or rejected
+	StatusHeld: {StatusPending, StatusFailed},
+}
+
+// IsValid reports whether the status is one of the known transaction states.
+func (s Status) IsValid() bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// IsTerminal reports whether no further transitions are possible from the status.
+func (s Status) IsTerminal() bool {
+	return len(transitions[s]) == 0
+}
+
+// PostsToLedger reports whether moving a transaction to the status posts ledger entries.
+func (s Status) PostsToLedger() bool {
+	return s == StatusCompleted || s == StatusReversed
+}
+
+// CanTransitionTo reports whether the state machine allows moving from s to next.
+func (s Status) CanTransitionTo(next Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// SourceStatuses returns every status from which a transaction may move to target.
+// The result is ordered by the declaration of the state machine and is empty if target is unreachable.
+func SourceStatuses(target Status) []Status {
+	var sources []Status
+	for _, from := range []Status{StatusPending, StatusProcessing, StatusCompleted, StatusFailed, StatusReversed, StatusHeld} {
+		if from.CanTransitionTo(target) {
+			sources = append(sources, from)
+		}
+	}
+	return sources
+}
+
+// ValidateTransition returns an error wrapping ErrInvalidTransition if from cannot move to to.
+func ValidateTransition(from, to Status) error {
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+	return nil
+}
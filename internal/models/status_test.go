@@ -0,0 +1,60 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from, to Status
+		allowed  bool
+	}{
+		{StatusPending, StatusProcessing, true},
+		{StatusPending, StatusCompleted, true},
+		{StatusPending, StatusFailed, true},
+		{StatusProcessing, StatusCompleted, true},
+		{StatusProcessing, StatusFailed, true},
+		{StatusCompleted, StatusReversed, true},
+		{StatusCompleted, StatusFailed, false},
+		{StatusFailed, StatusCompleted, false},
+		{StatusReversed, StatusCompleted, false},
+		{StatusProcessing, StatusPending, false},
+		{StatusPending, StatusPending, false},
+		{StatusHeld, StatusPending, true},
+		{StatusHeld, StatusFailed, true},
+		{StatusHeld, StatusCompleted, false},
+		{StatusPending, StatusHeld, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			assert.Equal(t, tt.allowed, tt.from.CanTransitionTo(tt.to))
+		})
+	}
+}
+
+func TestSourceStatuses(t *testing.T) {
+	assert.Equal(t, []Status{StatusPending, StatusProcessing}, SourceStatuses(StatusCompleted))
+	assert.Equal(t, []Status{StatusCompleted}, SourceStatuses(StatusReversed))
+	assert.Equal(t, []Status{StatusHeld}, SourceStatuses(StatusPending))
+	assert.Equal(t, []Status{StatusPending, StatusProcessing, StatusHeld}, SourceStatuses(StatusFailed))
+	assert.Empty(t, SourceStatuses(StatusHeld), "only screening holds a transaction")
+}
+
+func TestValidateTransition(t *testing.T) {
+	assert.NoError(t, ValidateTransition(StatusPending, StatusCompleted))
+
+	err := ValidateTransition(StatusCompleted, StatusFailed)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	assert.Contains(t, err.Error(), "completed -> failed")
+}
+
+func TestStatus_IsTerminal(t *testing.T) {
+	assert.False(t, StatusPending.IsTerminal())
+	assert.False(t, StatusCompleted.IsTerminal())
+	assert.True(t, StatusFailed.IsTerminal())
+	assert.True(t, StatusReversed.IsTerminal())
+	assert.False(t, Status("unknown").IsValid())
+}
@@ -5,16 +5,22 @@ package models
 import "time"
 
 // Status represents the current state of a transaction.
-// Transactions can be in one of three states: pending, completed, or failed.
+// Allowed movements between states are defined by the transition table in status.go.
 type Status string
 
 const (
 	// StatusPending indicates a transaction that has been created but not yet processed
 	StatusPending Status = "pending"
+	// StatusProcessing indicates a transaction that has been picked up for processing
+	StatusProcessing Status = "processing"
 	// StatusCompleted indicates a transaction that has been successfully processed
 	StatusCompleted Status = "completed"
 	// StatusFailed indicates a transaction that failed during processing
 	StatusFailed Status = "failed"
+	// StatusReversed indicates a completed transaction whose funds have been returned
+	StatusReversed Status = "reversed"
+	// StatusHeld indicates a transaction that screening has held for manual review before it can proceed
+	StatusHeld Status = "held"
 )
 
 // Transaction represents a financial transaction between two parties.
@@ -22,8 +28,8 @@ const (
 type Transaction struct {
 	// ID is a unique identifier for the transaction (max 64 characters)
 	ID string `json:"id"`
-	// Amount is the monetary value of the transaction (must be positive)
-	Amount float64 `json:"amount"`
+	// Amount is the exact monetary value of the transaction in the currency's minor units (must be positive)
+	Amount Amount `json:"amount"`
 	// Currency is the 3-letter ISO currency code (e.g., USD, EUR, GBP)
 	Currency string `json:"currency"`
 	// Sender is the identifier of the party sending the money
@@ -32,6 +38,12 @@ type Transaction struct {
 	Receiver string `json:"receiver"`
 	// Status indicates the current state of the transaction
 	Status Status `json:"status"`
+	// FailureReason explains why the transaction failed; it is only set once the status is failed
+	FailureReason string `json:"failure_reason,omitempty"`
+	// Screening is the decision of the checks run before the transaction was accepted, if any ran
+	Screening *Screening `json:"screening,omitempty"`
+	// Conversion is the currency exchange applied when the receiver is paid in another currency, if any
+	Conversion *Conversion `json:"conversion,omitempty"`
 	// CreatedAt is the timestamp when the transaction was created
 	CreatedAt time.Time `json:"created_at"`
 }
@@ -0,0 +1,95 @@
+package models
+
+import "time"
+
+// MaxWebhookURLLength is the longest webhook endpoint URL that can be registered.
+const MaxWebhookURLLength = 2048
+
+// MaxDeliveryErrorLength is the longest delivery error stored with a webhook delivery; longer errors are truncated.
+const MaxDeliveryErrorLength = 1024
+
+// DeliveryStatus is the state of a webhook delivery.
+type DeliveryStatus string
+
+const (
+	// DeliveryPending deliveries are waiting for their next attempt
+	DeliveryPending DeliveryStatus = "pending"
+	// DeliveryDelivered deliveries were acknowledged with a 2xx response
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryDead deliveries ran out of attempts and are only retried by a manual redelivery
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+// IsValid reports whether the status is one of the known delivery states.
+func (s DeliveryStatus) IsValid() bool {
+	switch s {
+	case DeliveryPending, DeliveryDelivered, DeliveryDead:
+		return true
+	}
+	return false
+}
+
+// WebhookEndpoint is a URL that receives every outbox event as a signed POST request.
+type WebhookEndpoint struct {
+	// ID is the unique identifier of the endpoint
+	ID string `json:"id"`
+	// URL is the absolute http or https URL events are posted to
+	URL string `json:"url"`
+	// Secret is the key the HMAC-SHA256 signature of each delivery is computed with.
+	// It is only shown when the endpoint is created.
+	Secret string `json:"secret,omitempty"`
+	// Enabled endpoints receive new events and have their pending deliveries attempted
+	Enabled bool `json:"enabled"`
+	// CreatedAt is the timestamp when the endpoint was registered
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDelivery tracks the delivery of one outbox event to one endpoint.
+type WebhookDelivery struct {
+	// ID is the sequential identifier of the delivery; later deliveries have larger IDs
+	ID int64 `json:"id"`
+	// EventID is the outbox event being delivered
+	EventID int64 `json:"event_id"`
+	// EndpointID is the endpoint the event is delivered to
+	EndpointID string `json:"endpoint_id"`
+	// Status is the state of the delivery
+	Status DeliveryStatus `json:"status"`
+	// Attempts is the number of requests made since the delivery was created or last redelivered
+	Attempts int `json:"attempts"`
+	// NextAttemptAt is when a pending delivery is due to be attempted
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	// LastResponseCode is the HTTP status of the last attempt, or zero if it got no response
+	LastResponseCode int `json:"last_response_code,omitempty"`
+	// LastError describes why the last attempt failed
+	LastError string `json:"last_error,omitempty"`
+	// DeliveredAt is when the endpoint acknowledged the event
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	// CreatedAt is when the delivery was queued
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DeliveryAttempt is the outcome of one attempt to deliver a webhook.
+type DeliveryAttempt struct {
+	// Status is the state the delivery moves to
+	Status DeliveryStatus
+	// ResponseCode is the HTTP status returned by the endpoint, or zero if it did not respond
+	ResponseCode int
+	// Error describes why the attempt failed
+	Error string
+	// NextAttemptAt is when a delivery that stays pending is retried
+	NextAttemptAt time.Time
+	// AttemptedAt is when the attempt was made
+	AttemptedAt time.Time
+}
+
+// PendingDelivery is a claimed webhook delivery together with what is needed to attempt it.
+type PendingDelivery struct {
+	// Delivery is the delivery being attempted
+	Delivery WebhookDelivery
+	// Event is the outbox event to deliver
+	Event OutboxEvent
+	// URL is the endpoint's URL
+	URL string
+	// Secret is the endpoint's signing secret
+	Secret string
+}
@@ -0,0 +1,73 @@
+// Package pubsub provides an in-process publish/subscribe broker.
+package pubsub
+
+import "sync"
+
+// Broker fans published messages out to every current subscriber.
+// Publishing never blocks: a subscriber whose buffer is full misses the message, so messages are best
+// used as hints to re-read durable state rather than as the state itself.
+type Broker[T any] struct {
+	mu          sync.Mutex
+	subscribers map[chan T]struct{}
+	closed      bool
+}
+
+// NewBroker creates a Broker with no subscribers.
+func NewBroker[T any]() *Broker[T] {
+	return &Broker[T]{subscribers: make(map[chan T]struct{})}
+}
+
+// Subscribe registers a subscriber whose channel buffers up to size messages.
+// The channel is closed when the returned cancel function is called or the broker is closed.
+// Cancel may be called more than once.
+func (b *Broker[T]) Subscribe(size int) (<-chan T, func()) {
+	ch := make(chan T, size)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Publish sends a message to every subscriber with room in its buffer.
+func (b *Broker[T]) Publish(message T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- message:
+		default:
+		}
+	}
+}
+
+// Close closes every subscriber's channel. Later subscriptions are closed straight away and
+// later messages are dropped.
+func (b *Broker[T]) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
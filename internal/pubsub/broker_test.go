@@ -0,0 +1,62 @@
+package pubsub
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroker(t *testing.T) {
+	t.Run("fans out to every subscriber", func(t *testing.T) {
+		broker := NewBroker[string]()
+		first, cancelFirst := broker.Subscribe(1)
+		defer cancelFirst()
+		second, cancelSecond := broker.Subscribe(1)
+		defer cancelSecond()
+
+		broker.Publish("txn-1")
+
+		assert.Equal(t, "txn-1", <-first)
+		assert.Equal(t, "txn-1", <-second)
+	})
+
+	t.Run("a full subscriber misses messages without blocking", func(t *testing.T) {
+		broker := NewBroker[string]()
+		ch, cancel := broker.Subscribe(1)
+		defer cancel()
+
+		broker.Publish("txn-1")
+		broker.Publish("txn-2")
+
+		assert.Equal(t, "txn-1", <-ch)
+		assert.Empty(t, ch)
+	})
+
+	t.Run("cancel closes the channel once", func(t *testing.T) {
+		broker := NewBroker[string]()
+		ch, cancel := broker.Subscribe(1)
+
+		cancel()
+		cancel()
+		broker.Publish("txn-1")
+
+		_, ok := <-ch
+		assert.False(t, ok)
+	})
+
+	t.Run("close ends every subscription", func(t *testing.T) {
+		broker := NewBroker[string]()
+		ch, cancel := broker.Subscribe(1)
+		defer cancel()
+
+		broker.Close()
+		broker.Close()
+		_, ok := <-ch
+		assert.False(t, ok)
+
+		late, cancelLate := broker.Subscribe(1)
+		defer cancelLate()
+		_, ok = <-late
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,79 @@
+package screening
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// Blocklist rejects transactions sent by or to a listed party, such as one on a sanctions list.
+// Names are compared case-insensitively with runs of whitespace collapsed.
+type Blocklist struct {
+	parties map[string]bool
+}
+
+// NewBlocklist creates a Blocklist of the given parties.
+func NewBlocklist(parties ...string) *Blocklist {
+	b := &Blocklist{parties: make(map[string]bool, len(parties))}
+	for _, party := range parties {
+		if key := blocklistKey(party); key != "" {
+			b.parties[key] = true
+		}
+	}
+	return b
+}
+
+// LoadBlocklist reads a Blocklist from a file with one party per line.
+// Blank lines and lines starting with # are ignored.
+func LoadBlocklist(path string) (*Blocklist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blocklist: %w", err)
+	}
+	defer file.Close()
+
+	var parties []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parties = append(parties, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read blocklist %s: %w", path, err)
+	}
+
+	return NewBlocklist(parties...), nil
+}
+
+// Len returns the number of parties on the list.
+func (b *Blocklist) Len() int {
+	return len(b.parties)
+}
+
+// Name identifies the blocklist in stored decisions.
+func (b *Blocklist) Name() string {
+	return "blocklist"
+}
+
+// Screen rejects the transaction if its sender or receiver is on the list.
+func (b *Blocklist) Screen(ctx context.Context, transaction models.Transaction) (models.Screening, error) {
+	if b.parties[blocklistKey(transaction.Sender)] {
+		return models.Screening{Decision: models.ScreeningReject, Reason: "sender is on the blocklist"}, nil
+	}
+	if b.parties[blocklistKey(transaction.Receiver)] {
+		return models.Screening{Decision: models.ScreeningReject, Reason: "receiver is on the blocklist"}, nil
+	}
+	return approve, nil
+}
+
+// blocklistKey normalizes a party name for comparison.
+func blocklistKey(party string) string {
+	return strings.ToLower(strings.Join(strings.Fields(party), " "))
+}
@@ -0,0 +1,49 @@
+package screening
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# Sanctioned parties\n\nAcme  Shell Corp\n  mallory \n"), 0o600))
+
+	blocklist, err := LoadBlocklist(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, blocklist.Len())
+
+	_, err = LoadBlocklist(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestBlocklist_Screen(t *testing.T) {
+	blocklist := NewBlocklist("Acme Shell Corp", "mallory", "  ")
+	assert.Equal(t, 2, blocklist.Len(), "blank names are ignored")
+
+	tests := []struct {
+		name     string
+		sender   string
+		receiver string
+		want     models.Screening
+	}{
+		{"clean", "alice", "bob", approve},
+		{"blocked sender", "Mallory", "bob", models.Screening{Decision: models.ScreeningReject, Reason: "sender is on the blocklist"}},
+		{"blocked receiver", "alice", " acme   shell corp", models.Screening{Decision: models.ScreeningReject, Reason: "receiver is on the blocklist"}},
+		{"partial names do not match", "mallory ltd", "acme", approve},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := blocklist.Screen(context.Background(), models.Transaction{Sender: tt.sender, Receiver: tt.receiver})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
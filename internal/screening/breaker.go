@@ -0,0 +1,82 @@
+package screening
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of calling a dependency whose circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// Breaker is a circuit breaker that stops calls to a failing dependency.
+// After Threshold consecutive failures it opens and fails calls straight away. Once Cooldown has passed
+// it lets a single trial call through: success closes it again, and failure keeps it open for another Cooldown.
+type Breaker struct {
+	// Threshold is the number of consecutive failures that opens the breaker
+	Threshold int
+	// Cooldown is how long the breaker stays open before a trial call
+	Cooldown time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	// trial is set while the trial call of an open breaker is in flight
+	trial bool
+	// now returns the current time; tests replace it
+	now func() time.Time
+}
+
+// NewBreaker creates a closed Breaker.
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{Threshold: threshold, Cooldown: cooldown, now: time.Now}
+}
+
+// Allow reports whether a call may proceed, returning ErrCircuitOpen if not.
+// Every allowed call must be followed by a call to Record with its outcome, or to Release if it has none.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// A breaker without a threshold never opens
+	if b.Threshold <= 0 || b.failures < b.Threshold {
+		return nil
+	}
+	if b.trial || b.clock().Sub(b.openedAt) < b.Cooldown {
+		return ErrCircuitOpen
+	}
+	b.trial = true
+	return nil
+}
+
+// Record reports the outcome of an allowed call; a nil error is a success.
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+	if err == nil {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.Threshold {
+		b.openedAt = b.clock()
+	}
+}
+
+// Release ends an allowed call whose outcome says nothing about the dependency, such as one its caller abandoned.
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+}
+
+// clock returns the current time from the breaker's clock.
+func (b *Breaker) clock() time.Time {
+	if b.now == nil {
+		return time.Now()
+	}
+	return b.now()
+}
@@ -0,0 +1,99 @@
+package screening
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// maxRemoteResponse is the largest response read from a remote screener.
+const maxRemoteResponse = 64 << 10
+
+// remoteResponse is the body a remote screener answers with.
+type remoteResponse struct {
+	Decision models.ScreeningDecision `json:"decision"`
+	Reason   string                   `json:"reason"`
+}
+
+// Remote asks an external HTTP service, such as an AML provider, to screen transactions.
+// The transaction is POSTed as JSON to URL, and the service answers 200 with a body such as
+// {"decision": "review", "reason": "name matches a watch list"}, where decision is approve, review or reject.
+// Calls are bounded by the client's timeout, and a circuit breaker stops calls while the service is failing.
+type Remote struct {
+	URL    string
+	Client *http.Client
+	// Breaker guards the service; calls it refuses fail with ErrCircuitOpen
+	Breaker *Breaker
+}
+
+// NewRemote creates a Remote screener with a 2 second timeout whose breaker opens after 5 consecutive
+// failures for 30 seconds.
+func NewRemote(url string) *Remote {
+	return &Remote{
+		URL:     url,
+		Client:  &http.Client{Timeout: 2 * time.Second},
+		Breaker: NewBreaker(5, 30*time.Second),
+	}
+}
+
+// Name identifies the remote screener in stored decisions.
+func (r *Remote) Name() string {
+	return "remote"
+}
+
+// Screen asks the service for its decision. Errors and non-200 responses count as failures of the service,
+// except when ctx itself is done.
+func (r *Remote) Screen(ctx context.Context, transaction models.Transaction) (models.Screening, error) {
+	if err := r.Breaker.Allow(); err != nil {
+		return models.Screening{}, err
+	}
+
+	decision, err := r.call(ctx, transaction)
+	if err != nil && ctx.Err() != nil {
+		// The caller gave up; that says nothing about the service's health
+		r.Breaker.Release()
+		return models.Screening{}, err
+	}
+	r.Breaker.Record(err)
+	return decision, err
+}
+
+// call posts the transaction to the service and decodes its decision.
+func (r *Remote) call(ctx context.Context, transaction models.Transaction) (models.Screening, error) {
+	body, err := json.Marshal(transaction)
+	if err != nil {
+		return models.Screening{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(body))
+	if err != nil {
+		return models.Screening{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return models.Screening{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxRemoteResponse))
+		return models.Screening{}, fmt.Errorf("screening service responded with %s", resp.Status)
+	}
+
+	var response remoteResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxRemoteResponse)).Decode(&response); err != nil {
+		return models.Screening{}, fmt.Errorf("invalid screening response: %w", err)
+	}
+	if !response.Decision.IsValid() {
+		return models.Screening{}, fmt.Errorf("invalid screening response: unknown decision %q", response.Decision)
+	}
+	return models.Screening{Decision: response.Decision, Reason: response.Reason}, nil
+}
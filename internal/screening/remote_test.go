@@ -0,0 +1,145 @@
+package screening
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemote_Screen(t *testing.T) {
+	transaction := models.Transaction{ID: "txn-1", Amount: models.MustParseAmount("10.00"), Currency: "USD", Sender: "alice", Receiver: "bob"}
+
+	t.Run("returns the service's decision", func(t *testing.T) {
+		var received models.Transaction
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+			w.Write([]byte(`{"decision":"review","reason":"name matches a watch list"}`))
+		}))
+		defer server.Close()
+
+		result, err := NewRemote(server.URL).Screen(context.Background(), transaction)
+		require.NoError(t, err)
+		assert.Equal(t, models.Screening{Decision: models.ScreeningReview, Reason: "name matches a watch list"}, result)
+		assert.Equal(t, "txn-1", received.ID)
+		assert.Equal(t, "10.00", received.Amount.String())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			handler http.HandlerFunc
+		}{
+			{"server error", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadGateway) }},
+			{"malformed body", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`not json`)) }},
+			{"unknown decision", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{"decision":"maybe"}`)) }},
+			{"timeout", func(w http.ResponseWriter, r *http.Request) { time.Sleep(200 * time.Millisecond) }},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				server := httptest.NewServer(tt.handler)
+				defer server.Close()
+
+				remote := NewRemote(server.URL)
+				remote.Client.Timeout = 50 * time.Millisecond
+				_, err := remote.Screen(context.Background(), transaction)
+				assert.Error(t, err)
+			})
+		}
+	})
+
+	t.Run("the breaker stops calls to a failing service", func(t *testing.T) {
+		calls := 0
+		healthy := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if !healthy {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"decision":"approve"}`))
+		}))
+		defer server.Close()
+
+		now := time.Now()
+		remote := NewRemote(server.URL)
+		remote.Breaker = NewBreaker(2, time.Minute)
+		remote.Breaker.now = func() time.Time { return now }
+
+		for i := 0; i < 2; i++ {
+			_, err := remote.Screen(context.Background(), transaction)
+			assert.Error(t, err)
+		}
+		_, err := remote.Screen(context.Background(), transaction)
+		assert.ErrorIs(t, err, ErrCircuitOpen)
+		assert.Equal(t, 2, calls)
+
+		// After the cooldown a trial call is let through and closes the breaker
+		healthy = true
+		now = now.Add(time.Minute)
+		result, err := remote.Screen(context.Background(), transaction)
+		require.NoError(t, err)
+		assert.Equal(t, models.ScreeningApprove, result.Decision)
+		_, err = remote.Screen(context.Background(), transaction)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("a cancelled caller does not trip the breaker", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		remote := NewRemote(server.URL)
+		remote.Breaker = NewBreaker(2, time.Minute)
+		remote.Breaker.Record(errors.New("unavailable"))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := remote.Screen(ctx, transaction)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NoError(t, remote.Breaker.Allow())
+		remote.Breaker.Record(errors.New("unavailable"))
+		assert.ErrorIs(t, remote.Breaker.Allow(), ErrCircuitOpen, "nor does it reset earlier failures")
+	})
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	breaker := NewBreaker(3, time.Minute)
+	breaker.now = func() time.Time { return now }
+	failure := errors.New("unavailable")
+
+	// A success resets the count of consecutive failures
+	for _, err := range []error{failure, failure, nil, failure, failure} {
+		require.NoError(t, breaker.Allow())
+		breaker.Record(err)
+	}
+	require.NoError(t, breaker.Allow())
+	breaker.Record(failure)
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+
+	// Only one trial call at a time, and a failed trial reopens the breaker
+	now = now.Add(time.Minute)
+	require.NoError(t, breaker.Allow())
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+	breaker.Record(failure)
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+
+	now = now.Add(time.Minute)
+	require.NoError(t, breaker.Allow())
+	breaker.Record(nil)
+	assert.NoError(t, breaker.Allow())
+
+	disabled := NewBreaker(0, time.Minute)
+	disabled.Record(failure)
+	assert.NoError(t, disabled.Allow())
+}
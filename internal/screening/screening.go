@@ -0,0 +1,90 @@
+// Package screening checks transactions for fraud and AML risk before they are accepted.
+// A Screener approves, rejects or holds a transaction for manual review; a Pipeline combines several.
+package screening
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// Screener checks a transaction before it is accepted.
+type Screener interface {
+	// Name identifies the screener in the decisions stored with transactions
+	Name() string
+	// Screen returns the decision about a transaction; an error means that no decision could be made
+	Screen(ctx context.Context, transaction models.Transaction) (models.Screening, error)
+}
+
+// approve is the decision of a screener with no objection to a transaction.
+var approve = models.Screening{Decision: models.ScreeningApprove}
+
+// Pipeline runs screeners in order and combines their decisions.
+// The first rejection wins and stops the pipeline; otherwise the first hold wins; otherwise the transaction is approved.
+// A screener that fails holds the transaction for review, so that an outage neither lets unchecked
+// transactions through nor turns customers away.
+type Pipeline struct {
+	Screeners []Screener
+}
+
+// NewPipeline creates a Pipeline that runs the screeners in the given order.
+func NewPipeline(screeners ...Screener) *Pipeline {
+	return &Pipeline{Screeners: screeners}
+}
+
+// Name identifies decisions made by the pipeline itself, which are always approvals.
+func (p *Pipeline) Name() string {
+	return "pipeline"
+}
+
+// Screen runs the pipeline and names the screener behind a hold or rejection. It never returns an error.
+func (p *Pipeline) Screen(ctx context.Context, transaction models.Transaction) (models.Screening, error) {
+	result := approve
+	for _, screener := range p.Screeners {
+		decision, err := screener.Screen(ctx, transaction)
+		if err == nil && !decision.Decision.IsValid() {
+			err = fmt.Errorf("unknown screening decision %q", decision.Decision)
+		}
+		if err != nil {
+			log.Printf("screening transaction %s: %v", transaction.ID, err)
+			decision = models.Screening{Decision: models.ScreeningReview, Reason: "screening unavailable: " + err.Error()}
+		}
+		// A nested pipeline has already named the screener behind its decision
+		if decision.Screener == "" {
+			decision.Screener = screener.Name()
+		}
+
+		switch decision.Decision {
+		case models.ScreeningReject:
+			return normalize(decision), nil
+		case models.ScreeningReview:
+			if result.Decision == models.ScreeningApprove {
+				result = decision
+			}
+		}
+	}
+	return normalize(result), nil
+}
+
+// normalize cuts a decision's screener name and reason to the lengths that can be stored.
+func normalize(decision models.Screening) models.Screening {
+	if decision.Decision == models.ScreeningApprove {
+		return approve
+	}
+	decision.Screener = truncate(decision.Screener, models.MaxScreenerNameLength)
+	decision.Reason = truncate(decision.Reason, models.MaxFailureReasonLength)
+	return decision
+}
+
+// truncate shortens s to at most n bytes without splitting a UTF-8 sequence.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && s[n]&0xC0 == 0x80 {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,111 @@
+package screening
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedScreener returns the same decision for every transaction and counts its calls.
+type fixedScreener struct {
+	name     string
+	decision models.Screening
+	err      error
+	calls    int
+}
+
+func (s *fixedScreener) Name() string {
+	return s.name
+}
+
+func (s *fixedScreener) Screen(ctx context.Context, transaction models.Transaction) (models.Screening, error) {
+	s.calls++
+	return s.decision, s.err
+}
+
+func decide(decision models.ScreeningDecision, reason string) models.Screening {
+	return models.Screening{Decision: decision, Reason: reason}
+}
+
+func TestPipeline_Screen(t *testing.T) {
+	ctx := context.Background()
+	transaction := models.Transaction{ID: "txn-1"}
+
+	t.Run("approves when every screener approves", func(t *testing.T) {
+		first := &fixedScreener{name: "first", decision: approve}
+		second := &fixedScreener{name: "second", decision: approve}
+
+		result, err := NewPipeline(first, second).Screen(ctx, transaction)
+		require.NoError(t, err)
+		assert.Equal(t, models.Screening{Decision: models.ScreeningApprove}, result)
+		assert.Equal(t, 1, second.calls)
+	})
+
+	t.Run("an empty pipeline approves", func(t *testing.T) {
+		result, err := NewPipeline().Screen(ctx, transaction)
+		require.NoError(t, err)
+		assert.Equal(t, models.ScreeningApprove, result.Decision)
+	})
+
+	t.Run("the first rejection wins and stops the pipeline", func(t *testing.T) {
+		review := &fixedScreener{name: "review", decision: decide(models.ScreeningReview, "large amount")}
+		reject := &fixedScreener{name: "reject", decision: decide(models.ScreeningReject, "sanctioned")}
+		after := &fixedScreener{name: "after", decision: approve}
+
+		result, err := NewPipeline(review, reject, after).Screen(ctx, transaction)
+		require.NoError(t, err)
+		assert.Equal(t, models.Screening{Decision: models.ScreeningReject, Screener: "reject", Reason: "sanctioned"}, result)
+		assert.Zero(t, after.calls)
+	})
+
+	t.Run("the first hold wins over later holds", func(t *testing.T) {
+		first := &fixedScreener{name: "first", decision: decide(models.ScreeningReview, "one")}
+		second := &fixedScreener{name: "second", decision: decide(models.ScreeningReview, "two")}
+
+		result, err := NewPipeline(first, second).Screen(ctx, transaction)
+		require.NoError(t, err)
+		assert.Equal(t, models.Screening{Decision: models.ScreeningReview, Screener: "first", Reason: "one"}, result)
+		assert.Equal(t, 1, second.calls, "later screeners still run in case they reject")
+	})
+
+	t.Run("a failing screener holds the transaction", func(t *testing.T) {
+		failing := &fixedScreener{name: "remote", err: errors.New("connection refused")}
+
+		result, err := NewPipeline(failing).Screen(ctx, transaction)
+		require.NoError(t, err)
+		assert.Equal(t, models.ScreeningReview, result.Decision)
+		assert.Equal(t, "remote", result.Screener)
+		assert.Equal(t, "screening unavailable: connection refused", result.Reason)
+	})
+
+	t.Run("an unknown decision holds the transaction", func(t *testing.T) {
+		odd := &fixedScreener{name: "odd", decision: decide("maybe", "")}
+
+		result, err := NewPipeline(odd).Screen(ctx, transaction)
+		require.NoError(t, err)
+		assert.Equal(t, models.ScreeningReview, result.Decision)
+		assert.Contains(t, result.Reason, `unknown screening decision "maybe"`)
+	})
+
+	t.Run("nested pipelines keep the inner screener's name", func(t *testing.T) {
+		inner := NewPipeline(&fixedScreener{name: "inner", decision: decide(models.ScreeningReject, "no")})
+
+		result, err := NewPipeline(inner).Screen(ctx, transaction)
+		require.NoError(t, err)
+		assert.Equal(t, "inner", result.Screener)
+	})
+
+	t.Run("reasons are cut to the stored length", func(t *testing.T) {
+		long := &fixedScreener{name: "long", decision: decide(models.ScreeningReject, strings.Repeat("é", models.MaxFailureReasonLength))}
+
+		result, err := NewPipeline(long).Screen(ctx, transaction)
+		require.NoError(t, err)
+		assert.Len(t, result.Reason, models.MaxFailureReasonLength)
+		assert.True(t, strings.HasSuffix(result.Reason, "é"), "multi-byte characters are not split")
+	})
+}
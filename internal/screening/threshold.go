@@ -0,0 +1,102 @@
+package screening
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// Threshold holds the amount limits for one currency. Either limit may be nil.
+type Threshold struct {
+	// Review holds transactions for review when their amount is greater than it
+	Review *models.Amount
+	// Reject rejects transactions when their amount is greater than it
+	Reject *models.Amount
+}
+
+// Thresholds screens transactions against amount limits per currency.
+// Transactions in a currency without limits are approved.
+type Thresholds map[string]Threshold
+
+// ParseThresholds parses limits written as comma-separated CURRENCY=REVIEW:REJECT entries, for example
+// "USD=10000:50000,KES=1000000". Either amount may be left out, as in "EUR=:20000" or "GBP=5000".
+func ParseThresholds(spec string) (Thresholds, error) {
+	thresholds := make(Thresholds)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		currency, limits, ok := strings.Cut(entry, "=")
+		currency = strings.ToUpper(strings.TrimSpace(currency))
+		if !ok || currency == "" {
+			return nil, fmt.Errorf("invalid threshold %q: expected CURRENCY=REVIEW:REJECT", entry)
+		}
+		if _, ok := thresholds[currency]; ok {
+			return nil, fmt.Errorf("duplicate threshold for %s", currency)
+		}
+
+		review, reject, _ := strings.Cut(limits, ":")
+		var threshold Threshold
+		var err error
+		if threshold.Review, err = parseLimit(review); err != nil {
+			return nil, fmt.Errorf("invalid review threshold for %s: %w", currency, err)
+		}
+		if threshold.Reject, err = parseLimit(reject); err != nil {
+			return nil, fmt.Errorf("invalid reject threshold for %s: %w", currency, err)
+		}
+		if threshold.Review == nil && threshold.Reject == nil {
+			return nil, fmt.Errorf("threshold for %s sets no limit", currency)
+		}
+		thresholds[currency] = threshold
+	}
+	return thresholds, nil
+}
+
+// parseLimit parses an optional positive amount.
+func parseLimit(value string) (*models.Amount, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	amount, err := models.ParseAmount(value)
+	if err != nil {
+		return nil, err
+	}
+	if amount.Sign() <= 0 {
+		return nil, fmt.Errorf("%s is not positive", value)
+	}
+	return &amount, nil
+}
+
+// Name identifies the thresholds in stored decisions.
+func (t Thresholds) Name() string {
+	return "amount_threshold"
+}
+
+// Screen rejects or holds the transaction if its amount is above a limit for its currency.
+func (t Thresholds) Screen(ctx context.Context, transaction models.Transaction) (models.Screening, error) {
+	currency := strings.ToUpper(transaction.Currency)
+	threshold, ok := t[currency]
+	if !ok {
+		return approve, nil
+	}
+
+	if threshold.Reject != nil && transaction.Amount.Cmp(*threshold.Reject) > 0 {
+		return models.Screening{
+			Decision: models.ScreeningReject,
+			Reason:   fmt.Sprintf("amount exceeds the %s %s rejection threshold", threshold.Reject, currency),
+		}, nil
+	}
+	if threshold.Review != nil && transaction.Amount.Cmp(*threshold.Review) > 0 {
+		return models.Screening{
+			Decision: models.ScreeningReview,
+			Reason:   fmt.Sprintf("amount exceeds the %s %s review threshold", threshold.Review, currency),
+		}, nil
+	}
+	return approve, nil
+}
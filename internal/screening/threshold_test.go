@@ -0,0 +1,58 @@
+package screening
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseThresholds(t *testing.T) {
+	thresholds, err := ParseThresholds(" usd=10000:50000, EUR=:20000,KES=1000000 ,")
+	require.NoError(t, err)
+	require.Len(t, thresholds, 3)
+	assert.Equal(t, "10000", thresholds["USD"].Review.String())
+	assert.Equal(t, "50000", thresholds["USD"].Reject.String())
+	assert.Nil(t, thresholds["EUR"].Review)
+	assert.Equal(t, "20000", thresholds["EUR"].Reject.String())
+	assert.Equal(t, "1000000", thresholds["KES"].Review.String())
+	assert.Nil(t, thresholds["KES"].Reject)
+
+	empty, err := ParseThresholds("")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	for _, spec := range []string{"USD", "=100", "USD=abc", "USD=-5", "USD=:", "USD=1,USD=2"} {
+		_, err := ParseThresholds(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestThresholds_Screen(t *testing.T) {
+	thresholds, err := ParseThresholds("USD=10000:50000")
+	require.NoError(t, err)
+
+	tests := []struct {
+		amount   string
+		currency string
+		decision models.ScreeningDecision
+		reason   string
+	}{
+		{"10000.00", "USD", models.ScreeningApprove, ""},
+		{"10000.01", "USD", models.ScreeningReview, "amount exceeds the 10000 USD review threshold"},
+		{"50000.01", "USD", models.ScreeningReject, "amount exceeds the 50000 USD rejection threshold"},
+		{"50000.01", "usd", models.ScreeningReject, "amount exceeds the 50000 USD rejection threshold"},
+		{"99999999.00", "EUR", models.ScreeningApprove, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount+" "+tt.currency, func(t *testing.T) {
+			result, err := thresholds.Screen(context.Background(), models.Transaction{Amount: models.MustParseAmount(tt.amount), Currency: tt.currency})
+			require.NoError(t, err)
+			assert.Equal(t, tt.decision, result.Decision)
+			assert.Equal(t, tt.reason, result.Reason)
+		})
+	}
+}
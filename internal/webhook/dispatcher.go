@@ -0,0 +1,199 @@
+// Package webhook delivers the events in the transactional outbox to registered endpoints.
+// This file contains the dispatcher that attempts due deliveries and schedules their retries.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+)
+
+// Store is the subset of db.DB the dispatcher needs.
+type Store interface {
+	// ClaimWebhookDeliveries leases a batch of due deliveries to the caller
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.PendingDelivery, error)
+	// RecordDeliveryAttempt stores the outcome of an attempt to deliver a claimed webhook
+	RecordDeliveryAttempt(ctx context.Context, deliveryID int64, attempt models.DeliveryAttempt) error
+}
+
+// maxResponseBody is how much of an endpoint's response is read before the connection is reused.
+const maxResponseBody = 64 << 10
+
+// Dispatcher polls the store for due webhook deliveries and posts them to their endpoints.
+// A delivery succeeds when its endpoint answers with a 2xx status. Failed deliveries are retried
+// with exponential backoff and moved to the dead state once MaxAttempts is reached.
+// Delivery is at least once: several dispatchers may run against the same store, and an event is
+// delivered again if a dispatcher stops between posting it and recording the outcome.
+type Dispatcher struct {
+	Store Store
+	// Client posts the deliveries; its Timeout should be well below Lease
+	Client *http.Client
+	// PollInterval is how long the dispatcher waits after finding fewer due deliveries than BatchSize
+	PollInterval time.Duration
+	// BatchSize is the most deliveries claimed, and attempted concurrently, at once
+	BatchSize int
+	// Lease is how long a claimed delivery is hidden from other dispatchers
+	Lease time.Duration
+	// MaxAttempts is the number of failed attempts after which a delivery is dead
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry; each later retry waits twice as long
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between retries
+	MaxBackoff time.Duration
+
+	// now returns the current time; tests replace it
+	now func() time.Time
+}
+
+// NewDispatcher creates a Dispatcher with the default settings: a 10 second request timeout,
+// a one second poll interval, and 8 attempts spread over roughly an hour.
+func NewDispatcher(store Store) *Dispatcher {
+	return &Dispatcher{
+		Store: store,
+		Client: &http.Client{
+			Timeout: 10 * time.Second,
+			// A redirect is treated as a failed attempt rather than followed to an unregistered URL
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		PollInterval:   time.Second,
+		BatchSize:      20,
+		Lease:          time.Minute,
+		MaxAttempts:    8,
+		InitialBackoff: 30 * time.Second,
+		MaxBackoff:     time.Hour,
+		now:            time.Now,
+	}
+}
+
+// Run dispatches deliveries until ctx is cancelled. Errors are logged and retried after PollInterval.
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		claimed, err := d.DispatchOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("webhook dispatch: %v", err)
+		}
+
+		// A full batch suggests more deliveries are due, so only wait when the backlog is drained
+		if err == nil && claimed == d.BatchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(d.PollInterval):
+		}
+	}
+}
+
+// DispatchOnce claims one batch of due deliveries, attempts them concurrently and records the outcomes.
+// It returns the number of deliveries claimed.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	claimed, err := d.Store.ClaimWebhookDeliveries(ctx, d.clock(), d.Lease, d.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(claimed))
+	for i, pending := range claimed {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			attempt := d.attempt(ctx, pending)
+			if ctx.Err() != nil {
+				// Shutting down: the lease expires and another attempt is made, which costs no retry
+				return
+			}
+			if err := d.Store.RecordDeliveryAttempt(ctx, pending.Delivery.ID, attempt); err != nil {
+				errs[i] = fmt.Errorf("failed to record delivery %d: %w", pending.Delivery.ID, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return len(claimed), errors.Join(errs...)
+}
+
+// attempt posts a delivery to its endpoint and decides what happens to it next.
+func (d *Dispatcher) attempt(ctx context.Context, pending models.PendingDelivery) models.DeliveryAttempt {
+	code, err := d.post(ctx, pending)
+	attempt := models.DeliveryAttempt{ResponseCode: code, AttemptedAt: d.clock()}
+	if err == nil {
+		attempt.Status = models.DeliveryDelivered
+		return attempt
+	}
+
+	attempt.Error = err.Error()
+	attempts := pending.Delivery.Attempts + 1
+	if attempts >= d.MaxAttempts {
+		attempt.Status = models.DeliveryDead
+		return attempt
+	}
+	attempt.Status = models.DeliveryPending
+	attempt.NextAttemptAt = attempt.AttemptedAt.Add(d.backoff(attempts))
+	return attempt
+}
+
+// post sends a signed delivery and returns the response status, which is zero if there was no response.
+// A non-2xx status is reported as an error.
+func (d *Dispatcher) post(ctx context.Context, pending models.PendingDelivery) (int, error) {
+	body, err := json.Marshal(pending.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pending.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gapstack-webhooks/1")
+	req.Header.Set(EventIDHeader, strconv.FormatInt(pending.Event.ID, 10))
+	req.Header.Set(EventTypeHeader, pending.Event.Type)
+	req.Header.Set(DeliveryIDHeader, strconv.FormatInt(pending.Delivery.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(pending.Secret, d.clock(), body))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before retrying a delivery that has failed attempts times.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.InitialBackoff
+	for i := 1; i < attempts && delay < d.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.MaxBackoff)
+}
+
+// clock returns the current time from the dispatcher's clock.
+func (d *Dispatcher) clock() time.Time {
+	if d.now == nil {
+		return time.Now()
+	}
+	return d.now()
+}
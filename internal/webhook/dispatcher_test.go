@@ -0,0 +1,179 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abadojack/gapstack/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore hands out a fixed batch of deliveries and records the attempts reported for them.
+type fakeStore struct {
+	mu       sync.Mutex
+	pending  []models.PendingDelivery
+	attempts map[int64]models.DeliveryAttempt
+}
+
+func (s *fakeStore) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.PendingDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	claimed := s.pending
+	s.pending = nil
+	return claimed, nil
+}
+
+func (s *fakeStore) RecordDeliveryAttempt(ctx context.Context, deliveryID int64, attempt models.DeliveryAttempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.attempts == nil {
+		s.attempts = make(map[int64]models.DeliveryAttempt)
+	}
+	s.attempts[deliveryID] = attempt
+	return nil
+}
+
+// newTestDispatcher returns a dispatcher over the store whose clock is fixed at now.
+func newTestDispatcher(store Store, now time.Time) *Dispatcher {
+	d := NewDispatcher(store)
+	d.now = func() time.Time { return now }
+	return d
+}
+
+func pendingDelivery(id int64, url string, attempts int) models.PendingDelivery {
+	return models.PendingDelivery{
+		Delivery: models.WebhookDelivery{ID: id, EventID: 7, EndpointID: "wh-1", Status: models.DeliveryPending, Attempts: attempts},
+		Event: models.OutboxEvent{
+			ID:            7,
+			Type:          models.EventTransactionCreated,
+			TransactionID: "txn-1",
+			Data:          json.RawMessage(`{"transaction":{"id":"txn-1"}}`),
+			CreatedAt:     time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		},
+		URL:    url,
+		Secret: "whsec_test",
+	}
+}
+
+func TestDispatcher_DispatchOnce(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("delivers a signed event", func(t *testing.T) {
+		var received *http.Request
+		var body []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r
+			body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		store := &fakeStore{pending: []models.PendingDelivery{pendingDelivery(4, server.URL, 0)}}
+		claimed, err := newTestDispatcher(store, now).DispatchOnce(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, claimed)
+
+		require.NotNil(t, received)
+		assert.Equal(t, http.MethodPost, received.Method)
+		assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+		assert.Equal(t, "7", received.Header.Get(EventIDHeader))
+		assert.Equal(t, models.EventTransactionCreated, received.Header.Get(EventTypeHeader))
+		assert.Equal(t, "4", received.Header.Get(DeliveryIDHeader))
+		assert.NoError(t, Verify("whsec_test", received.Header.Get(SignatureHeader), body, now, time.Minute))
+		assert.JSONEq(t, `{
+			"id": 7,
+			"type": "transaction.created",
+			"transaction_id": "txn-1",
+			"data": {"transaction": {"id": "txn-1"}},
+			"created_at": "2025-01-02T03:04:05Z"
+		}`, string(body))
+
+		assert.Equal(t, models.DeliveryAttempt{Status: models.DeliveryDelivered, ResponseCode: http.StatusNoContent, AttemptedAt: now}, store.attempts[4])
+	})
+
+	t.Run("failures back off exponentially and then die", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		store := &fakeStore{pending: []models.PendingDelivery{
+			pendingDelivery(1, server.URL, 0),
+			pendingDelivery(2, server.URL, 3),
+			pendingDelivery(3, server.URL, 7),
+		}}
+		d := newTestDispatcher(store, now)
+		_, err := d.DispatchOnce(context.Background())
+		require.NoError(t, err)
+
+		first := store.attempts[1]
+		assert.Equal(t, models.DeliveryPending, first.Status)
+		assert.Equal(t, http.StatusServiceUnavailable, first.ResponseCode)
+		assert.Equal(t, "endpoint responded with 503 Service Unavailable", first.Error)
+		assert.Equal(t, now.Add(30*time.Second), first.NextAttemptAt)
+
+		assert.Equal(t, models.DeliveryPending, store.attempts[2].Status)
+		assert.Equal(t, now.Add(4*time.Minute), store.attempts[2].NextAttemptAt)
+
+		// The eighth failed attempt exhausts the default MaxAttempts
+		assert.Equal(t, models.DeliveryDead, store.attempts[3].Status)
+		assert.True(t, store.attempts[3].NextAttemptAt.IsZero())
+	})
+
+	t.Run("unreachable endpoints and redirects fail", func(t *testing.T) {
+		redirect := httptest.NewServer(http.RedirectHandler("https://example.com", http.StatusFound))
+		defer redirect.Close()
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+
+		store := &fakeStore{pending: []models.PendingDelivery{
+			pendingDelivery(1, redirect.URL, 0),
+			pendingDelivery(2, closed.URL, 0),
+		}}
+		_, err := newTestDispatcher(store, now).DispatchOnce(context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusFound, store.attempts[1].ResponseCode)
+		assert.Equal(t, models.DeliveryPending, store.attempts[1].Status)
+		assert.Zero(t, store.attempts[2].ResponseCode)
+		assert.NotEmpty(t, store.attempts[2].Error)
+	})
+
+	t.Run("shutdown leaves the outcome unrecorded", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cancel()
+			<-release
+		}))
+		defer server.Close()
+
+		store := &fakeStore{pending: []models.PendingDelivery{pendingDelivery(1, server.URL, 0)}}
+		claimed, err := newTestDispatcher(store, now).DispatchOnce(ctx)
+		close(release)
+		require.NoError(t, err)
+		assert.Equal(t, 1, claimed)
+		assert.Empty(t, store.attempts)
+	})
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	d := NewDispatcher(nil)
+	d.InitialBackoff = time.Second
+	d.MaxBackoff = 10 * time.Second
+
+	assert.Equal(t, time.Second, d.backoff(1))
+	assert.Equal(t, 2*time.Second, d.backoff(2))
+	assert.Equal(t, 8*time.Second, d.backoff(4))
+	assert.Equal(t, 10*time.Second, d.backoff(5))
+	assert.Equal(t, 10*time.Second, d.backoff(100))
+}
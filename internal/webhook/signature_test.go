@@ -0,0 +1,50 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	// Fixed vector so that receivers written in other languages can check their implementation
+	header := Sign("whsec_test", time.Unix(1700000000, 0), []byte(`{"id":1}`))
+	assert.Equal(t, "t=1700000000,v1=2f441ba4b3b2d50d28a9ab9d9fd8880376ecd1eb5d0435401553f5d8d0a5dcf8", header)
+}
+
+func TestVerify(t *testing.T) {
+	signedAt := time.Unix(1700000000, 0)
+	body := []byte(`{"id":1}`)
+	header := Sign("whsec_test", signedAt, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		header    string
+		body      []byte
+		now       time.Time
+		tolerance time.Duration
+		wantErr   bool
+	}{
+		{name: "valid", secret: "whsec_test", header: header, body: body, now: signedAt.Add(time.Minute), tolerance: 5 * time.Minute},
+		{name: "tolerance disabled", secret: "whsec_test", header: header, body: body, now: signedAt.Add(24 * time.Hour)},
+		{name: "wrong secret", secret: "whsec_other", header: header, body: body, now: signedAt, wantErr: true},
+		{name: "tampered body", secret: "whsec_test", header: header, body: []byte(`{"id":2}`), now: signedAt, wantErr: true},
+		{name: "too old", secret: "whsec_test", header: header, body: body, now: signedAt.Add(10 * time.Minute), tolerance: 5 * time.Minute, wantErr: true},
+		{name: "from the future", secret: "whsec_test", header: header, body: body, now: signedAt.Add(-10 * time.Minute), tolerance: 5 * time.Minute, wantErr: true},
+		{name: "missing timestamp", secret: "whsec_test", header: "v1=abc", body: body, now: signedAt, wantErr: true},
+		{name: "signature not hex", secret: "whsec_test", header: "t=1700000000,v1=xyz", body: body, now: signedAt, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.header, tt.body, tt.now, tt.tolerance)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidSignature)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}